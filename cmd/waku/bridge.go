@@ -0,0 +1,128 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net"
+
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/waku-org/go-waku/waku/v2/bridge"
+	"github.com/waku-org/go-waku/waku/v2/node"
+	wprotocol "github.com/waku-org/go-waku/waku/v2/protocol"
+	"go.uber.org/zap"
+	"golang.org/x/time/rate"
+)
+
+// bridgeOtherCluster returns the cluster of the topics of the rules that don't belong to
+// nodeClusterID, the cluster of the node. All these topics must belong to the same cluster
+func bridgeOtherCluster(rules []bridge.Rule, nodeClusterID uint16) (uint16, bool, error) {
+	clusters := make(map[uint16]struct{})
+	for _, rule := range rules {
+		for _, pubsubTopic := range []string{rule.SourcePubsubTopic, rule.DestinationPubsubTopic} {
+			topic, err := wprotocol.ToWakuPubsubTopic(pubsubTopic)
+			if err != nil {
+				// Named topics are bridged within the cluster of the node
+				continue
+			}
+			shardTopic, err := wprotocol.ToShardPubsubTopic(topic)
+			if err != nil {
+				continue
+			}
+			clusters[shardTopic.Cluster()] = struct{}{}
+		}
+	}
+
+	delete(clusters, nodeClusterID)
+	if len(clusters) > 1 {
+		return 0, false, fmt.Errorf("bridge topics outside the cluster of the node belong to %d different clusters", len(clusters))
+	}
+	for cluster := range clusters {
+		return cluster, true, nil
+	}
+	return 0, false, nil
+}
+
+// startBridge forwards messages according to the bridge rules. The topics of the cluster of
+// the node are relayed by wakuNode, and the topics of another cluster by a second node
+func startBridge(ctx context.Context, wakuNode *node.WakuNode, options NodeOptions, logger *zap.Logger) (func(), error) {
+	var rules []bridge.Rule
+	for _, value := range options.Bridge.Rules.Value() {
+		rule, err := bridge.ParseRule(value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid bridge rule %s: %w", value, err)
+		}
+		rule.SigningKeys = options.Bridge.SigningKeys
+		rules = append(rules, rule)
+	}
+
+	clusterID, otherCluster, err := bridgeOtherCluster(rules, uint16(options.ClusterID))
+	if err != nil {
+		return nil, err
+	}
+
+	var clusterNode *node.WakuNode
+	if otherCluster {
+		hostAddr, err := net.ResolveTCPAddr("tcp", fmt.Sprintf("%s:%d", options.Address, options.Bridge.Port))
+		if err != nil {
+			return nil, fmt.Errorf("invalid bridge host address: %w", err)
+		}
+
+		prvKey, err := crypto.GenerateKey()
+		if err != nil {
+			return nil, err
+		}
+
+		clusterNode, err = node.New(
+			node.WithLogger(logger.Named("bridge-node")),
+			node.WithPrometheusRegisterer(prometheus.NewRegistry()),
+			node.WithPrivateKey(prvKey),
+			node.WithHostAddress(hostAddr),
+			node.WithClusterID(clusterID),
+			node.WithWakuRelay(),
+		)
+		if err != nil {
+			return nil, fmt.Errorf("could not instantiate bridge node: %w", err)
+		}
+
+		if err = clusterNode.Start(ctx); err != nil {
+			return nil, fmt.Errorf("could not start bridge node: %w", err)
+		}
+
+		for _, addr := range options.Bridge.StaticNodes {
+			ctx, cancel := context.WithTimeout(ctx, dialTimeout)
+			err := clusterNode.DialPeerWithMultiAddress(ctx, addr)
+			cancel()
+			if err != nil {
+				logger.Error("dialing bridge peer", zap.Error(err))
+			}
+		}
+	}
+
+	var bridgeOpts []bridge.Option
+	if options.Bridge.RateLimit > 0 {
+		bridgeOpts = append(bridgeOpts, bridge.WithRateLimit(rate.Limit(options.Bridge.RateLimit), options.Bridge.RateBurst))
+	}
+
+	if clusterNode != nil {
+		bridgeOpts = append(bridgeOpts, bridge.WithClusterRelay(clusterID, clusterNode.Relay()))
+	}
+
+	b, err := bridge.NewBridge(wakuNode.Relay(), rules, prometheus.DefaultRegisterer, logger, bridgeOpts...)
+	if err == nil {
+		err = b.Start(ctx)
+	}
+	if err != nil {
+		if clusterNode != nil {
+			clusterNode.Stop()
+		}
+		return nil, fmt.Errorf("could not start bridge: %w", err)
+	}
+
+	return func() {
+		b.Stop()
+		if clusterNode != nil {
+			clusterNode.Stop()
+		}
+	}, nil
+}
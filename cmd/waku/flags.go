@@ -5,8 +5,12 @@ import (
 
 	cli "github.com/urfave/cli/v2"
 	"github.com/urfave/cli/v2/altsrc"
+	"github.com/waku-org/go-waku/cmd/waku/webhook"
 	"github.com/waku-org/go-waku/waku/cliutils"
 	"github.com/waku-org/go-waku/waku/v2/node"
+	"github.com/waku-org/go-waku/waku/v2/protocol/filter"
+	"github.com/waku-org/go-waku/waku/v2/protocol/relay"
+	"github.com/waku-org/go-waku/waku/v2/protocol/relay/tracer"
 )
 
 var (
@@ -274,12 +278,18 @@ var (
 	})
 	ProtectedTopics = cliutils.NewGenericFlagMultiValue(&cli.GenericFlag{
 		Name:    "protected-topic",
-		Usage:   "Topics and its public key to be used for message validation, topic:pubkey. Argument may be repeated.",
+		Usage:   "Topics and the public keys allowed to sign their messages, topic:pubkey[@notBefore..notAfter][|pubkey...][;threshold=M]. Key validity uses RFC3339 times and either bound may be omitted. Messages must be signed by M keys (default 1). Argument may be repeated.",
 		EnvVars: []string{"WAKUNODE2_PROTECTED_TOPIC"},
 		Value: &cliutils.ProtectedTopicSlice{
 			Values: &options.Relay.ProtectedTopics,
 		},
 	})
+	ProtectedTopicStateDir = altsrc.NewPathFlag(&cli.PathFlag{
+		Name:        "protected-topic-state-dir",
+		Usage:       "Directory where the key updates applied to protected topics are saved, so they're applied again after a restart. Without it, key updates are lost when the node stops",
+		Destination: &options.Relay.ProtectedTopicStateDir,
+		EnvVars:     []string{"WAKUNODE2_PROTECTED_TOPIC_STATE_DIR"},
+	})
 	RelayPeerExchange = altsrc.NewBoolFlag(&cli.BoolFlag{
 		Name:        "relay-peer-exchange",
 		Value:       false,
@@ -301,6 +311,170 @@ var (
 		Destination: &options.Relay.MaxMsgSize,
 		EnvVars:     []string{"WAKUNODE2_MAX_RELAY_MSG_SIZE"},
 	})
+	RelayPeerRateLimit = altsrc.NewFloat64Flag(&cli.Float64Flag{
+		Name:        "relay-peer-rate-limit",
+		Value:       0,
+		Usage:       "Maximum number of messages per second accepted via relay from each peer. Set to 0 to disable it",
+		Destination: &options.Relay.PeerRateLimit,
+		EnvVars:     []string{"WAKUNODE2_RELAY_PEER_RATE_LIMIT"},
+	})
+	RelayPeerRateBurst = altsrc.NewIntFlag(&cli.IntFlag{
+		Name:        "relay-peer-rate-burst",
+		Value:       10,
+		Usage:       "Maximum number of messages accepted at once via relay from each peer",
+		Destination: &options.Relay.PeerRateBurst,
+		EnvVars:     []string{"WAKUNODE2_RELAY_PEER_RATE_BURST"},
+	})
+	RelayContentTopicRateLimit = altsrc.NewFloat64Flag(&cli.Float64Flag{
+		Name:        "relay-content-topic-rate-limit",
+		Value:       0,
+		Usage:       "Maximum number of messages per second accepted via relay for each content topic. Set to 0 to disable it",
+		Destination: &options.Relay.ContentTopicRateLimit,
+		EnvVars:     []string{"WAKUNODE2_RELAY_CONTENT_TOPIC_RATE_LIMIT"},
+	})
+	RelayContentTopicRateBurst = altsrc.NewIntFlag(&cli.IntFlag{
+		Name:        "relay-content-topic-rate-burst",
+		Value:       10,
+		Usage:       "Maximum number of messages accepted at once via relay for each content topic",
+		Destination: &options.Relay.ContentTopicRateBurst,
+		EnvVars:     []string{"WAKUNODE2_RELAY_CONTENT_TOPIC_RATE_BURST"},
+	})
+	RelayRateLimitPenalty = altsrc.NewStringFlag(&cli.StringFlag{
+		Name:        "relay-rate-limit-penalty",
+		Value:       "ignore",
+		Usage:       "Action taken on messages exceeding the relay rate limits. Use 'ignore' to drop them, or 'reject' to also penalize the score of the peer that forwarded them",
+		Destination: &options.Relay.RateLimitPenalty,
+		EnvVars:     []string{"WAKUNODE2_RELAY_RATE_LIMIT_PENALTY"},
+	})
+	RelayTraceFile = altsrc.NewPathFlag(&cli.PathFlag{
+		Name:        "relay-trace-file",
+		Usage:       "Write the publish, deliver, duplicate, reject, graft and prune events of relay to this file. Use 'waku trace analyze' to inspect it",
+		Destination: &options.Relay.TraceFile,
+		EnvVars:     []string{"WAKUNODE2_RELAY_TRACE_FILE"},
+	})
+	RelayTraceFormat = altsrc.NewStringFlag(&cli.StringFlag{
+		Name:        "relay-trace-format",
+		Value:       tracer.FormatProtobuf,
+		Usage:       "Format of the relay trace file: protobuf (length-delimited) or jsonl",
+		Destination: &options.Relay.TraceFormat,
+		EnvVars:     []string{"WAKUNODE2_RELAY_TRACE_FORMAT"},
+	})
+	RelayTraceMaxFileSize = altsrc.NewStringFlag(&cli.StringFlag{
+		Name:        "relay-trace-max-file-size",
+		Value:       "100MiB",
+		Usage:       "Size at which the relay trace file is rotated. Supported formats are B, KiB, KB, MiB, MB, GiB, GB. Set to 0 to disable the rotation",
+		Destination: &options.Relay.TraceMaxFileSize,
+		EnvVars:     []string{"WAKUNODE2_RELAY_TRACE_MAX_FILE_SIZE"},
+	})
+	RelayTraceMaxFiles = altsrc.NewIntFlag(&cli.IntFlag{
+		Name:        "relay-trace-max-files",
+		Value:       tracer.DefaultMaxFiles,
+		Usage:       "Number of rotated relay trace files to keep",
+		Destination: &options.Relay.TraceMaxFiles,
+		EnvVars:     []string{"WAKUNODE2_RELAY_TRACE_MAX_FILES"},
+	})
+	GossipsubProfile = altsrc.NewStringFlag(&cli.StringFlag{
+		Name:        "gossipsub-profile",
+		Value:       relay.ProfileDefault,
+		Usage:       "Gossipsub parameters used by relay: default, low-latency, bandwidth-saving or mobile. The peer score parameters of each pubsub topic can be set in [gossipsub-topic.\"<pubsub topic>\"] tables of the config file",
+		Destination: &options.Relay.GossipsubProfile,
+		EnvVars:     []string{"WAKUNODE2_GOSSIPSUB_PROFILE"},
+	})
+	ShardDemand = altsrc.NewBoolFlag(&cli.BoolFlag{
+		Name:        "shard-demand",
+		Usage:       "Subscribe relay to the shards of the cluster requested by filter and lightpush clients, and advertise them in the ENR",
+		Destination: &options.Relay.ShardDemand,
+		EnvVars:     []string{"WAKUNODE2_SHARD_DEMAND"},
+	})
+	ShardDemandMaxShards = altsrc.NewIntFlag(&cli.IntFlag{
+		Name:        "shard-demand-max-shards",
+		Value:       8,
+		Usage:       "Maximum number of shards subscribed on demand, in addition to the configured ones",
+		Destination: &options.Relay.ShardDemandMaxShards,
+		EnvVars:     []string{"WAKUNODE2_SHARD_DEMAND_MAX_SHARDS"},
+	})
+	ShardDemandIdleTimeout = altsrc.NewDurationFlag(&cli.DurationFlag{
+		Name:        "shard-demand-idle-timeout",
+		Value:       10 * time.Minute,
+		Usage:       "Time after which a shard subscribed on demand is unsubscribed if no client requested it",
+		Destination: &options.Relay.ShardDemandIdleTimeout,
+		EnvVars:     []string{"WAKUNODE2_SHARD_DEMAND_IDLE_TIMEOUT"},
+	})
+	BridgeRule = altsrc.NewStringSliceFlag(&cli.StringSliceFlag{
+		Name:        "bridge-rule",
+		Usage:       "Forward the messages of a pubsub topic to another one, source_pubsub_topic:destination_pubsub_topic[:content_topic,...]. Content topics may be patterns. Argument may be repeated.",
+		Destination: &options.Bridge.Rules,
+		EnvVars:     []string{"WAKUNODE2_BRIDGE_RULE"},
+	})
+	BridgeSigningKey = cliutils.NewGenericFlagMultiValue(&cli.GenericFlag{
+		Name:  "bridge-signing-key",
+		Usage: "Private key as hex used to sign the forwarded messages again, for protected destination topics. Argument may be repeated for topics requiring several signatures",
+		Value: &cliutils.PrivateKeySlice{
+			Values: &options.Bridge.SigningKeys,
+		},
+		EnvVars: []string{"WAKUNODE2_BRIDGE_SIGNING_KEY"},
+	})
+	BridgeStaticNode = cliutils.NewGenericFlagMultiValue(&cli.GenericFlag{
+		Name:  "bridge-staticnode",
+		Usage: "Multiaddr of a peer of the cluster bridged with the cluster of the node. Option may be repeated",
+		Value: &cliutils.MultiaddrSlice{
+			Values: &options.Bridge.StaticNodes,
+		},
+		EnvVars: []string{"WAKUNODE2_BRIDGE_STATICNODE"},
+	})
+	BridgePort = altsrc.NewIntFlag(&cli.IntFlag{
+		Name:        "bridge-port",
+		Value:       0,
+		Usage:       "Libp2p TCP listening port of the node connected to the cluster bridged with the cluster of the node (0 for random)",
+		Destination: &options.Bridge.Port,
+		EnvVars:     []string{"WAKUNODE2_BRIDGE_PORT"},
+	})
+	BridgeRateLimit = altsrc.NewFloat64Flag(&cli.Float64Flag{
+		Name:        "bridge-rate-limit",
+		Value:       0,
+		Usage:       "Maximum number of messages per second forwarded by each bridge rule. Set to 0 to disable it",
+		Destination: &options.Bridge.RateLimit,
+		EnvVars:     []string{"WAKUNODE2_BRIDGE_RATE_LIMIT"},
+	})
+	BridgeRateBurst = altsrc.NewIntFlag(&cli.IntFlag{
+		Name:        "bridge-rate-burst",
+		Value:       10,
+		Usage:       "Maximum number of messages forwarded at once by each bridge rule",
+		Destination: &options.Bridge.RateBurst,
+		EnvVars:     []string{"WAKUNODE2_BRIDGE_RATE_BURST"},
+	})
+	Webhook = altsrc.NewStringSliceFlag(&cli.StringSliceFlag{
+		Name:        "webhook",
+		Usage:       "Post the messages received through relay or filter subscriptions to a URL, url[;pubsub_topic[;content_topic,...]]. Messages of any pubsub topic or content topic are posted if not specified. Content topics may be patterns. Argument may be repeated.",
+		Destination: &options.Webhook.Endpoints,
+		EnvVars:     []string{"WAKUNODE2_WEBHOOK"},
+	})
+	WebhookSecret = altsrc.NewStringFlag(&cli.StringFlag{
+		Name:        "webhook-secret",
+		Usage:       "Sign the webhook requests with an HMAC-SHA256 using this secret. The signature is sent in the X-Waku-Signature header",
+		Destination: &options.Webhook.Secret,
+		EnvVars:     []string{"WAKUNODE2_WEBHOOK_SECRET"},
+	})
+	WebhookMaxAttempts = altsrc.NewIntFlag(&cli.IntFlag{
+		Name:        "webhook-max-attempts",
+		Value:       webhook.DefaultMaxAttempts,
+		Usage:       "Number of times the delivery of a message to a webhook is attempted, with an exponential backoff",
+		Destination: &options.Webhook.MaxAttempts,
+		EnvVars:     []string{"WAKUNODE2_WEBHOOK_MAX_ATTEMPTS"},
+	})
+	WebhookTimeout = altsrc.NewDurationFlag(&cli.DurationFlag{
+		Name:        "webhook-timeout",
+		Value:       webhook.DefaultTimeout,
+		Usage:       "Timeout of the webhook requests",
+		Destination: &options.Webhook.Timeout,
+		EnvVars:     []string{"WAKUNODE2_WEBHOOK_TIMEOUT"},
+	})
+	WebhookDeadLetterFile = altsrc.NewPathFlag(&cli.PathFlag{
+		Name:        "webhook-dead-letter-file",
+		Usage:       "File to which the messages that could not be delivered to webhooks are appended, as JSON lines. These messages are only logged if not set",
+		Destination: &options.Webhook.DeadLetterFile,
+		EnvVars:     []string{"WAKUNODE2_WEBHOOK_DEAD_LETTER_FILE"},
+	})
 	StoreNodeFlag = cliutils.NewGenericFlagMultiValue(&cli.GenericFlag{
 		Name:  "storenode",
 		Usage: "Multiaddr of a peer that supports store protocol. Option may be repeated",
@@ -329,9 +503,24 @@ var (
 		Destination: &options.Store.RetentionMaxMessages,
 		EnvVars:     []string{"WAKUNODE2_STORE_MESSAGE_RETENTION_CAPACITY"},
 	})
+	StoreMessageRetentionSize = altsrc.NewStringFlag(&cli.StringFlag{
+		Name:        "store-message-retention-size",
+		Value:       "0",
+		Usage:       "maximum size of the stored message payloads, after which the oldest messages are removed. Supported formats are B, KiB, KB, MiB, MB, GiB, GB. Set to 0 to disable it",
+		Destination: &options.Store.RetentionMaxSize,
+		EnvVars:     []string{"WAKUNODE2_STORE_MESSAGE_RETENTION_SIZE"},
+	})
+	StoreMessageRetentionRule = cliutils.NewGenericFlagMultiValue(&cli.GenericFlag{
+		Name:  "store-message-retention-rule",
+		Usage: "Retention policy for the messages of specific topics, with the format name=telemetry;pubsubTopic=/waku/2/rs/1/*;contentTopic=/telemetry/*;maxAge=1h;maxMessages=1000;maxBytes=10MB. Topics support * as wildcard and at least one limit is required. Option may be repeated",
+		Value: &cliutils.RetentionRuleSlice{
+			Values: &options.Store.RetentionRules,
+		},
+		EnvVars: []string{"WAKUNODE2_STORE_MESSAGE_RETENTION_RULE"},
+	})
 	StoreMessageDBURL = altsrc.NewStringFlag(&cli.StringFlag{
 		Name:        "store-message-db-url",
-		Usage:       "The database connection URL for persistent storage.",
+		Usage:       "The database connection URL for persistent storage. Use leveldb:///path/to/dir for an embedded key-value store",
 		Value:       "sqlite3://store.db",
 		Destination: &options.Store.DatabaseURL,
 		EnvVars:     []string{"WAKUNODE2_STORE_MESSAGE_DB_URL"},
@@ -343,6 +532,61 @@ var (
 		Value:       true,
 		EnvVars:     []string{"WAKUNODE2_STORE_MESSAGE_DB_MIGRATION"},
 	})
+	StoreMessageDBPartitioning = altsrc.NewStringFlag(&cli.StringFlag{
+		Name:        "store-message-db-partitioning",
+		Usage:       "Partition the message table by time, and apply the retention time by dropping whole partitions. Supported values are hourly and daily. Only available for postgres",
+		Destination: &options.Store.Partitioning,
+		EnvVars:     []string{"WAKUNODE2_STORE_MESSAGE_DB_PARTITIONING"},
+	})
+	StoreMessageDBBatchSize = altsrc.NewIntFlag(&cli.IntFlag{
+		Name:        "store-message-db-batch-size",
+		Usage:       "Insert messages asynchronously in transactions of up to this number of messages. Use 0 to insert each message as it's received",
+		Value:       0,
+		Destination: &options.Store.BatchSize,
+		EnvVars:     []string{"WAKUNODE2_STORE_MESSAGE_DB_BATCH_SIZE"},
+	})
+	StoreMessageDBBatchInterval = altsrc.NewDurationFlag(&cli.DurationFlag{
+		Name:        "store-message-db-batch-interval",
+		Usage:       "Maximum time a message waits to be inserted when batched inserts are enabled",
+		Value:       time.Second,
+		Destination: &options.Store.BatchInterval,
+		EnvVars:     []string{"WAKUNODE2_STORE_MESSAGE_DB_BATCH_INTERVAL"},
+	})
+	StoreMessageDBWriteQueueSize = altsrc.NewIntFlag(&cli.IntFlag{
+		Name:        "store-message-db-write-queue-size",
+		Usage:       "Maximum number of messages waiting to be inserted when batched inserts are enabled. Message processing is paused while the queue is full",
+		Value:       10000,
+		Destination: &options.Store.WriteQueueSize,
+		EnvVars:     []string{"WAKUNODE2_STORE_MESSAGE_DB_WRITE_QUEUE_SIZE"},
+	})
+	StoreSyncFlag = altsrc.NewBoolFlag(&cli.BoolFlag{
+		Name:        "store-sync",
+		Usage:       "Enable store sync protocol to periodically retrieve missing messages from other store nodes. Requires --store",
+		Destination: &options.Store.SyncEnable,
+		EnvVars:     []string{"WAKUNODE2_STORE_SYNC"},
+	})
+	StoreSyncInterval = altsrc.NewDurationFlag(&cli.DurationFlag{
+		Name:        "store-sync-interval",
+		Value:       5 * time.Minute,
+		Usage:       "Interval between store sync sessions",
+		Destination: &options.Store.SyncInterval,
+		EnvVars:     []string{"WAKUNODE2_STORE_SYNC_INTERVAL"},
+	})
+	StoreSyncRange = altsrc.NewDurationFlag(&cli.DurationFlag{
+		Name:        "store-sync-range",
+		Value:       time.Hour,
+		Usage:       "Amount of time in the past whose messages are reconciled in each store sync session",
+		Destination: &options.Store.SyncRange,
+		EnvVars:     []string{"WAKUNODE2_STORE_SYNC_RANGE"},
+	})
+	StoreSyncNodeFlag = cliutils.NewGenericFlagMultiValue(&cli.GenericFlag{
+		Name:  "store-sync-node",
+		Usage: "Multiaddr of a store node to synchronize messages with. If not specified, discovered store nodes that support store sync are used. Option may be repeated",
+		Value: &cliutils.MultiaddrSlice{
+			Values: &options.Store.SyncNodes,
+		},
+		EnvVars: []string{"WAKUNODE2_STORE_SYNC_NODE"},
+	})
 	FilterFlag = altsrc.NewBoolFlag(&cli.BoolFlag{
 		Name:        "filter",
 		Usage:       "Enable filter protocol",
@@ -364,6 +608,39 @@ var (
 		Destination: &options.Filter.Timeout,
 		EnvVars:     []string{"WAKUNODE2_FILTER_TIMEOUT"},
 	})
+	FilterPersist = altsrc.NewBoolFlag(&cli.BoolFlag{
+		Name:        "filter-persist-subscriptions",
+		Usage:       "Store the subscriptions of filter clients in the database, so they are restored when the node restarts. Requires --store-migration",
+		Destination: &options.Filter.Persist,
+		EnvVars:     []string{"WAKUNODE2_FILTER_PERSIST_SUBSCRIPTIONS"},
+	})
+	FilterMaxPeers = altsrc.NewIntFlag(&cli.IntFlag{
+		Name:        "filter-max-peers",
+		Value:       filter.DefaultMaxSubscribers,
+		Usage:       "Maximum number of peers that can subscribe to the filter protocol",
+		Destination: &options.Filter.MaxPeers,
+		EnvVars:     []string{"WAKUNODE2_FILTER_MAX_PEERS"},
+	})
+	FilterMaxCriteria = altsrc.NewIntFlag(&cli.IntFlag{
+		Name:        "filter-max-criteria",
+		Value:       filter.MaxCriteriaPerSubscription,
+		Usage:       "Maximum number of content topics a peer can subscribe to with the filter protocol",
+		Destination: &options.Filter.MaxCriteria,
+		EnvVars:     []string{"WAKUNODE2_FILTER_MAX_CRITERIA"},
+	})
+	FilterPushRateLimit = altsrc.NewFloat64Flag(&cli.Float64Flag{
+		Name:        "filter-push-rate-limit",
+		Usage:       "Messages per second pushed to each filter subscriber. Messages exceeding the limit are dropped. 0 disables the limit",
+		Destination: &options.Filter.PushRateLimit,
+		EnvVars:     []string{"WAKUNODE2_FILTER_PUSH_RATE_LIMIT"},
+	})
+	FilterPushRateBurst = altsrc.NewIntFlag(&cli.IntFlag{
+		Name:        "filter-push-rate-burst",
+		Value:       10,
+		Usage:       "Number of messages that can be pushed at once to a filter subscriber",
+		Destination: &options.Filter.PushRateBurst,
+		EnvVars:     []string{"WAKUNODE2_FILTER_PUSH_RATE_BURST"},
+	})
 	LightPush = altsrc.NewBoolFlag(&cli.BoolFlag{
 		Name:        "lightpush",
 		Usage:       "Enable lightpush protocol",
@@ -520,6 +797,18 @@ var (
 		Destination: &options.RESTServer.Admin,
 		EnvVars:     []string{"WAKUNODE2_REST_ADMIN"},
 	})
+	RESTAdminToken = altsrc.NewStringFlag(&cli.StringFlag{
+		Name:        "rest-admin-token",
+		Usage:       "Bearer token required by the REST HTTP Admin API store routes (i.e. message deletion). These routes are disabled if no token is set",
+		Destination: &options.RESTServer.AdminToken,
+		EnvVars:     []string{"WAKUNODE2_REST_ADMIN_TOKEN"},
+	})
+	RESTAdminAuditLog = altsrc.NewPathFlag(&cli.PathFlag{
+		Name:        "rest-admin-audit-log",
+		Usage:       "File where the REST HTTP Admin API store operations are recorded. The node log is used if not set",
+		Destination: &options.RESTServer.AdminAuditLog,
+		EnvVars:     []string{"WAKUNODE2_REST_ADMIN_AUDIT_LOG"},
+	})
 	PProf = altsrc.NewBoolFlag(&cli.BoolFlag{
 		Name:        "pprof",
 		Usage:       "provides runtime profiling data at /debug/pprof in both REST and RPC servers if they're enabled",
@@ -7,6 +7,8 @@ import (
 	"github.com/urfave/cli/v2/altsrc"
 	"github.com/waku-org/go-waku/cmd/waku/keygen"
 	"github.com/waku-org/go-waku/cmd/waku/rlngenerate"
+	"github.com/waku-org/go-waku/cmd/waku/storearchive"
+	"github.com/waku-org/go-waku/cmd/waku/trace"
 	"github.com/waku-org/go-waku/waku/v2/node"
 	"github.com/waku-org/go-waku/waku/v2/utils"
 	"go.uber.org/zap"
@@ -20,7 +22,7 @@ func main() {
 	options.LogEncoding = "console"
 
 	cliFlags := []cli.Flag{
-		&cli.StringFlag{Name: "config-file", Usage: "loads configuration from a TOML file (cmd-line parameters take precedence)"},
+		&cli.StringFlag{Name: "config-file", Usage: "loads configuration from a TOML file (cmd-line parameters take precedence)", Destination: &options.ConfigFile},
 		TcpPort,
 		Address,
 		MaxPeerConnections,
@@ -58,18 +60,58 @@ func main() {
 		ContentTopics,
 		PubSubTopics,
 		ProtectedTopics,
+		ProtectedTopicStateDir,
 		RelayPeerExchange,
 		MinRelayPeersToPublish,
 		MaxRelayMsgSize,
+		RelayPeerRateLimit,
+		RelayPeerRateBurst,
+		RelayContentTopicRateLimit,
+		RelayContentTopicRateBurst,
+		RelayRateLimitPenalty,
+		RelayTraceFile,
+		RelayTraceFormat,
+		RelayTraceMaxFileSize,
+		RelayTraceMaxFiles,
+		GossipsubProfile,
+		ShardDemand,
+		ShardDemandMaxShards,
+		ShardDemandIdleTimeout,
+		BridgeRule,
+		BridgeSigningKey,
+		BridgeStaticNode,
+		BridgePort,
+		BridgeRateLimit,
+		BridgeRateBurst,
+		Webhook,
+		WebhookSecret,
+		WebhookMaxAttempts,
+		WebhookTimeout,
+		WebhookDeadLetterFile,
 		StoreNodeFlag,
 		StoreFlag,
 		StoreMessageDBURL,
 		StoreMessageRetentionTime,
 		StoreMessageRetentionCapacity,
+		StoreMessageRetentionSize,
+		StoreMessageRetentionRule,
 		StoreMessageDBMigration,
+		StoreMessageDBPartitioning,
+		StoreMessageDBBatchSize,
+		StoreMessageDBBatchInterval,
+		StoreMessageDBWriteQueueSize,
+		StoreSyncFlag,
+		StoreSyncInterval,
+		StoreSyncRange,
+		StoreSyncNodeFlag,
 		FilterFlag,
 		FilterNode,
 		FilterTimeout,
+		FilterPersist,
+		FilterMaxPeers,
+		FilterMaxCriteria,
+		FilterPushRateLimit,
+		FilterPushRateBurst,
 		LightPush,
 		LightPushNode,
 		Discv5Discovery,
@@ -93,6 +135,8 @@ func main() {
 		RESTRelayCacheCapacity,
 		RESTFilterCacheCapacity,
 		RESTAdmin,
+		RESTAdminToken,
+		RESTAdminAuditLog,
 		PProf,
 	}
 
@@ -125,6 +169,8 @@ func main() {
 		Commands: []*cli.Command{
 			&keygen.Command,
 			&rlngenerate.Command,
+			&storearchive.Command,
+			&trace.Command,
 		},
 	}
 
@@ -5,6 +5,7 @@ import (
 	"crypto/ecdsa"
 	"database/sql"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net"
 	"os"
@@ -40,9 +41,12 @@ import (
 	ws "github.com/libp2p/go-libp2p/p2p/transport/websocket"
 	"github.com/multiformats/go-multiaddr"
 	"github.com/waku-org/go-waku/cmd/waku/server/rest"
+	"github.com/waku-org/go-waku/cmd/waku/webhook"
 	"github.com/waku-org/go-waku/logging"
+	"github.com/waku-org/go-waku/waku/cliutils"
 	"github.com/waku-org/go-waku/waku/metrics"
 	"github.com/waku-org/go-waku/waku/persistence"
+	"github.com/waku-org/go-waku/waku/persistence/leveldb"
 	"github.com/waku-org/go-waku/waku/v2/node"
 	wprotocol "github.com/waku-org/go-waku/waku/v2/protocol"
 	"github.com/waku-org/go-waku/waku/v2/protocol/filter"
@@ -50,13 +54,17 @@ import (
 	"github.com/waku-org/go-waku/waku/v2/protocol/lightpush"
 	"github.com/waku-org/go-waku/waku/v2/protocol/peer_exchange"
 	"github.com/waku-org/go-waku/waku/v2/protocol/relay"
+	"github.com/waku-org/go-waku/waku/v2/protocol/relay/tracer"
+	"github.com/waku-org/go-waku/waku/v2/protocol/store"
+	"github.com/waku-org/go-waku/waku/v2/protocol/storesync"
 	"github.com/waku-org/go-waku/waku/v2/utils"
 
 	humanize "github.com/dustin/go-humanize"
+	"golang.org/x/time/rate"
 )
 
 func requiresDB(options NodeOptions) bool {
-	return options.Store.Enable || options.Rendezvous.Enable
+	return options.Store.Enable || options.Rendezvous.Enable || (options.Filter.Enable && options.Filter.Persist)
 }
 
 func scalePerc(value float64) float64 {
@@ -73,6 +81,9 @@ func scalePerc(value float64) float64 {
 
 const dialTimeout = 7 * time.Second
 
+// futurePartitions is the number of message table partitions created in advance
+const futurePartitions = 3
+
 func nonRecoverErrorMsg(format string, a ...any) error {
 	err := fmt.Errorf(format, a...)
 	return nonRecoverError(err)
@@ -110,10 +121,36 @@ func Execute(options NodeOptions) error {
 
 	logger := utils.Logger().With(logging.HostID("node", id))
 
+	partitionInterval, err := getPartitionInterval(options.Store.Partitioning)
+	if err != nil {
+		return nonRecoverError(err)
+	}
+
+	if options.Store.SyncEnable && !options.Store.Enable {
+		return nonRecoverError(errors.New("store sync requires the store protocol to be enabled"))
+	}
+
+	retentionMaxBytes, err := humanize.ParseBytes(options.Store.RetentionMaxSize)
+	if err != nil {
+		return nonRecoverErrorMsg("invalid store message retention size: %w", err)
+	}
+
+	kvStorePath, useKVStore := leveldb.ParseURL(options.Store.DatabaseURL)
+	if useKVStore {
+		if options.Rendezvous.Enable || (options.Store.Enable && options.PersistPeers) || (options.Filter.Enable && options.Filter.Persist) {
+			return nonRecoverError(errors.New("rendezvous, persistent peers and persistent filter subscriptions require a SQL database"))
+		}
+		if partitionInterval > 0 || len(options.Store.RetentionRules) != 0 || retentionMaxBytes > 0 || options.Store.BatchSize > 0 {
+			return nonRecoverError(errors.New("partitioning, retention rules, retention size and batched inserts require a SQL database"))
+		}
+	}
+
 	var db *sql.DB
 	var migrationFn func(*sql.DB, *zap.Logger) error
-	if requiresDB(options) && options.Store.Migration {
-		dbSettings := dbutils.DBSettings{}
+	if requiresDB(options) && options.Store.Migration && !useKVStore {
+		dbSettings := dbutils.DBSettings{
+			PartitionedMessages: partitionInterval > 0,
+		}
 		db, migrationFn, err = dbutils.ParseURL(options.Store.DatabaseURL, dbSettings, logger)
 		if err != nil {
 			return nonRecoverErrorMsg("could not connect to DB: %w", err)
@@ -241,38 +278,150 @@ func Execute(options NodeOptions) error {
 		wakurelayopts = append(wakurelayopts, pubsub.WithPeerExchange(options.Relay.PeerExchange))
 		wakurelayopts = append(wakurelayopts, pubsub.WithMaxMessageSize(maxMsgSize))
 
+		if options.Relay.TraceFile != "" {
+			traceMaxFileSize, err := humanize.ParseBytes(options.Relay.TraceMaxFileSize)
+			if err != nil {
+				return fmt.Errorf("invalid relay trace max file size: %w", err)
+			}
+
+			relayTracer, err := tracer.NewTracer(options.Relay.TraceFile, id, prometheus.DefaultRegisterer, logger,
+				tracer.WithFormat(options.Relay.TraceFormat),
+				tracer.WithRotation(int64(traceMaxFileSize), options.Relay.TraceMaxFiles))
+			if err != nil {
+				return fmt.Errorf("could not create relay tracer: %w", err)
+			}
+			defer relayTracer.Stop()
+
+			wakurelayopts = append(wakurelayopts, pubsub.WithRawTracer(relayTracer))
+		}
+
 		nodeOpts = append(nodeOpts, node.WithWakuRelayAndMinPeers(options.Relay.MinRelayPeersToPublish, wakurelayopts...))
 		nodeOpts = append(nodeOpts, node.WithMaxMsgSize(maxMsgSize))
+
+		if options.Relay.PeerRateLimit > 0 || options.Relay.ContentTopicRateLimit > 0 {
+			var reject bool
+			switch options.Relay.RateLimitPenalty {
+			case "ignore":
+			case "reject":
+				reject = true
+			default:
+				return fmt.Errorf("invalid relay rate limit penalty %q. Use 'ignore' or 'reject'", options.Relay.RateLimitPenalty)
+			}
+
+			nodeOpts = append(nodeOpts, node.WithRelayRateLimit(relay.RateLimitConfig{
+				PeerLimit:         rate.Limit(options.Relay.PeerRateLimit),
+				PeerBurst:         options.Relay.PeerRateBurst,
+				ContentTopicLimit: rate.Limit(options.Relay.ContentTopicRateLimit),
+				ContentTopicBurst: options.Relay.ContentTopicRateBurst,
+				Reject:            reject,
+			}))
+		}
+
+		nodeOpts = append(nodeOpts, node.WithGossipsubProfile(options.Relay.GossipsubProfile))
+		if options.ConfigFile != "" {
+			topicScoreParams, err := cliutils.LoadTopicScoreParams(options.ConfigFile, options.Relay.GossipsubProfile)
+			if err != nil {
+				return fmt.Errorf("could not load gossipsub topic score parameters: %w", err)
+			}
+			for pubsubTopic, scoreParams := range topicScoreParams {
+				nodeOpts = append(nodeOpts, node.WithTopicScoreParams(pubsubTopic, scoreParams))
+			}
+		}
+
+		if options.Relay.ShardDemand {
+			nodeOpts = append(nodeOpts, node.WithShardDemand(node.ShardDemandConfig{
+				MaxShards:   options.Relay.ShardDemandMaxShards,
+				IdleTimeout: options.Relay.ShardDemandIdleTimeout,
+			}))
+		}
 	}
 
 	nodeOpts = append(nodeOpts, node.WithWakuFilterLightNode())
 
 	if options.Filter.Enable {
-		nodeOpts = append(nodeOpts, node.WithWakuFilterFullNode(filter.WithTimeout(options.Filter.Timeout)))
+		filterOpts := []filter.Option{
+			filter.WithTimeout(options.Filter.Timeout),
+			filter.WithMaxSubscribers(options.Filter.MaxPeers),
+			filter.WithMaxCriteria(options.Filter.MaxCriteria),
+		}
+		if options.Filter.PushRateLimit > 0 {
+			filterOpts = append(filterOpts, filter.WithPushRateLimit(rate.Limit(options.Filter.PushRateLimit), options.Filter.PushRateBurst))
+		}
+		if options.Filter.Persist {
+			if db != nil {
+				filterOpts = append(filterOpts, filter.WithSubscriptionStore(filter.NewDBSubscriptionStore(db, logger)))
+			} else {
+				// The database is only opened when its migrations are enabled
+				logger.Warn("filter subscriptions are not persisted, as they require the store migrations to be enabled")
+			}
+		}
+		nodeOpts = append(nodeOpts, node.WithWakuFilterFullNode(filterOpts...))
 	}
 
-	var dbStore *persistence.DBStore
-	if requiresDB(options) {
+	var messageProvider legacy_store.MessageProvider
+	if requiresDB(options) && useKVStore {
+		kvStore, err := leveldb.NewMessageStore(kvStorePath, prometheus.DefaultRegisterer, logger,
+			leveldb.WithRetentionPolicy(options.Store.RetentionMaxMessages, options.Store.RetentionTime))
+		if err != nil {
+			return nonRecoverErrorMsg("error setting up key-value store: %w", err)
+		}
+
+		messageProvider = kvStore
+	} else if requiresDB(options) {
 		dbOptions := []persistence.DBOption{
 			persistence.WithDB(db),
 			persistence.WithRetentionPolicy(options.Store.RetentionMaxMessages, options.Store.RetentionTime),
+			persistence.WithRetentionRules(options.Store.RetentionRules...),
+			persistence.WithRetentionMaxBytes(retentionMaxBytes),
+		}
+
+		if partitionInterval > 0 {
+			dbOptions = append(dbOptions, persistence.WithPartitions(partitionInterval, futurePartitions))
+		}
+
+		if options.Store.BatchSize > 0 {
+			dbOptions = append(dbOptions, persistence.WithBatchedWrites(options.Store.BatchSize, options.Store.BatchInterval, options.Store.WriteQueueSize))
 		}
 
 		if options.Store.Migration {
 			dbOptions = append(dbOptions, persistence.WithMigrations(migrationFn)) // TODO: refactor migrations out of DBStore, or merge DBStore with rendezvous DB
 		}
 
-		dbStore, err = persistence.NewDBStore(prometheus.DefaultRegisterer, logger, dbOptions...)
+		dbStore, err := persistence.NewDBStore(prometheus.DefaultRegisterer, logger, dbOptions...)
 		if err != nil {
 			return nonRecoverErrorMsg("error setting up db store: %w", err)
 		}
 
-		nodeOpts = append(nodeOpts, node.WithMessageProvider(dbStore))
+		messageProvider = dbStore
+	}
+
+	if messageProvider != nil {
+		nodeOpts = append(nodeOpts, node.WithMessageProvider(messageProvider))
 	}
 
 	if options.Store.Enable {
 		nodeOpts = append(nodeOpts, node.WithWakuStore())
-		nodeOpts = append(nodeOpts, node.WithMessageProvider(dbStore))
+
+		if options.Store.SyncEnable {
+			syncOpts := []storesync.Option{
+				storesync.WithInterval(options.Store.SyncInterval),
+				storesync.WithSyncRange(options.Store.SyncRange),
+			}
+
+			var syncPeers []peer.ID
+			for _, addr := range options.Store.SyncNodes {
+				info, err := peer.AddrInfoFromP2pAddr(addr)
+				if err != nil {
+					return nonRecoverErrorMsg("invalid store sync node address: %w", err)
+				}
+				syncPeers = append(syncPeers, info.ID)
+			}
+			if len(syncPeers) != 0 {
+				syncOpts = append(syncOpts, storesync.WithPeers(syncPeers...))
+			}
+
+			nodeOpts = append(nodeOpts, node.WithWakuStoreSync(syncOpts...))
+		}
 	}
 
 	if options.LightPush.Enable {
@@ -357,6 +506,11 @@ func Execute(options NodeOptions) error {
 		}
 	}
 
+	// Store sync nodes also need to support the store protocol to retrieve the missing messages
+	if err = addStaticPeers(wakuNode, options.Store.SyncNodes, pubSubTopicMapKeys, storesync.StoreSyncID_v100, store.StoreQueryID_v300); err != nil {
+		return err
+	}
+
 	var wg sync.WaitGroup
 
 	if options.Relay.Enable {
@@ -365,6 +519,20 @@ func Execute(options NodeOptions) error {
 		}
 	}
 
+	stopBridge := func() {}
+	if options.Relay.Enable && len(options.Bridge.Rules.Value()) != 0 {
+		if stopBridge, err = startBridge(ctx, wakuNode, options, logger); err != nil {
+			return nonRecoverError(err)
+		}
+	}
+
+	stopWebhookSink := func() {}
+	if len(options.Webhook.Endpoints.Value()) != 0 {
+		if stopWebhookSink, err = startWebhookSink(ctx, wakuNode, options, logger); err != nil {
+			return nonRecoverError(err)
+		}
+	}
+
 	for _, n := range options.StaticNodes {
 		go func(ctx context.Context, node multiaddr.Multiaddr) {
 			ctx, cancel := context.WithTimeout(ctx, dialTimeout)
@@ -405,6 +573,8 @@ func Execute(options NodeOptions) error {
 			Port:                uint(options.RESTServer.Port),
 			EnablePProf:         options.PProf,
 			EnableAdmin:         options.RESTServer.Admin,
+			AdminToken:          options.RESTServer.AdminToken,
+			AdminAuditLog:       options.RESTServer.AdminAuditLog,
 			RelayCacheCapacity:  uint(options.RESTServer.RelayCacheCapacity),
 			FilterCacheCapacity: uint(options.RESTServer.FilterCacheCapacity)}
 
@@ -422,6 +592,8 @@ func Execute(options NodeOptions) error {
 	logger.Info("Received signal, shutting down...")
 
 	// shut the node down
+	stopWebhookSink()
+	stopBridge()
 	wakuNode.Stop()
 
 	if options.RESTServer.Enable {
@@ -479,6 +651,19 @@ func processTopics(options NodeOptions) (map[string][]string, error) {
 	return pubSubTopicMap, nil
 }
 
+func getPartitionInterval(partitioning string) (time.Duration, error) {
+	switch partitioning {
+	case "":
+		return 0, nil
+	case "hourly":
+		return time.Hour, nil
+	case "daily":
+		return 24 * time.Hour, nil
+	default:
+		return 0, fmt.Errorf("invalid message table partitioning: %s", partitioning)
+	}
+}
+
 func addStaticPeers(wakuNode *node.WakuNode, addresses []multiaddr.Multiaddr, pubSubTopics []string, protocols ...protocol.ID) error {
 	for _, addr := range addresses {
 		_, err := wakuNode.AddPeer(addr, wakupeerstore.Static, pubSubTopics, protocols...)
@@ -489,6 +674,37 @@ func addStaticPeers(wakuNode *node.WakuNode, addresses []multiaddr.Multiaddr, pu
 	return nil
 }
 
+// startWebhookSink posts the messages received through relay or filter subscriptions to the webhooks
+func startWebhookSink(ctx context.Context, wakuNode *node.WakuNode, options NodeOptions, logger *zap.Logger) (func(), error) {
+	var endpoints []webhook.Endpoint
+	for _, value := range options.Webhook.Endpoints.Value() {
+		endpoint, err := webhook.ParseEndpoint(value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid webhook %s: %w", value, err)
+		}
+		endpoints = append(endpoints, endpoint)
+	}
+
+	webhookOpts := []webhook.Option{
+		webhook.WithMaxAttempts(options.Webhook.MaxAttempts),
+		webhook.WithTimeout(options.Webhook.Timeout),
+		webhook.WithDeadLetterFile(options.Webhook.DeadLetterFile),
+	}
+	if options.Webhook.Secret != "" {
+		webhookOpts = append(webhookOpts, webhook.WithSecret([]byte(options.Webhook.Secret)))
+	}
+
+	sink, err := webhook.NewSink(wakuNode.Broadcaster(), endpoints, prometheus.DefaultRegisterer, logger, webhookOpts...)
+	if err == nil {
+		err = sink.Start(ctx)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("could not start webhook sink: %w", err)
+	}
+
+	return sink.Stop, nil
+}
+
 func loadPrivateKeyFromFile(path string, passwd string) (*ecdsa.PrivateKey, error) {
 	src, err := os.ReadFile(path)
 	if err != nil {
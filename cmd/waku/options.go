@@ -8,6 +8,7 @@ import (
 	"github.com/multiformats/go-multiaddr"
 	"github.com/urfave/cli/v2"
 	"github.com/waku-org/go-waku/waku/cliutils"
+	"github.com/waku-org/go-waku/waku/persistence"
 )
 
 // DiscV5Options are settings to enable a modified version of Ethereum’s Node
@@ -26,11 +27,47 @@ type RelayOptions struct {
 	Enable                 bool
 	Topics                 cli.StringSlice
 	ProtectedTopics        []cliutils.ProtectedTopic
+	ProtectedTopicStateDir string
 	PubSubTopics           cli.StringSlice
 	ContentTopics          cli.StringSlice
 	PeerExchange           bool
 	MinRelayPeersToPublish int
 	MaxMsgSize             string
+	PeerRateLimit          float64
+	PeerRateBurst          int
+	ContentTopicRateLimit  float64
+	ContentTopicRateBurst  int
+	RateLimitPenalty       string
+	TraceFile              string
+	TraceFormat            string
+	TraceMaxFileSize       string
+	TraceMaxFiles          int
+	GossipsubProfile       string
+	ShardDemand            bool
+	ShardDemandMaxShards   int
+	ShardDemandIdleTimeout time.Duration
+}
+
+// BridgeOptions are settings used to forward the messages of some pubsub topics
+// to others. The destination topics can belong to another cluster, in which
+// case a second node connected to that cluster is started
+type BridgeOptions struct {
+	Rules       cli.StringSlice
+	SigningKeys []*ecdsa.PrivateKey
+	StaticNodes []multiaddr.Multiaddr
+	Port        int
+	RateLimit   float64
+	RateBurst   int
+}
+
+// WebhookOptions are settings used to post the messages received by the node
+// to HTTP endpoints
+type WebhookOptions struct {
+	Endpoints      cli.StringSlice
+	Secret         string
+	MaxAttempts    int
+	Timeout        time.Duration
+	DeadLetterFile string
 }
 
 // RLNRelayOptions are settings used to enable RLN Relay. This is a protocol
@@ -56,6 +93,11 @@ type FilterOptions struct {
 	DisableFullNode bool
 	Nodes           []multiaddr.Multiaddr
 	Timeout         time.Duration
+	Persist         bool
+	MaxPeers        int
+	MaxCriteria     int
+	PushRateLimit   float64
+	PushRateBurst   int
 }
 
 // LightpushOptions are settings used to enable the lightpush protocol. This is
@@ -77,9 +119,21 @@ type StoreOptions struct {
 	DatabaseURL          string
 	RetentionTime        time.Duration
 	RetentionMaxMessages int
+	RetentionMaxSize     string
+	RetentionRules       []persistence.RetentionRule
 	//ResumeNodes          []multiaddr.Multiaddr
-	Nodes     []multiaddr.Multiaddr
-	Migration bool
+	Nodes        []multiaddr.Multiaddr
+	Migration    bool
+	Partitioning string
+
+	BatchSize      int
+	BatchInterval  time.Duration
+	WriteQueueSize int
+
+	SyncEnable   bool
+	SyncInterval time.Duration
+	SyncRange    time.Duration
+	SyncNodes    []multiaddr.Multiaddr
 }
 
 // DNSDiscoveryOptions are settings used for enabling DNS-based discovery
@@ -105,6 +159,8 @@ type RESTServerOptions struct {
 	Port                int
 	Address             string
 	Admin               bool
+	AdminToken          string
+	AdminAuditLog       string
 	RelayCacheCapacity  int
 	FilterCacheCapacity int
 }
@@ -136,6 +192,7 @@ type RendezvousOptions struct {
 // NodeOptions contains all the available features and settings that can be
 // configured via flags when executing go-waku as a service.
 type NodeOptions struct {
+	ConfigFile                   string
 	Port                         int
 	Address                      string
 	ClusterID                    uint
@@ -166,6 +223,8 @@ type NodeOptions struct {
 	PeerExchange PeerExchangeOptions
 	Websocket    WSOptions
 	Relay        RelayOptions
+	Bridge       BridgeOptions
+	Webhook      WebhookOptions
 	Store        StoreOptions
 	Filter       FilterOptions
 	LightPush    LightpushOptions
@@ -2,6 +2,9 @@ package main
 
 import (
 	"context"
+	"net/url"
+	"os"
+	"path/filepath"
 	"sync"
 	"time"
 
@@ -72,9 +75,18 @@ func handleRelayTopics(ctx context.Context, wg *sync.WaitGroup, wakuNode *node.W
 	}
 
 	// Protected topics
+	if options.Relay.ProtectedTopicStateDir != "" && len(options.Relay.ProtectedTopics) != 0 {
+		if err := os.MkdirAll(options.Relay.ProtectedTopicStateDir, 0700); err != nil {
+			return nonRecoverErrorMsg("could not create protected topic state directory: %w", err)
+		}
+	}
+
 	for _, protectedTopic := range options.Relay.ProtectedTopics {
-		if err := wakuNode.Relay().AddSignedTopicValidator(protectedTopic.Topic, protectedTopic.PublicKey); err != nil {
-			return nonRecoverErrorMsg("could not add signed topic validator: %w", err)
+		if options.Relay.ProtectedTopicStateDir != "" {
+			protectedTopic.Config.StatePath = filepath.Join(options.Relay.ProtectedTopicStateDir, url.PathEscape(protectedTopic.Topic)+".keyupdates")
+		}
+		if err := wakuNode.Relay().AddProtectedTopic(protectedTopic.Topic, protectedTopic.Config); err != nil {
+			return nonRecoverErrorMsg("could not add protected topic validator: %w", err)
 		}
 	}
 
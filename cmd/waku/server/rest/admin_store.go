@@ -0,0 +1,172 @@
+package rest
+
+import (
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/waku-org/go-waku/waku/persistence"
+	"github.com/waku-org/go-waku/waku/v2/protocol/pb"
+	"go.uber.org/zap"
+)
+
+// messageDeleter is implemented by the message providers that support removing messages
+type messageDeleter interface {
+	DeleteMessages(criteria persistence.DeleteCriteria) (int64, error)
+}
+
+// AdminStoreService exposes the administrative operations of the message store. Every request
+// must be authenticated with a bearer token, and produces an entry in the audit log
+type AdminStoreService struct {
+	msgDeleter messageDeleter
+	token      string
+	audit      *zap.Logger
+	log        *zap.Logger
+}
+
+type DeleteMessagesResponse struct {
+	Deleted int64 `json:"deleted"`
+}
+
+const routeAdminV1StoreMessages = "/admin/v1/store/messages"
+
+func NewAdminStoreService(msgDeleter messageDeleter, m *chi.Mux, token string, audit *zap.Logger, log *zap.Logger) *AdminStoreService {
+	s := &AdminStoreService{
+		msgDeleter: msgDeleter,
+		token:      token,
+		audit:      audit,
+		log:        log.Named("admin-store"),
+	}
+
+	m.With(s.authenticate).Delete(routeAdminV1StoreMessages, s.deleteV1Messages)
+
+	return s
+}
+
+// newAuditLogger creates a logger that appends JSON entries to a file. If no file is
+// specified, the audit entries are written to the node logger
+func newAuditLogger(path string, log *zap.Logger) (*zap.Logger, error) {
+	if path == "" {
+		return log.Named("audit"), nil
+	}
+
+	cfg := zap.NewProductionConfig()
+	cfg.OutputPaths = []string{path}
+	cfg.ErrorOutputPaths = []string{"stderr"}
+	cfg.Sampling = nil
+	cfg.DisableCaller = true
+	cfg.DisableStacktrace = true
+	return cfg.Build()
+}
+
+func (s *AdminStoreService) authenticate(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		auth := req.Header.Get("Authorization")
+		token, found := strings.CutPrefix(auth, "Bearer ")
+		if !found || subtle.ConstantTimeCompare([]byte(token), []byte(s.token)) != 1 {
+			s.audit.Warn("unauthorized admin request",
+				zap.String("method", req.Method),
+				zap.String("path", req.URL.Path),
+				zap.String("remoteAddr", req.RemoteAddr))
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		next.ServeHTTP(w, req)
+	})
+}
+
+func getDeleteCriteria(r *http.Request) (persistence.DeleteCriteria, error) {
+	var criteria persistence.DeleteCriteria
+
+	if hashesStr := r.URL.Query().Get("hashes"); hashesStr != "" {
+		for _, hashStr := range strings.Split(hashesStr, ",") {
+			hash, err := base64.URLEncoding.DecodeString(hashStr)
+			if err != nil {
+				return criteria, errors.New("invalid value for hashes. Use base64 url encoded message hashes")
+			}
+			if len(hash) != len(pb.MessageHash{}) {
+				return criteria, errors.New("invalid message hash length")
+			}
+			criteria.MessageHashes = append(criteria.MessageHashes, pb.ToMessageHash(hash))
+		}
+	}
+
+	criteria.PubsubTopic = r.URL.Query().Get("pubsubTopic")
+
+	if contentTopics := r.URL.Query().Get("contentTopics"); contentTopics != "" {
+		criteria.ContentTopics = strings.Split(contentTopics, ",")
+	}
+
+	if startTimeStr := r.URL.Query().Get("startTime"); startTimeStr != "" {
+		startTime, err := strconv.ParseInt(startTimeStr, 10, 64)
+		if err != nil {
+			return criteria, errors.New("invalid value for startTime. Use unix time in nanoseconds")
+		}
+		criteria.StartTime = &startTime
+	}
+
+	if endTimeStr := r.URL.Query().Get("endTime"); endTimeStr != "" {
+		endTime, err := strconv.ParseInt(endTimeStr, 10, 64)
+		if err != nil {
+			return criteria, errors.New("invalid value for endTime. Use unix time in nanoseconds")
+		}
+		criteria.EndTime = &endTime
+	}
+
+	if criteria.IsEmpty() {
+		return criteria, persistence.ErrEmptyDeleteCriteria
+	}
+
+	return criteria, nil
+}
+
+func criteriaFields(criteria persistence.DeleteCriteria) []zap.Field {
+	var hashes []string
+	for _, hash := range criteria.MessageHashes {
+		hashes = append(hashes, hash.String())
+	}
+
+	fields := []zap.Field{
+		zap.Strings("messageHashes", hashes),
+		zap.String("pubsubTopic", criteria.PubsubTopic),
+		zap.Strings("contentTopics", criteria.ContentTopics),
+	}
+	if criteria.StartTime != nil {
+		fields = append(fields, zap.Int64("startTime", *criteria.StartTime))
+	}
+	if criteria.EndTime != nil {
+		fields = append(fields, zap.Int64("endTime", *criteria.EndTime))
+	}
+	return fields
+}
+
+func (s *AdminStoreService) deleteV1Messages(w http.ResponseWriter, req *http.Request) {
+	requestFields := []zap.Field{
+		zap.String("remoteAddr", req.RemoteAddr),
+		zap.String("userAgent", req.UserAgent()),
+	}
+
+	criteria, err := getDeleteCriteria(req)
+	if err != nil {
+		s.audit.Warn("invalid message deletion request", append(requestFields, zap.String("query", req.URL.RawQuery), zap.Error(err))...)
+		writeErrResponse(w, s.log, err, http.StatusBadRequest)
+		return
+	}
+
+	fields := append(requestFields, criteriaFields(criteria)...)
+
+	deleted, err := s.msgDeleter.DeleteMessages(criteria)
+	if err != nil {
+		s.audit.Error("message deletion failed", append(fields, zap.Error(err))...)
+		writeErrResponse(w, s.log, err, http.StatusInternalServerError)
+		return
+	}
+
+	s.audit.Info("messages deleted", append(fields, zap.Int64("deleted", deleted))...)
+
+	writeResponse(w, DeleteMessagesResponse{Deleted: deleted}, http.StatusOK)
+}
@@ -0,0 +1,98 @@
+package rest
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/require"
+	"github.com/waku-org/go-waku/tests"
+	"github.com/waku-org/go-waku/waku/v2/protocol"
+	"github.com/waku-org/go-waku/waku/v2/protocol/pb"
+	"github.com/waku-org/go-waku/waku/v2/utils"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+	"google.golang.org/protobuf/proto"
+)
+
+func TestDeleteStoreMessages(t *testing.T) {
+	db := MemoryDB(t)
+
+	pubsubTopic := "/waku/2/rs/99/1"
+	now := *utils.GetUnixEpoch()
+	msg1 := tests.CreateWakuMessage("ct1", proto.Int64(now+1))
+	msg2 := tests.CreateWakuMessage("ct1", proto.Int64(now+2))
+	msg3 := tests.CreateWakuMessage("ct2", proto.Int64(now+3))
+	msg4 := tests.CreateWakuMessage("ct3", proto.Int64(now+4))
+	for _, msg := range []*pb.WakuMessage{msg1, msg2, msg3, msg4} {
+		require.NoError(t, db.Put(protocol.NewEnvelope(msg, msg.GetTimestamp(), pubsubTopic)))
+	}
+
+	core, auditLogs := observer.New(zapcore.InfoLevel)
+	router := chi.NewRouter()
+	_ = NewAdminStoreService(db, router, "secret", zap.New(core), utils.Logger())
+
+	deleteMessages := func(query url.Values, token string) *httptest.ResponseRecorder {
+		req, err := http.NewRequest(http.MethodDelete, routeAdminV1StoreMessages+"?"+query.Encode(), nil)
+		require.NoError(t, err)
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+		return rr
+	}
+
+	// Requests without a valid token are rejected
+	rr := deleteMessages(url.Values{"contentTopics": {"ct1"}}, "")
+	require.Equal(t, http.StatusUnauthorized, rr.Code)
+	rr = deleteMessages(url.Values{"contentTopics": {"ct1"}}, "invalid")
+	require.Equal(t, http.StatusUnauthorized, rr.Code)
+
+	// At least one criteria is required
+	rr = deleteMessages(url.Values{}, "secret")
+	require.Equal(t, http.StatusBadRequest, rr.Code)
+
+	count, err := db.Count()
+	require.NoError(t, err)
+	require.Equal(t, 4, count)
+
+	// Delete by hash
+	hash := msg1.Hash(pubsubTopic)
+	rr = deleteMessages(url.Values{"hashes": {base64.URLEncoding.EncodeToString(hash[:])}}, "secret")
+	require.Equal(t, http.StatusOK, rr.Code)
+	var response DeleteMessagesResponse
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &response))
+	require.Equal(t, int64(1), response.Deleted)
+
+	// Delete by content topic and time range
+	rr = deleteMessages(url.Values{
+		"pubsubTopic":   {pubsubTopic},
+		"contentTopics": {"ct1,ct2"},
+		"startTime":     {fmt.Sprint(now + 3)},
+		"endTime":       {fmt.Sprint(now + 4)},
+	}, "secret")
+	require.Equal(t, http.StatusOK, rr.Code)
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &response))
+	require.Equal(t, int64(1), response.Deleted)
+
+	messages, err := db.GetAll()
+	require.NoError(t, err)
+	require.Len(t, messages, 2)
+	require.Equal(t, msg2.Hash(pubsubTopic), messages[0].Message.Hash(pubsubTopic))
+	require.Equal(t, msg4.Hash(pubsubTopic), messages[1].Message.Hash(pubsubTopic))
+
+	// Every request was audited
+	require.Equal(t, 2, auditLogs.FilterMessage("unauthorized admin request").Len())
+	require.Equal(t, 1, auditLogs.FilterMessage("invalid message deletion request").Len())
+	deletions := auditLogs.FilterMessage("messages deleted").All()
+	require.Len(t, deletions, 2)
+	require.Equal(t, int64(1), deletions[0].ContextMap()["deleted"])
+	require.Equal(t, []interface{}{hash.String()}, deletions[0].ContextMap()["messageHashes"])
+}
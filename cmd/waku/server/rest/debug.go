@@ -4,6 +4,7 @@ import (
 	"net/http"
 
 	"github.com/go-chi/chi/v5"
+	pubsub "github.com/libp2p/go-libp2p-pubsub"
 	"github.com/waku-org/go-waku/waku/v2/node"
 )
 
@@ -22,6 +23,7 @@ type InfoReply struct {
 
 const routeDebugInfoV1 = "/debug/v1/info"
 const routeDebugVersionV1 = "/debug/v1/version"
+const routeDebugRelayParamsV1 = "/debug/v1/relay/params"
 
 func NewDebugService(node *node.WakuNode, m *chi.Mux) *DebugService {
 	d := &DebugService{
@@ -31,12 +33,20 @@ func NewDebugService(node *node.WakuNode, m *chi.Mux) *DebugService {
 
 	m.Get(routeDebugInfoV1, d.getV1Info)
 	m.Get(routeDebugVersionV1, d.getV1Version)
+	m.Get(routeDebugRelayParamsV1, d.getV1RelayParams)
 
 	return d
 }
 
 type VersionResponse string
 
+type RelayParamsReply struct {
+	Profile string                 `json:"profile"`
+	Params  pubsub.GossipSubParams `json:"params"`
+	// Topics contains the peer score parameters of the pubsub topics the node is subscribed to
+	Topics map[string]*pubsub.TopicScoreParams `json:"topics"`
+}
+
 func (d *DebugService) getV1Info(w http.ResponseWriter, req *http.Request) {
 	response := new(InfoReply)
 	response.ENRUri = d.node.ENR().String()
@@ -50,3 +60,21 @@ func (d *DebugService) getV1Version(w http.ResponseWriter, req *http.Request) {
 	response := VersionResponse(node.GetVersionInfo().String())
 	writeErrOrResponse(w, nil, response)
 }
+
+func (d *DebugService) getV1RelayParams(w http.ResponseWriter, req *http.Request) {
+	wakuRelay := d.node.Relay()
+	if wakuRelay == nil {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+
+	response := RelayParamsReply{
+		Profile: wakuRelay.Profile(),
+		Params:  wakuRelay.Params(),
+		Topics:  make(map[string]*pubsub.TopicScoreParams),
+	}
+	for _, topic := range wakuRelay.Topics() {
+		response.Topics[topic] = wakuRelay.TopicScoreParams(topic)
+	}
+	writeErrOrResponse(w, nil, response)
+}
@@ -3,12 +3,15 @@ package rest
 import (
 	"bytes"
 	"context"
+	"encoding/json"
 	"net/http"
 	"net/http/httptest"
 	"testing"
 
 	"github.com/stretchr/testify/require"
 	"github.com/waku-org/go-waku/waku/v2/node"
+	"github.com/waku-org/go-waku/waku/v2/protocol"
+	"github.com/waku-org/go-waku/waku/v2/protocol/relay"
 )
 
 func TestGetV1Info(t *testing.T) {
@@ -31,3 +34,33 @@ func TestGetV1Info(t *testing.T) {
 
 	require.Equal(t, http.StatusOK, rr.Code)
 }
+
+func TestGetV1RelayParams(t *testing.T) {
+	wakuNode1, err := node.New(node.WithWakuRelay(), node.WithGossipsubProfile(relay.ProfileMobile))
+	require.NoError(t, err)
+	defer wakuNode1.Stop()
+	err = wakuNode1.Start(context.Background())
+	require.NoError(t, err)
+
+	_, err = wakuNode1.Relay().Subscribe(context.Background(), protocol.NewContentFilter(relay.DefaultWakuTopic))
+	require.NoError(t, err)
+
+	d := &DebugService{
+		node: wakuNode1,
+	}
+
+	request, err := http.NewRequest(http.MethodGet, routeDebugRelayParamsV1, bytes.NewReader([]byte("")))
+	require.NoError(t, err)
+
+	rr := httptest.NewRecorder()
+
+	d.getV1RelayParams(rr, request)
+
+	require.Equal(t, http.StatusOK, rr.Code)
+
+	var response RelayParamsReply
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &response))
+	require.Equal(t, relay.ProfileMobile, response.Profile)
+	require.Equal(t, 3, response.Params.D)
+	require.Contains(t, response.Topics, relay.DefaultWakuTopic)
+}
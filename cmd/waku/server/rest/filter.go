@@ -18,6 +18,7 @@ import (
 
 const filterV2Subscriptions = "/filter/v2/subscriptions"
 const filterv2Messages = "/filter/v2/messages"
+const filterv2Stream = "/filter/v2/stream"
 
 // FilterService represents the REST service for Filter client
 type FilterService struct {
@@ -26,8 +27,9 @@ type FilterService struct {
 
 	log *zap.Logger
 
-	cache  *filterCache
-	runner *runnerService
+	cache    *filterCache
+	runner   *runnerService
+	streamer *messageStreamer
 }
 
 // Start starts the RelayService
@@ -44,6 +46,7 @@ func (s *FilterService) Start(ctx context.Context) {
 
 // Stop stops the RelayService
 func (r *FilterService) Stop() {
+	r.streamer.stop()
 	if r.cancel == nil {
 		return
 	}
@@ -55,9 +58,10 @@ func NewFilterService(node *node.WakuNode, m *chi.Mux, cacheCapacity int, log *z
 	logger := log.Named("filter")
 
 	s := &FilterService{
-		node:  node,
-		log:   logger,
-		cache: newFilterCache(cacheCapacity, logger),
+		node:     node,
+		log:      logger,
+		cache:    newFilterCache(cacheCapacity, logger),
+		streamer: newMessageStreamer(node, logger),
 	}
 
 	m.Route(filterV2Subscriptions, func(r chi.Router) {
@@ -73,6 +77,8 @@ func NewFilterService(node *node.WakuNode, m *chi.Mux, cacheCapacity int, log *z
 		r.Get("/{pubsubTopic}/{contentTopic}", s.getMessagesByPubsubTopic)
 	})
 
+	m.Get(filterv2Stream, s.stream)
+
 	s.runner = newRunnerService(node.Broadcaster(), s.cache.addMessage)
 
 	return s
@@ -378,3 +384,23 @@ func (s *FilterService) getMessages(w http.ResponseWriter, req *http.Request, pu
 	}
 	writeResponse(w, msgs, http.StatusOK)
 }
+
+// 400 on invalid request
+// 404 if the node is not subscribed to the content filter
+// streams the messages pushed by filter full nodes until the client disconnects
+func (s *FilterService) stream(w http.ResponseWriter, req *http.Request) {
+	contentFilter, err := contentFilterFromQuery(req)
+	if err != nil {
+		writeGetMessageErr(w, err, http.StatusBadRequest, s.log)
+		return
+	}
+
+	for _, contentTopic := range contentFilter.ContentTopicsList() {
+		if !s.node.FilterLightnode().IsListening(contentFilter.PubsubTopic, contentTopic) {
+			writeGetMessageErr(w, fmt.Errorf("not subscribed to content topic %s", contentTopic), http.StatusNotFound, s.log)
+			return
+		}
+	}
+
+	s.streamer.serve(w, req, contentFilter)
+}
@@ -2,6 +2,7 @@ package rest
 
 import (
 	"encoding/json"
+	"fmt"
 	"net/http"
 	"strings"
 
@@ -20,6 +21,8 @@ const routeRelayV1Messages = "/relay/v1/messages/{topic}"
 const routeRelayV1AutoSubscriptions = "/relay/v1/auto/subscriptions"
 const routeRelayV1AutoMessages = "/relay/v1/auto/messages"
 
+const routeRelayV1Stream = "/relay/v1/stream"
+
 // RelayService represents the REST service for WakuRelay
 type RelayService struct {
 	node *node.WakuNode
@@ -27,14 +30,19 @@ type RelayService struct {
 	log *zap.Logger
 
 	cacheCapacity uint
+
+	streamer *messageStreamer
 }
 
 // NewRelayService returns an instance of RelayService
 func NewRelayService(node *node.WakuNode, m *chi.Mux, cacheCapacity uint, log *zap.Logger) *RelayService {
+	logger := log.Named("relay")
+
 	s := &RelayService{
 		node:          node,
-		log:           log.Named("relay"),
+		log:           logger,
 		cacheCapacity: cacheCapacity,
+		streamer:      newMessageStreamer(node, logger),
 	}
 
 	m.Post(routeRelayV1Subscriptions, s.postV1Subscriptions)
@@ -50,9 +58,16 @@ func NewRelayService(node *node.WakuNode, m *chi.Mux, cacheCapacity uint, log *z
 		r.Post("/", s.postV1AutoMessage)
 	})
 
+	m.Get(routeRelayV1Stream, s.getV1Stream)
+
 	return s
 }
 
+// Stop closes the open message streams
+func (r *RelayService) Stop() {
+	r.streamer.stop()
+}
+
 func (r *RelayService) deleteV1Subscriptions(w http.ResponseWriter, req *http.Request) {
 	var topics []string
 	decoder := json.NewDecoder(req.Body)
@@ -314,3 +329,18 @@ func (r *RelayService) postV1AutoMessage(w http.ResponseWriter, req *http.Reques
 	}
 
 }
+
+func (r *RelayService) getV1Stream(w http.ResponseWriter, req *http.Request) {
+	contentFilter, err := contentFilterFromQuery(req)
+	if err != nil {
+		writeGetMessageErr(w, err, http.StatusBadRequest, r.log)
+		return
+	}
+
+	if !r.node.Relay().IsSubscribed(contentFilter.PubsubTopic) {
+		writeGetMessageErr(w, fmt.Errorf("not subscribed to pubsub topic %s", contentFilter.PubsubTopic), http.StatusNotFound, r.log)
+		return
+	}
+
+	r.streamer.serve(w, req, contentFilter)
+}
@@ -1,6 +1,7 @@
 package rest
 
 import (
+	"bufio"
 	"bytes"
 	"context"
 	"encoding/json"
@@ -8,6 +9,7 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"net/url"
+	"strings"
 	"testing"
 	"time"
 
@@ -301,3 +303,69 @@ func TestRelayGetV1AutoMessages(t *testing.T) {
 	require.Equal(t, http.StatusNotFound, rr.Code)
 
 }
+
+func TestRelayStream(t *testing.T) {
+	router := chi.NewRouter()
+	r := makeRelayService(t, router)
+	r.streamer.heartbeat = 200 * time.Millisecond
+
+	server := httptest.NewServer(router)
+	defer server.Close()
+	defer r.Stop()
+
+	cTopic := "/toychat/1/huilong/proto"
+	streamURL := fmt.Sprintf("%s%s?pubsubTopic=test&contentTopics=%s", server.URL, routeRelayV1Stream, url.QueryEscape(cTopic))
+
+	// Content topics are required
+	rr := httptest.NewRecorder()
+	req, _ := http.NewRequest(http.MethodGet, routeRelayV1Stream+"?pubsubTopic=test", nil)
+	router.ServeHTTP(rr, req)
+	require.Equal(t, http.StatusBadRequest, rr.Code)
+
+	// The node must be subscribed to the pubsub topic
+	resp, err := http.Get(streamURL)
+	require.NoError(t, err)
+	require.Equal(t, http.StatusNotFound, resp.StatusCode)
+	resp.Body.Close()
+
+	_, err = r.node.Relay().Subscribe(context.Background(), protocol.NewContentFilter("test"))
+	require.NoError(t, err)
+
+	resp, err = http.Get(streamURL)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	require.Equal(t, "text/event-stream", resp.Header.Get("Content-Type"))
+
+	events := make(chan streamEvent, 10)
+	go func() {
+		scanner := bufio.NewScanner(resp.Body)
+		for scanner.Scan() {
+			if data, ok := strings.CutPrefix(scanner.Text(), "data: "); ok {
+				var event streamEvent
+				if json.Unmarshal([]byte(data), &event) == nil {
+					events <- event
+				}
+			}
+		}
+		close(events)
+	}()
+
+	require.Equal(t, streamEventHeartbeat, (<-events).Type)
+
+	_, err = r.node.Relay().Publish(context.Background(), tests.CreateWakuMessage("other", utils.GetUnixEpoch()), relay.WithPubSubTopic("test"))
+	require.NoError(t, err)
+	_, err = r.node.Relay().Publish(context.Background(), tests.CreateWakuMessage(cTopic, utils.GetUnixEpoch()), relay.WithPubSubTopic("test"))
+	require.NoError(t, err)
+
+	// Only the messages of the content topic are streamed
+	for event := range events {
+		if event.Type == streamEventHeartbeat {
+			continue
+		}
+		require.Equal(t, streamEventMessage, event.Type)
+		require.Equal(t, "test", event.PubsubTopic)
+		require.Equal(t, cTopic, event.Message.ContentTopic)
+		break
+	}
+}
@@ -0,0 +1,398 @@
+package rest
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/gorilla/websocket"
+	"github.com/waku-org/go-waku/waku/v2/node"
+	"github.com/waku-org/go-waku/waku/v2/protocol"
+	"github.com/waku-org/go-waku/waku/v2/protocol/pb"
+	"github.com/waku-org/go-waku/waku/v2/protocol/relay"
+	"github.com/waku-org/go-waku/waku/v2/protocol/store"
+	"github.com/waku-org/go-waku/waku/v2/utils"
+	"go.uber.org/zap"
+)
+
+// streamHeartbeatInterval is the frequency at which heartbeats are sent to streaming clients,
+// so proxies keep the connection open and clients can detect a stale stream
+const streamHeartbeatInterval = 15 * time.Second
+
+// streamBufferSize is the number of messages waiting to be sent to a streaming client. Messages
+// are dropped once it's full, and the client is notified so it can resume from the store
+const streamBufferSize = 1024
+
+// streamMaxResumeMessages is the maximum number of messages retrieved from the store when a
+// client resumes a stream
+const streamMaxResumeMessages = 1000
+
+const streamResumePageSize = 100
+
+const (
+	streamEventMessage   = "message"
+	streamEventHeartbeat = "heartbeat"
+	streamEventOverflow  = "overflow"
+	streamEventError     = "error"
+)
+
+var errStreamingUnsupported = errors.New("streaming is not supported by the connection")
+
+var errStreamerStopped = errors.New("streaming is stopped")
+
+// streamEvent is sent to streaming clients. With server-sent events, the type is the name of the
+// event and the message hash is its id. With WebSocket, each event is a JSON text message
+type streamEvent struct {
+	Type        string           `json:"type"`
+	MessageHash string           `json:"messageHash,omitempty"`
+	PubsubTopic string           `json:"pubsubTopic,omitempty"`
+	Message     *RestWakuMessage `json:"message,omitempty"`
+	Description string           `json:"description,omitempty"`
+	Timestamp   int64            `json:"timestamp,omitempty"`
+}
+
+type streamWriter interface {
+	write(event streamEvent) error
+	close()
+}
+
+// sseWriter sends events with the server-sent events protocol
+type sseWriter struct {
+	w       http.ResponseWriter
+	flusher http.Flusher
+}
+
+func newSSEWriter(w http.ResponseWriter) (*sseWriter, error) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		return nil, errStreamingUnsupported
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Connection", "keep-alive")
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	return &sseWriter{w: w, flusher: flusher}, nil
+}
+
+func (s *sseWriter) write(event streamEvent) error {
+	data, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+
+	if event.MessageHash != "" {
+		_, err = fmt.Fprintf(s.w, "event: %s\nid: %s\ndata: %s\n\n", event.Type, event.MessageHash, data)
+	} else {
+		_, err = fmt.Fprintf(s.w, "event: %s\ndata: %s\n\n", event.Type, data)
+	}
+	if err != nil {
+		return err
+	}
+
+	s.flusher.Flush()
+	return nil
+}
+
+func (s *sseWriter) close() {}
+
+// wsWriter sends events as JSON text messages over a WebSocket
+type wsWriter struct {
+	conn *websocket.Conn
+}
+
+func (s *wsWriter) write(event streamEvent) error {
+	err := s.conn.SetWriteDeadline(time.Now().Add(streamHeartbeatInterval))
+	if err != nil {
+		return err
+	}
+	return s.conn.WriteJSON(event)
+}
+
+func (s *wsWriter) close() {
+	_ = s.conn.WriteControl(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.CloseNormalClosure, ""), time.Now().Add(time.Second))
+	_ = s.conn.Close()
+}
+
+// messageStreamer pushes the messages of a content filter to HTTP clients as they arrive, using
+// server-sent events or WebSocket. Clients can resume a stream from the hash of the last message
+// they received, in which case the messages they missed are retrieved from the store
+type messageStreamer struct {
+	node      *node.WakuNode
+	log       *zap.Logger
+	upgrader  websocket.Upgrader
+	heartbeat time.Duration
+
+	// mu guarantees that no stream is added to wg once stop waits for it
+	mu     sync.Mutex
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+func newMessageStreamer(node *node.WakuNode, log *zap.Logger) *messageStreamer {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &messageStreamer{
+		node: node,
+		log:  log.Named("stream"),
+		upgrader: websocket.Upgrader{
+			CheckOrigin: func(r *http.Request) bool { return true },
+		},
+		heartbeat: streamHeartbeatInterval,
+		ctx:       ctx,
+		cancel:    cancel,
+	}
+}
+
+// stop closes the open streams
+func (s *messageStreamer) stop() {
+	s.mu.Lock()
+	s.cancel()
+	s.mu.Unlock()
+
+	s.wg.Wait()
+}
+
+// add registers a stream, unless the streamer is stopped
+func (s *messageStreamer) add() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.ctx.Err() != nil {
+		return false
+	}
+
+	s.wg.Add(1)
+	return true
+}
+
+// lastHashFromRequest returns the hash of the last message received by the client, from the
+// lastHash query parameter or the Last-Event-ID header sent by browsers when reconnecting
+func lastHashFromRequest(req *http.Request) (*pb.MessageHash, error) {
+	value := req.URL.Query().Get("lastHash")
+	if value == "" {
+		value = req.Header.Get("Last-Event-ID")
+	}
+	if value == "" {
+		return nil, nil
+	}
+
+	b, err := hexutil.Decode(value)
+	if err != nil || len(b) != len(pb.MessageHash{}) {
+		return nil, errors.New("invalid lastHash")
+	}
+
+	hash := pb.ToMessageHash(b)
+	return &hash, nil
+}
+
+// serve streams the messages matching a content filter until the client disconnects
+func (s *messageStreamer) serve(w http.ResponseWriter, req *http.Request, contentFilter protocol.ContentFilter) {
+	lastHash, err := lastHashFromRequest(req)
+	if err != nil {
+		writeGetMessageErr(w, err, http.StatusBadRequest, s.log)
+		return
+	}
+
+	if !s.add() {
+		writeGetMessageErr(w, errStreamerStopped, http.StatusServiceUnavailable, s.log)
+		return
+	}
+	defer s.wg.Done()
+
+	// Messages that arrive while the client is resuming are buffered, up to streamBufferSize.
+	// Further messages are dropped, and the client is sent an overflow event so it can resume
+	// the stream again
+	var overflow atomic.Bool
+	sub := s.node.Broadcaster().Register(contentFilter,
+		relay.WithBufferSize(streamBufferSize),
+		relay.WithOverflowPolicy(relay.OverflowDropNewest),
+		relay.WithDropHandler(func(*protocol.Envelope) { overflow.Store(true) }),
+	)
+	defer sub.Unsubscribe()
+
+	ctx, cancel := context.WithCancel(req.Context())
+	defer cancel()
+
+	var writer streamWriter
+	if websocket.IsWebSocketUpgrade(req) {
+		conn, err := s.upgrader.Upgrade(w, req, nil)
+		if err != nil {
+			// The upgrader already replied with an error
+			s.log.Debug("upgrading to websocket", zap.Error(err))
+			return
+		}
+
+		// Reading is required to process the control messages, and detects when the client disconnects
+		go func() {
+			defer utils.LogOnPanic()
+			defer cancel()
+			for {
+				if _, _, err := conn.NextReader(); err != nil {
+					return
+				}
+			}
+		}()
+
+		writer = &wsWriter{conn: conn}
+	} else {
+		writer, err = newSSEWriter(w)
+		if err != nil {
+			writeGetMessageErr(w, err, http.StatusInternalServerError, s.log)
+			return
+		}
+	}
+	defer writer.close()
+
+	sent := make(map[pb.MessageHash]struct{})
+	if lastHash != nil {
+		if err := s.resume(ctx, writer, contentFilter, *lastHash, sent); err != nil {
+			s.log.Warn("resuming stream", zap.Error(err))
+			if err := writer.write(streamEvent{Type: streamEventError, Description: "could not resume stream: " + err.Error()}); err != nil {
+				return
+			}
+		}
+
+		// Don't wait for the next heartbeat to report the messages dropped while resuming
+		if overflow.Swap(false) {
+			if err := writeOverflow(writer); err != nil {
+				return
+			}
+		}
+	}
+
+	ticker := time.NewTicker(s.heartbeat)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-s.ctx.Done():
+			return
+		case <-ticker.C:
+			if overflow.Swap(false) {
+				err = writeOverflow(writer)
+			} else {
+				err = writer.write(streamEvent{Type: streamEventHeartbeat, Timestamp: time.Now().UnixNano()})
+			}
+			if err != nil {
+				return
+			}
+		case env, ok := <-sub.Ch:
+			if !ok {
+				return
+			}
+
+			hash := env.Hash()
+			if _, ok := sent[hash]; ok {
+				delete(sent, hash)
+				continue
+			}
+
+			if err := s.writeMessage(writer, hash, env.PubsubTopic(), env.Message()); err != nil {
+				return
+			}
+		}
+	}
+}
+
+// resume sends the messages stored after the last message received by the client
+func (s *messageStreamer) resume(ctx context.Context, writer streamWriter, contentFilter protocol.ContentFilter, lastHash pb.MessageHash, sent map[pb.MessageHash]struct{}) error {
+	result, err := s.node.Store().Request(ctx, store.FilterCriteria{ContentFilter: contentFilter},
+		store.WithCursor(lastHash.Bytes()),
+		store.WithPaging(true, streamResumePageSize),
+		store.IncludeData(true),
+	)
+	if err != nil {
+		return err
+	}
+
+	count := 0
+	for {
+		for _, kv := range result.Messages() {
+			if kv.Message == nil {
+				continue
+			}
+
+			hash := pb.ToMessageHash(kv.MessageHash)
+			pubsubTopic := kv.GetPubsubTopic()
+			if pubsubTopic == "" {
+				pubsubTopic = contentFilter.PubsubTopic
+			}
+
+			if err := s.writeMessage(writer, hash, pubsubTopic, kv.Message); err != nil {
+				return err
+			}
+			sent[hash] = struct{}{}
+
+			count++
+			if count >= streamMaxResumeMessages {
+				return writer.write(streamEvent{Type: streamEventOverflow, Description: "too many messages to resume, resume the stream again to retrieve the rest"})
+			}
+		}
+
+		if result.IsComplete() {
+			return nil
+		}
+
+		if err := result.Next(ctx); err != nil {
+			return err
+		}
+	}
+}
+
+// writeOverflow notifies the client that messages were dropped because its buffer was full
+func writeOverflow(writer streamWriter) error {
+	return writer.write(streamEvent{Type: streamEventOverflow, Description: "messages were dropped, resume the stream to retrieve them"})
+}
+
+func (s *messageStreamer) writeMessage(writer streamWriter, hash pb.MessageHash, pubsubTopic string, msg *pb.WakuMessage) error {
+	message := &RestWakuMessage{}
+	if err := message.FromProto(msg); err != nil {
+		s.log.Error("converting protobuffer msg into rest msg", zap.Error(err))
+		return nil
+	}
+
+	return writer.write(streamEvent{
+		Type:        streamEventMessage,
+		MessageHash: hash.String(),
+		PubsubTopic: pubsubTopic,
+		Message:     message,
+	})
+}
+
+// contentFilterFromQuery builds a content filter from the pubsubTopic and contentTopics query
+// parameters. When the pubsub topic is not specified, it's derived from the content topics
+func contentFilterFromQuery(req *http.Request) (protocol.ContentFilter, error) {
+	pubsubTopic := req.URL.Query().Get("pubsubTopic")
+	contentTopics := req.URL.Query().Get("contentTopics")
+	if contentTopics == "" {
+		return protocol.ContentFilter{}, errors.New("contentTopics are required")
+	}
+
+	contentFilter := protocol.NewContentFilter(pubsubTopic, strings.Split(contentTopics, ",")...)
+	if pubsubTopic == "" {
+		pubsubTopics, err := protocol.ContentFilterToPubSubTopicMap(contentFilter)
+		if err != nil {
+			return protocol.ContentFilter{}, err
+		}
+		if len(pubsubTopics) != 1 {
+			return protocol.ContentFilter{}, errors.New("content topics must belong to the same pubsub topic")
+		}
+		for pubsubTopic := range pubsubTopics {
+			contentFilter.PubsubTopic = pubsubTopic
+		}
+	}
+
+	return contentFilter, nil
+}
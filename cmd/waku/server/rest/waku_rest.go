@@ -27,6 +27,8 @@ type RestConfig struct {
 	Port                uint
 	EnablePProf         bool
 	EnableAdmin         bool
+	AdminToken          string
+	AdminAuditLog       string
 	RelayCacheCapacity  uint
 	FilterCacheCapacity uint
 }
@@ -67,11 +69,27 @@ func NewWakuRest(node *node.WakuNode, config RestConfig, log *zap.Logger) *WakuR
 
 	if node.Relay() != nil {
 		relayService := NewRelayService(node, mux, config.RelayCacheCapacity, log)
+		server.RegisterOnShutdown(func() {
+			relayService.Stop()
+		})
 		wrpc.relayService = relayService
 	}
 
 	if config.EnableAdmin {
 		_ = NewAdminService(node, mux, wrpc.log)
+
+		if msgDeleter, ok := node.MessageProvider().(messageDeleter); ok {
+			if config.AdminToken == "" {
+				wrpc.log.Warn("store admin API disabled: an admin token is required")
+			} else if audit, err := newAuditLogger(config.AdminAuditLog, wrpc.log); err != nil {
+				wrpc.log.Error("store admin API disabled: could not open audit log", zap.Error(err))
+			} else {
+				_ = NewAdminStoreService(msgDeleter, mux, config.AdminToken, audit, wrpc.log)
+				server.RegisterOnShutdown(func() {
+					_ = audit.Sync()
+				})
+			}
+		}
 	}
 
 	if node.FilterLightnode() != nil {
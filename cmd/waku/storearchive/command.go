@@ -0,0 +1,279 @@
+package storearchive
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"os"
+
+	"github.com/prometheus/client_golang/prometheus"
+	cli "github.com/urfave/cli/v2"
+	"github.com/waku-org/go-waku/cmd/waku/storearchive/pb"
+	"github.com/waku-org/go-waku/logging"
+	"github.com/waku-org/go-waku/waku/persistence"
+	"github.com/waku-org/go-waku/waku/persistence/leveldb"
+	dbutils "github.com/waku-org/go-waku/waku/persistence/utils"
+	"github.com/waku-org/go-waku/waku/v2/protocol"
+	"github.com/waku-org/go-waku/waku/v2/protocol/legacy_store"
+	storepb "github.com/waku-org/go-waku/waku/v2/protocol/legacy_store/pb"
+	"github.com/waku-org/go-waku/waku/v2/utils"
+	"go.uber.org/zap"
+	"google.golang.org/protobuf/proto"
+)
+
+var logger = utils.Logger().Named("store-archive")
+
+// exportPageSize is the number of messages retrieved from the store on each query
+const exportPageSize = 1000
+
+var archiveFlags = []cli.Flag{
+	DatabaseURL,
+	File,
+	Format,
+	PubsubTopic,
+	ContentTopics,
+	StartTime,
+	EndTime,
+}
+
+// Command groups the subcommands used to back up and restore the message history of a store node
+var Command = cli.Command{
+	Name:  "store",
+	Usage: "Export and import the message history of a store node",
+	Subcommands: []*cli.Command{
+		{
+			Name:  "export",
+			Usage: "Export the stored messages to an archive file",
+			Action: func(cCtx *cli.Context) error {
+				count, err := execute(Options, exportMessages)
+				if err != nil {
+					logger.Error("exporting messages", zap.Error(err))
+					return cli.Exit(err, 1)
+				}
+				logger.Info("messages exported", zap.Int("count", count), zap.String("file", Options.File))
+				return nil
+			},
+			Flags: archiveFlags,
+		},
+		{
+			Name:  "import",
+			Usage: "Import the messages of an archive file into the store",
+			Action: func(cCtx *cli.Context) error {
+				count, err := execute(Options, importMessages)
+				if err != nil {
+					logger.Error("importing messages", zap.Error(err))
+					return cli.Exit(err, 1)
+				}
+				logger.Info("messages imported", zap.Int("count", count), zap.String("file", Options.File))
+				return nil
+			},
+			Flags: archiveFlags,
+		},
+	},
+}
+
+type archiveFn func(options ArchiveOptions, msgProvider legacy_store.MessageProvider) (int, error)
+
+func execute(options ArchiveOptions, fn archiveFn) (int, error) {
+	msgProvider, closeFn, err := openMessageProvider(options.DatabaseURL)
+	if err != nil {
+		return 0, err
+	}
+	defer closeFn()
+
+	return fn(options, msgProvider)
+}
+
+// openMessageProvider opens the message store located at a database URL. The returned
+// function must be used to close it
+func openMessageProvider(databaseURL string) (legacy_store.MessageProvider, func(), error) {
+	// The stores log every query, so only the summary of the command is logged
+	storeLogger := zap.NewNop()
+
+	if path, ok := leveldb.ParseURL(databaseURL); ok {
+		kvStore, err := leveldb.NewMessageStore(path, prometheus.NewRegistry(), storeLogger)
+		if err != nil {
+			return nil, nil, err
+		}
+		return kvStore, kvStore.Stop, nil
+	}
+
+	db, migrationFn, err := dbutils.ParseURL(databaseURL, dbutils.DBSettings{}, logger)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	dbStore, err := persistence.NewDBStore(prometheus.NewRegistry(), storeLogger, persistence.WithDB(db), persistence.WithMigrations(migrationFn))
+	if err != nil {
+		db.Close()
+		return nil, nil, err
+	}
+
+	return dbStore, func() { db.Close() }, nil
+}
+
+func (o ArchiveOptions) historyQuery() *storepb.HistoryQuery {
+	query := &storepb.HistoryQuery{
+		PubsubTopic: o.PubsubTopic,
+		PagingInfo: &storepb.PagingInfo{
+			PageSize:  exportPageSize,
+			Direction: storepb.PagingInfo_FORWARD,
+		},
+	}
+
+	for _, ct := range o.ContentTopics.Value() {
+		query.ContentFilters = append(query.ContentFilters, &storepb.ContentFilter{ContentTopic: ct})
+	}
+
+	if o.StartTime != 0 {
+		query.StartTime = proto.Int64(o.StartTime)
+	}
+
+	if o.EndTime != 0 {
+		query.EndTime = proto.Int64(o.EndTime)
+	}
+
+	return query
+}
+
+// matches indicates whether an archived message is selected by the topic and time filters
+func (o ArchiveOptions) matches(record *pb.ArchivedMessage) bool {
+	if o.PubsubTopic != "" && record.PubsubTopic != o.PubsubTopic {
+		return false
+	}
+
+	if contentTopics := o.ContentTopics.Value(); len(contentTopics) != 0 {
+		found := false
+		for _, ct := range contentTopics {
+			if record.Message.ContentTopic == ct {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+
+	if o.StartTime != 0 && record.StoredAt < o.StartTime {
+		return false
+	}
+
+	if o.EndTime != 0 && record.StoredAt > o.EndTime {
+		return false
+	}
+
+	return true
+}
+
+func exportMessages(options ArchiveOptions, msgProvider legacy_store.MessageProvider) (int, error) {
+	f, err := os.Create(options.File)
+	if err != nil {
+		return 0, err
+	}
+	defer f.Close()
+
+	writer, err := newRecordWriter(options.Format, f)
+	if err != nil {
+		return 0, err
+	}
+
+	count, err := writeMessages(options, msgProvider, writer)
+	if err != nil {
+		return count, err
+	}
+
+	return count, f.Sync()
+}
+
+// writeMessages writes the messages of the store matching the options filters
+// in chronological order
+func writeMessages(options ArchiveOptions, msgProvider legacy_store.MessageProvider, writer recordWriter) (int, error) {
+	count := 0
+	query := options.historyQuery()
+	for {
+		cursor, messages, err := msgProvider.Query(query)
+		if err != nil {
+			return count, err
+		}
+
+		for _, m := range messages {
+			hash := m.Message.Hash(m.PubsubTopic)
+			err := writer.Write(&pb.ArchivedMessage{
+				MessageHash: hash[:],
+				PubsubTopic: m.PubsubTopic,
+				StoredAt:    m.ReceiverTime,
+				Message:     m.Message,
+			})
+			if err != nil {
+				return count, err
+			}
+			count++
+		}
+
+		if cursor == nil {
+			return count, nil
+		}
+
+		query.PagingInfo.Cursor = cursor
+	}
+}
+
+func importMessages(options ArchiveOptions, msgProvider legacy_store.MessageProvider) (int, error) {
+	f, err := os.Open(options.File)
+	if err != nil {
+		return 0, err
+	}
+	defer f.Close()
+
+	reader, err := newRecordReader(options.Format, f)
+	if err != nil {
+		return 0, err
+	}
+
+	return readMessages(options, msgProvider, reader)
+}
+
+// readMessages stores the messages of an archive matching the options filters. Messages whose
+// hash does not match the archived one are skipped, as well as those that could not be stored
+// (i.e. because they already exist). storedAt is preserved for the messages without timestamp,
+// since the store uses the message timestamp otherwise
+func readMessages(options ArchiveOptions, msgProvider legacy_store.MessageProvider, reader recordReader) (int, error) {
+	count := 0
+	for {
+		record, err := reader.Read()
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				return count, nil
+			}
+			return count, err
+		}
+
+		if record.Message == nil {
+			logger.Warn("skipping archived record without message", logging.HexBytes("hash", record.MessageHash))
+			continue
+		}
+
+		if !options.matches(record) {
+			continue
+		}
+
+		hash := record.Message.Hash(record.PubsubTopic)
+		if !bytes.Equal(hash[:], record.MessageHash) {
+			logger.Warn("skipping message with invalid hash", logging.HexBytes("archivedHash", record.MessageHash), logging.Hash(hash))
+			continue
+		}
+
+		err = msgProvider.Put(protocol.NewEnvelope(record.Message, record.StoredAt, record.PubsubTopic))
+		if errors.Is(err, persistence.ErrDuplicatedMessage) {
+			// The message was already stored, so it's not counted as imported
+			continue
+		}
+		if err != nil {
+			logger.Warn("could not store message", logging.Hash(hash), zap.Error(err))
+			continue
+		}
+
+		count++
+	}
+}
@@ -0,0 +1,104 @@
+package storearchive
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/stretchr/testify/require"
+	cli "github.com/urfave/cli/v2"
+	"github.com/waku-org/go-waku/tests"
+	"github.com/waku-org/go-waku/waku/persistence/leveldb"
+	"github.com/waku-org/go-waku/waku/v2/protocol"
+	"github.com/waku-org/go-waku/waku/v2/utils"
+	"google.golang.org/protobuf/proto"
+)
+
+func TestExportImport(t *testing.T) {
+	source, err := leveldb.NewMessageStore(leveldb.InMemoryPath, prometheus.NewRegistry(), utils.Logger())
+	require.NoError(t, err)
+	defer source.Stop()
+
+	pubsubTopic := "/waku/2/rs/99/1"
+	now := time.Now()
+	for i := 0; i < 5; i++ {
+		msg := tests.CreateWakuMessage("test", proto.Int64(now.Add(time.Duration(i)*time.Second).UnixNano()), "payload")
+		require.NoError(t, source.Put(protocol.NewEnvelope(msg, msg.GetTimestamp(), pubsubTopic)))
+	}
+	otherMsg := tests.CreateWakuMessage("other", proto.Int64(now.UnixNano()))
+	require.NoError(t, source.Put(protocol.NewEnvelope(otherMsg, otherMsg.GetTimestamp(), pubsubTopic)))
+
+	// Messages without timestamp keep the time at which they were stored
+	noTimestampMsg := tests.CreateWakuMessage("test", nil)
+	storedAt := now.Add(-time.Hour).UnixNano()
+	require.NoError(t, source.Put(protocol.NewEnvelope(noTimestampMsg, storedAt, pubsubTopic)))
+
+	expected, err := source.GetAll()
+	require.NoError(t, err)
+
+	for _, format := range []string{FormatProtobuf, FormatJSONL} {
+		t.Run(format, func(t *testing.T) {
+			buf := new(bytes.Buffer)
+			writer, err := newRecordWriter(format, buf)
+			require.NoError(t, err)
+
+			count, err := writeMessages(ArchiveOptions{}, source, writer)
+			require.NoError(t, err)
+			require.Equal(t, 7, count)
+
+			destination, err := leveldb.NewMessageStore(leveldb.InMemoryPath, prometheus.NewRegistry(), utils.Logger())
+			require.NoError(t, err)
+			defer destination.Stop()
+
+			reader, err := newRecordReader(format, bytes.NewReader(buf.Bytes()))
+			require.NoError(t, err)
+
+			count, err = readMessages(ArchiveOptions{}, destination, reader)
+			require.NoError(t, err)
+			require.Equal(t, 7, count)
+
+			result, err := destination.GetAll()
+			require.NoError(t, err)
+			require.Len(t, result, len(expected))
+			for i := range expected {
+				require.Equal(t, expected[i].MessageHash, result[i].MessageHash)
+				require.Equal(t, expected[i].ReceiverTime, result[i].ReceiverTime)
+				require.Equal(t, expected[i].PubsubTopic, result[i].PubsubTopic)
+			}
+			require.Equal(t, storedAt, result[0].ReceiverTime)
+		})
+	}
+
+	// Filter the exported messages by content topic and time range
+	options := ArchiveOptions{
+		PubsubTopic:   pubsubTopic,
+		ContentTopics: *cli.NewStringSlice("test"),
+		StartTime:     now.Add(time.Second).UnixNano(),
+		EndTime:       now.Add(3 * time.Second).UnixNano(),
+	}
+
+	buf := new(bytes.Buffer)
+	writer, err := newRecordWriter(FormatProtobuf, buf)
+	require.NoError(t, err)
+	count, err := writeMessages(options, source, writer)
+	require.NoError(t, err)
+	require.Equal(t, 3, count)
+
+	// The same filters can be used when importing
+	buf = new(bytes.Buffer)
+	writer, err = newRecordWriter(FormatJSONL, buf)
+	require.NoError(t, err)
+	_, err = writeMessages(ArchiveOptions{}, source, writer)
+	require.NoError(t, err)
+
+	destination, err := leveldb.NewMessageStore(leveldb.InMemoryPath, prometheus.NewRegistry(), utils.Logger())
+	require.NoError(t, err)
+	defer destination.Stop()
+
+	reader, err := newRecordReader(FormatJSONL, buf)
+	require.NoError(t, err)
+	count, err = readMessages(options, destination, reader)
+	require.NoError(t, err)
+	require.Equal(t, 3, count)
+}
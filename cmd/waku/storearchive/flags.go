@@ -0,0 +1,58 @@
+package storearchive
+
+import (
+	cli "github.com/urfave/cli/v2"
+	"github.com/urfave/cli/v2/altsrc"
+)
+
+// Options contain the settings used by the store export and import commands
+var Options ArchiveOptions
+
+var (
+	// DatabaseURL is a flag that contains the URL of the database of the store
+	DatabaseURL = altsrc.NewStringFlag(&cli.StringFlag{
+		Name:        "store-message-db-url",
+		Usage:       "The database connection URL for persistent storage.",
+		Value:       "sqlite3://store.db",
+		Destination: &Options.DatabaseURL,
+		EnvVars:     []string{"WAKUNODE2_STORE_MESSAGE_DB_URL"},
+	})
+	// File is a flag that contains the path of the archive file
+	File = altsrc.NewPathFlag(&cli.PathFlag{
+		Name:        "file",
+		Usage:       "Path of the archive file",
+		Required:    true,
+		Destination: &Options.File,
+	})
+	// Format is a flag used to choose the format of the archive file
+	Format = altsrc.NewStringFlag(&cli.StringFlag{
+		Name:        "format",
+		Value:       FormatProtobuf,
+		Usage:       "Format of the archive file: protobuf (length-delimited) or jsonl",
+		Destination: &Options.Format,
+	})
+	// PubsubTopic is a flag used to only process the messages of a pubsub topic
+	PubsubTopic = altsrc.NewStringFlag(&cli.StringFlag{
+		Name:        "pubsub-topic",
+		Usage:       "Only process the messages of this pubsub topic",
+		Destination: &Options.PubsubTopic,
+	})
+	// ContentTopics is a flag used to only process the messages of some content topics
+	ContentTopics = altsrc.NewStringSliceFlag(&cli.StringSliceFlag{
+		Name:        "content-topic",
+		Usage:       "Only process the messages of these content topics. Option may be repeated",
+		Destination: &Options.ContentTopics,
+	})
+	// StartTime is a flag used to only process the messages stored after a time
+	StartTime = altsrc.NewInt64Flag(&cli.Int64Flag{
+		Name:        "start-time",
+		Usage:       "Only process the messages stored at or after this unix timestamp in nanoseconds",
+		Destination: &Options.StartTime,
+	})
+	// EndTime is a flag used to only process the messages stored before a time
+	EndTime = altsrc.NewInt64Flag(&cli.Int64Flag{
+		Name:        "end-time",
+		Usage:       "Only process the messages stored at or before this unix timestamp in nanoseconds",
+		Destination: &Options.EndTime,
+	})
+)
@@ -0,0 +1,122 @@
+package storearchive
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/libp2p/go-msgio/pbio"
+	"github.com/waku-org/go-waku/cmd/waku/storearchive/pb"
+	"google.golang.org/protobuf/encoding/protojson"
+)
+
+const (
+	// FormatProtobuf stores each message as a length-delimited protobuf
+	FormatProtobuf = "protobuf"
+	// FormatJSONL stores each message as a JSON object in its own line
+	FormatJSONL = "jsonl"
+)
+
+// maxRecordSize is the maximum size accepted for a single record of an archive
+const maxRecordSize = 16 * 1024 * 1024
+
+// recordWriter writes the messages of an archive
+type recordWriter interface {
+	Write(record *pb.ArchivedMessage) error
+}
+
+// recordReader reads the messages of an archive. It returns io.EOF once there
+// are no more messages to read
+type recordReader interface {
+	Read() (*pb.ArchivedMessage, error)
+}
+
+func newRecordWriter(format string, w io.Writer) (recordWriter, error) {
+	switch format {
+	case FormatProtobuf:
+		return &protobufWriter{writer: pbio.NewDelimitedWriter(w)}, nil
+	case FormatJSONL:
+		return &jsonlWriter{writer: w}, nil
+	default:
+		return nil, fmt.Errorf("unsupported archive format: %s", format)
+	}
+}
+
+func newRecordReader(format string, r io.Reader) (recordReader, error) {
+	switch format {
+	case FormatProtobuf:
+		return &protobufReader{reader: pbio.NewDelimitedReader(r, maxRecordSize)}, nil
+	case FormatJSONL:
+		return &jsonlReader{reader: bufio.NewReader(r)}, nil
+	default:
+		return nil, fmt.Errorf("unsupported archive format: %s", format)
+	}
+}
+
+type protobufWriter struct {
+	writer pbio.WriteCloser
+}
+
+func (p *protobufWriter) Write(record *pb.ArchivedMessage) error {
+	return p.writer.WriteMsg(record)
+}
+
+type protobufReader struct {
+	reader pbio.ReadCloser
+}
+
+func (p *protobufReader) Read() (*pb.ArchivedMessage, error) {
+	record := new(pb.ArchivedMessage)
+	err := p.reader.ReadMsg(record)
+	if err != nil {
+		return nil, err
+	}
+	return record, nil
+}
+
+type jsonlWriter struct {
+	writer io.Writer
+}
+
+func (j *jsonlWriter) Write(record *pb.ArchivedMessage) error {
+	line, err := protojson.Marshal(record)
+	if err != nil {
+		return err
+	}
+
+	_, err = j.writer.Write(append(line, '\n'))
+	return err
+}
+
+type jsonlReader struct {
+	reader *bufio.Reader
+}
+
+func (j *jsonlReader) Read() (*pb.ArchivedMessage, error) {
+	for {
+		line, err := j.reader.ReadBytes('\n')
+		if err != nil && (!errors.Is(err, io.EOF) || len(line) == 0) {
+			return nil, err
+		}
+
+		line = bytes.TrimSpace(line)
+		if len(line) == 0 {
+			// Skip empty lines
+			continue
+		}
+
+		if len(line) > maxRecordSize {
+			return nil, errors.New("record exceeds the maximum size")
+		}
+
+		record := new(pb.ArchivedMessage)
+		err = protojson.Unmarshal(line, record)
+		if err != nil {
+			return nil, err
+		}
+
+		return record, nil
+	}
+}
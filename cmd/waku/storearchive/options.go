@@ -0,0 +1,16 @@
+package storearchive
+
+import (
+	cli "github.com/urfave/cli/v2"
+)
+
+// ArchiveOptions contains the settings used to export and import the messages of a store
+type ArchiveOptions struct {
+	DatabaseURL   string
+	File          string
+	Format        string
+	PubsubTopic   string
+	ContentTopics cli.StringSlice
+	StartTime     int64
+	EndTime       int64
+}
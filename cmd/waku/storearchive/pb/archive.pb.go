@@ -0,0 +1,181 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.34.2
+// 	protoc        v3.21.12
+// source: archive.proto
+
+package pb
+
+import (
+	pb "github.com/waku-org/go-waku/waku/v2/protocol/pb"
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	reflect "reflect"
+	sync "sync"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+// ArchivedMessage is a message exported from the store of a node, along with the
+// hash and the timestamp used to index it
+type ArchivedMessage struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	MessageHash []byte          `protobuf:"bytes,1,opt,name=message_hash,json=messageHash,proto3" json:"message_hash,omitempty"`
+	PubsubTopic string          `protobuf:"bytes,2,opt,name=pubsub_topic,json=pubsubTopic,proto3" json:"pubsub_topic,omitempty"`
+	StoredAt    int64           `protobuf:"zigzag64,3,opt,name=stored_at,json=storedAt,proto3" json:"stored_at,omitempty"`
+	Message     *pb.WakuMessage `protobuf:"bytes,4,opt,name=message,proto3" json:"message,omitempty"`
+}
+
+func (x *ArchivedMessage) Reset() {
+	*x = ArchivedMessage{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_archive_proto_msgTypes[0]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *ArchivedMessage) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ArchivedMessage) ProtoMessage() {}
+
+func (x *ArchivedMessage) ProtoReflect() protoreflect.Message {
+	mi := &file_archive_proto_msgTypes[0]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ArchivedMessage.ProtoReflect.Descriptor instead.
+func (*ArchivedMessage) Descriptor() ([]byte, []int) {
+	return file_archive_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *ArchivedMessage) GetMessageHash() []byte {
+	if x != nil {
+		return x.MessageHash
+	}
+	return nil
+}
+
+func (x *ArchivedMessage) GetPubsubTopic() string {
+	if x != nil {
+		return x.PubsubTopic
+	}
+	return ""
+}
+
+func (x *ArchivedMessage) GetStoredAt() int64 {
+	if x != nil {
+		return x.StoredAt
+	}
+	return 0
+}
+
+func (x *ArchivedMessage) GetMessage() *pb.WakuMessage {
+	if x != nil {
+		return x.Message
+	}
+	return nil
+}
+
+var File_archive_proto protoreflect.FileDescriptor
+
+var file_archive_proto_rawDesc = []byte{
+	0x0a, 0x0d, 0x61, 0x72, 0x63, 0x68, 0x69, 0x76, 0x65, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x12,
+	0x0f, 0x77, 0x61, 0x6b, 0x75, 0x2e, 0x61, 0x72, 0x63, 0x68, 0x69, 0x76, 0x65, 0x2e, 0x76, 0x31,
+	0x1a, 0x1d, 0x77, 0x61, 0x6b, 0x75, 0x2f, 0x6d, 0x65, 0x73, 0x73, 0x61, 0x67, 0x65, 0x2f, 0x76,
+	0x31, 0x2f, 0x6d, 0x65, 0x73, 0x73, 0x61, 0x67, 0x65, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x22,
+	0xac, 0x01, 0x0a, 0x0f, 0x41, 0x72, 0x63, 0x68, 0x69, 0x76, 0x65, 0x64, 0x4d, 0x65, 0x73, 0x73,
+	0x61, 0x67, 0x65, 0x12, 0x21, 0x0a, 0x0c, 0x6d, 0x65, 0x73, 0x73, 0x61, 0x67, 0x65, 0x5f, 0x68,
+	0x61, 0x73, 0x68, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x0b, 0x6d, 0x65, 0x73, 0x73, 0x61,
+	0x67, 0x65, 0x48, 0x61, 0x73, 0x68, 0x12, 0x21, 0x0a, 0x0c, 0x70, 0x75, 0x62, 0x73, 0x75, 0x62,
+	0x5f, 0x74, 0x6f, 0x70, 0x69, 0x63, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0b, 0x70, 0x75,
+	0x62, 0x73, 0x75, 0x62, 0x54, 0x6f, 0x70, 0x69, 0x63, 0x12, 0x1b, 0x0a, 0x09, 0x73, 0x74, 0x6f,
+	0x72, 0x65, 0x64, 0x5f, 0x61, 0x74, 0x18, 0x03, 0x20, 0x01, 0x28, 0x12, 0x52, 0x08, 0x73, 0x74,
+	0x6f, 0x72, 0x65, 0x64, 0x41, 0x74, 0x12, 0x36, 0x0a, 0x07, 0x6d, 0x65, 0x73, 0x73, 0x61, 0x67,
+	0x65, 0x18, 0x04, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x1c, 0x2e, 0x77, 0x61, 0x6b, 0x75, 0x2e, 0x6d,
+	0x65, 0x73, 0x73, 0x61, 0x67, 0x65, 0x2e, 0x76, 0x31, 0x2e, 0x57, 0x61, 0x6b, 0x75, 0x4d, 0x65,
+	0x73, 0x73, 0x61, 0x67, 0x65, 0x52, 0x07, 0x6d, 0x65, 0x73, 0x73, 0x61, 0x67, 0x65, 0x62, 0x06,
+	0x70, 0x72, 0x6f, 0x74, 0x6f, 0x33,
+}
+
+var (
+	file_archive_proto_rawDescOnce sync.Once
+	file_archive_proto_rawDescData = file_archive_proto_rawDesc
+)
+
+func file_archive_proto_rawDescGZIP() []byte {
+	file_archive_proto_rawDescOnce.Do(func() {
+		file_archive_proto_rawDescData = protoimpl.X.CompressGZIP(file_archive_proto_rawDescData)
+	})
+	return file_archive_proto_rawDescData
+}
+
+var file_archive_proto_msgTypes = make([]protoimpl.MessageInfo, 1)
+var file_archive_proto_goTypes = []any{
+	(*ArchivedMessage)(nil), // 0: waku.archive.v1.ArchivedMessage
+	(*pb.WakuMessage)(nil),  // 1: waku.message.v1.WakuMessage
+}
+var file_archive_proto_depIdxs = []int32{
+	1, // 0: waku.archive.v1.ArchivedMessage.message:type_name -> waku.message.v1.WakuMessage
+	1, // [1:1] is the sub-list for method output_type
+	1, // [1:1] is the sub-list for method input_type
+	1, // [1:1] is the sub-list for extension type_name
+	1, // [1:1] is the sub-list for extension extendee
+	0, // [0:1] is the sub-list for field type_name
+}
+
+func init() { file_archive_proto_init() }
+func file_archive_proto_init() {
+	if File_archive_proto != nil {
+		return
+	}
+	if !protoimpl.UnsafeEnabled {
+		file_archive_proto_msgTypes[0].Exporter = func(v any, i int) any {
+			switch v := v.(*ArchivedMessage); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: file_archive_proto_rawDesc,
+			NumEnums:      0,
+			NumMessages:   1,
+			NumExtensions: 0,
+			NumServices:   0,
+		},
+		GoTypes:           file_archive_proto_goTypes,
+		DependencyIndexes: file_archive_proto_depIdxs,
+		MessageInfos:      file_archive_proto_msgTypes,
+	}.Build()
+	File_archive_proto = out.File
+	file_archive_proto_rawDesc = nil
+	file_archive_proto_goTypes = nil
+	file_archive_proto_depIdxs = nil
+}
@@ -0,0 +1,3 @@
+package pb
+
+//go:generate protoc -I. -I./../../../../waku/v2/waku-proto/ --go_opt=paths=source_relative --go_opt=Marchive.proto=github.com/waku-org/go-waku/cmd/waku/storearchive/pb --go_opt=Mwaku/message/v1/message.proto=github.com/waku-org/go-waku/waku/v2/protocol/pb --go_out=. ./archive.proto
@@ -0,0 +1,231 @@
+package trace
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	wpb "github.com/waku-org/go-waku/waku/v2/protocol/pb"
+	"github.com/waku-org/go-waku/waku/v2/protocol/relay/tracer"
+	"github.com/waku-org/go-waku/waku/v2/protocol/relay/tracer/pb"
+)
+
+// ErrMessageNotFound indicates that the trace files do not contain events of a message
+var ErrMessageNotFound = errors.New("message not found in the trace files")
+
+// nodeTrace is the reception of a message by a node
+type nodeTrace struct {
+	nodeID       string
+	receivedFrom string
+	timestamp    int64
+	duplicates   []string
+}
+
+// messageTrace is the propagation of a message through the relay mesh, reconstructed
+// from the trace files of the nodes that received it
+type messageTrace struct {
+	hash        wpb.MessageHash
+	pubsubTopic string
+	// origin is the node that published the message, if its trace file was analyzed
+	origin    string
+	published int64
+	// nodes are ordered by the time at which they received the message
+	nodes      []*nodeTrace
+	rejections []*pb.TraceEvent
+}
+
+// readEvents returns the events of a message contained in the trace files
+func readEvents(files []string, format string, hash wpb.MessageHash) ([]*pb.TraceEvent, error) {
+	var result []*pb.TraceEvent
+	for _, file := range files {
+		f, err := os.Open(file)
+		if err != nil {
+			return nil, err
+		}
+
+		events, err := filterEvents(f, format, hash)
+		f.Close()
+		if err != nil {
+			return nil, fmt.Errorf("reading %s: %w", file, err)
+		}
+
+		result = append(result, events...)
+	}
+	return result, nil
+}
+
+func filterEvents(r io.Reader, format string, hash wpb.MessageHash) ([]*pb.TraceEvent, error) {
+	reader, err := tracer.NewReader(format, r)
+	if err != nil {
+		return nil, err
+	}
+
+	var result []*pb.TraceEvent
+	for {
+		evt, err := reader.Read()
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				return result, nil
+			}
+			return nil, err
+		}
+
+		if bytes.Equal(evt.MessageHash, hash[:]) {
+			result = append(result, evt)
+		}
+	}
+}
+
+// analyzeMessage reconstructs the propagation of a message. Nodes are considered to receive
+// the message from the first peer that delivered it, and later receptions are duplicates
+func analyzeMessage(hash wpb.MessageHash, events []*pb.TraceEvent) (*messageTrace, error) {
+	if len(events) == 0 {
+		return nil, ErrMessageNotFound
+	}
+
+	sort.SliceStable(events, func(i, j int) bool {
+		return events[i].Timestamp < events[j].Timestamp
+	})
+
+	trace := &messageTrace{hash: hash}
+	nodes := make(map[string]*nodeTrace)
+	for _, evt := range events {
+		trace.pubsubTopic = evt.PubsubTopic
+
+		switch evt.Type {
+		case pb.TraceEvent_PUBLISH:
+			if trace.origin == "" {
+				trace.origin = evt.NodeId
+				trace.published = evt.Timestamp
+			}
+			fallthrough
+		case pb.TraceEvent_DELIVER:
+			if node, ok := nodes[evt.NodeId]; ok {
+				node.duplicates = append(node.duplicates, evt.ReceivedFrom)
+				continue
+			}
+			node := &nodeTrace{
+				nodeID:    evt.NodeId,
+				timestamp: evt.Timestamp,
+			}
+			if evt.Type == pb.TraceEvent_DELIVER {
+				node.receivedFrom = evt.ReceivedFrom
+			}
+			nodes[evt.NodeId] = node
+			trace.nodes = append(trace.nodes, node)
+		case pb.TraceEvent_DUPLICATE:
+			if node, ok := nodes[evt.NodeId]; ok {
+				node.duplicates = append(node.duplicates, evt.ReceivedFrom)
+			}
+		case pb.TraceEvent_REJECT:
+			trace.rejections = append(trace.rejections, evt)
+		}
+	}
+
+	if len(trace.nodes) == 0 {
+		return nil, ErrMessageNotFound
+	}
+
+	return trace, nil
+}
+
+// path returns the nodes the message went through until reaching a node, starting with
+// the origin. It starts with "?" if the path goes through a node that was not traced
+func (m *messageTrace) path(node *nodeTrace) []string {
+	nodes := make(map[string]*nodeTrace)
+	for _, n := range m.nodes {
+		nodes[n.nodeID] = n
+	}
+
+	result := []string{node.nodeID}
+	visited := map[string]struct{}{node.nodeID: {}}
+	current := node
+	for current.receivedFrom != "" {
+		if _, ok := visited[current.receivedFrom]; ok {
+			break
+		}
+		visited[current.receivedFrom] = struct{}{}
+
+		result = append([]string{current.receivedFrom}, result...)
+
+		previous, ok := nodes[current.receivedFrom]
+		if !ok {
+			// The peer that forwarded the message was not traced
+			result = append([]string{"?"}, result...)
+			break
+		}
+		current = previous
+	}
+
+	return result
+}
+
+// start is the time used to calculate the latency of each node. If the origin of the
+// message was not traced, the time of the first reception is used instead
+func (m *messageTrace) start() int64 {
+	if m.origin != "" {
+		return m.published
+	}
+	return m.nodes[0].timestamp
+}
+
+func (m *messageTrace) print(w io.Writer) error {
+	fmt.Fprintf(w, "Message:      %s\n", m.hash)
+	fmt.Fprintf(w, "Pubsub topic: %s\n", m.pubsubTopic)
+	if m.origin != "" {
+		fmt.Fprintf(w, "Published by: %s at %s\n", m.origin, time.Unix(0, m.published).UTC().Format(time.RFC3339Nano))
+	} else {
+		fmt.Fprintln(w, "Published by: unknown (no trace file contains the publish event)")
+	}
+	fmt.Fprintf(w, "Nodes:        %d\n\n", len(m.nodes))
+
+	timestamps := make(map[string]int64)
+	for _, node := range m.nodes {
+		timestamps[node.nodeID] = node.timestamp
+	}
+
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "NODE\tRECEIVED FROM\tLATENCY\tHOP LATENCY\tDUPLICATES\tPATH")
+	for _, node := range m.nodes {
+		receivedFrom := node.receivedFrom
+		hopLatency := "-"
+		if receivedFrom == "" {
+			receivedFrom = "-"
+		} else if ts, ok := timestamps[node.receivedFrom]; ok {
+			hopLatency = time.Duration(node.timestamp - ts).String()
+		}
+
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%d\t%s\n",
+			node.nodeID,
+			receivedFrom,
+			time.Duration(node.timestamp-m.start()),
+			hopLatency,
+			len(node.duplicates),
+			strings.Join(m.path(node), " -> "))
+	}
+	err := tw.Flush()
+	if err != nil {
+		return err
+	}
+
+	if len(m.rejections) != 0 {
+		fmt.Fprintf(w, "\nRejections:\n")
+		tw = tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(tw, "NODE\tRECEIVED FROM\tTIME\tREASON")
+		for _, evt := range m.rejections {
+			fmt.Fprintf(tw, "%s\t%s\t%s\t%s\n", evt.NodeId, evt.ReceivedFrom, time.Unix(0, evt.Timestamp).UTC().Format(time.RFC3339Nano), evt.Reason)
+		}
+		err = tw.Flush()
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
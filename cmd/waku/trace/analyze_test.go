@@ -0,0 +1,95 @@
+package trace
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/waku-org/go-waku/tests"
+	"github.com/waku-org/go-waku/waku/v2/protocol/relay/tracer"
+	"github.com/waku-org/go-waku/waku/v2/protocol/relay/tracer/pb"
+	"github.com/waku-org/go-waku/waku/v2/utils"
+	"google.golang.org/protobuf/encoding/protojson"
+)
+
+func TestAnalyzeMessage(t *testing.T) {
+	pubsubTopic := "/waku/2/rs/99/1"
+	hash := tests.CreateWakuMessage("test", utils.GetUnixEpoch()).Hash(pubsubTopic)
+	otherHash := tests.CreateWakuMessage("other", utils.GetUnixEpoch()).Hash(pubsubTopic)
+
+	start := time.Now().UnixNano()
+	ms := int64(time.Millisecond)
+	newEvent := func(eventType pb.TraceEvent_Type, offset int64, nodeID string, receivedFrom string) *pb.TraceEvent {
+		return &pb.TraceEvent{
+			Type:         eventType,
+			Timestamp:    start + offset*ms,
+			NodeId:       nodeID,
+			PubsubTopic:  pubsubTopic,
+			MessageHash:  hash[:],
+			ReceivedFrom: receivedFrom,
+		}
+	}
+
+	// Events of the trace files of several nodes, in no particular order
+	events := []*pb.TraceEvent{
+		newEvent(pb.TraceEvent_DELIVER, 30, "C", "B"),
+		newEvent(pb.TraceEvent_PUBLISH, 0, "A", "A"),
+		newEvent(pb.TraceEvent_DUPLICATE, 40, "C", "A"),
+		newEvent(pb.TraceEvent_DELIVER, 10, "B", "A"),
+		newEvent(pb.TraceEvent_DELIVER, 50, "D", "X"),
+		newEvent(pb.TraceEvent_REJECT, 20, "E", "A"),
+		{Type: pb.TraceEvent_DELIVER, Timestamp: start, NodeId: "B", MessageHash: otherHash[:]},
+	}
+
+	// Only the events of the message are read from the trace files
+	buf := new(bytes.Buffer)
+	for _, evt := range events {
+		line, err := protojson.Marshal(evt)
+		require.NoError(t, err)
+		buf.Write(append(line, '\n'))
+	}
+	events, err := filterEvents(buf, tracer.FormatJSONL, hash)
+	require.NoError(t, err)
+	require.Len(t, events, 6)
+
+	trace, err := analyzeMessage(hash, events)
+	require.NoError(t, err)
+
+	require.Equal(t, "A", trace.origin)
+	require.Equal(t, start, trace.published)
+	require.Len(t, trace.nodes, 4)
+	require.Len(t, trace.rejections, 1)
+
+	var nodeIDs []string
+	for _, node := range trace.nodes {
+		nodeIDs = append(nodeIDs, node.nodeID)
+	}
+	require.Equal(t, []string{"A", "B", "C", "D"}, nodeIDs)
+	require.Equal(t, []string{"A"}, trace.nodes[2].duplicates)
+
+	require.Equal(t, []string{"A"}, trace.path(trace.nodes[0]))
+	require.Equal(t, []string{"A", "B", "C"}, trace.path(trace.nodes[2]))
+	require.Equal(t, []string{"?", "X", "D"}, trace.path(trace.nodes[3]))
+
+	out := new(bytes.Buffer)
+	require.NoError(t, trace.print(out))
+	lines := strings.Split(out.String(), "\n")
+	require.Contains(t, out.String(), "Published by: A")
+	found := false
+	for _, line := range lines {
+		fields := strings.Fields(line)
+		if len(fields) == 0 || fields[0] != "C" {
+			continue
+		}
+		// Latency since the publication, and since the reception by the previous node
+		require.Equal(t, []string{"C", "B", "30ms", "20ms", "1", "A", "->", "B", "->", "C"}, fields)
+		found = true
+	}
+	require.True(t, found)
+
+	// Unknown messages
+	_, err = analyzeMessage(otherHash, nil)
+	require.ErrorIs(t, err, ErrMessageNotFound)
+}
@@ -0,0 +1,61 @@
+package trace
+
+import (
+	"errors"
+
+	cli "github.com/urfave/cli/v2"
+	wpb "github.com/waku-org/go-waku/waku/v2/protocol/pb"
+	"github.com/waku-org/go-waku/waku/v2/utils"
+	"go.uber.org/zap"
+)
+
+var logger = utils.Logger().Named("trace")
+
+// Command groups the subcommands used to inspect the relay trace files
+var Command = cli.Command{
+	Name:  "trace",
+	Usage: "Inspect the relay trace files written by nodes started with --relay-trace-file",
+	Subcommands: []*cli.Command{
+		{
+			Name:  "analyze",
+			Usage: "Reconstruct the path and latency of a message. Latencies between nodes depend on their clocks being synchronized",
+			Action: func(cCtx *cli.Context) error {
+				err := analyze(cCtx, Options)
+				if err != nil {
+					logger.Error("analyzing trace files", zap.Error(err))
+					return cli.Exit(err, 1)
+				}
+				return nil
+			},
+			Flags: []cli.Flag{
+				Files,
+				Format,
+				MessageHash,
+			},
+		},
+	},
+}
+
+func analyze(cCtx *cli.Context, options AnalyzeOptions) error {
+	hashBytes, err := utils.DecodeHexString(options.MessageHash)
+	if err != nil {
+		return err
+	}
+
+	if len(hashBytes) != len(wpb.MessageHash{}) {
+		return errors.New("invalid message hash length")
+	}
+
+	hash := wpb.ToMessageHash(hashBytes)
+	events, err := readEvents(options.Files.Value(), options.Format, hash)
+	if err != nil {
+		return err
+	}
+
+	trace, err := analyzeMessage(hash, events)
+	if err != nil {
+		return err
+	}
+
+	return trace.print(cCtx.App.Writer)
+}
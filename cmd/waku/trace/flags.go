@@ -0,0 +1,34 @@
+package trace
+
+import (
+	cli "github.com/urfave/cli/v2"
+	"github.com/urfave/cli/v2/altsrc"
+	"github.com/waku-org/go-waku/waku/v2/protocol/relay/tracer"
+)
+
+// Options contain the settings used by the trace analyze command
+var Options AnalyzeOptions
+
+var (
+	// Files is a flag that contains the paths of the trace files
+	Files = altsrc.NewStringSliceFlag(&cli.StringSliceFlag{
+		Name:        "file",
+		Usage:       "Path of a relay trace file. Option may be repeated to analyze the trace files of several nodes, or rotated files",
+		Required:    true,
+		Destination: &Options.Files,
+	})
+	// Format is a flag used to choose the format of the trace files
+	Format = altsrc.NewStringFlag(&cli.StringFlag{
+		Name:        "format",
+		Value:       tracer.FormatProtobuf,
+		Usage:       "Format of the trace files: protobuf (length-delimited) or jsonl",
+		Destination: &Options.Format,
+	})
+	// MessageHash is a flag that contains the hash of the message to analyze
+	MessageHash = altsrc.NewStringFlag(&cli.StringFlag{
+		Name:        "hash",
+		Usage:       "Hash of the message to analyze, in hex format",
+		Required:    true,
+		Destination: &Options.MessageHash,
+	})
+)
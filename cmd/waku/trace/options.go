@@ -0,0 +1,12 @@
+package trace
+
+import (
+	cli "github.com/urfave/cli/v2"
+)
+
+// AnalyzeOptions contains the settings used to analyze the relay trace files
+type AnalyzeOptions struct {
+	Files       cli.StringSlice
+	Format      string
+	MessageHash string
+}
@@ -0,0 +1,76 @@
+package webhook
+
+import (
+	"github.com/libp2p/go-libp2p/p2p/metricshelper"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var deliveredMessages = prometheus.NewCounter(
+	prometheus.CounterOpts{
+		Name: "waku_webhook_delivered_messages",
+		Help: "The number of messages delivered to webhooks",
+	},
+)
+
+var deliveryRetries = prometheus.NewCounter(
+	prometheus.CounterOpts{
+		Name: "waku_webhook_delivery_retries",
+		Help: "The number of failed attempts to deliver a message to a webhook that were retried",
+	},
+)
+
+var deadLetterMessages = prometheus.NewCounterVec(
+	prometheus.CounterOpts{
+		Name: "waku_webhook_dead_letter_messages",
+		Help: "The number of messages that could not be delivered to webhooks",
+	},
+	[]string{"reason"},
+)
+
+var collectors = []prometheus.Collector{
+	deliveredMessages,
+	deliveryRetries,
+	deadLetterMessages,
+}
+
+// Metrics exposes the functions required to update prometheus metrics for the webhook sink
+type Metrics interface {
+	RecordDelivered()
+	RecordRetry()
+	RecordDeadLetter(reason deadLetterReason)
+}
+
+type metricsImpl struct {
+	reg prometheus.Registerer
+}
+
+func newMetrics(reg prometheus.Registerer) Metrics {
+	metricshelper.RegisterCollectors(reg, collectors...)
+	return &metricsImpl{
+		reg: reg,
+	}
+}
+
+type deadLetterReason string
+
+const (
+	queueFull        deadLetterReason = "queue_full"
+	rejected         deadLetterReason = "rejected"
+	retriesExhausted deadLetterReason = "retries_exhausted"
+	shutdown         deadLetterReason = "shutdown"
+)
+
+// RecordDelivered increases the counter of messages delivered to webhooks
+func (m *metricsImpl) RecordDelivered() {
+	deliveredMessages.Inc()
+}
+
+// RecordRetry increases the counter of delivery attempts that failed and were retried
+func (m *metricsImpl) RecordRetry() {
+	deliveryRetries.Inc()
+}
+
+// RecordDeadLetter increases the counter of messages that could not be delivered
+func (m *metricsImpl) RecordDeadLetter(reason deadLetterReason) {
+	deadLetterMessages.WithLabelValues(string(reason)).Inc()
+}
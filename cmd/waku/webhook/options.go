@@ -0,0 +1,87 @@
+package webhook
+
+import (
+	"time"
+)
+
+const (
+	// DefaultMaxAttempts is the number of times the delivery of a message is attempted
+	// before it's written to the dead-letter file
+	DefaultMaxAttempts = 8
+	// DefaultInitialInterval is the time waited before the first retry. It increases exponentially
+	DefaultInitialInterval = time.Second
+	// DefaultMaxInterval is the maximum time waited between two retries
+	DefaultMaxInterval = time.Minute
+	// DefaultTimeout is the timeout of each request
+	DefaultTimeout = 10 * time.Second
+	// DefaultQueueSize is the number of messages waiting to be delivered to each endpoint
+	DefaultQueueSize = 1000
+)
+
+type params struct {
+	secret          []byte
+	maxAttempts     int
+	initialInterval time.Duration
+	maxInterval     time.Duration
+	timeout         time.Duration
+	queueSize       int
+	deadLetterPath  string
+}
+
+// Option is an optional setting of the Sink
+type Option func(*params)
+
+// WithSecret signs the requests with an HMAC-SHA256 of the timestamp and body, using secret as key
+func WithSecret(secret []byte) Option {
+	return func(p *params) {
+		p.secret = secret
+	}
+}
+
+// WithMaxAttempts sets the number of times the delivery of a message is attempted
+func WithMaxAttempts(attempts int) Option {
+	return func(p *params) {
+		p.maxAttempts = attempts
+	}
+}
+
+// WithBackoff sets the time waited before the first retry, which increases exponentially up to maxInterval
+func WithBackoff(initialInterval time.Duration, maxInterval time.Duration) Option {
+	return func(p *params) {
+		p.initialInterval = initialInterval
+		p.maxInterval = maxInterval
+	}
+}
+
+// WithTimeout sets the timeout of each request
+func WithTimeout(timeout time.Duration) Option {
+	return func(p *params) {
+		p.timeout = timeout
+	}
+}
+
+// WithQueueSize sets the number of messages waiting to be delivered to each endpoint, and waiting
+// to be dispatched to the endpoints. Messages received while a queue is full are written to the
+// dead-letter file
+func WithQueueSize(size int) Option {
+	return func(p *params) {
+		p.queueSize = size
+	}
+}
+
+// WithDeadLetterFile appends the messages that could not be delivered to a file, as JSON lines.
+// These messages are only logged if no file is set
+func WithDeadLetterFile(path string) Option {
+	return func(p *params) {
+		p.deadLetterPath = path
+	}
+}
+
+func defaultOptions() []Option {
+	return []Option{
+		WithMaxAttempts(DefaultMaxAttempts),
+		WithBackoff(DefaultInitialInterval, DefaultMaxInterval),
+		WithTimeout(DefaultTimeout),
+		WithQueueSize(DefaultQueueSize),
+	}
+}
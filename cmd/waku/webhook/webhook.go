@@ -0,0 +1,473 @@
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/cenkalti/backoff/v4"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/waku-org/go-waku/cmd/waku/server/rest"
+	"github.com/waku-org/go-waku/logging"
+	"github.com/waku-org/go-waku/waku/v2/protocol"
+	"github.com/waku-org/go-waku/waku/v2/protocol/pb"
+	"github.com/waku-org/go-waku/waku/v2/protocol/relay"
+	"github.com/waku-org/go-waku/waku/v2/service"
+	"github.com/waku-org/go-waku/waku/v2/utils"
+	"go.uber.org/zap"
+)
+
+const (
+	// SignatureHeader contains the signature of a request, sha256=<hex encoded HMAC-SHA256 of timestamp.body>
+	SignatureHeader = "X-Waku-Signature"
+	// TimestampHeader contains the time at which a request was signed, in seconds since the epoch
+	TimestampHeader = "X-Waku-Timestamp"
+	// MessageHashHeader contains the hash of the message delivered
+	MessageHashHeader = "X-Waku-Message-Hash"
+)
+
+var (
+	ErrNoEndpoints = errors.New("webhook sink requires at least one endpoint")
+	ErrInvalidURL  = errors.New("webhook URL must be an absolute http or https URL")
+)
+
+// Endpoint is a URL to which the messages matching a content filter are posted
+type Endpoint struct {
+	URL string
+	// PubsubTopic restricts the messages posted to a pubsub topic. Messages of any pubsub topic
+	// are posted if empty
+	PubsubTopic string
+	// ContentTopics restricts the messages posted. They can be patterns. Messages of any content
+	// topic are posted if empty
+	ContentTopics []string
+}
+
+func (e Endpoint) String() string {
+	return fmt.Sprintf("%s %s %v", e.URL, e.PubsubTopic, e.ContentTopics)
+}
+
+func (e Endpoint) Validate() error {
+	u, err := url.Parse(e.URL)
+	if err != nil || !u.IsAbs() || (u.Scheme != "http" && u.Scheme != "https") {
+		return ErrInvalidURL
+	}
+	for _, contentTopic := range e.ContentTopics {
+		if err := protocol.ValidateContentTopicPattern(contentTopic); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Match indicates whether a message must be posted to the endpoint
+func (e Endpoint) Match(env *protocol.Envelope) bool {
+	if e.PubsubTopic != "" && e.PubsubTopic != env.PubsubTopic() {
+		return false
+	}
+	if len(e.ContentTopics) == 0 {
+		return true
+	}
+	for _, contentTopic := range e.ContentTopics {
+		if protocol.MatchContentTopic(contentTopic, env.Message().ContentTopic) {
+			return true
+		}
+	}
+	return false
+}
+
+// ParseEndpoint parses url[;pubsub_topic[;content_topic,...]]
+func ParseEndpoint(value string) (Endpoint, error) {
+	parts := strings.SplitN(value, ";", 3)
+
+	endpoint := Endpoint{URL: strings.TrimSpace(parts[0])}
+	if len(parts) > 1 {
+		endpoint.PubsubTopic = strings.TrimSpace(parts[1])
+	}
+	if len(parts) == 3 && parts[2] != "" {
+		for _, contentTopic := range strings.Split(parts[2], ",") {
+			endpoint.ContentTopics = append(endpoint.ContentTopics, strings.TrimSpace(contentTopic))
+		}
+	}
+
+	return endpoint, endpoint.Validate()
+}
+
+// Event is the body of the requests sent to the endpoints
+type Event struct {
+	MessageHash string                `json:"messageHash"`
+	PubsubTopic string                `json:"pubsubTopic"`
+	Message     *rest.RestWakuMessage `json:"message"`
+}
+
+// Sign returns the value of the signature header of a request
+func Sign(secret []byte, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+type delivery struct {
+	hash pb.MessageHash
+	body []byte
+}
+
+type endpoint struct {
+	Endpoint
+	queue chan *delivery
+}
+
+// Sink posts the messages received by the node, through relay or filter subscriptions, to the
+// endpoints whose content filter they match. Failed deliveries are retried with an exponential
+// backoff, and the messages that could not be delivered are written to a dead-letter file
+type Sink struct {
+	*service.CommonService
+	bcaster    relay.Broadcaster
+	sub        *relay.Subscription
+	endpoints  []*endpoint
+	params     *params
+	client     *http.Client
+	deadLetter *deadLetterFile
+	metrics    Metrics
+	log        *zap.Logger
+}
+
+// NewSink creates a Sink for the messages submitted to a broadcaster
+func NewSink(bcaster relay.Broadcaster, endpoints []Endpoint, reg prometheus.Registerer, log *zap.Logger, opts ...Option) (*Sink, error) {
+	p := new(params)
+	for _, opt := range append(defaultOptions(), opts...) {
+		opt(p)
+	}
+
+	if len(endpoints) == 0 {
+		return nil, ErrNoEndpoints
+	}
+	if p.maxAttempts <= 0 {
+		return nil, errors.New("max attempts must be greater than 0")
+	}
+
+	s := &Sink{
+		CommonService: service.NewCommonService(),
+		bcaster:       bcaster,
+		params:        p,
+		client:        &http.Client{Timeout: p.timeout},
+		metrics:       newMetrics(reg),
+		log:           log.Named("webhook"),
+	}
+
+	for _, e := range endpoints {
+		if err := e.Validate(); err != nil {
+			return nil, fmt.Errorf("invalid endpoint %s: %w", e, err)
+		}
+		s.endpoints = append(s.endpoints, &endpoint{Endpoint: e})
+	}
+
+	return s, nil
+}
+
+// Start starts posting the messages to the endpoints
+func (s *Sink) Start(ctx context.Context) error {
+	return s.CommonService.Start(ctx, s.start)
+}
+
+func (s *Sink) start() error {
+	if s.params.deadLetterPath != "" {
+		f, err := os.OpenFile(s.params.deadLetterPath, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+		if err != nil {
+			return fmt.Errorf("opening dead-letter file: %w", err)
+		}
+		s.deadLetter = &deadLetterFile{f: f}
+	}
+
+	for _, e := range s.endpoints {
+		e.queue = make(chan *delivery, s.params.queueSize)
+		s.WaitGroup().Add(1)
+		go s.deliverLoop(e)
+
+		s.log.Info("posting messages to webhook", zap.String("url", e.URL), zap.String("pubsubTopic", e.PubsubTopic), zap.Strings("contentTopics", e.ContentTopics))
+	}
+
+	// The subscription is buffered and drops the messages it can't hold, so a slow dispatch doesn't
+	// hold up the other subscribers of the broadcaster
+	s.sub = s.bcaster.RegisterForAll(
+		relay.WithBufferSize(s.params.queueSize),
+		relay.WithOverflowPolicy(relay.OverflowDropNewest),
+		relay.WithDropHandler(s.dropped),
+	)
+	s.WaitGroup().Add(1)
+	go s.dispatch()
+
+	return nil
+}
+
+// Stop stops posting messages. The messages waiting to be delivered are written to the dead-letter file
+func (s *Sink) Stop() {
+	// Unsubscribing before the context is cancelled closes the subscription while dispatch is still
+	// reading it, so the broadcaster is never left waiting on it
+	s.RLock()
+	sub := s.sub
+	s.RUnlock()
+	if sub != nil {
+		sub.Unsubscribe()
+	}
+
+	s.CommonService.Stop(func() {})
+
+	if s.deadLetter != nil {
+		if err := s.deadLetter.close(); err != nil {
+			s.log.Error("closing dead-letter file", zap.Error(err))
+		}
+		s.deadLetter = nil
+	}
+}
+
+func (s *Sink) dispatch() {
+	defer utils.LogOnPanic()
+	defer s.WaitGroup().Done()
+	defer func() {
+		for _, e := range s.endpoints {
+			close(e.queue)
+		}
+	}()
+
+	for {
+		select {
+		case <-s.Context().Done():
+			// The messages left in the subscription are written to the dead-letter file by deliverLoop
+			for {
+				select {
+				case env, ok := <-s.sub.Ch:
+					if !ok {
+						return
+					}
+					s.enqueue(env)
+				default:
+					return
+				}
+			}
+		case env, ok := <-s.sub.Ch:
+			if !ok {
+				return
+			}
+			s.enqueue(env)
+		}
+	}
+}
+
+func (s *Sink) enqueue(env *protocol.Envelope) {
+	var d *delivery
+	for _, e := range s.endpoints {
+		if !e.Match(env) {
+			continue
+		}
+
+		if d == nil {
+			d = s.newDelivery(env)
+			if d == nil {
+				return
+			}
+		}
+
+		select {
+		case e.queue <- d:
+		default:
+			s.writeDeadLetter(e, d, 0, errors.New("queue is full"), queueFull)
+		}
+	}
+}
+
+// dropped writes the messages discarded by the subscription, because dispatch could not keep up,
+// to the dead-letter file of the endpoints they were meant for
+func (s *Sink) dropped(env *protocol.Envelope) {
+	var d *delivery
+	for _, e := range s.endpoints {
+		if !e.Match(env) {
+			continue
+		}
+
+		if d == nil {
+			d = s.newDelivery(env)
+			if d == nil {
+				return
+			}
+		}
+
+		s.writeDeadLetter(e, d, 0, errors.New("subscription is full"), queueFull)
+	}
+}
+
+func (s *Sink) newDelivery(env *protocol.Envelope) *delivery {
+	message := &rest.RestWakuMessage{}
+	if err := message.FromProto(env.Message()); err != nil {
+		s.log.Error("converting protobuffer msg into rest msg", logging.Hash(env.Hash()), zap.Error(err))
+		return nil
+	}
+
+	body, err := json.Marshal(Event{
+		MessageHash: env.Hash().String(),
+		PubsubTopic: env.PubsubTopic(),
+		Message:     message,
+	})
+	if err != nil {
+		s.log.Error("encoding webhook event", logging.Hash(env.Hash()), zap.Error(err))
+		return nil
+	}
+
+	return &delivery{hash: env.Hash(), body: body}
+}
+
+func (s *Sink) deliverLoop(e *endpoint) {
+	defer utils.LogOnPanic()
+	defer s.WaitGroup().Done()
+
+	for d := range e.queue {
+		if s.Context().Err() != nil {
+			s.writeDeadLetter(e, d, 0, s.Context().Err(), shutdown)
+			continue
+		}
+		s.deliver(e, d)
+	}
+}
+
+// permanentError is returned for the responses indicating that the request must not be retried
+type permanentError struct {
+	statusCode int
+}
+
+func (e *permanentError) Error() string {
+	return fmt.Sprintf("request rejected with status %d", e.statusCode)
+}
+
+func (s *Sink) deliver(e *endpoint, d *delivery) {
+	logger := s.log.With(logging.Hash(d.hash), zap.String("url", e.URL))
+
+	bo := backoff.NewExponentialBackOff()
+	bo.InitialInterval = s.params.initialInterval
+	bo.MaxInterval = s.params.maxInterval
+	bo.MaxElapsedTime = 0 // limited by the number of attempts
+
+	attempts := 0
+	err := backoff.RetryNotify(
+		func() error {
+			attempts++
+			return s.post(e, d)
+		},
+		backoff.WithContext(backoff.WithMaxRetries(bo, uint64(s.params.maxAttempts-1)), s.Context()),
+		func(err error, next time.Duration) {
+			logger.Debug("retrying webhook delivery", zap.Int("attempt", attempts), zap.Duration("next", next), zap.Error(err))
+			s.metrics.RecordRetry()
+		},
+	)
+	if err == nil {
+		s.metrics.RecordDelivered()
+		return
+	}
+
+	var permanent *permanentError
+	switch {
+	case errors.As(err, &permanent):
+		s.writeDeadLetter(e, d, attempts, err, rejected)
+	case s.Context().Err() != nil:
+		s.writeDeadLetter(e, d, attempts, err, shutdown)
+	default:
+		s.writeDeadLetter(e, d, attempts, err, retriesExhausted)
+	}
+}
+
+func (s *Sink) post(e *endpoint, d *delivery) error {
+	req, err := http.NewRequestWithContext(s.Context(), http.MethodPost, e.URL, bytes.NewReader(d.body))
+	if err != nil {
+		return backoff.Permanent(err)
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(MessageHashHeader, d.hash.String())
+	if len(s.params.secret) != 0 {
+		timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+		req.Header.Set(TimestampHeader, timestamp)
+		req.Header.Set(SignatureHeader, Sign(s.params.secret, timestamp, d.body))
+	}
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, resp.Body)
+
+	switch {
+	case resp.StatusCode >= 200 && resp.StatusCode < 300:
+		return nil
+	case resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode == http.StatusRequestTimeout || resp.StatusCode >= 500:
+		return fmt.Errorf("request failed with status %d", resp.StatusCode)
+	default:
+		return backoff.Permanent(&permanentError{statusCode: resp.StatusCode})
+	}
+}
+
+func (s *Sink) writeDeadLetter(e *endpoint, d *delivery, attempts int, err error, reason deadLetterReason) {
+	s.metrics.RecordDeadLetter(reason)
+
+	logger := s.log.With(logging.Hash(d.hash), zap.String("url", e.URL), zap.Int("attempts", attempts), zap.Error(err))
+	if s.deadLetter == nil {
+		logger.Warn("could not deliver message to webhook")
+		return
+	}
+
+	logger.Debug("could not deliver message to webhook, writing it to the dead-letter file")
+	if err := s.deadLetter.write(deadLetter{
+		Time:     time.Now().UTC(),
+		URL:      e.URL,
+		Attempts: attempts,
+		Error:    err.Error(),
+		Event:    d.body,
+	}); err != nil {
+		logger.Error("writing to dead-letter file", zap.NamedError("writeError", err))
+	}
+}
+
+// deadLetter is a line of the dead-letter file
+type deadLetter struct {
+	Time     time.Time       `json:"time"`
+	URL      string          `json:"url"`
+	Attempts int             `json:"attempts"`
+	Error    string          `json:"error"`
+	Event    json.RawMessage `json:"event"`
+}
+
+type deadLetterFile struct {
+	sync.Mutex
+	f *os.File
+}
+
+func (d *deadLetterFile) write(entry deadLetter) error {
+	line, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+
+	d.Lock()
+	defer d.Unlock()
+	_, err = d.f.Write(append(line, '\n'))
+	return err
+}
+
+func (d *deadLetterFile) close() error {
+	d.Lock()
+	defer d.Unlock()
+	return d.f.Close()
+}
@@ -0,0 +1,133 @@
+package webhook
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/stretchr/testify/require"
+	"github.com/waku-org/go-waku/tests"
+	"github.com/waku-org/go-waku/waku/v2/protocol"
+	"github.com/waku-org/go-waku/waku/v2/protocol/relay"
+	"github.com/waku-org/go-waku/waku/v2/utils"
+)
+
+func TestParseEndpoint(t *testing.T) {
+	e, err := ParseEndpoint("https://example.com/hook?a=b")
+	require.NoError(t, err)
+	require.Equal(t, Endpoint{URL: "https://example.com/hook?a=b"}, e)
+
+	e, err = ParseEndpoint("http://localhost:8080;/waku/2/rs/16/32;/app/1/*/proto, /app/2/chat/proto")
+	require.NoError(t, err)
+	require.Equal(t, "http://localhost:8080", e.URL)
+	require.Equal(t, "/waku/2/rs/16/32", e.PubsubTopic)
+	require.Equal(t, []string{"/app/1/*/proto", "/app/2/chat/proto"}, e.ContentTopics)
+
+	_, err = ParseEndpoint("localhost:8080")
+	require.ErrorIs(t, err, ErrInvalidURL)
+
+	_, err = ParseEndpoint("ftp://localhost")
+	require.ErrorIs(t, err, ErrInvalidURL)
+}
+
+func TestSink(t *testing.T) {
+	secret := []byte("secret")
+
+	var mu sync.Mutex
+	received := make(map[string][]Event)
+	failOnce := true
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, err := io.ReadAll(r.Body)
+		require.NoError(t, err)
+		require.Equal(t, Sign(secret, r.Header.Get(TimestampHeader), body), r.Header.Get(SignatureHeader))
+
+		var event Event
+		require.NoError(t, json.Unmarshal(body, &event))
+		require.Equal(t, event.MessageHash, r.Header.Get(MessageHashHeader))
+
+		mu.Lock()
+		defer mu.Unlock()
+		received[r.URL.Path] = append(received[r.URL.Path], event)
+
+		switch {
+		case r.URL.Path == "/reject":
+			w.WriteHeader(http.StatusBadRequest)
+		case failOnce:
+			failOnce = false
+			w.WriteHeader(http.StatusServiceUnavailable)
+		default:
+			w.WriteHeader(http.StatusOK)
+		}
+	}))
+	defer server.Close()
+
+	bcaster := relay.NewBroadcaster(10)
+	require.NoError(t, bcaster.Start(context.Background()))
+	defer bcaster.Stop()
+
+	deadLetterPath := filepath.Join(t.TempDir(), "dead-letter.jsonl")
+	endpoints := []Endpoint{
+		{URL: server.URL + "/ok", ContentTopics: []string{"/app/1/*/proto"}},
+		{URL: server.URL + "/reject", PubsubTopic: "test"},
+	}
+
+	sink, err := NewSink(bcaster, endpoints, prometheus.NewRegistry(), utils.Logger(),
+		WithSecret(secret),
+		WithBackoff(10*time.Millisecond, 50*time.Millisecond),
+		WithDeadLetterFile(deadLetterPath),
+	)
+	require.NoError(t, err)
+	require.NoError(t, sink.Start(context.Background()))
+
+	msg1 := tests.CreateWakuMessage("/app/1/chat/proto", utils.GetUnixEpoch())
+	msg2 := tests.CreateWakuMessage("/app/2/chat/proto", utils.GetUnixEpoch())
+	bcaster.Submit(protocol.NewEnvelope(msg1, *utils.GetUnixEpoch(), "test"))
+	bcaster.Submit(protocol.NewEnvelope(msg2, *utils.GetUnixEpoch(), "test"))
+
+	// The first delivery fails and is retried. Rejected messages are not retried
+	require.Eventually(t, func() bool {
+		mu.Lock()
+		defer mu.Unlock()
+		return len(received["/ok"]) == 2 && len(received["/reject"]) == 2
+	}, 5*time.Second, 10*time.Millisecond)
+
+	sink.Stop()
+
+	mu.Lock()
+	for _, event := range received["/ok"] {
+		require.Equal(t, msg1.ContentTopic, event.Message.ContentTopic)
+		require.Equal(t, "test", event.PubsubTopic)
+	}
+	mu.Unlock()
+
+	f, err := os.Open(deadLetterPath)
+	require.NoError(t, err)
+	defer f.Close()
+
+	var letters []deadLetter
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		var letter deadLetter
+		require.NoError(t, json.Unmarshal(scanner.Bytes(), &letter))
+		letters = append(letters, letter)
+	}
+	require.Len(t, letters, 2)
+	for _, letter := range letters {
+		require.Equal(t, server.URL+"/reject", letter.URL)
+		require.Equal(t, 1, letter.Attempts)
+
+		var event Event
+		require.NoError(t, json.Unmarshal(letter.Event, &event))
+		require.Equal(t, "test", event.PubsubTopic)
+	}
+}
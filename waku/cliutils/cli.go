@@ -53,6 +53,34 @@ func (v *PrivateKeyValue) String() string {
 	return "0x" + common.Bytes2Hex(crypto.FromECDSA(*v.Value))
 }
 
+type PrivateKeySlice struct {
+	Values *[]*ecdsa.PrivateKey
+}
+
+func (k *PrivateKeySlice) Set(value string) error {
+	for _, key := range strings.Split(value, ",") {
+		prvKey, err := crypto.ToECDSA(common.FromHex(strings.TrimSpace(key)))
+		if err != nil {
+			return errors.New("invalid private key")
+		}
+		*k.Values = append(*k.Values, prvKey)
+	}
+	return nil
+}
+
+func (k *PrivateKeySlice) String() string {
+	if k.Values == nil {
+		return ""
+	}
+
+	var output []string
+	for _, v := range *k.Values {
+		output = append(output, "0x"+common.Bytes2Hex(crypto.FromECDSA(v)))
+	}
+
+	return strings.Join(output, ", ")
+}
+
 type ChoiceValue struct {
 	Choices []string // the choices that this value can take
 	Value   *string  // the actual value
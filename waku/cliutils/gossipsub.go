@@ -0,0 +1,108 @@
+package cliutils
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/BurntSushi/toml"
+	pubsub "github.com/libp2p/go-libp2p-pubsub"
+	"github.com/waku-org/go-waku/waku/v2/protocol/relay"
+)
+
+// TopicScoreConfig contains the peer score parameters of a pubsub topic, as written in a
+// [gossipsub-topic."<pubsub topic>"] table of the TOML configuration file. The parameters
+// not set are taken from the topic score parameters of Profile, or of the profile of the node
+type TopicScoreConfig struct {
+	Profile string `toml:"profile"`
+
+	TopicWeight *float64 `toml:"topic-weight"`
+
+	TimeInMeshWeight  *float64       `toml:"time-in-mesh-weight"`
+	TimeInMeshQuantum *time.Duration `toml:"time-in-mesh-quantum"`
+	TimeInMeshCap     *float64       `toml:"time-in-mesh-cap"`
+
+	FirstMessageDeliveriesWeight *float64 `toml:"first-message-deliveries-weight"`
+	FirstMessageDeliveriesDecay  *float64 `toml:"first-message-deliveries-decay"`
+	FirstMessageDeliveriesCap    *float64 `toml:"first-message-deliveries-cap"`
+
+	MeshMessageDeliveriesWeight     *float64       `toml:"mesh-message-deliveries-weight"`
+	MeshMessageDeliveriesDecay      *float64       `toml:"mesh-message-deliveries-decay"`
+	MeshMessageDeliveriesCap        *float64       `toml:"mesh-message-deliveries-cap"`
+	MeshMessageDeliveriesThreshold  *float64       `toml:"mesh-message-deliveries-threshold"`
+	MeshMessageDeliveriesWindow     *time.Duration `toml:"mesh-message-deliveries-window"`
+	MeshMessageDeliveriesActivation *time.Duration `toml:"mesh-message-deliveries-activation"`
+
+	MeshFailurePenaltyWeight *float64 `toml:"mesh-failure-penalty-weight"`
+	MeshFailurePenaltyDecay  *float64 `toml:"mesh-failure-penalty-decay"`
+
+	InvalidMessageDeliveriesWeight *float64 `toml:"invalid-message-deliveries-weight"`
+	InvalidMessageDeliveriesDecay  *float64 `toml:"invalid-message-deliveries-decay"`
+}
+
+func setFloat(dst *float64, src *float64) {
+	if src != nil {
+		*dst = *src
+	}
+}
+
+func setDuration(dst *time.Duration, src *time.Duration) {
+	if src != nil {
+		*dst = *src
+	}
+}
+
+// ScoreParams applies the values of the configuration on top of the topic score parameters of a profile
+func (c TopicScoreConfig) ScoreParams(defaultProfile string) (pubsub.TopicScoreParams, error) {
+	profileName := defaultProfile
+	if c.Profile != "" {
+		profileName = c.Profile
+	}
+
+	profile, err := relay.GetGossipsubProfile(profileName)
+	if err != nil {
+		return pubsub.TopicScoreParams{}, err
+	}
+
+	p := profile.TopicScoreParams
+	setFloat(&p.TopicWeight, c.TopicWeight)
+	setFloat(&p.TimeInMeshWeight, c.TimeInMeshWeight)
+	setDuration(&p.TimeInMeshQuantum, c.TimeInMeshQuantum)
+	setFloat(&p.TimeInMeshCap, c.TimeInMeshCap)
+	setFloat(&p.FirstMessageDeliveriesWeight, c.FirstMessageDeliveriesWeight)
+	setFloat(&p.FirstMessageDeliveriesDecay, c.FirstMessageDeliveriesDecay)
+	setFloat(&p.FirstMessageDeliveriesCap, c.FirstMessageDeliveriesCap)
+	setFloat(&p.MeshMessageDeliveriesWeight, c.MeshMessageDeliveriesWeight)
+	setFloat(&p.MeshMessageDeliveriesDecay, c.MeshMessageDeliveriesDecay)
+	setFloat(&p.MeshMessageDeliveriesCap, c.MeshMessageDeliveriesCap)
+	setFloat(&p.MeshMessageDeliveriesThreshold, c.MeshMessageDeliveriesThreshold)
+	setDuration(&p.MeshMessageDeliveriesWindow, c.MeshMessageDeliveriesWindow)
+	setDuration(&p.MeshMessageDeliveriesActivation, c.MeshMessageDeliveriesActivation)
+	setFloat(&p.MeshFailurePenaltyWeight, c.MeshFailurePenaltyWeight)
+	setFloat(&p.MeshFailurePenaltyDecay, c.MeshFailurePenaltyDecay)
+	setFloat(&p.InvalidMessageDeliveriesWeight, c.InvalidMessageDeliveriesWeight)
+	setFloat(&p.InvalidMessageDeliveriesDecay, c.InvalidMessageDeliveriesDecay)
+
+	return p, nil
+}
+
+// LoadTopicScoreParams reads the score parameters of each pubsub topic from a TOML configuration file
+func LoadTopicScoreParams(configFile string, defaultProfile string) (map[string]pubsub.TopicScoreParams, error) {
+	var cfg struct {
+		Topics map[string]TopicScoreConfig `toml:"gossipsub-topic"`
+	}
+
+	if _, err := toml.DecodeFile(configFile, &cfg); err != nil {
+		return nil, err
+	}
+
+	result := make(map[string]pubsub.TopicScoreParams)
+	for topic, topicCfg := range cfg.Topics {
+		scoreParams, err := topicCfg.ScoreParams(defaultProfile)
+		if err != nil {
+			return nil, fmt.Errorf("invalid score parameters of pubsub topic %s: %w", topic, err)
+		}
+		result[topic] = scoreParams
+	}
+
+	return result, nil
+}
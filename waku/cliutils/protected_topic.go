@@ -1,24 +1,131 @@
 package cliutils
 
 import (
-	"crypto/ecdsa"
 	"encoding/hex"
 	"errors"
 	"fmt"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/waku-org/go-waku/waku/v2/protocol/relay"
+)
+
+const (
+	// keySeparator separates the keys of a topic. A comma can't be used, since it separates
+	// the values of the flag, i.e. when they're read from a TOML list
+	keySeparator         = "|"
+	keyValiditySeparator = ".."
+	thresholdOption      = "threshold="
 )
 
 type ProtectedTopic struct {
-	Topic     string
-	PublicKey *ecdsa.PublicKey
+	Topic  string
+	Config relay.ProtectedTopicConfig
 }
 
 func (p ProtectedTopic) String() string {
-	pubKBytes := crypto.FromECDSAPub(p.PublicKey)
-	return fmt.Sprintf("%s:%s", p.Topic, hex.EncodeToString(pubKBytes))
+	var keys []string
+	for _, k := range p.Config.Keys {
+		key := hex.EncodeToString(crypto.FromECDSAPub(k.PublicKey))
+		if !k.NotBefore.IsZero() || !k.NotAfter.IsZero() {
+			key += "@" + formatKeyTime(k.NotBefore) + keyValiditySeparator + formatKeyTime(k.NotAfter)
+		}
+		keys = append(keys, key)
+	}
+
+	result := fmt.Sprintf("%s:%s", p.Topic, strings.Join(keys, keySeparator))
+	if p.Config.Threshold > 1 {
+		result += fmt.Sprintf(";%s%d", thresholdOption, p.Config.Threshold)
+	}
+	return result
+}
+
+func formatKeyTime(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.Format(time.RFC3339)
+}
+
+func parseKeyTime(value string) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, nil
+	}
+	return time.Parse(time.RFC3339, value)
+}
+
+// parseTopicKey parses a hex encoded public key, optionally followed by its validity
+// window: pubkey@notBefore..notAfter, using RFC3339 times. Either bound can be omitted
+func parseTopicKey(value string) (relay.ProtectedTopicKey, error) {
+	keyParts := strings.SplitN(value, "@", 2)
+
+	pubk, err := crypto.UnmarshalPubkey(common.FromHex(keyParts[0]))
+	if err != nil {
+		return relay.ProtectedTopicKey{}, err
+	}
+
+	result := relay.ProtectedTopicKey{PublicKey: pubk}
+	if len(keyParts) == 1 {
+		return result, nil
+	}
+
+	bounds := strings.Split(keyParts[1], keyValiditySeparator)
+	if len(bounds) != 2 {
+		return relay.ProtectedTopicKey{}, errors.New("expected key validity notBefore..notAfter")
+	}
+
+	result.NotBefore, err = parseKeyTime(bounds[0])
+	if err != nil {
+		return relay.ProtectedTopicKey{}, err
+	}
+
+	result.NotAfter, err = parseKeyTime(bounds[1])
+	if err != nil {
+		return relay.ProtectedTopicKey{}, err
+	}
+
+	return result, nil
+}
+
+// ParseProtectedTopic parses topic:pubkey[@notBefore..notAfter][|pubkey...][;threshold=M].
+// Messages of the topic must be signed by M of the keys, 1 by default
+func ParseProtectedTopic(value string) (ProtectedTopic, error) {
+	protectedTopicParts := strings.SplitN(value, ":", 2)
+	if len(protectedTopicParts) != 2 || protectedTopicParts[0] == "" {
+		return ProtectedTopic{}, errors.New("expected topic_name:hex_encoded_public_key")
+	}
+
+	options := strings.Split(protectedTopicParts[1], ";")
+
+	result := ProtectedTopic{Topic: protectedTopicParts[0]}
+	for _, k := range strings.Split(options[0], keySeparator) {
+		key, err := parseTopicKey(strings.TrimSpace(k))
+		if err != nil {
+			return ProtectedTopic{}, fmt.Errorf("invalid key for topic %s: %w", result.Topic, err)
+		}
+		result.Config.Keys = append(result.Config.Keys, key)
+	}
+
+	for _, option := range options[1:] {
+		if !strings.HasPrefix(option, thresholdOption) {
+			return ProtectedTopic{}, fmt.Errorf("unknown option %s", option)
+		}
+
+		threshold, err := strconv.Atoi(strings.TrimPrefix(option, thresholdOption))
+		if err != nil {
+			return ProtectedTopic{}, fmt.Errorf("invalid threshold: %w", err)
+		}
+		result.Config.Threshold = threshold
+	}
+
+	if err := result.Config.Validate(); err != nil {
+		return ProtectedTopic{}, err
+	}
+
+	return result, nil
 }
 
 type ProtectedTopicSlice struct {
@@ -26,19 +133,20 @@ type ProtectedTopicSlice struct {
 }
 
 func (k *ProtectedTopicSlice) Set(value string) error {
-	protectedTopicParts := strings.Split(value, ":")
-	if len(protectedTopicParts) != 2 {
-		return errors.New("expected topic_name:hex_encoded_public_key")
-	}
+	for _, v := range strings.Split(value, ",") {
+		protectedTopic, err := ParseProtectedTopic(strings.TrimSpace(v))
+		if err != nil {
+			return err
+		}
 
-	pubk, err := crypto.UnmarshalPubkey(common.FromHex(protectedTopicParts[1]))
-	if err != nil {
-		return err
+		for _, p := range *k.Values {
+			if p.Topic == protectedTopic.Topic {
+				return fmt.Errorf("topic %s is protected more than once", p.Topic)
+			}
+		}
+
+		*k.Values = append(*k.Values, protectedTopic)
 	}
-	*k.Values = append(*k.Values, ProtectedTopic{
-		Topic:     protectedTopicParts[0],
-		PublicKey: pubk,
-	})
 	return nil
 }
 
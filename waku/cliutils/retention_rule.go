@@ -0,0 +1,36 @@
+package cliutils
+
+import (
+	"strings"
+
+	"github.com/waku-org/go-waku/waku/persistence"
+)
+
+type RetentionRuleSlice struct {
+	Values *[]persistence.RetentionRule
+}
+
+func (k *RetentionRuleSlice) Set(value string) error {
+	rules := strings.Split(value, ",")
+	for _, r := range rules {
+		rule, err := persistence.ParseRetentionRule(r)
+		if err != nil {
+			return err
+		}
+		*k.Values = append(*k.Values, rule)
+	}
+	return nil
+}
+
+func (k *RetentionRuleSlice) String() string {
+	if k.Values == nil {
+		return ""
+	}
+
+	var output []string
+	for _, v := range *k.Values {
+		output = append(output, v.String())
+	}
+
+	return strings.Join(output, ", ")
+}
@@ -0,0 +1,250 @@
+package persistence
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/waku-org/go-waku/logging"
+	"github.com/waku-org/go-waku/waku/v2/protocol"
+	wpb "github.com/waku-org/go-waku/waku/v2/protocol/pb"
+	"github.com/waku-org/go-waku/waku/v2/utils"
+	"go.uber.org/zap"
+)
+
+// ErrStoreStopped indicates that a message could not be queued because the store was stopped
+var ErrStoreStopped = errors.New("message store stopped")
+
+// WithBatchedWrites is a DBOption that makes Put queue the messages instead of inserting
+// them immediately. Queued messages are inserted in a single transaction once batchSize
+// messages are queued or flushInterval elapses, whatever happens first. The queue can hold up
+// to queueSize messages; once it's full, Put blocks until there is room for the message.
+// Messages are inserted immediately until the store is started, queued messages are flushed
+// when the store is stopped, and Put fails with ErrStoreStopped afterwards. Messages are not
+// returned by queries until flushed
+func WithBatchedWrites(batchSize int, flushInterval time.Duration, queueSize int) DBOption {
+	return func(d *DBStore) error {
+		if batchSize <= 0 {
+			return errors.New("batch size must be greater than 0")
+		}
+		if flushInterval <= 0 {
+			return errors.New("batch flush interval must be greater than 0")
+		}
+		if queueSize < batchSize {
+			return errors.New("write queue size must be greater than or equal to the batch size")
+		}
+		d.batchSize = batchSize
+		d.batchFlushInterval = flushInterval
+		d.writeQueue = make(chan *protocol.Envelope, queueSize)
+		d.pending = make(map[wpb.MessageHash]struct{})
+		return nil
+	}
+}
+
+// enqueue adds a message to the write queue, blocking while the queue is full. Messages
+// already queued are reported with ErrDuplicatedMessage, while the ones already stored are
+// skipped, and counted, when the batch is inserted
+func (d *DBStore) enqueue(env *protocol.Envelope) error {
+	d.writerMu.RLock()
+	defer d.writerMu.RUnlock()
+
+	if d.writerStopped {
+		return ErrStoreStopped
+	}
+
+	if !d.writerStarted {
+		return d.insert(env)
+	}
+
+	hash := env.Hash()
+	err := d.addPending(hash)
+	if err != nil {
+		return err
+	}
+
+	select {
+	case d.writeQueue <- env:
+		d.metrics.RecordWriteQueueLength(len(d.writeQueue))
+		return nil
+	default:
+	}
+
+	d.metrics.RecordWriteQueueFull()
+
+	select {
+	case d.writeQueue <- env:
+		d.metrics.RecordWriteQueueLength(len(d.writeQueue))
+		return nil
+	case <-d.writerStopping:
+		d.removePending(hash)
+		return ErrStoreStopped
+	}
+}
+
+// addPending registers the hash of a message to be queued, unless the message is already
+// queued. Messages already stored are only detected when the batch is inserted
+func (d *DBStore) addPending(hash wpb.MessageHash) error {
+	d.pendingMu.Lock()
+	defer d.pendingMu.Unlock()
+
+	if _, queued := d.pending[hash]; queued {
+		return ErrDuplicatedMessage
+	}
+	d.pending[hash] = struct{}{}
+
+	return nil
+}
+
+func (d *DBStore) removePending(hashes ...wpb.MessageHash) {
+	d.pendingMu.Lock()
+	defer d.pendingMu.Unlock()
+	for _, hash := range hashes {
+		delete(d.pending, hash)
+	}
+}
+
+// batchWriter inserts the queued messages until the context is cancelled, and then
+// flushes the messages remaining in the queue
+func (d *DBStore) batchWriter(ctx context.Context) {
+	defer utils.LogOnPanic()
+	defer d.wg.Done()
+
+	ticker := time.NewTicker(d.batchFlushInterval)
+	defer ticker.Stop()
+
+	batch := make([]*protocol.Envelope, 0, d.batchSize)
+	flush := func() {
+		if len(batch) == 0 {
+			return
+		}
+		d.flush(batch)
+		batch = batch[:0]
+		d.metrics.RecordWriteQueueLength(len(d.writeQueue))
+	}
+
+	for {
+		select {
+		case env := <-d.writeQueue:
+			batch = append(batch, env)
+			if len(batch) >= d.batchSize {
+				flush()
+			}
+		case <-ticker.C:
+			flush()
+		case <-ctx.Done():
+			// Once no Put can queue more messages, the remaining ones are flushed
+			d.writerMu.Lock()
+			d.writerStopped = true
+			d.writerMu.Unlock()
+
+			for {
+				select {
+				case env := <-d.writeQueue:
+					batch = append(batch, env)
+					if len(batch) >= d.batchSize {
+						flush()
+					}
+				default:
+					flush()
+					return
+				}
+			}
+		}
+	}
+}
+
+// flush inserts a batch of messages. If the transaction fails, the messages are inserted one
+// by one, so a single message can't make the whole batch be lost
+func (d *DBStore) flush(batch []*protocol.Envelope) {
+	hashes := make([]wpb.MessageHash, len(batch))
+	for i, env := range batch {
+		hashes[i] = env.Hash()
+	}
+	defer d.removePending(hashes...)
+
+	duplicates, err := d.insertBatch(batch)
+	if err == nil {
+		d.recordDuplicates(duplicates)
+		return
+	}
+
+	d.log.Warn("inserting batch of messages, inserting them individually", zap.Int("messages", len(batch)), zap.Error(err))
+
+	duplicates = 0
+	for i, env := range batch {
+		err := d.insert(env)
+		if errors.Is(err, ErrDuplicatedMessage) {
+			duplicates++
+		} else if err != nil {
+			d.metrics.RecordError(insertFailure)
+			d.log.Error("inserting message", logging.Hash(hashes[i]), zap.Error(err))
+		}
+	}
+	d.recordDuplicates(duplicates)
+}
+
+func (d *DBStore) recordDuplicates(duplicates int) {
+	if duplicates == 0 {
+		return
+	}
+	d.metrics.RecordDuplicatedMessages(duplicates)
+	d.log.Debug("skipped messages already stored", zap.Int("duplicates", duplicates))
+}
+
+// insertBatch inserts a list of messages in a single transaction, and returns the number
+// of messages that were not inserted because they were already stored
+func (d *DBStore) insertBatch(batch []*protocol.Envelope) (int, error) {
+	start := time.Now()
+
+	tx, err := d.db.Begin()
+	if err != nil {
+		d.metrics.RecordError(insertFailure)
+		return 0, err
+	}
+
+	stmt, err := tx.Prepare(d.insertMessageQuery())
+	if err != nil {
+		_ = tx.Rollback()
+		d.metrics.RecordError(insertFailure)
+		return 0, err
+	}
+	defer stmt.Close()
+
+	var insertedBytes int64
+	var duplicates int
+	for _, env := range batch {
+		storedAt := env.Message().GetTimestamp()
+		if storedAt == 0 {
+			storedAt = env.Index().ReceiverTime
+		}
+
+		hash := env.Hash()
+		result, err := stmt.Exec(env.Index().Digest, hash[:], storedAt, env.Message().GetTimestamp(), env.Message().ContentTopic, env.PubsubTopic(), env.Message().Payload, env.Message().GetVersion())
+		if err != nil {
+			_ = tx.Rollback()
+			d.metrics.RecordError(insertFailure)
+			return 0, err
+		}
+
+		if n, _ := result.RowsAffected(); n != 0 {
+			insertedBytes += int64(len(env.Message().Payload))
+		} else {
+			duplicates++
+		}
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		d.metrics.RecordError(insertFailure)
+		return 0, err
+	}
+
+	d.metrics.RecordBatchInsert(len(batch), time.Since(start))
+
+	d.storedBytes.Add(insertedBytes)
+	if d.quotaExceeded() {
+		d.notifyQuotaExceeded()
+	}
+
+	return duplicates, nil
+}
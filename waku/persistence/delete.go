@@ -0,0 +1,149 @@
+package persistence
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	wpb "github.com/waku-org/go-waku/waku/v2/protocol/pb"
+	"go.uber.org/zap"
+)
+
+// ErrEmptyDeleteCriteria indicates that a deletion did not specify which messages to remove
+var ErrEmptyDeleteCriteria = errors.New("at least one deletion criteria is required")
+
+// DeleteCriteria selects the messages to remove from the message store. Only the messages
+// matching all the criteria that are set are removed
+type DeleteCriteria struct {
+	// MessageHashes selects the messages with any of these hashes
+	MessageHashes []wpb.MessageHash
+	// PubsubTopic selects the messages published on this pubsub topic
+	PubsubTopic string
+	// ContentTopics selects the messages with any of these content topics
+	ContentTopics []string
+	// StartTime selects the messages stored at or after this time (unix nanoseconds)
+	StartTime *int64
+	// EndTime selects the messages stored at or before this time (unix nanoseconds)
+	EndTime *int64
+}
+
+// IsEmpty returns true if none of the criteria is set
+func (c DeleteCriteria) IsEmpty() bool {
+	return len(c.MessageHashes) == 0 && c.PubsubTopic == "" && len(c.ContentTopics) == 0 && c.StartTime == nil && c.EndTime == nil
+}
+
+func (c DeleteCriteria) whereClause() (string, []interface{}) {
+	var conditions []string
+	var parameters []interface{}
+
+	placeholders := func(values int) string {
+		var result []string
+		for i := 0; i < values; i++ {
+			result = append(result, fmt.Sprintf("$%d", len(parameters)+i+1))
+		}
+		return strings.Join(result, ", ")
+	}
+
+	if len(c.MessageHashes) != 0 {
+		conditions = append(conditions, fmt.Sprintf("messageHash IN (%s)", placeholders(len(c.MessageHashes))))
+		for _, hash := range c.MessageHashes {
+			parameters = append(parameters, hash.Bytes())
+		}
+	}
+
+	if c.PubsubTopic != "" {
+		conditions = append(conditions, fmt.Sprintf("pubsubTopic = %s", placeholders(1)))
+		parameters = append(parameters, c.PubsubTopic)
+	}
+
+	if len(c.ContentTopics) != 0 {
+		conditions = append(conditions, fmt.Sprintf("contentTopic IN (%s)", placeholders(len(c.ContentTopics))))
+		for _, ct := range c.ContentTopics {
+			parameters = append(parameters, ct)
+		}
+	}
+
+	if c.StartTime != nil {
+		conditions = append(conditions, fmt.Sprintf("storedAt >= %s", placeholders(1)))
+		parameters = append(parameters, *c.StartTime)
+	}
+
+	if c.EndTime != nil {
+		conditions = append(conditions, fmt.Sprintf("storedAt <= %s", placeholders(1)))
+		parameters = append(parameters, *c.EndTime)
+	}
+
+	return strings.Join(conditions, " AND "), parameters
+}
+
+// DeleteMessages removes the messages matching the criteria from the message store, and returns
+// the number of messages that were removed. Messages queued by WithBatchedWrites that were not
+// inserted yet are not affected
+func (d *DBStore) DeleteMessages(criteria DeleteCriteria) (int64, error) {
+	if criteria.IsEmpty() {
+		return 0, ErrEmptyDeleteCriteria
+	}
+
+	start := time.Now()
+	where, parameters := criteria.whereClause()
+
+	deleted, deletedBytes, err := d.deleteFrom("message", where, parameters...)
+	if err != nil {
+		d.metrics.RecordError(deleteFailure)
+		return 0, err
+	}
+
+	d.metrics.RecordDeletedMessages(deleted)
+
+	d.log.Debug("deleted messages", zap.Int64("deleted", deleted), zap.Int64("deletedBytes", deletedBytes), zap.Duration("duration", time.Since(start)))
+
+	return deleted, nil
+}
+
+// deleteFrom removes the messages of a table matching a condition, and returns the number of
+// messages removed and the size of their payloads. The size of the payloads is only calculated,
+// and subtracted from the stored bytes, when a quota is set
+func (d *DBStore) deleteFrom(table string, where string, parameters ...interface{}) (int64, int64, error) {
+	if d.maxBytes == 0 {
+		result, err := d.db.Exec(fmt.Sprintf("DELETE FROM %s WHERE %s", table, where), parameters...)
+		if err != nil {
+			return 0, 0, err
+		}
+		deleted, err := result.RowsAffected()
+		return deleted, 0, err
+	}
+
+	tx, err := d.db.Begin()
+	if err != nil {
+		return 0, 0, err
+	}
+
+	var deletedBytes int64
+	err = tx.QueryRow(fmt.Sprintf("SELECT COALESCE(SUM(LENGTH(payload)), 0) FROM %s WHERE %s", table, where), parameters...).Scan(&deletedBytes)
+	if err != nil {
+		_ = tx.Rollback()
+		return 0, 0, err
+	}
+
+	result, err := tx.Exec(fmt.Sprintf("DELETE FROM %s WHERE %s", table, where), parameters...)
+	if err != nil {
+		_ = tx.Rollback()
+		return 0, 0, err
+	}
+
+	deleted, err := result.RowsAffected()
+	if err != nil {
+		_ = tx.Rollback()
+		return 0, 0, err
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return 0, 0, err
+	}
+
+	d.storedBytes.Add(-deletedBytes)
+
+	return deleted, deletedBytes, nil
+}
@@ -0,0 +1,124 @@
+package leveldb
+
+import (
+	"errors"
+	"time"
+
+	goleveldb "github.com/syndtr/goleveldb/leveldb"
+	"github.com/syndtr/goleveldb/leveldb/util"
+	"github.com/waku-org/go-waku/waku/persistence"
+	wpb "github.com/waku-org/go-waku/waku/v2/protocol/pb"
+	"go.uber.org/zap"
+)
+
+// matchesCriteria returns true if a record matches all the criteria that are set, except
+// the message hashes
+func matchesCriteria(criteria persistence.DeleteCriteria, r record) bool {
+	if criteria.PubsubTopic != "" && r.pubsubTopic != criteria.PubsubTopic {
+		return false
+	}
+
+	if len(criteria.ContentTopics) != 0 {
+		found := false
+		for _, ct := range criteria.ContentTopics {
+			if r.msg.ContentTopic == ct {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+
+	if criteria.StartTime != nil && r.storedAt < *criteria.StartTime {
+		return false
+	}
+
+	if criteria.EndTime != nil && r.storedAt > *criteria.EndTime {
+		return false
+	}
+
+	return true
+}
+
+// DeleteMessages removes the messages matching the criteria from the message store, and
+// returns the number of messages that were removed
+func (s *MessageStore) DeleteMessages(criteria persistence.DeleteCriteria) (int64, error) {
+	if criteria.IsEmpty() {
+		return 0, persistence.ErrEmptyDeleteCriteria
+	}
+
+	start := time.Now()
+
+	filter := func(r record) bool {
+		return matchesCriteria(criteria, r)
+	}
+
+	var deleted int64
+	var err error
+	if len(criteria.MessageHashes) != 0 {
+		deleted, err = s.deleteByHash(criteria.MessageHashes, filter)
+	} else {
+		rng := util.BytesPrefix([]byte{timeIndexPrefix})
+		if criteria.StartTime != nil {
+			rng.Start = concat([]byte{timeIndexPrefix}, encodeTimestamp(*criteria.StartTime))
+		}
+		if criteria.EndTime != nil {
+			rng.Limit = util.BytesPrefix(concat([]byte{timeIndexPrefix}, encodeTimestamp(*criteria.EndTime))).Limit
+		}
+		deleted, err = s.deleteOldest(rng, -1, filter)
+	}
+	if err != nil {
+		s.metrics.RecordError(deleteFailure)
+		return deleted, err
+	}
+
+	s.log.Debug("deleted messages", zap.Int64("deleted", deleted), zap.Duration("duration", time.Since(start)))
+
+	return deleted, nil
+}
+
+// deleteByHash removes the messages with any of the hashes that are accepted by the filter
+func (s *MessageStore) deleteByHash(hashes []wpb.MessageHash, filter func(record) bool) (int64, error) {
+	s.writeMu.Lock()
+	defer s.writeMu.Unlock()
+
+	var deleted int64
+	seen := make(map[string]struct{})
+	batch := new(goleveldb.Batch)
+	for _, hash := range hashes {
+		if _, ok := seen[string(hash[:])]; ok {
+			continue
+		}
+		seen[string(hash[:])] = struct{}{}
+
+		r, err := s.get(hash[:])
+		if err != nil {
+			if errors.Is(err, goleveldb.ErrNotFound) {
+				continue
+			}
+			return 0, err
+		}
+
+		if !filter(r) {
+			continue
+		}
+
+		deleteRecord(batch, r)
+		deleted++
+	}
+
+	if deleted == 0 {
+		return 0, nil
+	}
+
+	err := s.db.Write(batch, nil)
+	if err != nil {
+		return 0, err
+	}
+
+	s.count.Add(-deleted)
+
+	return deleted, nil
+}
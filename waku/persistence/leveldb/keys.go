@@ -0,0 +1,161 @@
+package leveldb
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+
+	"github.com/waku-org/go-waku/waku/persistence"
+	wpb "github.com/waku-org/go-waku/waku/v2/protocol/pb"
+	"google.golang.org/protobuf/proto"
+)
+
+// Key layout:
+//
+//	m | messageHash                                                               -> record
+//	t | storedAt | messageHash                                                    -> pubsubTopic
+//	c | len(pubsubTopic) | pubsubTopic | len(contentTopic) | contentTopic | storedAt | messageHash -> (empty)
+//	k | len(contentTopic) | contentTopic | storedAt | messageHash                 -> (empty)
+//
+// storedAt is encoded in big endian with the sign bit flipped, so the lexicographical order
+// of the index keys matches the (storedAt, messageHash) order used by the SQL message providers
+const (
+	messagePrefix           byte = 'm'
+	timeIndexPrefix         byte = 't'
+	topicIndexPrefix        byte = 'c'
+	contentTopicIndexPrefix byte = 'k'
+)
+
+const hashLength = 32
+
+var errInvalidRecord = errors.New("invalid record")
+
+func encodeTimestamp(timestamp int64) []byte {
+	b := make([]byte, 8)
+	binary.BigEndian.PutUint64(b, uint64(timestamp)^(1<<63))
+	return b
+}
+
+func decodeTimestamp(b []byte) int64 {
+	return int64(binary.BigEndian.Uint64(b) ^ (1 << 63))
+}
+
+func concat(parts ...[]byte) []byte {
+	return bytes.Join(parts, nil)
+}
+
+func messageKey(hash []byte) []byte {
+	return concat([]byte{messagePrefix}, hash)
+}
+
+// sortKey is the suffix shared by the index keys, used to order the messages
+func sortKey(storedAt int64, hash []byte) []byte {
+	return concat(encodeTimestamp(storedAt), hash)
+}
+
+func timeIndexKey(storedAt int64, hash []byte) []byte {
+	return concat([]byte{timeIndexPrefix}, sortKey(storedAt, hash))
+}
+
+func appendLengthPrefixed(b []byte, value string) []byte {
+	b = binary.AppendUvarint(b, uint64(len(value)))
+	return append(b, value...)
+}
+
+func topicPrefix(pubsubTopic string, contentTopic string) []byte {
+	b := []byte{topicIndexPrefix}
+	b = appendLengthPrefixed(b, pubsubTopic)
+	return appendLengthPrefixed(b, contentTopic)
+}
+
+func topicIndexKey(pubsubTopic string, contentTopic string, storedAt int64, hash []byte) []byte {
+	return concat(topicPrefix(pubsubTopic, contentTopic), sortKey(storedAt, hash))
+}
+
+func contentTopicPrefix(contentTopic string) []byte {
+	return appendLengthPrefixed([]byte{contentTopicIndexPrefix}, contentTopic)
+}
+
+func contentTopicIndexKey(contentTopic string, storedAt int64, hash []byte) []byte {
+	return concat(contentTopicPrefix(contentTopic), sortKey(storedAt, hash))
+}
+
+// hashFromIndexKey returns the message hash at the end of an index key
+func hashFromIndexKey(key []byte) []byte {
+	return key[len(key)-hashLength:]
+}
+
+// record is the value stored for each message
+type record struct {
+	storedAt    int64
+	id          []byte
+	hash        []byte
+	pubsubTopic string
+	msg         *wpb.WakuMessage
+}
+
+func (r record) sortKey() []byte {
+	return sortKey(r.storedAt, r.hash)
+}
+
+func (r record) storedMessage() persistence.StoredMessage {
+	return persistence.StoredMessage{
+		ID:           r.id,
+		MessageHash:  r.hash,
+		PubsubTopic:  r.pubsubTopic,
+		ReceiverTime: r.storedAt,
+		Message:      r.msg,
+	}
+}
+
+func (r record) encode() ([]byte, error) {
+	msgBytes, err := proto.Marshal(r.msg)
+	if err != nil {
+		return nil, err
+	}
+
+	b := encodeTimestamp(r.storedAt)
+	b = binary.AppendUvarint(b, uint64(len(r.id)))
+	b = append(b, r.id...)
+	b = appendLengthPrefixed(b, r.pubsubTopic)
+	return append(b, msgBytes...), nil
+}
+
+func readLengthPrefixed(b []byte) ([]byte, []byte, error) {
+	l, n := binary.Uvarint(b)
+	if n <= 0 || uint64(len(b)-n) < l {
+		return nil, nil, errInvalidRecord
+	}
+	return b[n : n+int(l)], b[n+int(l):], nil
+}
+
+func decodeRecord(hash []byte, value []byte) (record, error) {
+	if len(value) < 8 {
+		return record{}, errInvalidRecord
+	}
+
+	r := record{
+		storedAt: decodeTimestamp(value[:8]),
+		hash:     append([]byte(nil), hash...),
+	}
+
+	id, rest, err := readLengthPrefixed(value[8:])
+	if err != nil {
+		return record{}, err
+	}
+	r.id = append([]byte(nil), id...)
+
+	pubsubTopic, rest, err := readLengthPrefixed(rest)
+	if err != nil {
+		return record{}, err
+	}
+	r.pubsubTopic = string(pubsubTopic)
+
+	r.msg = new(wpb.WakuMessage)
+	err = proto.Unmarshal(rest, r.msg)
+	if err != nil {
+		return record{}, err
+	}
+
+	return r, nil
+}
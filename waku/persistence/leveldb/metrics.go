@@ -0,0 +1,89 @@
+package leveldb
+
+import (
+	"time"
+
+	"github.com/libp2p/go-libp2p/p2p/metricshelper"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var kvMessages = prometheus.NewGauge(
+	prometheus.GaugeOpts{
+		Name: "waku_archive_kv_messages",
+		Help: "The number of messages stored in the embedded key-value archive",
+	})
+
+var kvErrors = prometheus.NewCounterVec(
+	prometheus.CounterOpts{
+		Name: "waku_archive_kv_errors",
+		Help: "The distribution of the embedded key-value archive errors",
+	},
+	[]string{"error_type"},
+)
+
+var kvInsertDurationSeconds = prometheus.NewHistogram(
+	prometheus.HistogramOpts{
+		Name: "waku_archive_kv_insert_duration_seconds",
+		Help: "Message insertion duration in the embedded key-value archive",
+	})
+
+var kvQueryDurationSeconds = prometheus.NewHistogram(
+	prometheus.HistogramOpts{
+		Name: "waku_archive_kv_query_duration_seconds",
+		Help: "History query duration in the embedded key-value archive",
+	})
+
+var collectors = []prometheus.Collector{
+	kvMessages,
+	kvErrors,
+	kvInsertDurationSeconds,
+	kvQueryDurationSeconds,
+}
+
+// Metrics exposes the functions required to update prometheus metrics for the key-value archive
+type Metrics interface {
+	RecordMessages(num int)
+	RecordError(err metricsErrCategory)
+	RecordInsertDuration(duration time.Duration)
+	RecordQueryDuration(duration time.Duration)
+}
+
+type metricsImpl struct {
+	reg prometheus.Registerer
+}
+
+func newMetrics(reg prometheus.Registerer) Metrics {
+	metricshelper.RegisterCollectors(reg, collectors...)
+	return &metricsImpl{
+		reg: reg,
+	}
+}
+
+// RecordMessages sets the gauge for the number of messages stored in the archive
+func (m *metricsImpl) RecordMessages(num int) {
+	kvMessages.Set(float64(num))
+}
+
+type metricsErrCategory string
+
+var (
+	insertFailure    metricsErrCategory = "insert_failure"
+	queryFailure     metricsErrCategory = "query_failure"
+	retPolicyFailure metricsErrCategory = "retpolicy_failure"
+	deleteFailure    metricsErrCategory = "delete_failure"
+)
+
+// RecordError increases the counter for different error types
+func (m *metricsImpl) RecordError(err metricsErrCategory) {
+	kvErrors.WithLabelValues(string(err)).Inc()
+}
+
+// RecordInsertDuration tracks the duration for inserting a record in the archive
+func (m *metricsImpl) RecordInsertDuration(duration time.Duration) {
+	kvInsertDurationSeconds.Observe(duration.Seconds())
+}
+
+// RecordQueryDuration tracks the duration for executing a query in the archive
+func (m *metricsImpl) RecordQueryDuration(duration time.Duration) {
+	kvQueryDurationSeconds.Observe(duration.Seconds())
+}
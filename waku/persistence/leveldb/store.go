@@ -0,0 +1,655 @@
+package leveldb
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	goleveldb "github.com/syndtr/goleveldb/leveldb"
+	"github.com/syndtr/goleveldb/leveldb/storage"
+	"github.com/syndtr/goleveldb/leveldb/util"
+	"github.com/waku-org/go-waku/waku/persistence"
+	"github.com/waku-org/go-waku/waku/v2/protocol"
+	"github.com/waku-org/go-waku/waku/v2/protocol/legacy_store/pb"
+	wpb "github.com/waku-org/go-waku/waku/v2/protocol/pb"
+	"github.com/waku-org/go-waku/waku/v2/timesource"
+	"github.com/waku-org/go-waku/waku/v2/utils"
+	"go.uber.org/zap"
+)
+
+// URLScheme is the scheme used in a database URL to select the embedded key-value
+// message store, i.e. leveldb:///path/to/dir or leveldb://:memory:
+const URLScheme = "leveldb"
+
+// InMemoryPath is the path used to keep the message store in memory
+const InMemoryPath = ":memory:"
+
+// ErrDuplicatedMessage indicates that a message with the same hash was already stored
+var ErrDuplicatedMessage = persistence.ErrDuplicatedMessage
+
+// deleteBatchSize is the max number of messages removed in a single write by the retention policy
+const deleteBatchSize = 1000
+
+// ParseURL returns the path of the message store if the database URL uses the leveldb scheme
+func ParseURL(databaseURL string) (string, bool) {
+	path, ok := strings.CutPrefix(databaseURL, URLScheme+"://")
+	if !ok || path == "" {
+		return "", false
+	}
+	return path, true
+}
+
+// MessageStore is a MessageProvider backed by an embedded leveldb key-value store, for
+// environments where a SQL database is not available. Besides the messages indexed by hash,
+// it keeps an index by time, an index by pubsub topic and content topic, and an index by
+// content topic, used to serve history queries with the same semantics as the SQL message providers
+type MessageStore struct {
+	db *goleveldb.DB
+
+	metrics    Metrics
+	timesource timesource.Timesource
+	log        *zap.Logger
+
+	maxMessages int
+	maxDuration time.Duration
+
+	// writeMu serializes the writes, so the message count and the indexes stay consistent
+	writeMu sync.Mutex
+	count   atomic.Int64
+
+	wg     sync.WaitGroup
+	cancel context.CancelFunc
+}
+
+// Option is an optional setting that can be used to configure the MessageStore
+type Option func(*MessageStore) error
+
+// WithRetentionPolicy is an Option that specifies the max number of messages
+// to be stored and duration before they're removed from the message store
+func WithRetentionPolicy(maxMessages int, maxDuration time.Duration) Option {
+	return func(s *MessageStore) error {
+		s.maxDuration = maxDuration
+		s.maxMessages = maxMessages
+		return nil
+	}
+}
+
+// NewMessageStore opens or creates the message store located in path. If the path is
+// InMemoryPath, messages are kept in memory and lost once the store is stopped
+func NewMessageStore(path string, reg prometheus.Registerer, log *zap.Logger, options ...Option) (*MessageStore, error) {
+	result := new(MessageStore)
+	result.log = log.Named("kvstore")
+	result.metrics = newMetrics(reg)
+
+	for _, opt := range options {
+		err := opt(result)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	var err error
+	if path == InMemoryPath {
+		result.db, err = goleveldb.Open(storage.NewMemStorage(), nil)
+	} else {
+		result.db, err = goleveldb.OpenFile(path, nil)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	count, err := result.countMessages()
+	if err != nil {
+		result.db.Close()
+		return nil, err
+	}
+	result.count.Store(count)
+
+	return result, nil
+}
+
+func (s *MessageStore) countMessages() (int64, error) {
+	iter := s.db.NewIterator(util.BytesPrefix([]byte{messagePrefix}), nil)
+	defer iter.Release()
+
+	var count int64
+	for iter.Next() {
+		count++
+	}
+
+	return count, iter.Error()
+}
+
+// Start starts the retention policy and metrics tasks
+func (s *MessageStore) Start(ctx context.Context, timesource timesource.Timesource) error {
+	ctx, cancel := context.WithCancel(ctx)
+
+	s.cancel = cancel
+	s.timesource = timesource
+
+	err := s.cleanOlderRecords()
+	if err != nil {
+		return err
+	}
+
+	s.wg.Add(2)
+	go s.checkForOlderRecords(ctx, 60*time.Second)
+	go s.updateMetrics(ctx)
+
+	return nil
+}
+
+// Stop closes the message store
+func (s *MessageStore) Stop() {
+	if s.cancel != nil {
+		s.cancel()
+		s.wg.Wait()
+	}
+
+	s.db.Close()
+}
+
+func (s *MessageStore) updateMetrics(ctx context.Context) {
+	defer utils.LogOnPanic()
+	ticker := time.NewTicker(5 * time.Second)
+	defer ticker.Stop()
+	defer s.wg.Done()
+
+	for {
+		select {
+		case <-ticker.C:
+			s.metrics.RecordMessages(int(s.count.Load()))
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+func (s *MessageStore) checkForOlderRecords(ctx context.Context, t time.Duration) {
+	defer utils.LogOnPanic()
+	defer s.wg.Done()
+
+	ticker := time.NewTicker(t)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			err := s.cleanOlderRecords()
+			if err != nil {
+				s.log.Error("cleaning older records", zap.Error(err))
+			}
+		}
+	}
+}
+
+func (s *MessageStore) cleanOlderRecords() error {
+	s.log.Info("Cleaning older records...")
+
+	// Delete older messages
+	if s.maxDuration > 0 {
+		start := time.Now()
+		cutoff := s.timesource.Now().Add(-s.maxDuration).UnixNano()
+		rng := &util.Range{
+			Start: []byte{timeIndexPrefix},
+			Limit: concat([]byte{timeIndexPrefix}, encodeTimestamp(cutoff)),
+		}
+		deleted, err := s.deleteOldest(rng, -1, nil)
+		if err != nil {
+			s.metrics.RecordError(retPolicyFailure)
+			return err
+		}
+		s.log.Debug("deleting older records from the DB", zap.Int64("deleted", deleted), zap.Duration("duration", time.Since(start)))
+	}
+
+	// Limit number of records to a max N
+	if s.maxMessages > 0 {
+		start := time.Now()
+		excess := s.count.Load() - int64(s.maxMessages)
+		if excess > 0 {
+			deleted, err := s.deleteOldest(util.BytesPrefix([]byte{timeIndexPrefix}), excess, nil)
+			if err != nil {
+				s.metrics.RecordError(retPolicyFailure)
+				return err
+			}
+			s.log.Debug("deleting excess records from the DB", zap.Int64("deleted", deleted), zap.Duration("duration", time.Since(start)))
+		}
+	}
+
+	s.log.Info("Older records removed")
+
+	return nil
+}
+
+// deleteOldest removes up to max messages (or all of them if max is negative) whose time
+// index key is within a range and that are accepted by the filter, starting from the oldest one
+func (s *MessageStore) deleteOldest(rng *util.Range, max int64, filter func(record) bool) (int64, error) {
+	s.writeMu.Lock()
+	defer s.writeMu.Unlock()
+
+	iter := s.db.NewIterator(rng, nil)
+	defer iter.Release()
+
+	var deleted int64
+	var pending int64
+	batch := new(goleveldb.Batch)
+	flush := func() error {
+		if pending == 0 {
+			return nil
+		}
+		err := s.db.Write(batch, nil)
+		if err != nil {
+			return err
+		}
+		s.count.Add(-pending)
+		deleted += pending
+		pending = 0
+		batch.Reset()
+		return nil
+	}
+
+	for (max < 0 || deleted+pending < max) && iter.Next() {
+		r, err := s.get(hashFromIndexKey(iter.Key()))
+		if err != nil {
+			return deleted, err
+		}
+
+		if filter != nil && !filter(r) {
+			continue
+		}
+
+		deleteRecord(batch, r)
+		pending++
+
+		if pending == deleteBatchSize {
+			err := flush()
+			if err != nil {
+				return deleted, err
+			}
+		}
+	}
+
+	err := iter.Error()
+	if err != nil {
+		return deleted, err
+	}
+
+	err = flush()
+	return deleted, err
+}
+
+func deleteRecord(batch *goleveldb.Batch, r record) {
+	batch.Delete(messageKey(r.hash))
+	batch.Delete(timeIndexKey(r.storedAt, r.hash))
+	batch.Delete(topicIndexKey(r.pubsubTopic, r.msg.ContentTopic, r.storedAt, r.hash))
+	batch.Delete(contentTopicIndexKey(r.msg.ContentTopic, r.storedAt, r.hash))
+}
+
+func (s *MessageStore) get(hash []byte) (record, error) {
+	value, err := s.db.Get(messageKey(hash), nil)
+	if err != nil {
+		return record{}, err
+	}
+	return decodeRecord(hash, value)
+}
+
+// Validate validates the message to be stored against possible fradulent conditions.
+func (s *MessageStore) Validate(env *protocol.Envelope) error {
+	return persistence.ValidateTimestamp(env)
+}
+
+// Put stores a WakuMessage and its indexes
+func (s *MessageStore) Put(env *protocol.Envelope) error {
+	storedAt := env.Message().GetTimestamp()
+	if storedAt == 0 {
+		storedAt = env.Index().ReceiverTime
+	}
+
+	hash := env.Hash()
+	r := record{
+		storedAt:    storedAt,
+		id:          env.Index().Digest,
+		hash:        hash[:],
+		pubsubTopic: env.PubsubTopic(),
+		msg:         env.Message(),
+	}
+
+	value, err := r.encode()
+	if err != nil {
+		s.metrics.RecordError(insertFailure)
+		return err
+	}
+
+	batch := new(goleveldb.Batch)
+	batch.Put(messageKey(r.hash), value)
+	batch.Put(timeIndexKey(r.storedAt, r.hash), []byte(r.pubsubTopic))
+	batch.Put(topicIndexKey(r.pubsubTopic, r.msg.ContentTopic, r.storedAt, r.hash), nil)
+	batch.Put(contentTopicIndexKey(r.msg.ContentTopic, r.storedAt, r.hash), nil)
+
+	s.writeMu.Lock()
+	defer s.writeMu.Unlock()
+
+	exists, err := s.db.Has(messageKey(r.hash), nil)
+	if err != nil {
+		s.metrics.RecordError(insertFailure)
+		return err
+	}
+	if exists {
+		return ErrDuplicatedMessage
+	}
+
+	start := time.Now()
+	err = s.db.Write(batch, nil)
+	if err != nil {
+		s.metrics.RecordError(insertFailure)
+		return err
+	}
+
+	s.metrics.RecordInsertDuration(time.Since(start))
+	s.count.Add(1)
+
+	return nil
+}
+
+// queryBounds returns the range of sort keys, lower inclusive and upper exclusive, matching the
+// cursor and time range of a query. A nil bound means the range is unbounded on that side
+func (s *MessageStore) queryBounds(query *pb.HistoryQuery) ([]byte, []byte, error) {
+	var lower, upper []byte
+
+	usesCursor := false
+	if query.PagingInfo.Cursor != nil {
+		usesCursor = true
+
+		cursorKey, err := s.legacyCursorKey(query.PagingInfo.Cursor)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		if query.PagingInfo.Direction == pb.PagingInfo_BACKWARD {
+			upper = cursorKey
+		} else {
+			lower = util.BytesPrefix(cursorKey).Limit
+		}
+	}
+
+	startTime := query.GetStartTime()
+	if startTime != 0 {
+		if !usesCursor || query.PagingInfo.Direction == pb.PagingInfo_BACKWARD {
+			lower = encodeTimestamp(startTime)
+		}
+	}
+
+	endTime := query.GetEndTime()
+	if endTime != 0 {
+		if !usesCursor || query.PagingInfo.Direction == pb.PagingInfo_FORWARD {
+			upper = encodeTimestamp(endTime + 1)
+		}
+	}
+
+	return lower, upper, nil
+}
+
+// legacyCursorKey returns the sort key of the message referenced by a legacy cursor, which
+// identifies the message by its storedAt and id
+func (s *MessageStore) legacyCursorKey(cursor *pb.Index) ([]byte, error) {
+	iter := s.db.NewIterator(util.BytesPrefix(concat([]byte{timeIndexPrefix}, encodeTimestamp(cursor.ReceiverTime))), nil)
+	defer iter.Release()
+
+	for iter.Next() {
+		r, err := s.get(hashFromIndexKey(iter.Key()))
+		if err != nil {
+			return nil, err
+		}
+
+		if bytes.Equal(r.id, cursor.Digest) {
+			return r.sortKey(), nil
+		}
+	}
+
+	err := iter.Error()
+	if err != nil {
+		return nil, err
+	}
+
+	return nil, persistence.ErrInvalidCursor
+}
+
+// scan returns up to limit records from the index keys starting with prefix whose sort key
+// is within the bounds and that are accepted by the filter
+func (s *MessageStore) scan(prefix []byte, lower []byte, upper []byte, forward bool, limit int, filter func(record) bool) ([]record, error) {
+	rng := util.BytesPrefix(prefix)
+	if lower != nil {
+		rng.Start = concat(prefix, lower)
+	}
+	if upper != nil {
+		rng.Limit = concat(prefix, upper)
+	}
+
+	iter := s.db.NewIterator(rng, nil)
+	defer iter.Release()
+
+	next := iter.Next
+	ok := iter.First()
+	if !forward {
+		next = iter.Prev
+		ok = iter.Last()
+	}
+
+	var result []record
+	for ; ok && len(result) < limit; ok = next() {
+		r, err := s.get(hashFromIndexKey(iter.Key()))
+		if err != nil {
+			return nil, err
+		}
+
+		if filter != nil && !filter(r) {
+			continue
+		}
+
+		result = append(result, r)
+	}
+
+	return result, iter.Error()
+}
+
+// queryIndexes returns up to limit records of a pubsub topic and content topics whose sort
+// key is within the bounds, using the most selective index available. An empty pubsub topic
+// or list of content topics matches any topic
+func (s *MessageStore) queryIndexes(pubsubTopic string, contentTopics []string, lower []byte, upper []byte, forward bool, limit int) ([]record, error) {
+	if len(contentTopics) == 0 {
+		var filter func(record) bool
+		if pubsubTopic != "" {
+			filter = func(r record) bool {
+				return r.pubsubTopic == pubsubTopic
+			}
+		}
+		return s.scan([]byte{timeIndexPrefix}, lower, upper, forward, limit, filter)
+	}
+
+	// Each content topic index is scanned separately and the results merged
+	var records []record
+	seen := make(map[string]struct{})
+	for _, ct := range contentTopics {
+		if _, ok := seen[ct]; ok {
+			continue
+		}
+		seen[ct] = struct{}{}
+
+		prefix := contentTopicPrefix(ct)
+		if pubsubTopic != "" {
+			prefix = topicPrefix(pubsubTopic, ct)
+		}
+
+		result, err := s.scan(prefix, lower, upper, forward, limit, nil)
+		if err != nil {
+			return nil, err
+		}
+		records = append(records, result...)
+	}
+
+	sortRecords(records, forward)
+
+	if len(records) > limit {
+		records = records[:limit]
+	}
+
+	return records, nil
+}
+
+func sortRecords(records []record, forward bool) {
+	sort.Slice(records, func(i, j int) bool {
+		cmp := bytes.Compare(records[i].sortKey(), records[j].sortKey())
+		if forward {
+			return cmp < 0
+		}
+		return cmp > 0
+	})
+}
+
+// Query retrieves messages from the store. Messages are ordered by (storedAt, messageHash), so
+// messages stored at the same time may be returned in a different order than the SQL message providers
+func (s *MessageStore) Query(query *pb.HistoryQuery) (*pb.Index, []persistence.StoredMessage, error) {
+	start := time.Now()
+	defer func() {
+		elapsed := time.Since(start)
+		s.log.Info("loading records from the DB", zap.Duration("duration", elapsed))
+	}()
+
+	lower, upper, err := s.queryBounds(query)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var contentTopics []string
+	for _, cf := range query.ContentFilters {
+		if cf.ContentTopic != "" {
+			contentTopics = append(contentTopics, cf.ContentTopic)
+		}
+	}
+
+	if len(query.ContentFilters) != 0 && len(contentTopics) == 0 {
+		// No content topic can match an empty list of content topics
+		return nil, nil, nil
+	}
+
+	forward := query.PagingInfo.Direction != pb.PagingInfo_BACKWARD
+	// Always search for _max page size_ + 1. If the extra record does not exist, do not return pagination info.
+	limit := int(query.PagingInfo.PageSize) + 1
+
+	measurementStart := time.Now()
+
+	records, err := s.queryIndexes(query.PubsubTopic, contentTopics, lower, upper, forward, limit)
+	if err != nil {
+		s.metrics.RecordError(queryFailure)
+		return nil, nil, err
+	}
+
+	s.metrics.RecordQueryDuration(time.Since(measurementStart))
+
+	result := make([]persistence.StoredMessage, len(records))
+	for i, r := range records {
+		result[i] = r.storedMessage()
+	}
+
+	var cursor *pb.Index
+	// since there are more records than pagingInfo.PageSize, we need to return a cursor, for pagination
+	if len(result) > int(query.PagingInfo.PageSize) {
+		result = result[0:query.PagingInfo.PageSize]
+		lastMsgIdx := len(result) - 1
+		cursor = protocol.NewEnvelope(result[lastMsgIdx].Message, result[lastMsgIdx].ReceiverTime, result[lastMsgIdx].PubsubTopic).Index()
+	}
+
+	// The retrieved messages list should always be in chronological order
+	if !forward {
+		for i, j := 0, len(result)-1; i < j; i, j = i+1, j-1 {
+			result[i], result[j] = result[j], result[i]
+		}
+	}
+
+	return cursor, result, nil
+}
+
+// MostRecentTimestamp returns an unix timestamp with the most recent storedAt
+// of the messages in the store
+func (s *MessageStore) MostRecentTimestamp() (int64, error) {
+	iter := s.db.NewIterator(util.BytesPrefix([]byte{timeIndexPrefix}), nil)
+	defer iter.Release()
+
+	if !iter.Last() {
+		return 0, iter.Error()
+	}
+
+	return decodeTimestamp(iter.Key()[1:9]), nil
+}
+
+// MessageHashes returns the hashes of the messages stored within a time range (both ends
+// inclusive), optionally restricted to a pubsub topic, ordered by (storedAt, messageHash)
+func (s *MessageStore) MessageHashes(pubsubTopic string, start int64, end int64) ([]persistence.StoredMessageHash, error) {
+	rng := util.BytesPrefix([]byte{timeIndexPrefix})
+	rng.Start = concat([]byte{timeIndexPrefix}, encodeTimestamp(start))
+	if end != math.MaxInt64 {
+		rng.Limit = concat([]byte{timeIndexPrefix}, encodeTimestamp(end+1))
+	}
+
+	iter := s.db.NewIterator(rng, nil)
+	defer iter.Release()
+
+	var result []persistence.StoredMessageHash
+	for iter.Next() {
+		// The time index stores the pubsub topic, so the messages don't need to be loaded
+		if pubsubTopic != "" && string(iter.Value()) != pubsubTopic {
+			continue
+		}
+
+		result = append(result, persistence.StoredMessageHash{
+			StoredAt: decodeTimestamp(iter.Key()[1:9]),
+			Hash:     wpb.ToMessageHash(hashFromIndexKey(iter.Key())),
+		})
+	}
+
+	return result, iter.Error()
+}
+
+// Count returns the number of messages in the store
+func (s *MessageStore) Count() (int, error) {
+	return int(s.count.Load()), nil
+}
+
+// GetAll returns all the stored WakuMessages
+func (s *MessageStore) GetAll() ([]persistence.StoredMessage, error) {
+	start := time.Now()
+	defer func() {
+		elapsed := time.Since(start)
+		s.log.Info("loading records from the DB", zap.Duration("duration", elapsed))
+	}()
+
+	iter := s.db.NewIterator(util.BytesPrefix([]byte{timeIndexPrefix}), nil)
+	defer iter.Release()
+
+	var result []persistence.StoredMessage
+	for iter.Next() {
+		r, err := s.get(hashFromIndexKey(iter.Key()))
+		if err != nil {
+			return nil, fmt.Errorf("could not load message: %w", err)
+		}
+		result = append(result, r.storedMessage())
+	}
+
+	err := iter.Error()
+	if err != nil {
+		return nil, err
+	}
+
+	s.log.Info("DB returned records", zap.Int("count", len(result)))
+
+	return result, nil
+}
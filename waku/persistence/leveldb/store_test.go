@@ -0,0 +1,295 @@
+package leveldb
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/stretchr/testify/require"
+	"github.com/waku-org/go-waku/tests"
+	"github.com/waku-org/go-waku/waku/persistence"
+	"github.com/waku-org/go-waku/waku/v2/protocol"
+	"github.com/waku-org/go-waku/waku/v2/protocol/legacy_store/pb"
+	wpb "github.com/waku-org/go-waku/waku/v2/protocol/pb"
+	storepb "github.com/waku-org/go-waku/waku/v2/protocol/store/pb"
+	"github.com/waku-org/go-waku/waku/v2/timesource"
+	"github.com/waku-org/go-waku/waku/v2/utils"
+	"google.golang.org/protobuf/proto"
+)
+
+func TestParseURL(t *testing.T) {
+	path, ok := ParseURL("leveldb:///var/lib/waku/store")
+	require.True(t, ok)
+	require.Equal(t, "/var/lib/waku/store", path)
+
+	path, ok = ParseURL("leveldb://:memory:")
+	require.True(t, ok)
+	require.Equal(t, InMemoryPath, path)
+
+	_, ok = ParseURL("sqlite3://store.db")
+	require.False(t, ok)
+}
+
+func TestMessageStoreQuery(t *testing.T) {
+	store, err := NewMessageStore(InMemoryPath, prometheus.DefaultRegisterer, utils.Logger())
+	require.NoError(t, err)
+	defer store.Stop()
+
+	pubsubTopic := "/waku/2/rs/99/1"
+	now := time.Now()
+	var messages []*wpb.WakuMessage
+	for i := 0; i < 6; i++ {
+		contentTopic := "test1"
+		if i%2 == 1 {
+			contentTopic = "test2"
+		}
+		msg := tests.CreateWakuMessage(contentTopic, proto.Int64(now.Add(time.Duration(i)*time.Second).UnixNano()))
+		require.NoError(t, store.Put(protocol.NewEnvelope(msg, msg.GetTimestamp(), pubsubTopic)))
+		messages = append(messages, msg)
+	}
+	otherMsg := tests.CreateWakuMessage("test1", proto.Int64(now.UnixNano()))
+	require.NoError(t, store.Put(protocol.NewEnvelope(otherMsg, otherMsg.GetTimestamp(), "/waku/2/rs/99/2")))
+
+	// Duplicated messages are rejected
+	require.ErrorIs(t, store.Put(protocol.NewEnvelope(messages[0], messages[0].GetTimestamp(), pubsubTopic)), ErrDuplicatedMessage)
+
+	count, err := store.Count()
+	require.NoError(t, err)
+	require.Equal(t, 7, count)
+
+	mostRecent, err := store.MostRecentTimestamp()
+	require.NoError(t, err)
+	require.Equal(t, messages[5].GetTimestamp(), mostRecent)
+
+	// Forward pagination over two content topics
+	query := &pb.HistoryQuery{
+		PubsubTopic:    pubsubTopic,
+		ContentFilters: []*pb.ContentFilter{{ContentTopic: "test1"}, {ContentTopic: "test2"}},
+		PagingInfo:     &pb.PagingInfo{PageSize: 4, Direction: pb.PagingInfo_FORWARD},
+	}
+	cursor, result, err := store.Query(query)
+	require.NoError(t, err)
+	require.NotNil(t, cursor)
+	require.Len(t, result, 4)
+	for i := range result {
+		require.Equal(t, messages[i].Hash(pubsubTopic).Bytes(), result[i].MessageHash)
+	}
+
+	query.PagingInfo.Cursor = cursor
+	cursor, result, err = store.Query(query)
+	require.NoError(t, err)
+	require.Nil(t, cursor)
+	require.Len(t, result, 2)
+	require.Equal(t, messages[4].Hash(pubsubTopic).Bytes(), result[0].MessageHash)
+	require.Equal(t, messages[5].Hash(pubsubTopic).Bytes(), result[1].MessageHash)
+
+	// Backward pagination returns the messages in chronological order
+	query = &pb.HistoryQuery{
+		ContentFilters: []*pb.ContentFilter{{ContentTopic: "test1"}},
+		PagingInfo:     &pb.PagingInfo{PageSize: 2, Direction: pb.PagingInfo_BACKWARD},
+	}
+	cursor, result, err = store.Query(query)
+	require.NoError(t, err)
+	require.NotNil(t, cursor)
+	require.Len(t, result, 2)
+	require.Equal(t, messages[2].Hash(pubsubTopic).Bytes(), result[0].MessageHash)
+	require.Equal(t, messages[4].Hash(pubsubTopic).Bytes(), result[1].MessageHash)
+
+	query.PagingInfo.Cursor = cursor
+	cursor, result, err = store.Query(query)
+	require.NoError(t, err)
+	require.Nil(t, cursor)
+	require.Len(t, result, 2)
+	require.Equal(t, messages[0].GetTimestamp(), result[0].Message.GetTimestamp())
+
+	// Time range, both ends inclusive
+	query = &pb.HistoryQuery{
+		PubsubTopic: pubsubTopic,
+		StartTime:   messages[1].Timestamp,
+		EndTime:     messages[3].Timestamp,
+		PagingInfo:  &pb.PagingInfo{PageSize: 10, Direction: pb.PagingInfo_FORWARD},
+	}
+	_, result, err = store.Query(query)
+	require.NoError(t, err)
+	require.Len(t, result, 3)
+
+	// Inexistent cursors should fail
+	query.PagingInfo.Cursor = &pb.Index{Digest: make([]byte, 32), ReceiverTime: now.UnixNano()}
+	_, _, err = store.Query(query)
+	require.ErrorIs(t, err, persistence.ErrInvalidCursor)
+}
+
+func TestMessageStoreRetention(t *testing.T) {
+	store, err := NewMessageStore(InMemoryPath, prometheus.DefaultRegisterer, utils.Logger(), WithRetentionPolicy(5, 20*time.Second))
+	require.NoError(t, err)
+
+	insertTime := time.Now()
+	for i := 0; i < 10; i++ {
+		msg := tests.CreateWakuMessage("test", proto.Int64(insertTime.Add(time.Duration(i)*time.Second).UnixNano()))
+		require.NoError(t, store.Put(protocol.NewEnvelope(msg, msg.GetTimestamp(), "test")))
+	}
+	for i := 0; i < 3; i++ {
+		msg := tests.CreateWakuMessage("test", proto.Int64(insertTime.Add(-time.Duration(i+1)*time.Minute).UnixNano()))
+		require.NoError(t, store.Put(protocol.NewEnvelope(msg, msg.GetTimestamp(), "test")))
+	}
+
+	require.NoError(t, store.Start(context.Background(), timesource.NewDefaultClock()))
+	defer store.Stop()
+
+	count, err := store.Count()
+	require.NoError(t, err)
+	require.Equal(t, 5, count)
+
+	result, err := store.GetAll()
+	require.NoError(t, err)
+	require.Len(t, result, 5)
+	require.Equal(t, insertTime.Add(5*time.Second).UnixNano(), result[0].ReceiverTime)
+
+	// The indexes of the removed messages are removed too
+	_, queryResult, err := store.Query(&pb.HistoryQuery{
+		PubsubTopic:    "test",
+		ContentFilters: []*pb.ContentFilter{{ContentTopic: "test"}},
+		PagingInfo:     &pb.PagingInfo{PageSize: 20, Direction: pb.PagingInfo_FORWARD},
+	})
+	require.NoError(t, err)
+	require.Len(t, queryResult, 5)
+}
+
+func TestMessageStoreStoreQuery(t *testing.T) {
+	store, err := NewMessageStore(InMemoryPath, prometheus.DefaultRegisterer, utils.Logger())
+	require.NoError(t, err)
+	defer store.Stop()
+
+	pubsubTopic := "/waku/2/rs/99/1"
+	now := time.Now()
+	var hashes [][]byte
+	for i := 0; i < 5; i++ {
+		msg := tests.CreateWakuMessage("test1", proto.Int64(now.Add(time.Duration(i)*time.Second).UnixNano()))
+		require.NoError(t, store.Put(protocol.NewEnvelope(msg, msg.GetTimestamp(), pubsubTopic)))
+		hashes = append(hashes, msg.Hash(pubsubTopic).Bytes())
+	}
+	otherMsg := tests.CreateWakuMessage("test1", proto.Int64(now.UnixNano()))
+	require.NoError(t, store.Put(protocol.NewEnvelope(otherMsg, otherMsg.GetTimestamp(), "/waku/2/rs/99/2")))
+
+	// Content topic only, using the content topic index
+	query := &storepb.StoreQueryRequest{
+		ContentTopics:     []string{"test1"},
+		PaginationForward: true,
+		PaginationLimit:   proto.Uint64(4),
+	}
+	cursor, result, err := store.StoreQuery(query)
+	require.NoError(t, err)
+	require.Len(t, result, 4)
+	require.Equal(t, result[3].MessageHash, cursor)
+
+	query.PaginationCursor = cursor
+	cursor, result, err = store.StoreQuery(query)
+	require.NoError(t, err)
+	require.Nil(t, cursor)
+	require.Len(t, result, 2)
+	require.Equal(t, hashes[4], result[1].MessageHash)
+
+	// Backward pagination returns the messages in chronological order
+	query = &storepb.StoreQueryRequest{
+		PubsubTopic:     proto.String(pubsubTopic),
+		ContentTopics:   []string{"test1"},
+		PaginationLimit: proto.Uint64(2),
+	}
+	cursor, result, err = store.StoreQuery(query)
+	require.NoError(t, err)
+	require.Len(t, result, 2)
+	require.Equal(t, hashes[3], result[0].MessageHash)
+	require.Equal(t, hashes[4], result[1].MessageHash)
+
+	// The cursor is combined with the time range
+	query.PaginationCursor = cursor
+	query.TimeStart = proto.Int64(now.Add(time.Second).UnixNano())
+	cursor, result, err = store.StoreQuery(query)
+	require.NoError(t, err)
+	require.Nil(t, cursor)
+	require.Len(t, result, 2)
+	require.Equal(t, hashes[1], result[0].MessageHash)
+
+	// Lookup by hash
+	_, result, err = store.StoreQuery(&storepb.StoreQueryRequest{
+		MessageHashes:     [][]byte{hashes[2], hashes[0], make([]byte, 32)},
+		PaginationForward: true,
+		PaginationLimit:   proto.Uint64(10),
+	})
+	require.NoError(t, err)
+	require.Len(t, result, 2)
+	require.Equal(t, hashes[0], result[0].MessageHash)
+	require.Equal(t, hashes[2], result[1].MessageHash)
+
+	// Inexistent cursors should fail
+	_, _, err = store.StoreQuery(&storepb.StoreQueryRequest{PaginationCursor: make([]byte, 32), PaginationLimit: proto.Uint64(10)})
+	require.ErrorIs(t, err, persistence.ErrInvalidCursor)
+
+	// Message hashes are returned in order, both ends inclusive
+	messageHashes, err := store.MessageHashes(pubsubTopic, now.Add(time.Second).UnixNano(), now.Add(3*time.Second).UnixNano())
+	require.NoError(t, err)
+	require.Len(t, messageHashes, 3)
+	for i, h := range messageHashes {
+		require.Equal(t, hashes[i+1], h.Hash.Bytes())
+		require.Equal(t, now.Add(time.Duration(i+1)*time.Second).UnixNano(), h.StoredAt)
+	}
+
+	messageHashes, err = store.MessageHashes("", now.UnixNano(), now.UnixNano())
+	require.NoError(t, err)
+	require.Len(t, messageHashes, 2)
+}
+
+func TestMessageStoreDeleteMessages(t *testing.T) {
+	store, err := NewMessageStore(InMemoryPath, prometheus.DefaultRegisterer, utils.Logger())
+	require.NoError(t, err)
+	defer store.Stop()
+
+	pubsubTopic := "/waku/2/rs/99/1"
+	now := time.Now()
+	var messages []*wpb.WakuMessage
+	for i := 0; i < 6; i++ {
+		contentTopic := "test1"
+		if i%2 == 1 {
+			contentTopic = "test2"
+		}
+		msg := tests.CreateWakuMessage(contentTopic, proto.Int64(now.Add(time.Duration(i)*time.Second).UnixNano()))
+		require.NoError(t, store.Put(protocol.NewEnvelope(msg, msg.GetTimestamp(), pubsubTopic)))
+		messages = append(messages, msg)
+	}
+
+	_, err = store.DeleteMessages(persistence.DeleteCriteria{})
+	require.ErrorIs(t, err, persistence.ErrEmptyDeleteCriteria)
+
+	// Hashes are combined with the other criteria
+	deleted, err := store.DeleteMessages(persistence.DeleteCriteria{
+		MessageHashes: []wpb.MessageHash{messages[0].Hash(pubsubTopic), messages[1].Hash(pubsubTopic)},
+		ContentTopics: []string{"test1"},
+	})
+	require.NoError(t, err)
+	require.Equal(t, int64(1), deleted)
+
+	deleted, err = store.DeleteMessages(persistence.DeleteCriteria{
+		ContentTopics: []string{"test2"},
+		StartTime:     proto.Int64(now.Add(time.Second).UnixNano()),
+		EndTime:       proto.Int64(now.Add(3 * time.Second).UnixNano()),
+	})
+	require.NoError(t, err)
+	require.Equal(t, int64(2), deleted)
+
+	count, err := store.Count()
+	require.NoError(t, err)
+	require.Equal(t, 3, count)
+
+	// The indexes of the removed messages are removed too
+	_, result, err := store.StoreQuery(&storepb.StoreQueryRequest{
+		ContentTopics:     []string{"test1", "test2"},
+		PaginationForward: true,
+		PaginationLimit:   proto.Uint64(10),
+	})
+	require.NoError(t, err)
+	require.Len(t, result, 3)
+	require.Equal(t, messages[2].Hash(pubsubTopic).Bytes(), result[0].MessageHash)
+	require.Equal(t, messages[4].Hash(pubsubTopic).Bytes(), result[1].MessageHash)
+	require.Equal(t, messages[5].Hash(pubsubTopic).Bytes(), result[2].MessageHash)
+}
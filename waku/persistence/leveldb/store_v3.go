@@ -0,0 +1,145 @@
+package leveldb
+
+import (
+	"bytes"
+	"errors"
+	"time"
+
+	goleveldb "github.com/syndtr/goleveldb/leveldb"
+	"github.com/syndtr/goleveldb/leveldb/util"
+	"github.com/waku-org/go-waku/waku/persistence"
+	storepb "github.com/waku-org/go-waku/waku/v2/protocol/store/pb"
+	"go.uber.org/zap"
+)
+
+// storeQueryBounds returns the range of sort keys, lower inclusive and upper exclusive, matching
+// the cursor and time range of a store v3 query. A nil bound means the range is unbounded on that
+// side. Like in the SQL message providers, the time range does not apply to the queries by hash
+func (s *MessageStore) storeQueryBounds(query *storepb.StoreQueryRequest) ([]byte, []byte, error) {
+	var lower, upper []byte
+
+	if len(query.MessageHashes) == 0 {
+		if query.GetTimeStart() != 0 {
+			lower = encodeTimestamp(query.GetTimeStart())
+		}
+
+		if query.GetTimeEnd() != 0 {
+			upper = encodeTimestamp(query.GetTimeEnd() + 1)
+		}
+	}
+
+	if len(query.PaginationCursor) != 0 {
+		r, err := s.get(query.PaginationCursor)
+		if err != nil {
+			if errors.Is(err, goleveldb.ErrNotFound) {
+				return nil, nil, persistence.ErrInvalidCursor
+			}
+			return nil, nil, err
+		}
+
+		cursorKey := r.sortKey()
+		if query.PaginationForward {
+			cursorLimit := util.BytesPrefix(cursorKey).Limit
+			if lower == nil || bytes.Compare(cursorLimit, lower) > 0 {
+				lower = cursorLimit
+			}
+		} else if upper == nil || bytes.Compare(cursorKey, upper) < 0 {
+			upper = cursorKey
+		}
+	}
+
+	return lower, upper, nil
+}
+
+// storeQueryByHash returns up to limit records among the messages with the hashes of a
+// store v3 query, that are after the cursor in the direction of the query
+func (s *MessageStore) storeQueryByHash(query *storepb.StoreQueryRequest, lower []byte, upper []byte, limit int) ([]record, error) {
+	var records []record
+	seen := make(map[string]struct{})
+	for _, hash := range query.MessageHashes {
+		if _, ok := seen[string(hash)]; ok {
+			continue
+		}
+		seen[string(hash)] = struct{}{}
+
+		r, err := s.get(hash)
+		if err != nil {
+			if errors.Is(err, goleveldb.ErrNotFound) {
+				continue
+			}
+			return nil, err
+		}
+
+		key := r.sortKey()
+		if (lower != nil && bytes.Compare(key, lower) < 0) || (upper != nil && bytes.Compare(key, upper) >= 0) {
+			continue
+		}
+
+		records = append(records, r)
+	}
+
+	sortRecords(records, query.PaginationForward)
+
+	if len(records) > limit {
+		records = records[:limit]
+	}
+
+	return records, nil
+}
+
+// StoreQuery retrieves the messages matching a store v3 query from the store. The
+// returned cursor is the hash of the last message of the page, and it's nil when
+// there are no more pages to retrieve. Messages are always returned in chronological order
+func (s *MessageStore) StoreQuery(query *storepb.StoreQueryRequest) ([]byte, []persistence.StoredMessage, error) {
+	start := time.Now()
+	defer func() {
+		elapsed := time.Since(start)
+		s.log.Info("loading records from the DB", zap.Duration("duration", elapsed))
+	}()
+
+	// Always search for _max page size_ + 1. If the extra record does not exist, do not return a cursor.
+	limit := int(query.GetPaginationLimit()) + 1
+
+	lower, upper, err := s.storeQueryBounds(query)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	measurementStart := time.Now()
+
+	var records []record
+	if len(query.MessageHashes) != 0 {
+		records, err = s.storeQueryByHash(query, lower, upper, limit)
+	} else {
+		records, err = s.queryIndexes(query.GetPubsubTopic(), query.ContentTopics, lower, upper, query.PaginationForward, limit)
+	}
+	if err != nil {
+		s.metrics.RecordError(queryFailure)
+		return nil, nil, err
+	}
+
+	s.metrics.RecordQueryDuration(time.Since(measurementStart))
+
+	result := make([]persistence.StoredMessage, len(records))
+	for i, r := range records {
+		result[i] = r.storedMessage()
+	}
+
+	var cursor []byte
+	if len(result) > int(query.GetPaginationLimit()) {
+		// since there are more records than the pagination limit, we need to return a cursor
+		result = result[0:query.GetPaginationLimit()]
+		if len(result) != 0 {
+			cursor = result[len(result)-1].MessageHash
+		}
+	}
+
+	// The retrieved messages list should always be in chronological order
+	if !query.PaginationForward {
+		for i, j := 0, len(result)-1; i < j; i, j = i+1, j-1 {
+			result[i], result[j] = result[j], result[i]
+		}
+	}
+
+	return cursor, result, nil
+}
@@ -13,6 +13,12 @@ var archiveMessages = prometheus.NewCounter(
 		Help: "The number of messages stored via archive protocol",
 	})
 
+var archiveStoredBytes = prometheus.NewGauge(
+	prometheus.GaugeOpts{
+		Name: "waku_archive_stored_bytes",
+		Help: "The approximate size in bytes of the payloads stored in the archive",
+	})
+
 var archiveErrors = prometheus.NewCounterVec(
 	prometheus.CounterOpts{
 		Name: "waku_archive_errors",
@@ -33,19 +39,79 @@ var archiveQueryDurationSeconds = prometheus.NewHistogram(
 		Help: "History query duration",
 	})
 
+var archiveWriteQueueLength = prometheus.NewGauge(
+	prometheus.GaugeOpts{
+		Name: "waku_archive_write_queue_length",
+		Help: "The number of messages waiting to be inserted in the archive",
+	})
+
+var archiveWriteQueueFull = prometheus.NewCounter(
+	prometheus.CounterOpts{
+		Name: "waku_archive_write_queue_full",
+		Help: "The number of times a message had to wait for room in the archive write queue",
+	})
+
+var archiveBatchInsertDurationSeconds = prometheus.NewHistogram(
+	prometheus.HistogramOpts{
+		Name: "waku_archive_batch_insert_duration_seconds",
+		Help: "Duration of the insertion of a batch of messages",
+	})
+
+var archiveBatchSize = prometheus.NewHistogram(
+	prometheus.HistogramOpts{
+		Name:    "waku_archive_batch_size",
+		Help:    "The number of messages inserted in each batch",
+		Buckets: prometheus.ExponentialBuckets(1, 2, 12),
+	})
+
+var archiveDuplicatedMessages = prometheus.NewCounter(
+	prometheus.CounterOpts{
+		Name: "waku_archive_duplicated_messages",
+		Help: "The number of queued messages that were not inserted because they were already stored",
+	})
+
+var archiveRetentionRuleDeletions = prometheus.NewCounterVec(
+	prometheus.CounterOpts{
+		Name: "waku_archive_retention_rule_deleted_messages",
+		Help: "The number of messages deleted by each retention rule",
+	},
+	[]string{"rule"},
+)
+
+var archiveDeletedMessages = prometheus.NewCounter(
+	prometheus.CounterOpts{
+		Name: "waku_archive_deleted_messages",
+		Help: "The number of messages explicitly deleted from the archive",
+	})
+
 var collectors = []prometheus.Collector{
 	archiveMessages,
+	archiveStoredBytes,
 	archiveErrors,
 	archiveInsertDurationSeconds,
 	archiveQueryDurationSeconds,
+	archiveRetentionRuleDeletions,
+	archiveWriteQueueLength,
+	archiveWriteQueueFull,
+	archiveBatchInsertDurationSeconds,
+	archiveBatchSize,
+	archiveDuplicatedMessages,
+	archiveDeletedMessages,
 }
 
 // Metrics exposes the functions required to update prometheus metrics for archive protocol
 type Metrics interface {
 	RecordMessage(num int)
+	RecordStoredBytes(num int64)
 	RecordError(err metricsErrCategory)
 	RecordInsertDuration(duration time.Duration)
 	RecordQueryDuration(duration time.Duration)
+	RecordRetentionRuleDeletions(rule string, num int64)
+	RecordWriteQueueLength(num int)
+	RecordWriteQueueFull()
+	RecordBatchInsert(size int, duration time.Duration)
+	RecordDuplicatedMessages(num int)
+	RecordDeletedMessages(num int64)
 }
 
 type metricsImpl struct {
@@ -64,11 +130,18 @@ func (m *metricsImpl) RecordMessage(num int) {
 	archiveMessages.Add(float64(num))
 }
 
+// RecordStoredBytes sets the gauge for the size of the payloads stored in the archive
+func (m *metricsImpl) RecordStoredBytes(num int64) {
+	archiveStoredBytes.Set(float64(num))
+}
+
 type metricsErrCategory string
 
 var (
 	retPolicyFailure metricsErrCategory = "retpolicy_failure"
 	insertFailure    metricsErrCategory = "retpolicy_failure"
+	partitionFailure metricsErrCategory = "partition_failure"
+	deleteFailure    metricsErrCategory = "delete_failure"
 )
 
 // RecordError increases the counter for different error types
@@ -85,3 +158,34 @@ func (m *metricsImpl) RecordInsertDuration(duration time.Duration) {
 func (m *metricsImpl) RecordQueryDuration(duration time.Duration) {
 	archiveQueryDurationSeconds.Observe(duration.Seconds())
 }
+
+// RecordRetentionRuleDeletions increases the counter of messages deleted by a retention rule
+func (m *metricsImpl) RecordRetentionRuleDeletions(rule string, num int64) {
+	archiveRetentionRuleDeletions.WithLabelValues(rule).Add(float64(num))
+}
+
+// RecordWriteQueueLength sets the gauge for the number of messages waiting to be inserted
+func (m *metricsImpl) RecordWriteQueueLength(num int) {
+	archiveWriteQueueLength.Set(float64(num))
+}
+
+// RecordWriteQueueFull increases the counter of messages that found the write queue full
+func (m *metricsImpl) RecordWriteQueueFull() {
+	archiveWriteQueueFull.Inc()
+}
+
+// RecordBatchInsert tracks the size and duration of the insertion of a batch of messages
+func (m *metricsImpl) RecordBatchInsert(size int, duration time.Duration) {
+	archiveBatchSize.Observe(float64(size))
+	archiveBatchInsertDurationSeconds.Observe(duration.Seconds())
+}
+
+// RecordDuplicatedMessages increases the counter of queued messages that were already stored
+func (m *metricsImpl) RecordDuplicatedMessages(num int) {
+	archiveDuplicatedMessages.Add(float64(num))
+}
+
+// RecordDeletedMessages increases the counter of messages explicitly deleted from the archive
+func (m *metricsImpl) RecordDeletedMessages(num int64) {
+	archiveDeletedMessages.Add(float64(num))
+}
@@ -0,0 +1,179 @@
+package persistence
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// ErrPartitionsNotSupported indicates that time partitioned tables were requested for a DB
+// engine that does not support them
+var ErrPartitionsNotSupported = errors.New("time partitioned message table is only supported by postgres")
+
+// defaultPartitionTable is the partition that stores the messages not covered by a time partition
+const defaultPartitionTable = "message_default"
+
+const partitionTablePrefix = "message_"
+
+// WithPartitions is a DBOption used to manage a message table partitioned by range on storedAt.
+// Partitions covering the given interval are created in advance for the current and the
+// next futurePartitions intervals, and the retention by time is done by dropping whole
+// partitions instead of deleting rows. The message table must have been partitioned
+// previously with postgres.PartitionedMigrations
+func WithPartitions(interval time.Duration, futurePartitions int) DBOption {
+	return func(d *DBStore) error {
+		if interval <= 0 {
+			return errors.New("partition interval must be greater than 0")
+		}
+		d.partitionInterval = interval
+		d.futurePartitions = futurePartitions
+		return nil
+	}
+}
+
+func partitionName(start time.Time, end time.Time) string {
+	return fmt.Sprintf("%s%d_%d", partitionTablePrefix, start.Unix(), end.Unix())
+}
+
+// parsePartitionName returns the time range covered by a partition, based on its name
+func parsePartitionName(name string) (time.Time, time.Time, bool) {
+	if !strings.HasPrefix(name, partitionTablePrefix) {
+		return time.Time{}, time.Time{}, false
+	}
+
+	parts := strings.Split(strings.TrimPrefix(name, partitionTablePrefix), "_")
+	if len(parts) != 2 {
+		return time.Time{}, time.Time{}, false
+	}
+
+	start, err := strconv.ParseInt(parts[0], 10, 64)
+	if err != nil {
+		return time.Time{}, time.Time{}, false
+	}
+
+	end, err := strconv.ParseInt(parts[1], 10, 64)
+	if err != nil {
+		return time.Time{}, time.Time{}, false
+	}
+
+	return time.Unix(start, 0), time.Unix(end, 0), true
+}
+
+// insertMessageQuery returns the statement used to insert a message. The primary key of a
+// partitioned table must include storedAt, so it does not prevent storing a message again
+// when it's received at a different time. In that case the message hash is checked instead
+func (d *DBStore) insertMessageQuery() string {
+	if d.partitionInterval == 0 {
+		return "INSERT INTO message (id, messageHash, storedAt, timestamp, contentTopic, pubsubTopic, payload, version) VALUES ($1, $2, $3, $4, $5, $6, $7, $8) ON CONFLICT DO NOTHING"
+	}
+
+	return `INSERT INTO message (id, messageHash, storedAt, timestamp, contentTopic, pubsubTopic, payload, version)
+	SELECT $1::BYTEA, $2::BYTEA, $3::BIGINT, $4::BIGINT, $5::BYTEA, $6::BYTEA, $7::BYTEA, $8::INTEGER
+	WHERE NOT EXISTS (SELECT 1 FROM message WHERE messageHash = $2)
+	ON CONFLICT DO NOTHING`
+}
+
+// partitions returns the names of the time partitions of the message table
+func (d *DBStore) partitions() ([]string, error) {
+	rows, err := d.db.Query(`SELECT c.relname
+	FROM pg_inherits i
+	JOIN pg_class c ON c.oid = i.inhrelid
+	JOIN pg_class p ON p.oid = i.inhparent
+	WHERE p.relname = 'message'`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var result []string
+	for rows.Next() {
+		var name string
+		err := rows.Scan(&name)
+		if err != nil {
+			return nil, err
+		}
+		if name != defaultPartitionTable {
+			result = append(result, name)
+		}
+	}
+
+	return result, rows.Err()
+}
+
+// createPartitions ensures the existence of the partitions for the current interval and the
+// following ones. A partition is not created if messages that would belong to it were already
+// stored in the default partition, since postgres would refuse to attach it
+func (d *DBStore) createPartitions() error {
+	var maxDefaultStoredAt sql.NullInt64
+	err := d.db.QueryRow(fmt.Sprintf("SELECT MAX(storedAt) FROM %s", defaultPartitionTable)).Scan(&maxDefaultStoredAt)
+	if err != nil {
+		return err
+	}
+
+	start := d.timesource.Now().Truncate(d.partitionInterval)
+	for i := 0; i <= d.futurePartitions; i++ {
+		end := start.Add(d.partitionInterval)
+
+		if maxDefaultStoredAt.Valid && maxDefaultStoredAt.Int64 >= start.UnixNano() {
+			start = end
+			continue
+		}
+
+		name := partitionName(start, end)
+		sqlStmt := fmt.Sprintf("CREATE TABLE IF NOT EXISTS %s PARTITION OF message FOR VALUES FROM (%d) TO (%d)", name, start.UnixNano(), end.UnixNano())
+		_, err := d.db.Exec(sqlStmt)
+		if err != nil {
+			return fmt.Errorf("could not create partition %s: %w", name, err)
+		}
+
+		start = end
+	}
+
+	return nil
+}
+
+// dropOlderPartitions removes the partitions whose messages are all older than the retention
+// time. Messages stored in the default partition are deleted individually
+func (d *DBStore) dropOlderPartitions(cutoff time.Time) error {
+	names, err := d.partitions()
+	if err != nil {
+		return err
+	}
+
+	for _, name := range names {
+		_, end, ok := parsePartitionName(name)
+		if !ok {
+			d.log.Warn("unexpected partition found", zap.String("partition", name))
+			continue
+		}
+
+		if end.After(cutoff) {
+			continue
+		}
+
+		var droppedBytes int64
+		if d.maxBytes > 0 {
+			err := d.db.QueryRow(fmt.Sprintf("SELECT COALESCE(SUM(LENGTH(payload)), 0) FROM %s", name)).Scan(&droppedBytes)
+			if err != nil {
+				return err
+			}
+		}
+
+		_, err := d.db.Exec(fmt.Sprintf("DROP TABLE %s", name))
+		if err != nil {
+			return fmt.Errorf("could not drop partition %s: %w", name, err)
+		}
+
+		d.storedBytes.Add(-droppedBytes)
+
+		d.log.Info("dropped partition", zap.String("partition", name))
+	}
+
+	_, _, err = d.deleteFrom(defaultPartitionTable, "storedAt < $1", cutoff.UnixNano())
+	return err
+}
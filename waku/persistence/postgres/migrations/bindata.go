@@ -10,6 +10,8 @@
 // 4_signed_peer_record.up.sql (178B)
 // 5_nwaku_schema.down.sql (891B)
 // 5_nwaku_schema.up.sql (838B)
+// 6_filter_subscriptions.down.sql (83B)
+// 6_filter_subscriptions.up.sql (294B)
 // doc.go (74B)
 
 package migrations
@@ -279,6 +281,46 @@ func _5_nwaku_schemaUpSql() (*asset, error) {
 	return a, nil
 }
 
+var __6_filter_subscriptionsDownSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x73\x09\xf2\x0f\x50\xf0\xf4\x73\x71\x8d\x50\xf0\x74\x53\x70\x8d\xf0\x0c\x0e\x09\x56\xc8\x8c\x4f\xcb\xcc\x29\x49\x2d\x8a\xcf\x49\x2c\x2e\x09\x4e\x4d\xcd\xb3\xe6\x72\x01\xa9\x0b\x71\x74\xf2\x71\x45\x52\x07\x55\x55\x5c\x9a\x54\x9c\x5c\x94\x59\x50\x92\x99\x9f\x57\x6c\xcd\x05\x00\xe2\x9a\x74\x3d\x53\x00\x00\x00")
+
+func _6_filter_subscriptionsDownSqlBytes() ([]byte, error) {
+	return bindataRead(
+		__6_filter_subscriptionsDownSql,
+		"6_filter_subscriptions.down.sql",
+	)
+}
+
+func _6_filter_subscriptionsDownSql() (*asset, error) {
+	bytes, err := _6_filter_subscriptionsDownSqlBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	info := bindataFileInfo{name: "6_filter_subscriptions.down.sql", size: 83, mode: os.FileMode(0664), modTime: time.Unix(1700000000, 0)}
+	a := &asset{bytes: bytes, info: info, digest: [32]uint8{0x8d, 0x64, 0x87, 0x8e, 0xc9, 0x5d, 0x41, 0xc, 0x38, 0x15, 0x53, 0xc4, 0x4e, 0x52, 0x1c, 0x29, 0xac, 0xef, 0x46, 0x25, 0x15, 0xc8, 0x26, 0xa9, 0xb5, 0xee, 0xfc, 0x5b, 0x67, 0xbc, 0xa6, 0xfd}}
+	return a, nil
+}
+
+var __6_filter_subscriptionsUpSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x75\x8f\xbb\x0e\x82\x30\x14\x86\x77\x9e\xe2\x8c\x90\x30\x1a\x17\xa7\x82\x55\x4f\xc4\x62\x4a\x35\x30\x11\xa8\x35\x69\x42\x0a\xa1\xe5\xfd\x45\x11\x23\x03\xf3\xf7\x5f\x63\x4e\x89\xa0\x20\x48\x94\x50\xc0\x03\xb0\x54\x00\xcd\x31\x13\x19\x3c\x75\xe3\x54\x5f\xda\xa1\xb6\xb2\xd7\x9d\xd3\xad\xb1\xe0\x7b\x00\x9d\x52\x3d\x3e\xe0\x4e\x78\x7c\x22\xdc\xdf\x6e\x82\x8f\x8d\xdd\x92\x24\x7c\xe3\xd1\x30\xd4\xa2\xed\xb4\x9c\x35\x0b\x2e\x5b\xe3\x94\x71\xeb\x82\xa6\xb2\x2e\x53\xca\x40\x84\x47\x64\x62\xc1\xae\x1c\x2f\x84\x17\x70\xa6\x05\xf8\xd3\x90\xf0\xbf\x31\x5c\xc4\x07\x5e\xb0\xf3\xe2\xe9\x22\xb2\x3d\xcd\x41\x97\xdf\x5b\xbf\x92\x94\xad\x3c\x9d\x15\x63\xc4\x0b\x53\xca\xb8\xbf\x26\x01\x00\x00")
+
+func _6_filter_subscriptionsUpSqlBytes() ([]byte, error) {
+	return bindataRead(
+		__6_filter_subscriptionsUpSql,
+		"6_filter_subscriptions.up.sql",
+	)
+}
+
+func _6_filter_subscriptionsUpSql() (*asset, error) {
+	bytes, err := _6_filter_subscriptionsUpSqlBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	info := bindataFileInfo{name: "6_filter_subscriptions.up.sql", size: 294, mode: os.FileMode(0664), modTime: time.Unix(1700000000, 0)}
+	a := &asset{bytes: bytes, info: info, digest: [32]uint8{0x26, 0x23, 0x24, 0xc7, 0x7c, 0xd, 0x1, 0x32, 0x54, 0xf1, 0x63, 0x6c, 0x10, 0xfa, 0x16, 0x38, 0xbe, 0x41, 0xfc, 0xa4, 0xc2, 0x22, 0xa5, 0xd2, 0x35, 0x70, 0xf4, 0x9, 0xdd, 0xff, 0x4d, 0x75}}
+	return a, nil
+}
+
 var _docGo = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x2c\xc9\xb1\x0d\xc4\x20\x0c\x05\xd0\x9e\x29\xfe\x02\xd8\xfd\x6d\xe3\x4b\xac\x2f\x44\x82\x09\x78\x7f\xa5\x49\xfd\xa6\x1d\xdd\xe8\xd8\xcf\x55\x8a\x2a\xe3\x47\x1f\xbe\x2c\x1d\x8c\xfa\x6f\xe3\xb4\x34\xd4\xd9\x89\xbb\x71\x59\xb6\x18\x1b\x35\x20\xa2\x9f\x0a\x03\xa2\xe5\x0d\x00\x00\xff\xff\x60\xcd\x06\xbe\x4a\x00\x00\x00")
 
 func docGoBytes() ([]byte, error) {
@@ -410,6 +452,10 @@ var _bindata = map[string]func() (*asset, error){
 
 	"5_nwaku_schema.up.sql": _5_nwaku_schemaUpSql,
 
+	"6_filter_subscriptions.down.sql": _6_filter_subscriptionsDownSql,
+
+	"6_filter_subscriptions.up.sql": _6_filter_subscriptionsUpSql,
+
 	"doc.go": docGo,
 }
 
@@ -454,17 +500,19 @@ type bintree struct {
 }
 
 var _bintree = &bintree{nil, map[string]*bintree{
-	"1_messages.down.sql":           &bintree{_1_messagesDownSql, map[string]*bintree{}},
-	"1_messages.up.sql":             &bintree{_1_messagesUpSql, map[string]*bintree{}},
-	"2_messages_index.down.sql":     &bintree{_2_messages_indexDownSql, map[string]*bintree{}},
-	"2_messages_index.up.sql":       &bintree{_2_messages_indexUpSql, map[string]*bintree{}},
-	"3_rendezvous.down.sql":         &bintree{_3_rendezvousDownSql, map[string]*bintree{}},
-	"3_rendezvous.up.sql":           &bintree{_3_rendezvousUpSql, map[string]*bintree{}},
-	"4_signed_peer_record.down.sql": &bintree{_4_signed_peer_recordDownSql, map[string]*bintree{}},
-	"4_signed_peer_record.up.sql":   &bintree{_4_signed_peer_recordUpSql, map[string]*bintree{}},
-	"5_nwaku_schema.down.sql":       &bintree{_5_nwaku_schemaDownSql, map[string]*bintree{}},
-	"5_nwaku_schema.up.sql":         &bintree{_5_nwaku_schemaUpSql, map[string]*bintree{}},
-	"doc.go":                        &bintree{docGo, map[string]*bintree{}},
+	"1_messages.down.sql":             &bintree{_1_messagesDownSql, map[string]*bintree{}},
+	"1_messages.up.sql":               &bintree{_1_messagesUpSql, map[string]*bintree{}},
+	"2_messages_index.down.sql":       &bintree{_2_messages_indexDownSql, map[string]*bintree{}},
+	"2_messages_index.up.sql":         &bintree{_2_messages_indexUpSql, map[string]*bintree{}},
+	"3_rendezvous.down.sql":           &bintree{_3_rendezvousDownSql, map[string]*bintree{}},
+	"3_rendezvous.up.sql":             &bintree{_3_rendezvousUpSql, map[string]*bintree{}},
+	"4_signed_peer_record.down.sql":   &bintree{_4_signed_peer_recordDownSql, map[string]*bintree{}},
+	"4_signed_peer_record.up.sql":     &bintree{_4_signed_peer_recordUpSql, map[string]*bintree{}},
+	"5_nwaku_schema.down.sql":         &bintree{_5_nwaku_schemaDownSql, map[string]*bintree{}},
+	"5_nwaku_schema.up.sql":           &bintree{_5_nwaku_schemaUpSql, map[string]*bintree{}},
+	"6_filter_subscriptions.down.sql": &bintree{_6_filter_subscriptionsDownSql, map[string]*bintree{}},
+	"6_filter_subscriptions.up.sql":   &bintree{_6_filter_subscriptionsUpSql, map[string]*bintree{}},
+	"doc.go":                          &bintree{docGo, map[string]*bintree{}},
 }}
 
 // RestoreAsset restores an asset under the given directory.
@@ -0,0 +1,27 @@
+ALTER TABLE message RENAME TO message_partitioned;
+
+DROP INDEX i_ts;
+DROP INDEX i_query;
+DROP INDEX i_msg_hash;
+
+CREATE TABLE message (
+  pubsubTopic BYTEA NOT NULL,
+  contentTopic BYTEA NOT NULL,
+  payload BYTEA,
+  version INTEGER NOT NULL DEFAULT 0,
+  timestamp BIGINT NOT NULL,
+  id BYTEA,
+  messageHash BYTEA,
+  storedAt BIGINT NOT NULL,
+  PRIMARY KEY (messageHash)
+);
+
+CREATE INDEX i_ts ON message (storedAt);
+CREATE INDEX i_query ON message (contentTopic, pubsubTopic, storedAt, id);
+
+INSERT INTO message(pubsubTopic, contentTopic, payload, version, timestamp, id, messageHash, storedAt)
+SELECT pubsubTopic, contentTopic, payload, version, timestamp, id, messageHash, storedAt
+FROM message_partitioned
+ON CONFLICT DO NOTHING;
+
+DROP TABLE message_partitioned;
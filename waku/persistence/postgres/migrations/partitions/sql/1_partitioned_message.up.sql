@@ -0,0 +1,31 @@
+ALTER TABLE message RENAME TO message_unpartitioned;
+
+DROP INDEX i_ts;
+DROP INDEX i_query;
+
+-- The partition key must be part of the primary key of a partitioned table, so the
+-- primary key does not ensure the message hash is unique. Inserts check it instead
+CREATE TABLE message (
+  pubsubTopic BYTEA NOT NULL,
+  contentTopic BYTEA NOT NULL,
+  payload BYTEA,
+  version INTEGER NOT NULL DEFAULT 0,
+  timestamp BIGINT NOT NULL,
+  id BYTEA,
+  messageHash BYTEA NOT NULL,
+  storedAt BIGINT NOT NULL,
+  PRIMARY KEY (storedAt, messageHash)
+) PARTITION BY RANGE (storedAt);
+
+-- Messages not covered by any time partition are stored in the default partition
+CREATE TABLE message_default PARTITION OF message DEFAULT;
+
+CREATE INDEX i_ts ON message (storedAt);
+CREATE INDEX i_query ON message (contentTopic, pubsubTopic, storedAt, id);
+CREATE INDEX i_msg_hash ON message (messageHash);
+
+INSERT INTO message(pubsubTopic, contentTopic, payload, version, timestamp, id, messageHash, storedAt)
+SELECT pubsubTopic, contentTopic, payload, version, timestamp, id, messageHash, storedAt
+FROM message_unpartitioned;
+
+DROP TABLE message_unpartitioned;
@@ -0,0 +1,3 @@
+package sql
+
+//go:generate go-bindata -pkg partitions -o ../bindata.go ./
@@ -0,0 +1,8 @@
+CREATE TABLE IF NOT EXISTS filter_subscriptions (
+  peerId VARCHAR(64) NOT NULL,
+  pubsubTopic VARCHAR NOT NULL,
+  contentTopic VARCHAR NOT NULL,
+  lastSeen BIGINT NOT NULL,
+  PRIMARY KEY (peerId, pubsubTopic, contentTopic)
+);
+CREATE INDEX i_filter_lastSeen ON filter_subscriptions (lastSeen);
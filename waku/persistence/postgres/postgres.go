@@ -10,6 +10,7 @@ import (
 	"github.com/waku-org/go-waku/waku/persistence"
 	"github.com/waku-org/go-waku/waku/persistence/migrate"
 	"github.com/waku-org/go-waku/waku/persistence/postgres/migrations"
+	"github.com/waku-org/go-waku/waku/persistence/postgres/migrations/partitions"
 	"go.uber.org/zap"
 )
 
@@ -23,9 +24,17 @@ func NewDB(dburl string, logger *zap.Logger) (*sql.DB, error) {
 	return db, nil
 }
 
+// PartitionMigrationsTable is the table used to keep track of the migrations applied
+// to convert the message table into a time partitioned table
+const PartitionMigrationsTable = "partition_migrations"
+
 func migrationDriver(db *sql.DB) (database.Driver, error) {
+	return migrationDriverWithTable(db, pgx.DefaultMigrationsTable)
+}
+
+func migrationDriverWithTable(db *sql.DB, migrationsTable string) (database.Driver, error) {
 	return pgx.WithInstance(db, &pgx.Config{
-		MigrationsTable: pgx.DefaultMigrationsTable,
+		MigrationsTable: migrationsTable,
 	})
 }
 
@@ -38,6 +47,24 @@ func Migrations(db *sql.DB, logger *zap.Logger) error {
 	return migrate.Migrate(db, migrationDriver, migrations.AssetNames(), migrations.Asset)
 }
 
+// PartitionedMigrations is the function used for DB migration with postgres driver when
+// the message table is partitioned by time. The regular migrations are executed first,
+// and the message table is then converted into a table partitioned by range on storedAt.
+// The partitioning migrations are numbered and tracked in PartitionMigrationsTable, apart
+// from the regular migrations, so both can evolve independently
+func PartitionedMigrations(db *sql.DB, logger *zap.Logger) error {
+	err := Migrations(db, logger)
+	if err != nil {
+		return err
+	}
+
+	migrationDriver, err := migrationDriverWithTable(db, PartitionMigrationsTable)
+	if err != nil {
+		return err
+	}
+	return migrate.Migrate(db, migrationDriver, partitions.AssetNames(), partitions.Asset)
+}
+
 // CreateTable creates the table that will persist the peers
 func CreateTable(db *sql.DB, tableName string) error {
 	sqlStmt := fmt.Sprintf("CREATE TABLE IF NOT EXISTS %s (key TEXT NOT NULL UNIQUE, data BYTEA);", tableName)
@@ -0,0 +1,77 @@
+package persistence
+
+import (
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// evictionBatchSize is the number of messages removed at once when the quota is exceeded
+const evictionBatchSize = 100
+
+// WithRetentionMaxBytes is a DBOption that specifies the maximum size of the
+// payloads stored. When the quota is exceeded, the oldest messages are removed
+// from the message store
+func WithRetentionMaxBytes(maxBytes uint64) DBOption {
+	return func(d *DBStore) error {
+		d.maxBytes = maxBytes
+		return nil
+	}
+}
+
+// StoredBytes returns the approximate size of the payloads stored. It's only tracked when
+// a quota is set with WithRetentionMaxBytes: it's calculated when the store starts, and
+// updated as messages are inserted and removed
+func (d *DBStore) StoredBytes() int64 {
+	return d.storedBytes.Load()
+}
+
+func (d *DBStore) calculateStoredBytes() error {
+	var result int64
+	err := d.db.QueryRow(`SELECT COALESCE(SUM(LENGTH(payload)), 0) FROM message`).Scan(&result)
+	if err != nil {
+		return err
+	}
+	d.storedBytes.Store(result)
+	return nil
+}
+
+func (d *DBStore) quotaExceeded() bool {
+	return d.maxBytes > 0 && d.storedBytes.Load() > int64(d.maxBytes)
+}
+
+// notifyQuotaExceeded requests the eviction of messages without blocking the insertion
+func (d *DBStore) notifyQuotaExceeded() {
+	select {
+	case d.quotaExceededCh <- struct{}{}:
+	default:
+	}
+}
+
+// evictExceedingBytes removes the oldest messages, in batches, until the stored bytes fit in the quota
+func (d *DBStore) evictExceedingBytes() error {
+	start := time.Now()
+
+	// Among the oldest messages of a batch, only the ones required to cover the excess are removed
+	where := `messageHash IN (SELECT messageHash FROM (
+		SELECT messageHash, SUM(COALESCE(LENGTH(payload), 0)) OVER (ORDER BY storedAt, messageHash) - COALESCE(LENGTH(payload), 0) AS previous
+		FROM (SELECT messageHash, storedAt, payload FROM message ORDER BY storedAt, messageHash LIMIT $1) AS oldest
+	) AS t WHERE previous < $2)`
+
+	var deleted int64
+	for d.quotaExceeded() {
+		excess := d.storedBytes.Load() - int64(d.maxBytes)
+		n, _, err := d.deleteFrom("message", where, evictionBatchSize, excess)
+		if err != nil {
+			return err
+		}
+		if n == 0 {
+			break
+		}
+		deleted += n
+	}
+
+	d.log.Debug("evicting records exceeding the quota from the DB", zap.Int64("deleted", deleted), zap.Int64("storedBytes", d.storedBytes.Load()), zap.Duration("duration", time.Since(start)))
+
+	return nil
+}
@@ -0,0 +1,286 @@
+package persistence
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/dustin/go-humanize"
+	"go.uber.org/zap"
+)
+
+// RetentionRule is a retention policy that only applies to the messages whose pubsub topic
+// and content topic match the rule patterns. Patterns can contain the wildcard `*` which
+// matches any sequence of characters. An empty pattern matches any topic. Rules are enforced
+// independently of each other and of the global retention policy, so a message is removed as
+// soon as any of the rules that match it expires it
+type RetentionRule struct {
+	Name         string
+	PubsubTopic  string
+	ContentTopic string
+	MaxAge       time.Duration
+	MaxMessages  int
+	MaxBytes     uint64
+}
+
+func (r RetentionRule) String() string {
+	var parts []string
+	if r.Name != "" {
+		parts = append(parts, "name="+r.Name)
+	}
+	if r.PubsubTopic != "" {
+		parts = append(parts, "pubsubTopic="+r.PubsubTopic)
+	}
+	if r.ContentTopic != "" {
+		parts = append(parts, "contentTopic="+r.ContentTopic)
+	}
+	if r.MaxAge > 0 {
+		parts = append(parts, "maxAge="+r.MaxAge.String())
+	}
+	if r.MaxMessages > 0 {
+		parts = append(parts, "maxMessages="+strconv.Itoa(r.MaxMessages))
+	}
+	if r.MaxBytes > 0 {
+		parts = append(parts, "maxBytes="+strconv.FormatUint(r.MaxBytes, 10))
+	}
+	return strings.Join(parts, ";")
+}
+
+// Validate checks that a retention rule limits the messages stored
+func (r RetentionRule) Validate() error {
+	if r.MaxAge <= 0 && r.MaxMessages <= 0 && r.MaxBytes == 0 {
+		return errors.New("retention rule must specify at least one of maxAge, maxMessages or maxBytes")
+	}
+	return nil
+}
+
+// ParseRetentionRule parses a retention rule with the format
+// `key=value;key=value`, where the keys are name, pubsubTopic, contentTopic,
+// maxAge (i.e. 1h), maxMessages and maxBytes (i.e. 10MB)
+func ParseRetentionRule(value string) (RetentionRule, error) {
+	var rule RetentionRule
+	for _, part := range strings.Split(value, ";") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+
+		kv := strings.SplitN(part, "=", 2)
+		if len(kv) != 2 {
+			return RetentionRule{}, fmt.Errorf("invalid retention rule attribute: %s", part)
+		}
+
+		key, val := strings.TrimSpace(kv[0]), strings.TrimSpace(kv[1])
+		switch key {
+		case "name":
+			rule.Name = val
+		case "pubsubTopic":
+			rule.PubsubTopic = val
+		case "contentTopic":
+			rule.ContentTopic = val
+		case "maxAge":
+			maxAge, err := time.ParseDuration(val)
+			if err != nil {
+				return RetentionRule{}, fmt.Errorf("invalid maxAge: %w", err)
+			}
+			rule.MaxAge = maxAge
+		case "maxMessages":
+			maxMessages, err := strconv.Atoi(val)
+			if err != nil {
+				return RetentionRule{}, fmt.Errorf("invalid maxMessages: %w", err)
+			}
+			rule.MaxMessages = maxMessages
+		case "maxBytes":
+			maxBytes, err := humanize.ParseBytes(val)
+			if err != nil {
+				return RetentionRule{}, fmt.Errorf("invalid maxBytes: %w", err)
+			}
+			rule.MaxBytes = maxBytes
+		default:
+			return RetentionRule{}, fmt.Errorf("unknown retention rule attribute: %s", key)
+		}
+	}
+
+	if err := rule.Validate(); err != nil {
+		return RetentionRule{}, err
+	}
+
+	return rule, nil
+}
+
+// WithRetentionRules is a DBOption that specifies retention policies that only
+// apply to the messages of some pubsub topics and content topics
+func WithRetentionRules(rules ...RetentionRule) DBOption {
+	return func(d *DBStore) error {
+		for i, rule := range rules {
+			if err := rule.Validate(); err != nil {
+				return err
+			}
+
+			if rule.Name == "" {
+				rule.Name = fmt.Sprintf("rule_%d", i)
+			}
+
+			d.retentionRules = append(d.retentionRules, rule)
+		}
+		return nil
+	}
+}
+
+// condition builds the SQL condition used to select the messages matched by the rule.
+// Parameter placeholders start after paramCnt
+func (r RetentionRule) condition(driverType int, paramCnt int) (string, []interface{}) {
+	var conditions []string
+	var parameters []interface{}
+
+	for _, c := range []struct {
+		column  string
+		pattern string
+	}{
+		{"pubsubTopic", r.PubsubTopic},
+		{"contentTopic", r.ContentTopic},
+	} {
+		if c.pattern == "" {
+			continue
+		}
+
+		paramCnt++
+		conditions = append(conditions, topicPatternCondition(driverType, c.column, c.pattern, paramCnt))
+		parameters = append(parameters, topicPatternValue(driverType, c.pattern))
+	}
+
+	if len(conditions) == 0 {
+		return "1 = 1", nil
+	}
+
+	return "(" + strings.Join(conditions, " AND ") + ")", parameters
+}
+
+// topicPatternCondition builds the SQL condition used to select the topics of a column matching
+// a pattern. Patterns without wildcard are compared for equality, so indexes can be used. Other
+// patterns are matched with GLOB in SQLite, and LIKE in postgres, which are both case sensitive
+func topicPatternCondition(driverType int, column string, pattern string, param int) string {
+	switch {
+	case !strings.Contains(pattern, "*"):
+		return fmt.Sprintf("%s = $%d", column, param)
+	case driverType == SQLiteDriver:
+		return fmt.Sprintf("%s GLOB $%d", column, param)
+	default:
+		return fmt.Sprintf("%s LIKE $%d ESCAPE '!'", column, param)
+	}
+}
+
+var globEscaper = strings.NewReplacer("[", "[[]", "?", "[?]")
+var likeEscaper = strings.NewReplacer("!", "!!", "%", "!%", "_", "!_", "*", "%")
+
+// topicPatternValue returns the parameter used by topicPatternCondition, with the characters
+// that have a special meaning for GLOB or LIKE escaped, and the wildcard `*` translated
+func topicPatternValue(driverType int, pattern string) string {
+	switch {
+	case !strings.Contains(pattern, "*"):
+		return pattern
+	case driverType == SQLiteDriver:
+		return globEscaper.Replace(pattern)
+	default:
+		return likeEscaper.Replace(pattern)
+	}
+}
+
+func (d *DBStore) applyRetentionRules() error {
+	for _, rule := range d.retentionRules {
+		start := time.Now()
+
+		deleted, err := d.applyRetentionRule(rule)
+		if err != nil {
+			return fmt.Errorf("applying retention rule %s: %w", rule.Name, err)
+		}
+
+		d.metrics.RecordRetentionRuleDeletions(rule.Name, deleted)
+
+		d.log.Debug("applied retention rule", zap.String("rule", rule.Name), zap.Int64("deleted", deleted), zap.Duration("duration", time.Since(start)))
+	}
+
+	return nil
+}
+
+func (d *DBStore) applyRetentionRule(rule RetentionRule) (int64, error) {
+	var deleted int64
+	driverType := GetDriverType(d.db)
+
+	if rule.MaxAge > 0 {
+		condition, parameters := rule.condition(driverType, 1)
+		parameters = append([]interface{}{d.timesource.Now().Add(-rule.MaxAge).UnixNano()}, parameters...)
+		n, _, err := d.deleteFrom("message", "storedAt < $1 AND "+condition, parameters...)
+		if err != nil {
+			return deleted, err
+		}
+		deleted += n
+	}
+
+	if rule.MaxMessages > 0 {
+		// SQLite numbers the parameters in the order they appear, so the offset goes last
+		condition, parameters := rule.condition(driverType, 0)
+		parameters = append(parameters, rule.MaxMessages)
+		where := fmt.Sprintf("messageHash IN (SELECT messageHash FROM message WHERE %s ORDER BY storedAt DESC, messageHash DESC %s OFFSET $%d)", condition, d.unlimitedClause(), len(parameters))
+		n, _, err := d.deleteFrom("message", where, parameters...)
+		if err != nil {
+			return deleted, err
+		}
+		deleted += n
+	}
+
+	if rule.MaxBytes > 0 {
+		n, err := d.deleteExceedingBytes(rule.MaxBytes, rule)
+		if err != nil {
+			return deleted, err
+		}
+		deleted += n
+	}
+
+	return deleted, nil
+}
+
+// deleteExceedingBytes removes the oldest messages matched by a retention rule until the total
+// size of their remaining payloads is below maxBytes
+func (d *DBStore) deleteExceedingBytes(maxBytes uint64, rule RetentionRule) (int64, error) {
+	driverType := GetDriverType(d.db)
+
+	condition, parameters := rule.condition(driverType, 0)
+	parameters = append(parameters, int64(maxBytes))
+
+	// Find the most recent message that exceeds the limit
+	sqlQuery := fmt.Sprintf(`SELECT storedAt, messageHash FROM (
+		SELECT storedAt, messageHash, SUM(COALESCE(LENGTH(payload), 0)) OVER (ORDER BY storedAt DESC, messageHash DESC) AS total
+		FROM message
+		WHERE %s
+	) AS t
+	WHERE total > $%d
+	ORDER BY storedAt DESC, messageHash DESC
+	LIMIT 1`, condition, len(parameters))
+
+	var storedAt int64
+	var messageHash []byte
+	err := d.db.QueryRow(sqlQuery, parameters...).Scan(&storedAt, &messageHash)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return 0, nil
+		}
+		return 0, err
+	}
+
+	condition, parameters = rule.condition(driverType, 2)
+	parameters = append([]interface{}{storedAt, messageHash}, parameters...)
+	deleted, _, err := d.deleteFrom("message", "(storedAt, messageHash) <= ($1, $2) AND "+condition, parameters...)
+	return deleted, err
+}
+
+// unlimitedClause returns the LIMIT clause required by the DB engine to use OFFSET without a limit
+func (d *DBStore) unlimitedClause() string {
+	if GetDriverType(d.db) == SQLiteDriver {
+		return "LIMIT -1"
+	}
+	return ""
+}
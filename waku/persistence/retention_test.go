@@ -0,0 +1,67 @@
+package persistence
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseRetentionRule(t *testing.T) {
+	rule, err := ParseRetentionRule("name=telemetry;pubsubTopic=/waku/2/rs/1/*;contentTopic=/telemetry/*;maxAge=1h;maxMessages=1000;maxBytes=10MB")
+	require.NoError(t, err)
+	require.Equal(t, RetentionRule{
+		Name:         "telemetry",
+		PubsubTopic:  "/waku/2/rs/1/*",
+		ContentTopic: "/telemetry/*",
+		MaxAge:       time.Hour,
+		MaxMessages:  1000,
+		MaxBytes:     10_000_000,
+	}, rule)
+
+	parsedRule, err := ParseRetentionRule(rule.String())
+	require.NoError(t, err)
+	require.Equal(t, rule, parsedRule)
+
+	_, err = ParseRetentionRule("contentTopic=/telemetry/*")
+	require.Error(t, err)
+
+	_, err = ParseRetentionRule("contentTopic=/telemetry/*;maxAge=abc")
+	require.Error(t, err)
+
+	_, err = ParseRetentionRule("topic=/telemetry/*;maxAge=1h")
+	require.Error(t, err)
+}
+
+func TestRetentionRuleCondition(t *testing.T) {
+	d := new(DBStore)
+	err := WithRetentionRules(
+		RetentionRule{ContentTopic: "/telemetry/*", MaxAge: time.Hour},
+		RetentionRule{Name: "chat", PubsubTopic: "/waku/2/rs/1/0", ContentTopic: "/toy_chat/2/*/proto", MaxAge: 30 * 24 * time.Hour},
+		RetentionRule{MaxMessages: 10},
+	)(d)
+	require.NoError(t, err)
+	require.Len(t, d.retentionRules, 3)
+	require.Equal(t, "rule_0", d.retentionRules[0].Name)
+	require.Equal(t, "chat", d.retentionRules[1].Name)
+
+	condition, parameters := d.retentionRules[0].condition(PostgresDriver, 1)
+	require.Equal(t, "(contentTopic LIKE $2 ESCAPE '!')", condition)
+	require.Equal(t, []interface{}{"/telemetry/%"}, parameters)
+
+	condition, parameters = d.retentionRules[0].condition(SQLiteDriver, 1)
+	require.Equal(t, "(contentTopic GLOB $2)", condition)
+	require.Equal(t, []interface{}{"/telemetry/*"}, parameters)
+
+	// Characters with a special meaning are escaped, and topics without wildcard are compared for equality
+	condition, parameters = d.retentionRules[1].condition(PostgresDriver, 0)
+	require.Equal(t, "(pubsubTopic = $1 AND contentTopic LIKE $2 ESCAPE '!')", condition)
+	require.Equal(t, []interface{}{"/waku/2/rs/1/0", "/toy!_chat/2/%/proto"}, parameters)
+
+	condition, parameters = d.retentionRules[2].condition(PostgresDriver, 0)
+	require.Equal(t, "1 = 1", condition)
+	require.Empty(t, parameters)
+
+	err = WithRetentionRules(RetentionRule{ContentTopic: "/telemetry/*"})(d)
+	require.Error(t, err)
+}
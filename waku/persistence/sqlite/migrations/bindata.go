@@ -10,6 +10,8 @@
 // 4_signed_peer_record.up.sql (197B)
 // 5_nwaku_schema.down.sql (927B)
 // 5_nwaku_schema.up.sql (862B)
+// 6_filter_subscriptions.down.sql (83B)
+// 6_filter_subscriptions.up.sql (331B)
 // doc.go (74B)
 
 package migrations
@@ -279,6 +281,46 @@ func _5_nwaku_schemaUpSql() (*asset, error) {
 	return a, nil
 }
 
+var __6_filter_subscriptionsDownSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x73\x09\xf2\x0f\x50\xf0\xf4\x73\x71\x8d\x50\xf0\x74\x53\x70\x8d\xf0\x0c\x0e\x09\x56\xc8\x8c\x4f\xcb\xcc\x29\x49\x2d\x8a\xcf\x49\x2c\x2e\x09\x4e\x4d\xcd\xb3\xe6\x72\x01\xa9\x0b\x71\x74\xf2\x71\x45\x52\x07\x55\x55\x5c\x9a\x54\x9c\x5c\x94\x59\x50\x92\x99\x9f\x57\x6c\xcd\x05\x00\xe2\x9a\x74\x3d\x53\x00\x00\x00")
+
+func _6_filter_subscriptionsDownSqlBytes() ([]byte, error) {
+	return bindataRead(
+		__6_filter_subscriptionsDownSql,
+		"6_filter_subscriptions.down.sql",
+	)
+}
+
+func _6_filter_subscriptionsDownSql() (*asset, error) {
+	bytes, err := _6_filter_subscriptionsDownSqlBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	info := bindataFileInfo{name: "6_filter_subscriptions.down.sql", size: 83, mode: os.FileMode(0664), modTime: time.Unix(1700000000, 0)}
+	a := &asset{bytes: bytes, info: info, digest: [32]uint8{0x8d, 0x64, 0x87, 0x8e, 0xc9, 0x5d, 0x41, 0xc, 0x38, 0x15, 0x53, 0xc4, 0x4e, 0x52, 0x1c, 0x29, 0xac, 0xef, 0x46, 0x25, 0x15, 0xc8, 0x26, 0xa9, 0xb5, 0xee, 0xfc, 0x5b, 0x67, 0xbc, 0xa6, 0xfd}}
+	return a, nil
+}
+
+var __6_filter_subscriptionsUpSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x75\x90\xdd\x0a\x82\x40\x10\x85\xef\x7d\x8a\xb9\x4c\xf0\x0d\xba\x32\x9d\x6a\xc8\x76\x63\x77\x42\xbd\x8a\xd2\x0d\x16\x62\x15\x5d\xa1\xc7\xef\x3f\x94\xe8\x76\xbe\x03\xe7\x9b\x93\x28\x8c\x19\x81\xe3\x45\x86\x40\x4b\x10\x92\x01\x0b\xd2\xac\xe1\x6c\x2f\xde\x74\x87\x7e\x38\xf5\x55\x67\x5b\x6f\x1b\xd7\xc3\x2c\x00\x68\x8d\xe9\xa8\x06\xc6\x82\x9f\x79\xb1\xcf\xb2\xe8\x71\xbf\x27\x87\x13\x37\xad\xad\x7e\x61\xd5\x38\x6f\x9c\xff\x43\x2f\xc7\xde\x6b\x63\x1c\x90\x60\x5c\xa1\x9a\xc0\x44\x0a\xcd\x2a\xbe\xa3\xb7\x93\x1e\x29\x91\xab\xcd\x15\x76\x8a\xb6\xb1\x2a\x61\x83\x25\xcc\x5e\x7e\xd1\xd8\x27\x9a\xf4\x87\x41\x08\x39\xf1\x5a\xee\x19\x94\xcc\x29\x9d\x07\xc9\x6b\x07\x12\x29\x16\x60\x0f\xef\xdf\xbf\x5a\x52\xfc\x99\xe3\x93\x08\xe7\xc1\x0d\x38\xda\x60\xd1\x4b\x01\x00\x00")
+
+func _6_filter_subscriptionsUpSqlBytes() ([]byte, error) {
+	return bindataRead(
+		__6_filter_subscriptionsUpSql,
+		"6_filter_subscriptions.up.sql",
+	)
+}
+
+func _6_filter_subscriptionsUpSql() (*asset, error) {
+	bytes, err := _6_filter_subscriptionsUpSqlBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	info := bindataFileInfo{name: "6_filter_subscriptions.up.sql", size: 331, mode: os.FileMode(0664), modTime: time.Unix(1700000000, 0)}
+	a := &asset{bytes: bytes, info: info, digest: [32]uint8{0x1a, 0x83, 0x5b, 0xcf, 0xf2, 0x8, 0xdf, 0x69, 0xb8, 0x7e, 0xf1, 0xaf, 0xfa, 0x70, 0x4d, 0x35, 0x4b, 0x2d, 0xde, 0xed, 0xf1, 0x93, 0xce, 0x79, 0xcc, 0xb6, 0x87, 0xad, 0x8d, 0xdc, 0x68, 0x8}}
+	return a, nil
+}
+
 var _docGo = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x2c\xc9\xb1\x0d\xc4\x20\x0c\x05\xd0\x9e\x29\xfe\x02\xd8\xfd\x6d\xe3\x4b\xac\x2f\x44\x82\x09\x78\x7f\xa5\x49\xfd\xa6\x1d\xdd\xe8\xd8\xcf\x55\x8a\x2a\xe3\x47\x1f\xbe\x2c\x1d\x8c\xfa\x6f\xe3\xb4\x34\xd4\xd9\x89\xbb\x71\x59\xb6\x18\x1b\x35\x20\xa2\x9f\x0a\x03\xa2\xe5\x0d\x00\x00\xff\xff\x60\xcd\x06\xbe\x4a\x00\x00\x00")
 
 func docGoBytes() ([]byte, error) {
@@ -410,6 +452,10 @@ var _bindata = map[string]func() (*asset, error){
 
 	"5_nwaku_schema.up.sql": _5_nwaku_schemaUpSql,
 
+	"6_filter_subscriptions.down.sql": _6_filter_subscriptionsDownSql,
+
+	"6_filter_subscriptions.up.sql": _6_filter_subscriptionsUpSql,
+
 	"doc.go": docGo,
 }
 
@@ -454,17 +500,19 @@ type bintree struct {
 }
 
 var _bintree = &bintree{nil, map[string]*bintree{
-	"1_messages.down.sql":           &bintree{_1_messagesDownSql, map[string]*bintree{}},
-	"1_messages.up.sql":             &bintree{_1_messagesUpSql, map[string]*bintree{}},
-	"2_messages_index.down.sql":     &bintree{_2_messages_indexDownSql, map[string]*bintree{}},
-	"2_messages_index.up.sql":       &bintree{_2_messages_indexUpSql, map[string]*bintree{}},
-	"3_rendezvous.down.sql":         &bintree{_3_rendezvousDownSql, map[string]*bintree{}},
-	"3_rendezvous.up.sql":           &bintree{_3_rendezvousUpSql, map[string]*bintree{}},
-	"4_signed_peer_record.down.sql": &bintree{_4_signed_peer_recordDownSql, map[string]*bintree{}},
-	"4_signed_peer_record.up.sql":   &bintree{_4_signed_peer_recordUpSql, map[string]*bintree{}},
-	"5_nwaku_schema.down.sql":       &bintree{_5_nwaku_schemaDownSql, map[string]*bintree{}},
-	"5_nwaku_schema.up.sql":         &bintree{_5_nwaku_schemaUpSql, map[string]*bintree{}},
-	"doc.go":                        &bintree{docGo, map[string]*bintree{}},
+	"1_messages.down.sql":             &bintree{_1_messagesDownSql, map[string]*bintree{}},
+	"1_messages.up.sql":               &bintree{_1_messagesUpSql, map[string]*bintree{}},
+	"2_messages_index.down.sql":       &bintree{_2_messages_indexDownSql, map[string]*bintree{}},
+	"2_messages_index.up.sql":         &bintree{_2_messages_indexUpSql, map[string]*bintree{}},
+	"3_rendezvous.down.sql":           &bintree{_3_rendezvousDownSql, map[string]*bintree{}},
+	"3_rendezvous.up.sql":             &bintree{_3_rendezvousUpSql, map[string]*bintree{}},
+	"4_signed_peer_record.down.sql":   &bintree{_4_signed_peer_recordDownSql, map[string]*bintree{}},
+	"4_signed_peer_record.up.sql":     &bintree{_4_signed_peer_recordUpSql, map[string]*bintree{}},
+	"5_nwaku_schema.down.sql":         &bintree{_5_nwaku_schemaDownSql, map[string]*bintree{}},
+	"5_nwaku_schema.up.sql":           &bintree{_5_nwaku_schemaUpSql, map[string]*bintree{}},
+	"6_filter_subscriptions.down.sql": &bintree{_6_filter_subscriptionsDownSql, map[string]*bintree{}},
+	"6_filter_subscriptions.up.sql":   &bintree{_6_filter_subscriptionsUpSql, map[string]*bintree{}},
+	"doc.go":                          &bintree{docGo, map[string]*bintree{}},
 }}
 
 // RestoreAsset restores an asset under the given directory.
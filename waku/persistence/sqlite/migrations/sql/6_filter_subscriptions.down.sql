@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS i_filter_lastSeen;
+DROP TABLE IF EXISTS filter_subscriptions;
@@ -0,0 +1,8 @@
+CREATE TABLE IF NOT EXISTS filter_subscriptions (
+  peerId TEXT NOT NULL,
+  pubsubTopic TEXT NOT NULL,
+  contentTopic TEXT NOT NULL,
+  lastSeen INTEGER NOT NULL,
+  CONSTRAINT filterSubscriptionIndex PRIMARY KEY (peerId, pubsubTopic, contentTopic)
+) WITHOUT ROWID;
+CREATE INDEX i_filter_lastSeen ON filter_subscriptions (lastSeen);
@@ -13,5 +13,8 @@ func NewMockSqliteDB() *sql.DB {
 		log.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
 	}
 
+	// Each connection to :memory: opens a new empty database
+	db.SetMaxOpenConns(1)
+
 	return db
 }
@@ -7,6 +7,7 @@ import (
 	"fmt"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/prometheus/client_golang/prometheus"
@@ -39,6 +40,9 @@ var ErrFutureMessage = errors.New("message timestamp in the future")
 // ErrMessageTooOld indicates that a message that was too old was requested to be stored.
 var ErrMessageTooOld = errors.New("message too old")
 
+// ErrDuplicatedMessage indicates that a message with the same hash was already stored
+var ErrDuplicatedMessage = errors.New("message already stored")
+
 // WALMode for sqlite.
 const WALMode = "wal"
 
@@ -58,6 +62,27 @@ type DBStore struct {
 
 	maxMessages int
 	maxDuration time.Duration
+	maxBytes    uint64
+
+	storedBytes     atomic.Int64
+	quotaExceededCh chan struct{}
+
+	partitionInterval time.Duration
+	futurePartitions  int
+
+	retentionRules []RetentionRule
+
+	batchSize          int
+	batchFlushInterval time.Duration
+	writeQueue         chan *protocol.Envelope
+	// writerMu guards the state of the batch writer, so no message is queued once it stops
+	writerMu       sync.RWMutex
+	writerStarted  bool
+	writerStopped  bool
+	writerStopping <-chan struct{}
+	// pendingMu guards the hashes of the queued messages, used to report duplicates
+	pendingMu sync.Mutex
+	pending   map[wpb.MessageHash]struct{}
 
 	enableMigrations bool
 
@@ -68,6 +93,7 @@ type DBStore struct {
 // StoredMessage is the format of the message stored in persistence store
 type StoredMessage struct {
 	ID           []byte
+	MessageHash  []byte
 	PubsubTopic  string
 	ReceiverTime int64
 	Message      *wpb.WakuMessage
@@ -146,6 +172,7 @@ func NewDBStore(reg prometheus.Registerer, log *zap.Logger, options ...DBOption)
 	result := new(DBStore)
 	result.log = log.Named("dbstore")
 	result.metrics = newMetrics(reg)
+	result.quotaExceededCh = make(chan struct{}, 1)
 
 	optList := DefaultOptions()
 	optList = append(optList, options...)
@@ -157,6 +184,10 @@ func NewDBStore(reg prometheus.Registerer, log *zap.Logger, options ...DBOption)
 		}
 	}
 
+	if result.partitionInterval > 0 && GetDriverType(result.db) != PostgresDriver {
+		return nil, ErrPartitionsNotSupported
+	}
+
 	if result.enableMigrations {
 		err := result.migrationFn(result.db, log)
 		if err != nil {
@@ -174,6 +205,21 @@ func (d *DBStore) Start(ctx context.Context, timesource timesource.Timesource) e
 	d.cancel = cancel
 	d.timesource = timesource
 
+	if d.partitionInterval > 0 {
+		err := d.createPartitions()
+		if err != nil {
+			d.metrics.RecordError(partitionFailure)
+			return err
+		}
+	}
+
+	if d.maxBytes > 0 {
+		err := d.calculateStoredBytes()
+		if err != nil {
+			return err
+		}
+	}
+
 	err := d.cleanOlderRecords(ctx)
 	if err != nil {
 		return err
@@ -183,6 +229,16 @@ func (d *DBStore) Start(ctx context.Context, timesource timesource.Timesource) e
 	go d.checkForOlderRecords(ctx, 60*time.Second)
 	go d.updateMetrics(ctx)
 
+	if d.writeQueue != nil {
+		d.writerMu.Lock()
+		d.writerStarted = true
+		d.writerStopping = ctx.Done()
+		d.writerMu.Unlock()
+
+		d.wg.Add(1)
+		go d.batchWriter(ctx)
+	}
+
 	return nil
 }
 
@@ -201,6 +257,9 @@ func (d *DBStore) updateMetrics(ctx context.Context) {
 			} else {
 				d.metrics.RecordMessage(msgCount)
 			}
+			if d.maxBytes > 0 {
+				d.metrics.RecordStoredBytes(d.storedBytes.Load())
+			}
 		case <-ctx.Done():
 			return
 		}
@@ -210,11 +269,22 @@ func (d *DBStore) updateMetrics(ctx context.Context) {
 func (d *DBStore) cleanOlderRecords(ctx context.Context) error {
 	d.log.Info("Cleaning older records...")
 
+	// Drop partitions with older messages
+	if d.maxDuration > 0 && d.partitionInterval > 0 {
+		start := time.Now()
+		err := d.dropOlderPartitions(d.timesource.Now().Add(-d.maxDuration))
+		if err != nil {
+			d.metrics.RecordError(retPolicyFailure)
+			return err
+		}
+		elapsed := time.Since(start)
+		d.log.Debug("dropping older partitions from the DB", zap.Duration("duration", elapsed))
+	}
+
 	// Delete older messages
-	if d.maxDuration > 0 {
+	if d.maxDuration > 0 && d.partitionInterval == 0 {
 		start := time.Now()
-		sqlStmt := `DELETE FROM message WHERE storedAt < $1`
-		_, err := d.db.Exec(sqlStmt, d.timesource.Now().Add(-d.maxDuration).UnixNano())
+		_, _, err := d.deleteFrom("message", "storedAt < $1", d.timesource.Now().Add(-d.maxDuration).UnixNano())
 		if err != nil {
 			d.metrics.RecordError(retPolicyFailure)
 			return err
@@ -227,7 +297,7 @@ func (d *DBStore) cleanOlderRecords(ctx context.Context) error {
 	if d.maxMessages > 0 {
 		start := time.Now()
 
-		_, err := d.db.Exec(d.getDeleteOldRowsQuery(), d.maxMessages)
+		_, _, err := d.deleteFrom("message", d.getDeleteOldRowsCondition(), d.maxMessages)
 		if err != nil {
 			d.metrics.RecordError(retPolicyFailure)
 			return err
@@ -236,20 +306,31 @@ func (d *DBStore) cleanOlderRecords(ctx context.Context) error {
 		d.log.Debug("deleting excess records from the DB", zap.Duration("duration", elapsed))
 	}
 
+	// Limit the size of the stored payloads
+	if d.quotaExceeded() {
+		err := d.evictExceedingBytes()
+		if err != nil {
+			d.metrics.RecordError(retPolicyFailure)
+			return err
+		}
+	}
+
+	// Apply the retention rules of specific topics
+	if len(d.retentionRules) > 0 {
+		err := d.applyRetentionRules()
+		if err != nil {
+			d.metrics.RecordError(retPolicyFailure)
+			return err
+		}
+	}
+
 	d.log.Info("Older records removed")
 
 	return nil
 }
 
-func (d *DBStore) getDeleteOldRowsQuery() string {
-	sqlStmt := `DELETE FROM message WHERE id IN (SELECT id FROM message ORDER BY storedAt DESC %s OFFSET $1)`
-	switch GetDriverType(d.db) {
-	case SQLiteDriver:
-		sqlStmt = fmt.Sprintf(sqlStmt, "LIMIT -1")
-	case PostgresDriver:
-		sqlStmt = fmt.Sprintf(sqlStmt, "")
-	}
-	return sqlStmt
+func (d *DBStore) getDeleteOldRowsCondition() string {
+	return fmt.Sprintf("id IN (SELECT id FROM message ORDER BY storedAt DESC %s OFFSET $1)", d.unlimitedClause())
 }
 
 func (d *DBStore) checkForOlderRecords(ctx context.Context, t time.Duration) {
@@ -264,10 +345,27 @@ func (d *DBStore) checkForOlderRecords(ctx context.Context, t time.Duration) {
 		case <-ctx.Done():
 			return
 		case <-ticker.C:
+			if d.partitionInterval > 0 {
+				err := d.createPartitions()
+				if err != nil {
+					d.metrics.RecordError(partitionFailure)
+					d.log.Error("creating partitions", zap.Error(err))
+				}
+			}
+
 			err := d.cleanOlderRecords(ctx)
 			if err != nil {
 				d.log.Error("cleaning older records", zap.Error(err))
 			}
+		case <-d.quotaExceededCh:
+			if !d.quotaExceeded() {
+				continue
+			}
+			err := d.evictExceedingBytes()
+			if err != nil {
+				d.metrics.RecordError(retPolicyFailure)
+				d.log.Error("evicting records exceeding the quota", zap.Error(err))
+			}
 		}
 	}
 }
@@ -285,6 +383,12 @@ func (d *DBStore) Stop() {
 
 // Validate validates the message to be stored against possible fradulent conditions.
 func (d *DBStore) Validate(env *protocol.Envelope) error {
+	return ValidateTimestamp(env)
+}
+
+// ValidateTimestamp checks that the timestamp of a message is within the time variance
+// accepted by the message providers
+func ValidateTimestamp(env *protocol.Envelope) error {
 	timestamp := env.Message().GetTimestamp()
 	if timestamp == 0 {
 		return nil
@@ -306,10 +410,18 @@ func (d *DBStore) Validate(env *protocol.Envelope) error {
 	return nil
 }
 
-// Put inserts a WakuMessage into the DB
+// Put inserts a WakuMessage into the DB. If batched writes are enabled, the message
+// is queued to be inserted later
 func (d *DBStore) Put(env *protocol.Envelope) error {
+	if d.writeQueue != nil {
+		return d.enqueue(env)
+	}
+
+	return d.insert(env)
+}
 
-	stmt, err := d.db.Prepare("INSERT INTO message (id, messageHash, storedAt, timestamp, contentTopic, pubsubTopic, payload, version) VALUES ($1, $2, $3, $4, $5, $6, $7, $8)")
+func (d *DBStore) insert(env *protocol.Envelope) error {
+	stmt, err := d.db.Prepare(d.insertMessageQuery())
 	if err != nil {
 		d.metrics.RecordError(insertFailure)
 		return err
@@ -323,7 +435,7 @@ func (d *DBStore) Put(env *protocol.Envelope) error {
 	hash := env.Hash()
 
 	start := time.Now()
-	_, err = stmt.Exec(env.Index().Digest, hash[:], storedAt, env.Message().GetTimestamp(), env.Message().ContentTopic, env.PubsubTopic(), env.Message().Payload, env.Message().GetVersion())
+	res, err := stmt.Exec(env.Index().Digest, hash[:], storedAt, env.Message().GetTimestamp(), env.Message().ContentTopic, env.PubsubTopic(), env.Message().Payload, env.Message().GetVersion())
 	if err != nil {
 		return err
 	}
@@ -335,6 +447,19 @@ func (d *DBStore) Put(env *protocol.Envelope) error {
 		return err
 	}
 
+	inserted, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if inserted == 0 {
+		return ErrDuplicatedMessage
+	}
+
+	d.storedBytes.Add(int64(len(env.Message().Payload)))
+	if d.quotaExceeded() {
+		d.notifyQuotaExceeded()
+	}
+
 	return nil
 }
 
@@ -512,6 +637,52 @@ func (d *DBStore) MostRecentTimestamp() (int64, error) {
 	return result.Int64, nil
 }
 
+// StoredMessageHash is the hash of a message along with the time used to index it in the store
+type StoredMessageHash struct {
+	StoredAt int64
+	Hash     wpb.MessageHash
+}
+
+// MessageHashes returns the hashes of the messages stored within a time range (both ends
+// inclusive), ordered by storedAt and message hash. If pubsubTopic is empty, the hashes of
+// messages from all the pubsub topics are returned
+func (d *DBStore) MessageHashes(pubsubTopic string, start int64, end int64) ([]StoredMessageHash, error) {
+	sqlQuery := "SELECT storedAt, messageHash FROM message WHERE storedAt >= $1 AND storedAt <= $2"
+	parameters := []interface{}{start, end}
+	if pubsubTopic != "" {
+		sqlQuery += " AND pubsubTopic = $3"
+		parameters = append(parameters, pubsubTopic)
+	}
+	sqlQuery += " ORDER BY storedAt ASC, messageHash ASC"
+
+	rows, err := d.db.Query(sqlQuery, parameters...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var result []StoredMessageHash
+	for rows.Next() {
+		var storedAt int64
+		var messageHash []byte
+		err := rows.Scan(&storedAt, &messageHash)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, StoredMessageHash{
+			StoredAt: storedAt,
+			Hash:     wpb.ToMessageHash(messageHash),
+		})
+	}
+
+	err = rows.Err()
+	if err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
 // Count returns the number of rows in the message table
 func (d *DBStore) Count() (int, error) {
 	var result int
@@ -573,6 +744,17 @@ func (d *DBStore) GetStoredMessage(row *sql.Rows) (StoredMessage, error) {
 		return StoredMessage{}, err
 	}
 
+	record := StoredMessage{
+		ID:           id,
+		PubsubTopic:  pubsubTopic,
+		ReceiverTime: storedAt,
+		Message:      newWakuMessage(contentTopic, payload, timestamp, version),
+	}
+
+	return record, nil
+}
+
+func newWakuMessage(contentTopic string, payload []byte, timestamp int64, version uint32) *wpb.WakuMessage {
 	msg := new(wpb.WakuMessage)
 	msg.ContentTopic = contentTopic
 	msg.Payload = payload
@@ -585,12 +767,5 @@ func (d *DBStore) GetStoredMessage(row *sql.Rows) (StoredMessage, error) {
 		msg.Version = proto.Uint32(version)
 	}
 
-	record := StoredMessage{
-		ID:           id,
-		PubsubTopic:  pubsubTopic,
-		ReceiverTime: storedAt,
-		Message:      msg,
-	}
-
-	return record, nil
+	return msg
 }
@@ -0,0 +1,197 @@
+package persistence
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	storepb "github.com/waku-org/go-waku/waku/v2/protocol/store/pb"
+	"go.uber.org/zap"
+)
+
+func (d *DBStore) handleStoreQueryCursor(query *storepb.StoreQueryRequest, paramCnt *int, conditions []string, parameters []interface{}) ([]string, []interface{}, error) {
+	if len(query.PaginationCursor) == 0 {
+		return conditions, parameters, nil
+	}
+
+	var storedAt int64
+	err := d.db.QueryRow("SELECT storedAt FROM message WHERE messageHash = $1", query.PaginationCursor).Scan(&storedAt)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil, ErrInvalidCursor
+		}
+		return nil, nil, err
+	}
+
+	eqOp := ">"
+	if !query.PaginationForward {
+		eqOp = "<"
+	}
+	conditions = append(conditions, fmt.Sprintf("(storedAt, messageHash) %s ($%d, $%d)", eqOp, *paramCnt+1, *paramCnt+2))
+	*paramCnt += 2
+
+	parameters = append(parameters, storedAt, query.PaginationCursor)
+
+	return conditions, parameters, nil
+}
+
+func (d *DBStore) prepareStoreQuerySQL(query *storepb.StoreQueryRequest) (string, []interface{}, error) {
+	sqlQuery := `SELECT messageHash, storedAt, timestamp, contentTopic, pubsubTopic, payload, version
+	FROM message
+	%s
+	ORDER BY storedAt %s, messageHash %s `
+
+	var conditions []string
+	parameters := make([]interface{}, 0) //Allocating as a slice so that references get passed rather than value
+	paramCnt := 0
+
+	if len(query.MessageHashes) != 0 {
+		var hashPlaceHolder []string
+		for _, hash := range query.MessageHashes {
+			paramCnt++
+			hashPlaceHolder = append(hashPlaceHolder, fmt.Sprintf("$%d", paramCnt))
+			parameters = append(parameters, hash)
+		}
+		conditions = append(conditions, "messageHash IN ("+strings.Join(hashPlaceHolder, ", ")+")")
+	} else {
+		if query.GetPubsubTopic() != "" {
+			paramCnt++
+			conditions = append(conditions, fmt.Sprintf("pubsubTopic = $%d", paramCnt))
+			parameters = append(parameters, query.GetPubsubTopic())
+		}
+
+		if len(query.ContentTopics) != 0 {
+			var ctPlaceHolder []string
+			for _, ct := range query.ContentTopics {
+				paramCnt++
+				ctPlaceHolder = append(ctPlaceHolder, fmt.Sprintf("$%d", paramCnt))
+				parameters = append(parameters, ct)
+			}
+			conditions = append(conditions, "contentTopic IN ("+strings.Join(ctPlaceHolder, ", ")+")")
+		}
+
+		if query.GetTimeStart() != 0 {
+			paramCnt++
+			conditions = append(conditions, fmt.Sprintf("storedAt >= $%d", paramCnt))
+			parameters = append(parameters, query.GetTimeStart())
+		}
+
+		if query.GetTimeEnd() != 0 {
+			paramCnt++
+			conditions = append(conditions, fmt.Sprintf("storedAt <= $%d", paramCnt))
+			parameters = append(parameters, query.GetTimeEnd())
+		}
+	}
+
+	conditions, parameters, err := d.handleStoreQueryCursor(query, &paramCnt, conditions, parameters)
+	if err != nil {
+		return "", nil, err
+	}
+
+	conditionStr := ""
+	if len(conditions) != 0 {
+		conditionStr = "WHERE " + strings.Join(conditions, " AND ")
+	}
+
+	orderDirection := "ASC"
+	if !query.PaginationForward {
+		orderDirection = "DESC"
+	}
+
+	paramCnt++
+	sqlQuery += fmt.Sprintf("LIMIT $%d", paramCnt)
+	// Always search for _max page size_ + 1. If the extra row does not exist, do not return a cursor.
+	parameters = append(parameters, query.GetPaginationLimit()+1)
+
+	sqlQuery = fmt.Sprintf(sqlQuery, conditionStr, orderDirection, orderDirection)
+	d.log.Debug(fmt.Sprintf("sqlQuery: %s", sqlQuery))
+
+	return sqlQuery, parameters, nil
+}
+
+// StoreQuery retrieves the messages matching a store v3 query from the DB. The
+// returned cursor is the hash of the last message of the page, and it's nil when
+// there are no more pages to retrieve. Messages are always returned in chronological order
+func (d *DBStore) StoreQuery(query *storepb.StoreQueryRequest) ([]byte, []StoredMessage, error) {
+	start := time.Now()
+	defer func() {
+		elapsed := time.Since(start)
+		d.log.Info("loading records from the DB", zap.Duration("duration", elapsed))
+	}()
+
+	sqlQuery, parameters, err := d.prepareStoreQuerySQL(query)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	stmt, err := d.db.Prepare(sqlQuery)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer stmt.Close()
+
+	measurementStart := time.Now()
+	rows, err := stmt.Query(parameters...)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer rows.Close()
+
+	d.metrics.RecordQueryDuration(time.Since(measurementStart))
+
+	var result []StoredMessage
+	for rows.Next() {
+		record, err := d.getStoredMessageWithHash(rows)
+		if err != nil {
+			return nil, nil, err
+		}
+		result = append(result, record)
+	}
+
+	err = rows.Err()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var cursor []byte
+	if len(result) > int(query.GetPaginationLimit()) {
+		// since there are more rows than the pagination limit, we need to return a cursor
+		result = result[0:query.GetPaginationLimit()]
+		if len(result) != 0 {
+			cursor = result[len(result)-1].MessageHash
+		}
+	}
+
+	// The retrieved messages list should always be in chronological order
+	if !query.PaginationForward {
+		for i, j := 0, len(result)-1; i < j; i, j = i+1, j-1 {
+			result[i], result[j] = result[j], result[i]
+		}
+	}
+
+	return cursor, result, nil
+}
+
+func (d *DBStore) getStoredMessageWithHash(row *sql.Rows) (StoredMessage, error) {
+	var messageHash []byte
+	var storedAt int64
+	var timestamp int64
+	var contentTopic string
+	var payload []byte
+	var version uint32
+	var pubsubTopic string
+
+	err := row.Scan(&messageHash, &storedAt, &timestamp, &contentTopic, &pubsubTopic, &payload, &version)
+	if err != nil {
+		d.log.Error("scanning messages from db", zap.Error(err))
+		return StoredMessage{}, err
+	}
+
+	return StoredMessage{
+		MessageHash:  messageHash,
+		PubsubTopic:  pubsubTopic,
+		ReceiverTime: storedAt,
+		Message:      newWakuMessage(contentTopic, payload, timestamp, version),
+	}, nil
+}
@@ -23,6 +23,10 @@ func validateDBUrl(val string) error {
 // DBSettings hold db specific configuration settings required during the db initialization
 type DBSettings struct {
 	// TODO: add any DB specific setting here
+
+	// PartitionedMessages indicates that the message table should be partitioned by time.
+	// Only supported by postgres
+	PartitionedMessages bool
 }
 
 // ParseURL will return a database connection, and migration function that should be used depending on a database connection string
@@ -50,11 +54,17 @@ func ParseURL(databaseURL string, dbSettings DBSettings, logger *zap.Logger) (*s
 	dbParams := dbURLParts[1]
 	switch dbEngine {
 	case "sqlite3":
+		if dbSettings.PartitionedMessages {
+			return nil, nil, persistence.ErrPartitionsNotSupported
+		}
 		db, err = sqlite.NewDB(dbParams, logger)
 		migrationFn = sqlite.Migrations
 	case "postgres", "postgresql":
 		db, err = postgres.NewDB(dbURL, logger)
 		migrationFn = postgres.Migrations
+		if dbSettings.PartitionedMessages {
+			migrationFn = postgres.PartitionedMigrations
+		}
 	default:
 		err = errors.New("unsupported database engine")
 	}
@@ -32,6 +32,9 @@ func TestStore(t *testing.T) {
 		{"testDbStore", testDbStore},
 		{"testStoreRetention", testStoreRetention},
 		{"testQuery", testQuery},
+		{"testRetentionRules", testRetentionRules},
+		{"testRetentionMaxBytes", testRetentionMaxBytes},
+		{"testBatchedWrites", testBatchedWrites},
 	}
 	for _, driverName := range []string{"postgres", "sqlite"} {
 		// all tests are run for each db
@@ -195,3 +198,182 @@ func testQuery(t *testing.T, db *sql.DB, migrationFn func(*sql.DB, *zap.Logger)
 	require.NoError(t, err)
 	require.Equal(t, timestamp, insertTime.UnixNano())
 }
+
+func testRetentionRules(t *testing.T, db *sql.DB, migrationFn func(*sql.DB, *zap.Logger) error) {
+	store, err := persistence.NewDBStore(prometheus.DefaultRegisterer, utils.Logger(), persistence.WithDB(db), persistence.WithMigrations(migrationFn),
+		persistence.WithRetentionRules(
+			persistence.RetentionRule{Name: "telemetry", ContentTopic: "/telemetry/*", MaxAge: time.Hour},
+			persistence.RetentionRule{Name: "chat", PubsubTopic: "test", ContentTopic: "/chat/*", MaxMessages: 2},
+			persistence.RetentionRule{Name: "files", ContentTopic: "/files/*", MaxBytes: 10},
+		))
+	require.NoError(t, err)
+
+	insertTime := time.Now()
+	put := func(contentTopic string, pubsubTopic string, age time.Duration, payload string) {
+		ts := insertTime.Add(-age).UnixNano()
+		err := store.Put(protocol.NewEnvelope(tests.CreateWakuMessage(contentTopic, proto.Int64(ts), payload), ts, pubsubTopic))
+		require.NoError(t, err)
+	}
+
+	put("/telemetry/1/metrics", "test", 2*time.Hour, "")
+	put("/telemetry/1/metrics", "test", 10*time.Minute, "")
+	put("/chat/1/room", "test", 3*time.Second, "")
+	put("/chat/1/room", "test", 2*time.Second, "")
+	put("/chat/1/room", "test", 1*time.Second, "")
+	put("/chat/1/room", "other", 2*time.Hour, "") // Not matched by any rule
+	put("/files/1/upload", "test", 3*time.Second, "123456")
+	put("/files/1/upload", "test", 2*time.Second, "123456")
+	put("/files/1/upload", "test", 1*time.Second, "1234")
+
+	err = store.Start(context.Background(), timesource.NewDefaultClock())
+	require.NoError(t, err)
+	defer store.Stop()
+
+	dbResults, err := store.GetAll()
+	require.NoError(t, err)
+	require.Len(t, dbResults, 6)
+
+	count := make(map[string]int)
+	for _, r := range dbResults {
+		count[r.PubsubTopic+r.Message.ContentTopic]++
+	}
+	require.Equal(t, 1, count["test/telemetry/1/metrics"])
+	require.Equal(t, 2, count["test/chat/1/room"])
+	require.Equal(t, 1, count["other/chat/1/room"])
+	require.Equal(t, 2, count["test/files/1/upload"])
+}
+
+func testRetentionMaxBytes(t *testing.T, db *sql.DB, migrationFn func(*sql.DB, *zap.Logger) error) {
+	store, err := persistence.NewDBStore(prometheus.DefaultRegisterer, utils.Logger(), persistence.WithDB(db), persistence.WithMigrations(migrationFn), persistence.WithRetentionMaxBytes(25))
+	require.NoError(t, err)
+
+	insertTime := time.Now()
+	for i := 0; i < 5; i++ {
+		ts := insertTime.Add(time.Duration(i-5) * time.Second).UnixNano()
+		err := store.Put(protocol.NewEnvelope(tests.CreateWakuMessage(fmt.Sprintf("test%d", i), proto.Int64(ts), "0123456789"), ts, "test"))
+		require.NoError(t, err)
+	}
+
+	// Messages exceeding the quota are removed when the store starts
+	err = store.Start(context.Background(), timesource.NewDefaultClock())
+	require.NoError(t, err)
+	defer store.Stop()
+
+	require.Equal(t, int64(20), store.StoredBytes())
+
+	dbResults, err := store.GetAll()
+	require.NoError(t, err)
+	require.Len(t, dbResults, 2)
+	require.Equal(t, "test3", dbResults[0].Message.ContentTopic)
+	require.Equal(t, "test4", dbResults[1].Message.ContentTopic)
+
+	// Inserting a message that exceeds the quota evicts the oldest message
+	ts := insertTime.UnixNano()
+	err = store.Put(protocol.NewEnvelope(tests.CreateWakuMessage("test5", proto.Int64(ts), "0123456789"), ts, "test"))
+	require.NoError(t, err)
+
+	require.Eventually(t, func() bool {
+		msgCount, err := store.Count()
+		return err == nil && msgCount == 2 && store.StoredBytes() == 20
+	}, 5*time.Second, 100*time.Millisecond)
+
+	// Removing messages updates the stored bytes
+	deleted, err := store.DeleteMessages(persistence.DeleteCriteria{ContentTopics: []string{"test5"}})
+	require.NoError(t, err)
+	require.Equal(t, int64(1), deleted)
+	require.Equal(t, int64(10), store.StoredBytes())
+}
+
+func testBatchedWrites(t *testing.T, db *sql.DB, migrationFn func(*sql.DB, *zap.Logger) error) {
+	store, err := persistence.NewDBStore(prometheus.DefaultRegisterer, utils.Logger(), persistence.WithDB(db), persistence.WithMigrations(migrationFn), persistence.WithBatchedWrites(10, 200*time.Millisecond, 10))
+	require.NoError(t, err)
+
+	insertTime := time.Now()
+	var envelopes []*protocol.Envelope
+	for i := 0; i < 25; i++ {
+		ts := insertTime.Add(time.Duration(i) * time.Second).UnixNano()
+		env := protocol.NewEnvelope(tests.CreateWakuMessage("test", proto.Int64(ts), "0123456789"), ts, "test")
+		envelopes = append(envelopes, env)
+	}
+
+	// Messages are inserted immediately until the store is started
+	require.NoError(t, store.Put(envelopes[0]))
+	msgCount, err := store.Count()
+	require.NoError(t, err)
+	require.Equal(t, 1, msgCount)
+
+	err = store.Start(context.Background(), timesource.NewDefaultClock())
+	require.NoError(t, err)
+
+	for _, env := range envelopes[1:] {
+		// Messages are queued, blocking while the queue is full
+		require.NoError(t, store.Put(env))
+	}
+
+	// Queued messages are reported as duplicated, while stored ones are skipped when inserted
+	require.ErrorIs(t, store.Put(envelopes[24]), persistence.ErrDuplicatedMessage)
+	require.NoError(t, store.Put(envelopes[0]))
+
+	// The last batch is inserted once the flush interval elapses
+	require.Eventually(t, func() bool {
+		msgCount, err := store.Count()
+		return err == nil && msgCount == 25
+	}, 5*time.Second, 50*time.Millisecond)
+
+	require.Equal(t, int64(250), store.StoredBytes())
+
+	// Messages can't be queued once the store is stopped
+	store.Stop()
+	ts := insertTime.Add(time.Minute).UnixNano()
+	err = store.Put(protocol.NewEnvelope(tests.CreateWakuMessage("test", proto.Int64(ts)), ts, "test"))
+	require.ErrorIs(t, err, persistence.ErrStoreStopped)
+}
+
+func TestPartitionedStoreRetention(t *testing.T) {
+	db := postgres.NewMockPgDB()
+
+	store, err := persistence.NewDBStore(prometheus.DefaultRegisterer, utils.Logger(), persistence.WithDB(db), persistence.WithMigrations(postgres.PartitionedMigrations), persistence.WithPartitions(time.Hour, 2), persistence.WithRetentionPolicy(0, 3*time.Hour))
+	require.NoError(t, err)
+
+	// Simulate a partition created by a previous execution, whose messages have expired
+	now := time.Now()
+	oldStart := now.Add(-5 * time.Hour).Truncate(time.Hour)
+	oldPartition := fmt.Sprintf("message_%d_%d", oldStart.Unix(), oldStart.Add(time.Hour).Unix())
+	_, err = db.Exec(fmt.Sprintf("CREATE TABLE %s PARTITION OF message FOR VALUES FROM (%d) TO (%d)", oldPartition, oldStart.UnixNano(), oldStart.Add(time.Hour).UnixNano()))
+	require.NoError(t, err)
+
+	oldTime := oldStart.Add(time.Minute)
+	require.NoError(t, store.Put(protocol.NewEnvelope(tests.CreateWakuMessage("old", proto.Int64(oldTime.UnixNano())), oldTime.UnixNano(), "test")))
+
+	// Stored in the default partition, as there is no partition for this time range
+	defaultTime := now.Add(-4 * time.Hour)
+	require.NoError(t, store.Put(protocol.NewEnvelope(tests.CreateWakuMessage("default", proto.Int64(defaultTime.UnixNano())), defaultTime.UnixNano(), "test")))
+
+	err = store.Start(context.Background(), timesource.NewDefaultClock())
+	require.NoError(t, err)
+	defer store.Stop()
+
+	recent := tests.CreateWakuMessage("recent", proto.Int64(now.UnixNano()))
+	require.NoError(t, store.Put(protocol.NewEnvelope(recent, now.UnixNano(), "test")))
+
+	// Message hashes are unique, even when the same message is received at a different time
+	require.ErrorIs(t, store.Put(protocol.NewEnvelope(recent, now.Add(time.Second).UnixNano(), "test")), persistence.ErrDuplicatedMessage)
+	noTimestamp := tests.CreateWakuMessage("no-timestamp", nil)
+	require.NoError(t, store.Put(protocol.NewEnvelope(noTimestamp, now.UnixNano(), "test")))
+	require.ErrorIs(t, store.Put(protocol.NewEnvelope(noTimestamp, now.Add(time.Second).UnixNano(), "test")), persistence.ErrDuplicatedMessage)
+
+	dbResults, err := store.GetAll()
+	require.NoError(t, err)
+	require.Len(t, dbResults, 2)
+
+	// The expired partition is dropped and partitions for the current and next hours exist
+	var partitionCount int
+	err = db.QueryRow(`SELECT COUNT(*) FROM pg_inherits i JOIN pg_class p ON p.oid = i.inhparent WHERE p.relname = 'message' AND i.inhrelid::regclass::text <> 'message_default'`).Scan(&partitionCount)
+	require.NoError(t, err)
+	require.Equal(t, 3, partitionCount)
+
+	var recentPartitionCount int
+	err = db.QueryRow(fmt.Sprintf("SELECT COUNT(*) FROM message_%d_%d", now.Truncate(time.Hour).Unix(), now.Truncate(time.Hour).Add(time.Hour).Unix())).Scan(&recentPartitionCount)
+	require.NoError(t, err)
+	require.Equal(t, 1, recentPartitionCount)
+}
@@ -9,6 +9,8 @@ import (
 	"github.com/google/uuid"
 	"github.com/libp2p/go-libp2p/core/peer"
 	"github.com/libp2p/go-libp2p/p2p/net/swarm"
+	"github.com/waku-org/go-waku/waku/v2/api/common"
+	"github.com/waku-org/go-waku/waku/v2/api/history"
 	"github.com/waku-org/go-waku/waku/v2/onlinechecker"
 	"github.com/waku-org/go-waku/waku/v2/protocol"
 	"github.com/waku-org/go-waku/waku/v2/protocol/filter"
@@ -43,6 +45,7 @@ type Sub struct {
 	cancel                context.CancelFunc
 	log                   *zap.Logger
 	closing               chan string
+	resubscribed          chan struct{}
 	onlineChecker         onlinechecker.OnlineChecker
 	resubscribeInProgress bool
 	id                    string
@@ -52,6 +55,9 @@ type Sub struct {
 type subscribeParameters struct {
 	batchInterval          time.Duration
 	multiplexChannelBuffer int
+	storenodeCycle         storenodeCycle
+	storenodeRequestor     common.StorenodeRequestor
+	maxGap                 time.Duration
 }
 
 type SubscribeOptions func(*subscribeParameters)
@@ -68,10 +74,29 @@ func WithMultiplexChannelBuffer(value int) SubscribeOptions {
 	}
 }
 
+// WithGapFilling retrieves the messages pushed while the subscriptions of the FilterManager were
+// down from the store node selected by cycle, after a network change or a reconnection
+func WithGapFilling(cycle *history.StorenodeCycle, requestor common.StorenodeRequestor) SubscribeOptions {
+	return func(params *subscribeParameters) {
+		if cycle != nil {
+			params.storenodeCycle = cycle
+		}
+		params.storenodeRequestor = requestor
+	}
+}
+
+// WithMaxGap limits the period of time for which the messages missed by a subscription are retrieved
+func WithMaxGap(t time.Duration) SubscribeOptions {
+	return func(params *subscribeParameters) {
+		params.maxGap = t
+	}
+}
+
 func defaultOptions() []SubscribeOptions {
 	return []SubscribeOptions{
 		WithBatchInterval(5 * time.Second),
 		WithMultiplexChannelBuffer(100),
+		WithMaxGap(time.Hour),
 	}
 }
 
@@ -88,6 +113,7 @@ func Subscribe(ctx context.Context, wf *filter.WakuFilterLightNode, contentFilte
 	sub.log = log.Named("filter-api").With(zap.String("apisub-id", sub.id), zap.Stringer("content-filter", sub.ContentFilter))
 	sub.log.Debug("filter subscribe params", zap.Int("max-peers", config.MaxPeers))
 	sub.closing = make(chan string, config.MaxPeers)
+	sub.resubscribed = make(chan struct{}, 1)
 
 	sub.onlineChecker = wf.OnlineChecker()
 	if wf.OnlineChecker().IsOnline() {
@@ -102,6 +128,18 @@ func Subscribe(ctx context.Context, wf *filter.WakuFilterLightNode, contentFilte
 	return sub, nil
 }
 
+// Resubscribed receives a value after the subscriptions were checked, and the missing ones renewed
+func (apiSub *Sub) Resubscribed() <-chan struct{} {
+	return apiSub.resubscribed
+}
+
+func (apiSub *Sub) notifyResubscribed() {
+	select {
+	case apiSub.resubscribed <- struct{}{}:
+	default:
+	}
+}
+
 func (apiSub *Sub) Unsubscribe(contentFilter protocol.ContentFilter) {
 	defer utils.LogOnPanic()
 	_, err := apiSub.wf.Unsubscribe(apiSub.ctx, contentFilter)
@@ -122,6 +160,8 @@ func (apiSub *Sub) subscriptionLoop(loopInterval time.Duration) {
 			if apiSub.onlineChecker.IsOnline() && len(apiSub.subs) < apiSub.Config.MaxPeers &&
 				!apiSub.resubscribeInProgress && len(apiSub.closing) < apiSub.Config.MaxPeers {
 				apiSub.closing <- ""
+			} else if len(apiSub.subs) >= apiSub.Config.MaxPeers && len(apiSub.closing) == 0 {
+				apiSub.notifyResubscribed()
 			}
 		case <-apiSub.ctx.Done():
 			apiSub.log.Debug("apiSub context: done")
@@ -152,6 +192,7 @@ func (apiSub *Sub) checkAndResubscribe(subId string) {
 		apiSub.resubscribe(failedPeer)
 	}
 	apiSub.resubscribeInProgress = false
+	apiSub.notifyResubscribed()
 }
 
 func (apiSub *Sub) cleanup() {
@@ -43,6 +43,7 @@ type FilterManager struct {
 	filterConfigs          appFilterMap // map of application filterID to {aggregatedFilterID, application ContentFilter}
 	waitingToSubQueue      chan filterConfig
 	envProcessor           EnevelopeProcessor
+	gapFiller              *gapFiller
 }
 
 type SubDetails struct {
@@ -83,6 +84,9 @@ func NewFilterManager(ctx context.Context, logger *zap.Logger, minPeersPerFilter
 		opt(mgr.params)
 	}
 	mgr.filterSubBatchDuration = mgr.params.batchInterval
+	if mgr.params.storenodeCycle != nil && mgr.params.storenodeRequestor != nil {
+		mgr.gapFiller = newGapFiller(mgr.params.storenodeCycle, mgr.params.storenodeRequestor, mgr.params.maxGap, mgr.processEnvelope, logger)
+	}
 	go mgr.startFilterSubLoop()
 	return mgr
 }
@@ -168,7 +172,10 @@ func (mgr *FilterManager) subscribeAndRunLoop(f filterConfig) {
 	mgr.Unlock()
 	if err == nil {
 		mgr.logger.Debug("subscription successful, running loop", zap.String("agg-filter-id", f.ID), zap.Stringer("content-filter", f.contentFilter))
-		mgr.runFilterSubscriptionLoop(sub)
+		if mgr.gapFiller != nil {
+			mgr.gapFiller.track(f.ID)
+		}
+		mgr.runFilterSubscriptionLoop(f.ID, sub)
 	} else {
 		mgr.logger.Error("subscription fail, need to debug issue", zap.String("agg-filter-id", f.ID), zap.Stringer("content-filter", f.contentFilter), zap.Error(err))
 	}
@@ -176,8 +183,48 @@ func (mgr *FilterManager) subscribeAndRunLoop(f filterConfig) {
 
 // NetworkChange is to be invoked when there is a change in network detected by application
 // This should retrigger a ping to verify if subscriptions are fine.
+// If gap filling is enabled, the messages missed in the meantime are then retrieved from a store node.
 func (mgr *FilterManager) NetworkChange() {
 	mgr.node.PingPeers() // ping all peers to check if subscriptions are alive
+	mgr.fillGaps("")
+}
+
+// fillGaps retrieves from a store node the messages missed by the subscriptions of a pubsubTopic,
+// or by all the subscriptions if pubsubTopic is empty. The gaps are filled once the subscriptions
+// are checked and renewed, so the messages pushed until then are retrieved too
+func (mgr *FilterManager) fillGaps(pubsubTopic string) {
+	if mgr.gapFiller == nil {
+		return
+	}
+
+	mgr.Lock()
+	defer mgr.Unlock()
+	for id, af := range mgr.filterSubscriptions {
+		if af.sub == nil {
+			continue
+		}
+		if pubsubTopic != "" && pubsubTopic != af.sub.ContentFilter.PubsubTopic {
+			continue
+		}
+		mgr.gapFiller.schedule(id)
+	}
+}
+
+// fillGap retrieves from a store node the messages missed by a subscription, if requested by fillGaps
+func (mgr *FilterManager) fillGap(subID string, sub *Sub) {
+	if mgr.gapFiller == nil || !mgr.gapFiller.takeScheduled(subID) {
+		return
+	}
+
+	// Content topics are removed from the subscription when filters are unsubscribed
+	mgr.Lock()
+	contentFilter := protocol.NewContentFilter(sub.ContentFilter.PubsubTopic, sub.ContentFilter.ContentTopicsList()...)
+	mgr.Unlock()
+
+	go func() {
+		defer utils.LogOnPanic()
+		_ = mgr.gapFiller.fill(mgr.ctx, subID, contentFilter)
+	}()
 }
 
 // OnConnectionStatusChange to be triggered when connection status change is detected either from offline to online or vice-versa
@@ -188,7 +235,8 @@ func (mgr *FilterManager) OnConnectionStatusChange(pubsubTopic string, newStatus
 	mgr.logger.Debug("inside on connection status change", zap.Bool("new-status", newStatus),
 		zap.Int("agg filters count", len(mgr.filterSubscriptions)), zap.Int("filter subs count", len(subs)))
 	if newStatus && !mgr.onlineChecker.IsOnline() { // switched from offline to Online
-		mgr.NetworkChange()
+		mgr.node.PingPeers() // ping all peers to check if subscriptions are alive
+		mgr.fillGaps(pubsubTopic)
 		mgr.logger.Debug("switching from offline to online")
 		mgr.Lock()
 		if len(mgr.waitingToSubQueue) > 0 {
@@ -231,6 +279,9 @@ func (mgr *FilterManager) UnsubscribeFilter(filterID string) {
 		if len(af.sub.ContentFilter.ContentTopics) == 0 {
 			af.cancel()
 			delete(mgr.filterSubscriptions, filterConfig.ID)
+			if mgr.gapFiller != nil {
+				mgr.gapFiller.untrack(filterConfig.ID)
+			}
 		} else {
 			go af.sub.Unsubscribe(filterConfig.contentFilter)
 		}
@@ -239,18 +290,21 @@ func (mgr *FilterManager) UnsubscribeFilter(filterID string) {
 	}
 }
 
-func (mgr *FilterManager) runFilterSubscriptionLoop(sub *Sub) {
+func (mgr *FilterManager) runFilterSubscriptionLoop(subID string, sub *Sub) {
 	for {
 		select {
 		case <-mgr.ctx.Done():
 			mgr.logger.Debug("subscription loop ended", zap.Stringer("content-filter", sub.ContentFilter))
 			return
+		case <-sub.Resubscribed():
+			mgr.fillGap(subID, sub)
 		case env, ok := <-sub.DataCh:
 			if ok {
-				err := mgr.envProcessor.OnNewEnvelope(env)
-				if err != nil {
-					mgr.logger.Error("invoking onNewEnvelopes error", zap.Error(err))
+				// Messages also retrieved by the gap filler are only processed once
+				if mgr.gapFiller != nil && !mgr.gapFiller.seen(subID, env.Hash()) {
+					continue
 				}
+				mgr.processEnvelope(env)
 			} else {
 				mgr.logger.Debug("filter sub is closed", zap.Any("content-filter", sub.ContentFilter))
 				return
@@ -258,3 +312,10 @@ func (mgr *FilterManager) runFilterSubscriptionLoop(sub *Sub) {
 		}
 	}
 }
+
+func (mgr *FilterManager) processEnvelope(env *protocol.Envelope) {
+	err := mgr.envProcessor.OnNewEnvelope(env)
+	if err != nil {
+		mgr.logger.Error("invoking onNewEnvelopes error", zap.Error(err))
+	}
+}
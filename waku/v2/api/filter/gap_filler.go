@@ -0,0 +1,256 @@
+package filter
+
+import (
+	"context"
+	"encoding/hex"
+	"sync"
+	"time"
+
+	"github.com/libp2p/go-libp2p/core/peer"
+	"github.com/waku-org/go-waku/logging"
+	"github.com/waku-org/go-waku/waku/v2/api/common"
+	"github.com/waku-org/go-waku/waku/v2/api/history"
+	"github.com/waku-org/go-waku/waku/v2/protocol"
+	"github.com/waku-org/go-waku/waku/v2/protocol/pb"
+	storepb "github.com/waku-org/go-waku/waku/v2/protocol/store/pb"
+	"go.uber.org/zap"
+	"google.golang.org/protobuf/proto"
+)
+
+const gapFillMaxContentTopicsPerRequest = 10
+const gapFillPageSize = 100
+
+// gapFillMargin is subtracted from the last time a subscription received a message, to account
+// for the clock skew between the sender and this node
+const gapFillMargin = 20 * time.Second
+
+// gapFillMaxHashes is the maximum number of hashes of processed messages kept to skip duplicates
+const gapFillMaxHashes = 100_000
+
+// storenodeCycle is the part of history.StorenodeCycle used to select the store node queried
+type storenodeCycle interface {
+	GetActiveStorenode() peer.ID
+	WaitForAvailableStoreNode(ctx context.Context) bool
+	PerformStorenodeTask(fn func() error, options ...history.StorenodeTaskOption) error
+}
+
+// gapFiller retrieves from a store node the messages that were pushed while the filter
+// subscriptions were down. It records the last time each subscription received a message, and
+// the hashes of the messages processed, so the messages received twice are only processed once.
+// Gaps are filled once the subscriptions are renewed, so the messages missed until then are retrieved too
+type gapFiller struct {
+	sync.Mutex
+	cycle     storenodeCycle
+	requestor common.StorenodeRequestor
+	maxGap    time.Duration
+	process   func(env *protocol.Envelope)
+	logger    *zap.Logger
+
+	lastSeen   map[string]time.Time
+	inProgress map[string]bool
+	scheduled  map[string]bool
+
+	// hashes are added in chronological order to order, so the oldest are removed first
+	hashes map[pb.MessageHash]time.Time
+	order  []pb.MessageHash
+}
+
+func newGapFiller(cycle storenodeCycle, requestor common.StorenodeRequestor, maxGap time.Duration, process func(env *protocol.Envelope), logger *zap.Logger) *gapFiller {
+	return &gapFiller{
+		cycle:      cycle,
+		requestor:  requestor,
+		maxGap:     maxGap,
+		process:    process,
+		logger:     logger.Named("gap-filler"),
+		lastSeen:   make(map[string]time.Time),
+		inProgress: make(map[string]bool),
+		scheduled:  make(map[string]bool),
+		hashes:     make(map[pb.MessageHash]time.Time),
+	}
+}
+
+// track starts recording the last time a subscription received a message
+func (g *gapFiller) track(subID string) {
+	g.Lock()
+	defer g.Unlock()
+
+	if _, ok := g.lastSeen[subID]; !ok {
+		g.lastSeen[subID] = time.Now()
+	}
+}
+
+// untrack stops recording the last time a subscription received a message
+func (g *gapFiller) untrack(subID string) {
+	g.Lock()
+	defer g.Unlock()
+
+	delete(g.lastSeen, subID)
+	delete(g.inProgress, subID)
+	delete(g.scheduled, subID)
+}
+
+// schedule requests the gap of a tracked subscription to be filled once it's renewed
+func (g *gapFiller) schedule(subID string) {
+	g.Lock()
+	defer g.Unlock()
+
+	if _, ok := g.lastSeen[subID]; ok {
+		g.scheduled[subID] = true
+	}
+}
+
+// takeScheduled returns true, only once, if the gap of a subscription must be filled
+func (g *gapFiller) takeScheduled(subID string) bool {
+	g.Lock()
+	defer g.Unlock()
+
+	scheduled := g.scheduled[subID]
+	delete(g.scheduled, subID)
+	return scheduled
+}
+
+// seen records a message received by a subscription, and returns false if it was already processed
+func (g *gapFiller) seen(subID string, hash pb.MessageHash) bool {
+	g.Lock()
+	defer g.Unlock()
+
+	now := time.Now()
+	if subID != "" {
+		if _, ok := g.lastSeen[subID]; ok {
+			g.lastSeen[subID] = now
+		}
+	}
+
+	for len(g.order) != 0 && (len(g.order) >= gapFillMaxHashes || now.Sub(g.hashes[g.order[0]]) > g.maxGap) {
+		delete(g.hashes, g.order[0])
+		g.order = g.order[1:]
+	}
+
+	if _, ok := g.hashes[hash]; ok {
+		return false
+	}
+	g.hashes[hash] = now
+	g.order = append(g.order, hash)
+	return true
+}
+
+// fill retrieves the messages of a content filter stored since the last time a subscription
+// received a message. Nothing is done if the subscription is not tracked, or is already being filled
+func (g *gapFiller) fill(ctx context.Context, subID string, contentFilter protocol.ContentFilter) error {
+	g.Lock()
+	lastSeen, ok := g.lastSeen[subID]
+	if !ok || g.inProgress[subID] {
+		g.Unlock()
+		return nil
+	}
+	g.inProgress[subID] = true
+	g.Unlock()
+
+	defer func() {
+		g.Lock()
+		delete(g.inProgress, subID)
+		g.Unlock()
+	}()
+
+	now := time.Now()
+	from := lastSeen.Add(-gapFillMargin)
+	if now.Sub(from) > g.maxGap {
+		from = now.Add(-g.maxGap)
+	}
+
+	logger := g.logger.With(
+		zap.String("agg-filter-id", subID),
+		zap.Stringer("content-filter", contentFilter),
+		logging.Epoch("from", from),
+		logging.Epoch("to", now),
+	)
+
+	if !g.cycle.WaitForAvailableStoreNode(ctx) {
+		logger.Warn("no storenode available to fill the gap of filter subscription")
+		return nil
+	}
+
+	peerID := g.cycle.GetActiveStorenode()
+	recovered := 0
+	err := g.cycle.PerformStorenodeTask(func() error {
+		contentTopics := contentFilter.ContentTopicsList()
+		for i := 0; i < len(contentTopics); i += gapFillMaxContentTopicsPerRequest {
+			j := i + gapFillMaxContentTopicsPerRequest
+			if j > len(contentTopics) {
+				j = len(contentTopics)
+			}
+
+			n, err := g.query(ctx, peerID, contentFilter.PubsubTopic, contentTopics[i:j], from, now)
+			recovered += n
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		logger.Error("could not fill the gap of filter subscription", zap.Stringer("peerID", peerID), zap.Error(err))
+		return err
+	}
+
+	logger.Debug("filled the gap of filter subscription", zap.Stringer("peerID", peerID), zap.Int("recovered", recovered))
+
+	// Messages older than this were retrieved
+	g.Lock()
+	if t, ok := g.lastSeen[subID]; ok && t.Before(now) {
+		g.lastSeen[subID] = now
+	}
+	g.Unlock()
+
+	return nil
+}
+
+// query processes the stored messages that were not processed yet, and returns their number
+func (g *gapFiller) query(ctx context.Context, peerID peer.ID, pubsubTopic string, contentTopics []string, from time.Time, to time.Time) (int, error) {
+	ctx, cancel := context.WithTimeout(ctx, common.DefaultStoreQueryTimeout)
+	defer cancel()
+
+	storeQueryRequest := &storepb.StoreQueryRequest{
+		RequestId:         hex.EncodeToString(protocol.GenerateRequestID()),
+		IncludeData:       true,
+		PubsubTopic:       proto.String(pubsubTopic),
+		ContentTopics:     contentTopics,
+		TimeStart:         proto.Int64(from.UnixNano()),
+		TimeEnd:           proto.Int64(to.UnixNano()),
+		PaginationForward: true,
+		PaginationLimit:   proto.Uint64(gapFillPageSize),
+	}
+
+	result, err := g.requestor.Query(ctx, peerID, storeQueryRequest)
+	if err != nil {
+		return 0, err
+	}
+
+	recovered := 0
+	for !result.IsComplete() {
+		for _, mkv := range result.Messages() {
+			if mkv.Message == nil {
+				continue
+			}
+
+			envPubsubTopic := mkv.GetPubsubTopic()
+			if envPubsubTopic == "" {
+				envPubsubTopic = pubsubTopic
+			}
+
+			env := protocol.NewEnvelope(mkv.Message, mkv.Message.GetTimestamp(), envPubsubTopic)
+			if !g.seen("", env.Hash()) {
+				continue
+			}
+
+			g.process(env)
+			recovered++
+		}
+
+		if err := result.Next(ctx); err != nil {
+			return recovered, err
+		}
+	}
+
+	return recovered, nil
+}
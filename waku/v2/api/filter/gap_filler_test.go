@@ -0,0 +1,183 @@
+package filter
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/libp2p/go-libp2p/core/peer"
+	"github.com/stretchr/testify/require"
+	"github.com/waku-org/go-waku/waku/v2/api/common"
+	"github.com/waku-org/go-waku/waku/v2/api/history"
+	"github.com/waku-org/go-waku/waku/v2/protocol"
+	"github.com/waku-org/go-waku/waku/v2/protocol/pb"
+	"github.com/waku-org/go-waku/waku/v2/protocol/store"
+	storepb "github.com/waku-org/go-waku/waku/v2/protocol/store/pb"
+	"github.com/waku-org/go-waku/waku/v2/utils"
+	"google.golang.org/protobuf/proto"
+)
+
+type mockStorenodeCycle struct {
+	peerID peer.ID
+}
+
+func (c *mockStorenodeCycle) GetActiveStorenode() peer.ID {
+	return c.peerID
+}
+
+func (c *mockStorenodeCycle) WaitForAvailableStoreNode(ctx context.Context) bool {
+	return true
+}
+
+func (c *mockStorenodeCycle) PerformStorenodeTask(fn func() error, options ...history.StorenodeTaskOption) error {
+	return fn()
+}
+
+// mockResult returns a message per page
+type mockResult struct {
+	peerID   peer.ID
+	messages []*storepb.WakuMessageKeyValue
+	done     bool
+}
+
+func (r *mockResult) Cursor() []byte {
+	return nil
+}
+
+func (r *mockResult) IsComplete() bool {
+	return r.done
+}
+
+func (r *mockResult) PeerID() peer.ID {
+	return r.peerID
+}
+
+func (r *mockResult) Next(ctx context.Context, opts ...store.RequestOption) error {
+	if len(r.messages) <= 1 {
+		r.messages = nil
+		r.done = true
+		return nil
+	}
+	r.messages = r.messages[1:]
+	return nil
+}
+
+func (r *mockResult) Messages() []*storepb.WakuMessageKeyValue {
+	if len(r.messages) == 0 {
+		return nil
+	}
+	return r.messages[:1]
+}
+
+type mockStorenodeRequestor struct {
+	sync.Mutex
+	messages []*pb.WakuMessage
+	requests []*storepb.StoreQueryRequest
+}
+
+func (r *mockStorenodeRequestor) Query(ctx context.Context, peerID peer.ID, query *storepb.StoreQueryRequest) (common.StoreRequestResult, error) {
+	r.Lock()
+	defer r.Unlock()
+	r.requests = append(r.requests, query)
+
+	result := &mockResult{peerID: peerID}
+	for _, msg := range r.messages {
+		result.messages = append(result.messages, &storepb.WakuMessageKeyValue{
+			MessageHash: msg.Hash(query.GetPubsubTopic()).Bytes(),
+			Message:     msg,
+			PubsubTopic: query.PubsubTopic,
+		})
+	}
+	return result, nil
+}
+
+func TestGapFiller(t *testing.T) {
+	pubsubTopic := "/waku/2/rs/16/32"
+	var contentTopics []string
+	for i := 0; i < 12; i++ {
+		contentTopics = append(contentTopics, fmt.Sprintf("/test/1/gap-%d/proto", i))
+	}
+	contentFilter := protocol.NewContentFilter(pubsubTopic, contentTopics...)
+
+	msg1 := &pb.WakuMessage{Payload: []byte{1}, ContentTopic: contentTopics[0], Timestamp: utils.GetUnixEpoch()}
+	msg2 := &pb.WakuMessage{Payload: []byte{2}, ContentTopic: contentTopics[11], Timestamp: utils.GetUnixEpoch()}
+
+	requestor := &mockStorenodeRequestor{messages: []*pb.WakuMessage{msg1, msg2}}
+
+	var processed []*protocol.Envelope
+	g := newGapFiller(&mockStorenodeCycle{peerID: "storenode"}, requestor, time.Hour, func(env *protocol.Envelope) {
+		processed = append(processed, env)
+	}, utils.Logger())
+
+	// Subscriptions that are not tracked are not filled
+	require.NoError(t, g.fill(context.Background(), "sub", contentFilter))
+	require.Empty(t, requestor.requests)
+
+	g.track("sub")
+
+	// Messages are only processed once
+	env1 := protocol.NewEnvelope(msg1, *utils.GetUnixEpoch(), pubsubTopic)
+	require.True(t, g.seen("sub", env1.Hash()))
+	require.False(t, g.seen("sub", env1.Hash()))
+
+	start := time.Now()
+	require.NoError(t, g.fill(context.Background(), "sub", contentFilter))
+
+	// Content topics are split between requests, and the messages already received are skipped
+	require.Len(t, requestor.requests, 2)
+	require.Len(t, requestor.requests[0].ContentTopics, 10)
+	require.Len(t, requestor.requests[1].ContentTopics, 2)
+	for _, request := range requestor.requests {
+		require.Equal(t, pubsubTopic, request.GetPubsubTopic())
+		require.True(t, request.IncludeData)
+		require.LessOrEqual(t, request.GetTimeStart(), start.Add(-gapFillMargin).UnixNano())
+	}
+	require.Len(t, processed, 1)
+	require.Equal(t, msg2.Hash(pubsubTopic), processed[0].Hash())
+
+	// The live copy of a recovered message is skipped
+	env2 := protocol.NewEnvelope(proto.Clone(msg2).(*pb.WakuMessage), *utils.GetUnixEpoch(), pubsubTopic)
+	require.False(t, g.seen("sub", env2.Hash()))
+
+	// The gap is limited to the maximum duration
+	g.Lock()
+	g.lastSeen["sub"] = time.Now().Add(-2 * time.Hour)
+	g.Unlock()
+	requestor.requests = nil
+	start = time.Now()
+	require.NoError(t, g.fill(context.Background(), "sub", contentFilter))
+	require.Len(t, requestor.requests, 2)
+	require.GreaterOrEqual(t, requestor.requests[0].GetTimeStart(), start.Add(-time.Hour).UnixNano())
+	require.Len(t, processed, 1)
+
+	// The gap starts after the last fill
+	require.GreaterOrEqual(t, g.lastSeen["sub"].UnixNano(), start.UnixNano())
+
+	g.untrack("sub")
+	requestor.requests = nil
+	require.NoError(t, g.fill(context.Background(), "sub", contentFilter))
+	require.Empty(t, requestor.requests)
+}
+
+func TestGapFillerScheduleAndHashes(t *testing.T) {
+	g := newGapFiller(&mockStorenodeCycle{peerID: "storenode"}, &mockStorenodeRequestor{}, time.Hour, func(env *protocol.Envelope) {}, utils.Logger())
+
+	// Only the gaps of tracked subscriptions are scheduled, and they're filled once
+	g.schedule("sub")
+	require.False(t, g.takeScheduled("sub"))
+	g.track("sub")
+	g.schedule("sub")
+	require.True(t, g.takeScheduled("sub"))
+	require.False(t, g.takeScheduled("sub"))
+
+	// The number of hashes kept is limited, and the oldest are removed first
+	for i := 0; i <= gapFillMaxHashes; i++ {
+		var hash pb.MessageHash
+		hash[0], hash[1], hash[2] = byte(i), byte(i>>8), byte(i>>16)
+		require.True(t, g.seen("sub", hash))
+	}
+	require.Len(t, g.hashes, gapFillMaxHashes)
+	require.True(t, g.seen("sub", pb.MessageHash{}))
+}
@@ -0,0 +1,91 @@
+package segmentation
+
+import (
+	"time"
+
+	"github.com/waku-org/go-waku/waku/v2/utils"
+	"go.uber.org/zap"
+)
+
+// DefaultSegmentSize is the maximum size of the payload of each segment. It leaves room for the
+// segment metadata and the rest of the message within the default max-msg-size of 150KB
+const DefaultSegmentSize = 100 * 1024
+
+// DefaultParityRatio is the number of parity segments per data segment
+const DefaultParityRatio = 0.125
+
+// DefaultTimeout is the time after which an incomplete message is discarded
+const DefaultTimeout = 10 * time.Minute
+
+// DefaultMaxPartialSize is the maximum number of bytes of the segments of incomplete messages
+const DefaultMaxPartialSize = 64 * 1024 * 1024
+
+type segmentParams struct {
+	segmentSize int
+	parityRatio float64
+}
+
+// SegmentOption is an optional setting used when splitting a message
+type SegmentOption func(*segmentParams)
+
+// WithSegmentSize sets the maximum size of the payload of each segment
+func WithSegmentSize(size int) SegmentOption {
+	return func(params *segmentParams) {
+		params.segmentSize = size
+	}
+}
+
+// WithParityRatio sets the number of parity segments per data segment. The message can be
+// reassembled as long as the number of segments lost is not greater than the number of parity
+// segments. Use 0 to disable the parity segments
+func WithParityRatio(ratio float64) SegmentOption {
+	return func(params *segmentParams) {
+		params.parityRatio = ratio
+	}
+}
+
+func defaultSegmentOptions() []SegmentOption {
+	return []SegmentOption{
+		WithSegmentSize(DefaultSegmentSize),
+		WithParityRatio(DefaultParityRatio),
+	}
+}
+
+type reassemblerParams struct {
+	timeout        time.Duration
+	maxPartialSize int
+	log            *zap.Logger
+}
+
+// ReassemblerOption is an optional setting of the Reassembler
+type ReassemblerOption func(*reassemblerParams)
+
+// WithTimeout sets the time after which an incomplete message is discarded
+func WithTimeout(timeout time.Duration) ReassemblerOption {
+	return func(params *reassemblerParams) {
+		params.timeout = timeout
+	}
+}
+
+// WithMaxPartialSize limits the memory used by the segments of incomplete messages. The oldest
+// incomplete messages are discarded when the limit is exceeded
+func WithMaxPartialSize(size int) ReassemblerOption {
+	return func(params *reassemblerParams) {
+		params.maxPartialSize = size
+	}
+}
+
+// WithLogger sets the logger of the Reassembler
+func WithLogger(log *zap.Logger) ReassemblerOption {
+	return func(params *reassemblerParams) {
+		params.log = log
+	}
+}
+
+func defaultReassemblerOptions() []ReassemblerOption {
+	return []ReassemblerOption{
+		WithTimeout(DefaultTimeout),
+		WithMaxPartialSize(DefaultMaxPartialSize),
+		WithLogger(utils.Logger()),
+	}
+}
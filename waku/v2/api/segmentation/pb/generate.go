@@ -0,0 +1,3 @@
+package pb
+
+//go:generate protoc -I. --go_opt=paths=source_relative --go_opt=Msegment.proto=github.com/waku-org/go-waku/waku/v2/api/segmentation/pb --go_out=. ./segment.proto
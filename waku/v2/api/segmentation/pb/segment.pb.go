@@ -0,0 +1,200 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.34.2
+// 	protoc        v3.21.12
+// source: segment.proto
+
+package pb
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	reflect "reflect"
+	sync "sync"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+// SegmentMessage carries one segment of a larger payload. It is encoded in the payload of a
+// WakuMessage after the "WSEG" magic and a version byte
+type SegmentMessage struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	// SHA256 of the entire payload
+	EntireMessageHash []byte `protobuf:"bytes,1,opt,name=entire_message_hash,json=entireMessageHash,proto3" json:"entire_message_hash,omitempty"`
+	// Index of the segment. Data segments come first, followed by the parity segments
+	Index               uint32 `protobuf:"varint,2,opt,name=index,proto3" json:"index,omitempty"`
+	DataSegmentsCount   uint32 `protobuf:"varint,3,opt,name=data_segments_count,json=dataSegmentsCount,proto3" json:"data_segments_count,omitempty"`
+	ParitySegmentsCount uint32 `protobuf:"varint,4,opt,name=parity_segments_count,json=paritySegmentsCount,proto3" json:"parity_segments_count,omitempty"`
+	// Size of the entire payload, used to remove the padding of the last data segment
+	PayloadSize uint64 `protobuf:"varint,5,opt,name=payload_size,json=payloadSize,proto3" json:"payload_size,omitempty"`
+	Payload     []byte `protobuf:"bytes,6,opt,name=payload,proto3" json:"payload,omitempty"`
+}
+
+func (x *SegmentMessage) Reset() {
+	*x = SegmentMessage{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_segment_proto_msgTypes[0]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *SegmentMessage) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SegmentMessage) ProtoMessage() {}
+
+func (x *SegmentMessage) ProtoReflect() protoreflect.Message {
+	mi := &file_segment_proto_msgTypes[0]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SegmentMessage.ProtoReflect.Descriptor instead.
+func (*SegmentMessage) Descriptor() ([]byte, []int) {
+	return file_segment_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *SegmentMessage) GetEntireMessageHash() []byte {
+	if x != nil {
+		return x.EntireMessageHash
+	}
+	return nil
+}
+
+func (x *SegmentMessage) GetIndex() uint32 {
+	if x != nil {
+		return x.Index
+	}
+	return 0
+}
+
+func (x *SegmentMessage) GetDataSegmentsCount() uint32 {
+	if x != nil {
+		return x.DataSegmentsCount
+	}
+	return 0
+}
+
+func (x *SegmentMessage) GetParitySegmentsCount() uint32 {
+	if x != nil {
+		return x.ParitySegmentsCount
+	}
+	return 0
+}
+
+func (x *SegmentMessage) GetPayloadSize() uint64 {
+	if x != nil {
+		return x.PayloadSize
+	}
+	return 0
+}
+
+func (x *SegmentMessage) GetPayload() []byte {
+	if x != nil {
+		return x.Payload
+	}
+	return nil
+}
+
+var File_segment_proto protoreflect.FileDescriptor
+
+var file_segment_proto_rawDesc = []byte{
+	0x0a, 0x0d, 0x73, 0x65, 0x67, 0x6d, 0x65, 0x6e, 0x74, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x12,
+	0x14, 0x77, 0x61, 0x6b, 0x75, 0x2e, 0x73, 0x65, 0x67, 0x6d, 0x65, 0x6e, 0x74, 0x61, 0x74, 0x69,
+	0x6f, 0x6e, 0x2e, 0x76, 0x31, 0x22, 0xf7, 0x01, 0x0a, 0x0e, 0x53, 0x65, 0x67, 0x6d, 0x65, 0x6e,
+	0x74, 0x4d, 0x65, 0x73, 0x73, 0x61, 0x67, 0x65, 0x12, 0x2e, 0x0a, 0x13, 0x65, 0x6e, 0x74, 0x69,
+	0x72, 0x65, 0x5f, 0x6d, 0x65, 0x73, 0x73, 0x61, 0x67, 0x65, 0x5f, 0x68, 0x61, 0x73, 0x68, 0x18,
+	0x01, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x11, 0x65, 0x6e, 0x74, 0x69, 0x72, 0x65, 0x4d, 0x65, 0x73,
+	0x73, 0x61, 0x67, 0x65, 0x48, 0x61, 0x73, 0x68, 0x12, 0x14, 0x0a, 0x05, 0x69, 0x6e, 0x64, 0x65,
+	0x78, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0d, 0x52, 0x05, 0x69, 0x6e, 0x64, 0x65, 0x78, 0x12, 0x2e,
+	0x0a, 0x13, 0x64, 0x61, 0x74, 0x61, 0x5f, 0x73, 0x65, 0x67, 0x6d, 0x65, 0x6e, 0x74, 0x73, 0x5f,
+	0x63, 0x6f, 0x75, 0x6e, 0x74, 0x18, 0x03, 0x20, 0x01, 0x28, 0x0d, 0x52, 0x11, 0x64, 0x61, 0x74,
+	0x61, 0x53, 0x65, 0x67, 0x6d, 0x65, 0x6e, 0x74, 0x73, 0x43, 0x6f, 0x75, 0x6e, 0x74, 0x12, 0x32,
+	0x0a, 0x15, 0x70, 0x61, 0x72, 0x69, 0x74, 0x79, 0x5f, 0x73, 0x65, 0x67, 0x6d, 0x65, 0x6e, 0x74,
+	0x73, 0x5f, 0x63, 0x6f, 0x75, 0x6e, 0x74, 0x18, 0x04, 0x20, 0x01, 0x28, 0x0d, 0x52, 0x13, 0x70,
+	0x61, 0x72, 0x69, 0x74, 0x79, 0x53, 0x65, 0x67, 0x6d, 0x65, 0x6e, 0x74, 0x73, 0x43, 0x6f, 0x75,
+	0x6e, 0x74, 0x12, 0x21, 0x0a, 0x0c, 0x70, 0x61, 0x79, 0x6c, 0x6f, 0x61, 0x64, 0x5f, 0x73, 0x69,
+	0x7a, 0x65, 0x18, 0x05, 0x20, 0x01, 0x28, 0x04, 0x52, 0x0b, 0x70, 0x61, 0x79, 0x6c, 0x6f, 0x61,
+	0x64, 0x53, 0x69, 0x7a, 0x65, 0x12, 0x18, 0x0a, 0x07, 0x70, 0x61, 0x79, 0x6c, 0x6f, 0x61, 0x64,
+	0x18, 0x06, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x07, 0x70, 0x61, 0x79, 0x6c, 0x6f, 0x61, 0x64, 0x62,
+	0x06, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x33,
+}
+
+var (
+	file_segment_proto_rawDescOnce sync.Once
+	file_segment_proto_rawDescData = file_segment_proto_rawDesc
+)
+
+func file_segment_proto_rawDescGZIP() []byte {
+	file_segment_proto_rawDescOnce.Do(func() {
+		file_segment_proto_rawDescData = protoimpl.X.CompressGZIP(file_segment_proto_rawDescData)
+	})
+	return file_segment_proto_rawDescData
+}
+
+var file_segment_proto_msgTypes = make([]protoimpl.MessageInfo, 1)
+var file_segment_proto_goTypes = []any{
+	(*SegmentMessage)(nil), // 0: waku.segmentation.v1.SegmentMessage
+}
+var file_segment_proto_depIdxs = []int32{
+	0, // [0:0] is the sub-list for method output_type
+	0, // [0:0] is the sub-list for method input_type
+	0, // [0:0] is the sub-list for extension type_name
+	0, // [0:0] is the sub-list for extension extendee
+	0, // [0:0] is the sub-list for field type_name
+}
+
+func init() { file_segment_proto_init() }
+func file_segment_proto_init() {
+	if File_segment_proto != nil {
+		return
+	}
+	if !protoimpl.UnsafeEnabled {
+		file_segment_proto_msgTypes[0].Exporter = func(v any, i int) any {
+			switch v := v.(*SegmentMessage); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: file_segment_proto_rawDesc,
+			NumEnums:      0,
+			NumMessages:   1,
+			NumExtensions: 0,
+			NumServices:   0,
+		},
+		GoTypes:           file_segment_proto_goTypes,
+		DependencyIndexes: file_segment_proto_depIdxs,
+		MessageInfos:      file_segment_proto_msgTypes,
+	}.Build()
+	File_segment_proto = out.File
+	file_segment_proto_rawDesc = nil
+	file_segment_proto_goTypes = nil
+	file_segment_proto_depIdxs = nil
+}
@@ -0,0 +1,249 @@
+package segmentation
+
+import (
+	"context"
+	"encoding/hex"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/waku-org/go-waku/logging"
+	"github.com/waku-org/go-waku/waku/v2/protocol"
+	"github.com/waku-org/go-waku/waku/v2/protocol/pb"
+	"github.com/waku-org/go-waku/waku/v2/protocol/store"
+	"github.com/waku-org/go-waku/waku/v2/utils"
+	"go.uber.org/zap"
+)
+
+type partialMessage struct {
+	pubsubTopic string
+	// message whose fields, except the payload, are used for the reassembled message
+	template  *pb.WakuMessage
+	shards    [][]byte
+	received  int
+	size      int
+	firstSeen time.Time
+}
+
+// Reassembler rebuilds the messages split with Segment from their segments, which can be
+// received via relay, filter or store
+type Reassembler struct {
+	sync.Mutex
+	params *reassemblerParams
+	now    func() time.Time
+
+	partial     map[string]*partialMessage
+	partialSize int
+	// completed contains the messages already reassembled, so the segments received afterwards
+	// are ignored
+	completed map[string]time.Time
+}
+
+// NewReassembler creates a Reassembler
+func NewReassembler(opts ...ReassemblerOption) *Reassembler {
+	params := new(reassemblerParams)
+	for _, opt := range append(defaultReassemblerOptions(), opts...) {
+		opt(params)
+	}
+
+	return &Reassembler{
+		params:    params,
+		now:       time.Now,
+		partial:   make(map[string]*partialMessage),
+		completed: make(map[string]time.Time),
+	}
+}
+
+// Add processes a received message. It returns the reassembled message once enough segments
+// have been received, and nil while the message is incomplete. Messages that are not segments
+// are returned unchanged
+func (r *Reassembler) Add(env *protocol.Envelope) (*protocol.Envelope, error) {
+	segment, err := DecodeSegment(env.Message())
+	if err != nil {
+		return env, nil
+	}
+
+	r.Lock()
+	defer r.Unlock()
+
+	now := r.now()
+	r.prune(now)
+
+	completedKey := env.PubsubTopic() + "/" + hex.EncodeToString(segment.EntireMessageHash)
+	if _, ok := r.completed[completedKey]; ok {
+		return nil, nil
+	}
+
+	// The segments are grouped by all their attributes, so a segment forged with the hash of a
+	// message but different counts or sizes doesn't prevent the reassembly of the real segments
+	key := fmt.Sprintf("%s/%d/%d/%d/%d", completedKey, segment.DataSegmentsCount, segment.ParitySegmentsCount, segment.PayloadSize, len(segment.Payload))
+
+	total := int(segment.DataSegmentsCount + segment.ParitySegmentsCount)
+	msg, ok := r.partial[key]
+	if !ok {
+		msg = &partialMessage{
+			pubsubTopic: env.PubsubTopic(),
+			template:    env.Message(),
+			shards:      make([][]byte, total),
+			firstSeen:   now,
+		}
+		r.partial[key] = msg
+	}
+
+	if msg.shards[segment.Index] != nil {
+		return nil, nil
+	}
+	msg.shards[segment.Index] = segment.Payload
+	msg.received++
+	msg.size += len(segment.Payload)
+	r.partialSize += len(segment.Payload)
+
+	if msg.received < int(segment.DataSegmentsCount) {
+		r.evict(key)
+		return nil, nil
+	}
+
+	r.remove(key)
+	payload, err := reassemble(segment, msg.shards)
+	if err != nil {
+		return nil, err
+	}
+	r.completed[completedKey] = now
+
+	result := &pb.WakuMessage{
+		Payload:      payload,
+		ContentTopic: msg.template.ContentTopic,
+		Version:      msg.template.Version,
+		Timestamp:    msg.template.Timestamp,
+		Ephemeral:    msg.template.Ephemeral,
+	}
+	return protocol.NewEnvelope(result, env.Index().ReceiverTime, msg.pubsubTopic), nil
+}
+
+// AddStoreResult processes the messages of a page of store results, and returns the messages
+// reassembled and the messages that are not segments
+func (r *Reassembler) AddStoreResult(result store.Result) []*protocol.Envelope {
+	var envelopes []*protocol.Envelope
+	for _, kv := range result.Messages() {
+		if kv.Message == nil {
+			continue
+		}
+
+		pubsubTopic := kv.GetPubsubTopic()
+		if pubsubTopic == "" {
+			pubsubTopic = result.Query().GetPubsubTopic()
+		}
+
+		env, err := r.Add(protocol.NewEnvelope(kv.Message, *utils.GetUnixEpoch(), pubsubTopic))
+		if err != nil {
+			r.params.log.Warn("reassembling message", logging.HexBytes("messageHash", kv.MessageHash), zap.Error(err))
+			continue
+		}
+		if env != nil {
+			envelopes = append(envelopes, env)
+		}
+	}
+	return envelopes
+}
+
+// Run reassembles the messages received on a channel, such as the channel of a relay or filter
+// subscription. The returned channel is closed when the input channel is closed or the context
+// is cancelled
+func (r *Reassembler) Run(ctx context.Context, in <-chan *protocol.Envelope) <-chan *protocol.Envelope {
+	out := make(chan *protocol.Envelope, cap(in))
+
+	go func() {
+		defer utils.LogOnPanic()
+		defer close(out)
+
+		ticker := time.NewTicker(r.params.timeout / 2)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				r.Prune()
+			case env, ok := <-in:
+				if !ok {
+					return
+				}
+
+				result, err := r.Add(env)
+				if err != nil {
+					r.params.log.Warn("reassembling message", logging.Hash(env.Hash()), zap.Error(err))
+					continue
+				}
+				if result == nil {
+					continue
+				}
+
+				select {
+				case out <- result:
+				case <-ctx.Done():
+					return
+				}
+			}
+		}
+	}()
+
+	return out
+}
+
+// Prune discards the incomplete messages older than the timeout
+func (r *Reassembler) Prune() {
+	r.Lock()
+	defer r.Unlock()
+	r.prune(r.now())
+}
+
+// PartialSize returns the number of bytes used by the segments of incomplete messages
+func (r *Reassembler) PartialSize() int {
+	r.Lock()
+	defer r.Unlock()
+	return r.partialSize
+}
+
+func (r *Reassembler) prune(now time.Time) {
+	for key, msg := range r.partial {
+		if now.Sub(msg.firstSeen) > r.params.timeout {
+			r.params.log.Debug("incomplete message expired", zap.String("pubsubTopic", msg.pubsubTopic), zap.Int("segments", msg.received))
+			r.remove(key)
+		}
+	}
+	for key, t := range r.completed {
+		if now.Sub(t) > r.params.timeout {
+			delete(r.completed, key)
+		}
+	}
+}
+
+// evict discards the oldest incomplete messages, other than the one being added, while the
+// segments use more memory than allowed. If that's not enough the message being added is discarded
+func (r *Reassembler) evict(current string) {
+	for r.partialSize > r.params.maxPartialSize {
+		oldest := ""
+		for key, msg := range r.partial {
+			if key != current && (oldest == "" || msg.firstSeen.Before(r.partial[oldest].firstSeen)) {
+				oldest = key
+			}
+		}
+		if oldest == "" {
+			oldest = current
+		}
+
+		r.params.log.Debug("incomplete message discarded", zap.String("pubsubTopic", r.partial[oldest].pubsubTopic), zap.Int("partialSize", r.partialSize))
+		r.remove(oldest)
+		if oldest == current {
+			return
+		}
+	}
+}
+
+func (r *Reassembler) remove(key string) {
+	if msg, ok := r.partial[key]; ok {
+		r.partialSize -= msg.size
+		delete(r.partial, key)
+	}
+}
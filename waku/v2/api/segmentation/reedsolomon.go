@@ -0,0 +1,194 @@
+package segmentation
+
+import (
+	"errors"
+)
+
+// Reed-Solomon erasure code over GF(2^8). The first dataShards shards are the data itself, and
+// the parity shards are computed with a Cauchy matrix, so any dataShards of the shards are
+// enough to recover the data
+
+var errTooFewShards = errors.New("too few shards to reconstruct the data")
+
+const gfPolynomial = 0x11d
+
+var gfExp [510]byte
+var gfLog [256]byte
+
+func init() {
+	x := 1
+	for i := 0; i < 255; i++ {
+		gfExp[i] = byte(x)
+		gfLog[x] = byte(i)
+		x <<= 1
+		if x&0x100 != 0 {
+			x ^= gfPolynomial
+		}
+	}
+	for i := 255; i < len(gfExp); i++ {
+		gfExp[i] = gfExp[i-255]
+	}
+}
+
+func gfMul(a, b byte) byte {
+	if a == 0 || b == 0 {
+		return 0
+	}
+	return gfExp[int(gfLog[a])+int(gfLog[b])]
+}
+
+// gfInv must not be called with 0
+func gfInv(a byte) byte {
+	return gfExp[255-int(gfLog[a])]
+}
+
+// mulAdd computes dst += c * src
+func mulAdd(dst []byte, c byte, src []byte) {
+	if c == 0 {
+		return
+	}
+	logC := int(gfLog[c])
+	for i, b := range src {
+		if b != 0 {
+			dst[i] ^= gfExp[logC+int(gfLog[b])]
+		}
+	}
+}
+
+type reedSolomon struct {
+	dataShards   int
+	parityShards int
+	// coefficients of the parity shards, parityShards x dataShards
+	parity [][]byte
+}
+
+func newReedSolomon(dataShards int, parityShards int) (*reedSolomon, error) {
+	if dataShards <= 0 || parityShards < 0 || dataShards+parityShards > MaxSegments {
+		return nil, ErrTooManySegments
+	}
+
+	rs := &reedSolomon{
+		dataShards:   dataShards,
+		parityShards: parityShards,
+		parity:       make([][]byte, parityShards),
+	}
+	// Cauchy matrix 1 / (x_i + y_j) with x_i = dataShards + i and y_j = j
+	for i := range rs.parity {
+		rs.parity[i] = make([]byte, dataShards)
+		for j := range rs.parity[i] {
+			rs.parity[i][j] = gfInv(byte(dataShards+i) ^ byte(j))
+		}
+	}
+	return rs, nil
+}
+
+// row returns the coefficients of a shard in terms of the data shards
+func (rs *reedSolomon) row(index int) []byte {
+	if index < rs.dataShards {
+		row := make([]byte, rs.dataShards)
+		row[index] = 1
+		return row
+	}
+	return rs.parity[index-rs.dataShards]
+}
+
+// encode computes the parity shards. All the shards must have the same size
+func (rs *reedSolomon) encode(shards [][]byte) {
+	for i, coefficients := range rs.parity {
+		parity := shards[rs.dataShards+i]
+		for j := range parity {
+			parity[j] = 0
+		}
+		for j, c := range coefficients {
+			mulAdd(parity, c, shards[j])
+		}
+	}
+}
+
+// reconstruct fills the missing data shards, which are nil, from the available shards
+func (rs *reedSolomon) reconstruct(shards [][]byte) error {
+	var present []int
+	missingData := false
+	for i, shard := range shards {
+		if shard != nil {
+			if len(present) < rs.dataShards {
+				present = append(present, i)
+			}
+		} else if i < rs.dataShards {
+			missingData = true
+		}
+	}
+
+	if !missingData {
+		return nil
+	}
+	if len(present) < rs.dataShards {
+		return errTooFewShards
+	}
+
+	matrix := make([][]byte, rs.dataShards)
+	for i, index := range present {
+		matrix[i] = append([]byte(nil), rs.row(index)...)
+	}
+
+	inverse, err := invert(matrix)
+	if err != nil {
+		return err
+	}
+
+	size := len(shards[present[0]])
+	for i := 0; i < rs.dataShards; i++ {
+		if shards[i] != nil {
+			continue
+		}
+		shard := make([]byte, size)
+		for j, index := range present {
+			mulAdd(shard, inverse[i][j], shards[index])
+		}
+		shards[i] = shard
+	}
+
+	return nil
+}
+
+// invert computes the inverse of a square matrix with Gauss-Jordan elimination
+func invert(matrix [][]byte) ([][]byte, error) {
+	n := len(matrix)
+	inverse := make([][]byte, n)
+	for i := range inverse {
+		inverse[i] = make([]byte, n)
+		inverse[i][i] = 1
+	}
+
+	for col := 0; col < n; col++ {
+		pivot := -1
+		for row := col; row < n; row++ {
+			if matrix[row][col] != 0 {
+				pivot = row
+				break
+			}
+		}
+		if pivot == -1 {
+			return nil, errors.New("singular matrix")
+		}
+		matrix[col], matrix[pivot] = matrix[pivot], matrix[col]
+		inverse[col], inverse[pivot] = inverse[pivot], inverse[col]
+
+		c := gfInv(matrix[col][col])
+		for j := 0; j < n; j++ {
+			matrix[col][j] = gfMul(matrix[col][j], c)
+			inverse[col][j] = gfMul(inverse[col][j], c)
+		}
+
+		for row := 0; row < n; row++ {
+			if row == col || matrix[row][col] == 0 {
+				continue
+			}
+			f := matrix[row][col]
+			mulAdd(matrix[row], f, matrix[col])
+			mulAdd(inverse[row], f, inverse[col])
+		}
+	}
+
+	return inverse, nil
+}
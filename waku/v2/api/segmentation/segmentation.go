@@ -0,0 +1,196 @@
+package segmentation
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"math"
+
+	"github.com/waku-org/go-waku/waku/v2/api/publish"
+	segmentpb "github.com/waku-org/go-waku/waku/v2/api/segmentation/pb"
+	"github.com/waku-org/go-waku/waku/v2/protocol"
+	"github.com/waku-org/go-waku/waku/v2/protocol/pb"
+	"google.golang.org/protobuf/proto"
+)
+
+// MaxSegments is the maximum number of data and parity segments of a message
+const MaxSegments = 256
+
+// segmentMagic prefixes the payload of the segments, followed by segmentVersion and the encoded
+// SegmentMessage, so regular payloads are not mistaken for segments
+var segmentMagic = []byte("WSEG")
+
+// segmentVersion is the version of the encoding of the segments
+const segmentVersion = 1
+
+var (
+	ErrTooManySegments    = fmt.Errorf("payload requires more than %d segments", MaxSegments)
+	ErrInvalidSegmentSize = errors.New("segment size must be greater than 0")
+	ErrInvalidParityRatio = errors.New("parity ratio must not be negative")
+	ErrInvalidSegment     = errors.New("invalid segment")
+	ErrUnsupportedVersion = errors.New("unsupported segment version")
+	ErrHashMismatch       = errors.New("reassembled payload doesn't match its hash")
+)
+
+// Segment splits a message whose payload is larger than the segment size into data and parity
+// segments. Each segment is a message with the same content topic, version, timestamp and
+// ephemeral flag, whose payload is a versioned SegmentMessage. Messages that fit in a single segment are
+// returned unchanged
+func Segment(msg *pb.WakuMessage, opts ...SegmentOption) ([]*pb.WakuMessage, error) {
+	params := new(segmentParams)
+	for _, opt := range append(defaultSegmentOptions(), opts...) {
+		opt(params)
+	}
+
+	if params.segmentSize <= 0 {
+		return nil, ErrInvalidSegmentSize
+	}
+	if params.parityRatio < 0 {
+		return nil, ErrInvalidParityRatio
+	}
+
+	if len(msg.Payload) <= params.segmentSize {
+		return []*pb.WakuMessage{msg}, nil
+	}
+
+	dataCount := (len(msg.Payload) + params.segmentSize - 1) / params.segmentSize
+	parityCount := int(math.Ceil(float64(dataCount) * params.parityRatio))
+	rs, err := newReedSolomon(dataCount, parityCount)
+	if err != nil {
+		return nil, err
+	}
+
+	// The last data segment is padded so all the segments have the same size
+	padded := make([]byte, (dataCount+parityCount)*params.segmentSize)
+	copy(padded, msg.Payload)
+	shards := make([][]byte, dataCount+parityCount)
+	for i := range shards {
+		shards[i] = padded[i*params.segmentSize : (i+1)*params.segmentSize]
+	}
+	rs.encode(shards)
+
+	hash := sha256.Sum256(msg.Payload)
+	var segments []*pb.WakuMessage
+	for i, shard := range shards {
+		payload, err := encodeSegment(&segmentpb.SegmentMessage{
+			EntireMessageHash:   hash[:],
+			Index:               uint32(i),
+			DataSegmentsCount:   uint32(dataCount),
+			ParitySegmentsCount: uint32(parityCount),
+			PayloadSize:         uint64(len(msg.Payload)),
+			Payload:             shard,
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		segments = append(segments, &pb.WakuMessage{
+			Payload:      payload,
+			ContentTopic: msg.ContentTopic,
+			Version:      msg.Version,
+			Timestamp:    msg.Timestamp,
+			Ephemeral:    msg.Ephemeral,
+		})
+	}
+
+	return segments, nil
+}
+
+func encodeSegment(segment *segmentpb.SegmentMessage) ([]byte, error) {
+	encoded, err := proto.Marshal(segment)
+	if err != nil {
+		return nil, err
+	}
+
+	payload := make([]byte, 0, len(segmentMagic)+1+len(encoded))
+	payload = append(payload, segmentMagic...)
+	payload = append(payload, segmentVersion)
+	return append(payload, encoded...), nil
+}
+
+// DecodeSegment returns the segment carried by a message, or an error if the message is not a segment
+func DecodeSegment(msg *pb.WakuMessage) (*segmentpb.SegmentMessage, error) {
+	if len(msg.Payload) <= len(segmentMagic) || !bytes.HasPrefix(msg.Payload, segmentMagic) {
+		return nil, ErrInvalidSegment
+	}
+	if version := msg.Payload[len(segmentMagic)]; version != segmentVersion {
+		return nil, fmt.Errorf("%w: %d", ErrUnsupportedVersion, version)
+	}
+
+	segment := new(segmentpb.SegmentMessage)
+	if err := proto.Unmarshal(msg.Payload[len(segmentMagic)+1:], segment); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidSegment, err)
+	}
+
+	total := uint64(segment.DataSegmentsCount) + uint64(segment.ParitySegmentsCount)
+	switch {
+	case len(segment.EntireMessageHash) != sha256.Size,
+		segment.DataSegmentsCount == 0,
+		total > MaxSegments,
+		uint64(segment.Index) >= total,
+		len(segment.Payload) == 0,
+		segment.PayloadSize > uint64(segment.DataSegmentsCount)*uint64(len(segment.Payload)):
+		return nil, ErrInvalidSegment
+	}
+
+	return segment, nil
+}
+
+// IsSegment returns true if the payload of a message is a segment
+func IsSegment(msg *pb.WakuMessage) bool {
+	_, err := DecodeSegment(msg)
+	return err == nil
+}
+
+func reassemble(segment *segmentpb.SegmentMessage, shards [][]byte) ([]byte, error) {
+	rs, err := newReedSolomon(int(segment.DataSegmentsCount), int(segment.ParitySegmentsCount))
+	if err != nil {
+		return nil, err
+	}
+
+	if err := rs.reconstruct(shards); err != nil {
+		return nil, err
+	}
+
+	payload := bytes.Join(shards[:segment.DataSegmentsCount], nil)[:segment.PayloadSize]
+	hash := sha256.Sum256(payload)
+	if !bytes.Equal(hash[:], segment.EntireMessageHash) {
+		return nil, ErrHashMismatch
+	}
+
+	return payload, nil
+}
+
+// Sender publishes the segments of large messages through a MessageSender
+type Sender struct {
+	sender *publish.MessageSender
+	opts   []SegmentOption
+}
+
+// NewSender creates a Sender that splits messages with the given options
+func NewSender(sender *publish.MessageSender, opts ...SegmentOption) *Sender {
+	return &Sender{
+		sender: sender,
+		opts:   opts,
+	}
+}
+
+// Send splits a message and publishes its segments in order. Messages that fit in a single
+// segment are published unchanged
+func (s *Sender) Send(ctx context.Context, envelope *protocol.Envelope) error {
+	segments, err := Segment(envelope.Message(), s.opts...)
+	if err != nil {
+		return err
+	}
+
+	for _, segment := range segments {
+		env := protocol.NewEnvelope(segment, envelope.Index().ReceiverTime, envelope.PubsubTopic())
+		if err := s.sender.Send(publish.NewRequest(ctx, env)); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
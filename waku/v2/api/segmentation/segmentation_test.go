@@ -0,0 +1,173 @@
+package segmentation
+
+import (
+	"crypto/rand"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	segmentpb "github.com/waku-org/go-waku/waku/v2/api/segmentation/pb"
+	"github.com/waku-org/go-waku/waku/v2/protocol"
+	"github.com/waku-org/go-waku/waku/v2/protocol/pb"
+	"github.com/waku-org/go-waku/waku/v2/utils"
+	"google.golang.org/protobuf/proto"
+)
+
+const testPubsubTopic = "/waku/2/rs/16/32"
+
+func newTestMessage(t *testing.T, size int) *pb.WakuMessage {
+	payload := make([]byte, size)
+	_, err := rand.Read(payload)
+	require.NoError(t, err)
+
+	return &pb.WakuMessage{
+		Payload:      payload,
+		ContentTopic: "/test/1/segmentation/proto",
+		Timestamp:    utils.GetUnixEpoch(),
+	}
+}
+
+func toEnvelope(msg *pb.WakuMessage) *protocol.Envelope {
+	return protocol.NewEnvelope(msg, *utils.GetUnixEpoch(), testPubsubTopic)
+}
+
+func TestSegmentAndReassemble(t *testing.T) {
+	msg := newTestMessage(t, 10*1000+7)
+
+	segments, err := Segment(msg, WithSegmentSize(1000), WithParityRatio(0.3))
+	require.NoError(t, err)
+	require.Len(t, segments, 11+4)
+	for _, segment := range segments {
+		require.True(t, IsSegment(segment))
+		require.Equal(t, msg.ContentTopic, segment.ContentTopic)
+	}
+
+	// Small messages are not segmented
+	small := newTestMessage(t, 1000)
+	segments2, err := Segment(small, WithSegmentSize(1000))
+	require.NoError(t, err)
+	require.Equal(t, []*pb.WakuMessage{small}, segments2)
+
+	r := NewReassembler()
+	result, err := r.Add(toEnvelope(small))
+	require.NoError(t, err)
+	require.Equal(t, small, result.Message())
+
+	// Lose as many segments as parity segments, including data segments, and deliver the rest out of order
+	received := append([]*pb.WakuMessage{}, segments[5:]...)
+	received = append(received, segments[:1]...)
+	for i, segment := range received {
+		result, err := r.Add(toEnvelope(segment))
+		require.NoError(t, err)
+		if i < len(received)-1 {
+			require.Nil(t, result)
+			continue
+		}
+		require.NotNil(t, result)
+		require.Equal(t, msg.Payload, result.Message().Payload)
+		require.Equal(t, msg.ContentTopic, result.Message().ContentTopic)
+		require.Equal(t, testPubsubTopic, result.PubsubTopic())
+	}
+	require.Equal(t, 0, r.PartialSize())
+
+	// Segments received after the message was reassembled are ignored
+	result, err = r.Add(toEnvelope(segments[1]))
+	require.NoError(t, err)
+	require.Nil(t, result)
+
+	// Corrupted segments are detected
+	r = NewReassembler()
+	segment, err := DecodeSegment(segments[0])
+	require.NoError(t, err)
+	segment.Payload[0] ^= 0xff
+	payload, err := encodeSegment(segment)
+	require.NoError(t, err)
+	_, err = r.Add(toEnvelope(&pb.WakuMessage{Payload: payload, ContentTopic: msg.ContentTopic}))
+	require.NoError(t, err)
+	for _, segment := range segments[1:10] {
+		_, err = r.Add(toEnvelope(segment))
+		require.NoError(t, err)
+	}
+	_, err = r.Add(toEnvelope(segments[10]))
+	require.ErrorIs(t, err, ErrHashMismatch)
+}
+
+func TestReassembleForgedSegment(t *testing.T) {
+	msg := newTestMessage(t, 5000)
+	segments, err := Segment(msg, WithSegmentSize(1000), WithParityRatio(0))
+	require.NoError(t, err)
+
+	// A segment with the hash of the message but different counts, received first, doesn't
+	// prevent the reassembly
+	segment, err := DecodeSegment(segments[0])
+	require.NoError(t, err)
+	forged := proto.Clone(segment).(*segmentpb.SegmentMessage)
+	forged.DataSegmentsCount = 10
+	forged.PayloadSize = 10000
+	payload, err := encodeSegment(forged)
+	require.NoError(t, err)
+
+	r := NewReassembler()
+	result, err := r.Add(toEnvelope(&pb.WakuMessage{Payload: payload, ContentTopic: msg.ContentTopic}))
+	require.NoError(t, err)
+	require.Nil(t, result)
+	for _, segment := range segments {
+		result, err = r.Add(toEnvelope(segment))
+		require.NoError(t, err)
+	}
+	require.NotNil(t, result)
+	require.Equal(t, msg.Payload, result.Message().Payload)
+
+	// Payloads without the prefix, or with another version, are not segments
+	encoded, err := proto.Marshal(segment)
+	require.NoError(t, err)
+	require.False(t, IsSegment(&pb.WakuMessage{Payload: encoded}))
+
+	unsupported := append([]byte{}, segments[0].Payload...)
+	unsupported[len(segmentMagic)] = segmentVersion + 1
+	_, err = DecodeSegment(&pb.WakuMessage{Payload: unsupported})
+	require.ErrorIs(t, err, ErrUnsupportedVersion)
+}
+
+func TestSegmentLimits(t *testing.T) {
+	_, err := Segment(newTestMessage(t, 300), WithSegmentSize(1))
+	require.ErrorIs(t, err, ErrTooManySegments)
+
+	_, err = Segment(newTestMessage(t, 300), WithSegmentSize(0))
+	require.ErrorIs(t, err, ErrInvalidSegmentSize)
+
+	require.False(t, IsSegment(newTestMessage(t, 100)))
+}
+
+func TestReassemblerTimeoutAndMemoryLimit(t *testing.T) {
+	now := time.Now()
+	r := NewReassembler(WithTimeout(time.Minute), WithMaxPartialSize(2500))
+	r.now = func() time.Time { return now }
+
+	msg1, err := Segment(newTestMessage(t, 5000), WithSegmentSize(1000), WithParityRatio(0))
+	require.NoError(t, err)
+	msg2, err := Segment(newTestMessage(t, 5000), WithSegmentSize(1000), WithParityRatio(0))
+	require.NoError(t, err)
+
+	for _, segment := range msg1[:2] {
+		_, err = r.Add(toEnvelope(segment))
+		require.NoError(t, err)
+	}
+	require.Equal(t, 2000, r.PartialSize())
+
+	// The oldest incomplete message is discarded when the limit is exceeded
+	now = now.Add(time.Second)
+	_, err = r.Add(toEnvelope(msg2[0]))
+	require.NoError(t, err)
+	require.Equal(t, 1000, r.PartialSize())
+
+	// Incomplete messages expire
+	now = now.Add(2 * time.Minute)
+	r.Prune()
+	require.Equal(t, 0, r.PartialSize())
+	for _, segment := range msg2[1:] {
+		result, err := r.Add(toEnvelope(segment))
+		require.NoError(t, err)
+		require.Nil(t, result)
+	}
+}
@@ -0,0 +1,301 @@
+package bridge
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/waku-org/go-waku/logging"
+	"github.com/waku-org/go-waku/waku/v2/protocol"
+	"github.com/waku-org/go-waku/waku/v2/protocol/pb"
+	"github.com/waku-org/go-waku/waku/v2/protocol/relay"
+	"github.com/waku-org/go-waku/waku/v2/service"
+	"github.com/waku-org/go-waku/waku/v2/utils"
+	"go.uber.org/zap"
+	"golang.org/x/time/rate"
+	"google.golang.org/protobuf/proto"
+)
+
+var (
+	ErrNoRules                 = errors.New("bridge requires at least one rule")
+	ErrMissingSourceTopic      = errors.New("missing source pubsub topic")
+	ErrMissingDestinationTopic = errors.New("missing destination pubsub topic")
+	ErrSameTopic               = errors.New("source and destination are the same pubsub topic of the same relay")
+)
+
+// Relay is the part of WakuRelay used by the bridge. The source and destination of a rule are the
+// relay of the same node when bridging shards, or the relays of two nodes when bridging clusters
+type Relay interface {
+	Subscribe(ctx context.Context, contentFilter protocol.ContentFilter, opts ...relay.RelaySubscribeOption) ([]*relay.Subscription, error)
+	Publish(ctx context.Context, message *pb.WakuMessage, opts ...relay.PublishOption) (pb.MessageHash, error)
+}
+
+// Rule forwards the messages of a pubsub topic to another one
+type Rule struct {
+	SourcePubsubTopic      string
+	DestinationPubsubTopic string
+	// ContentTopics restricts the messages forwarded. They can be patterns. All the messages of
+	// the source pubsub topic are forwarded if empty
+	ContentTopics []string
+	// SigningKeys sign the messages again before they're published, for protected destination
+	// topics. A single key signs the Meta field, several keys use relay.SignMessageWithKeys
+	SigningKeys []*ecdsa.PrivateKey
+}
+
+func (r Rule) String() string {
+	return fmt.Sprintf("%s -> %s %v", r.SourcePubsubTopic, r.DestinationPubsubTopic, r.ContentTopics)
+}
+
+func (r Rule) Validate() error {
+	if r.SourcePubsubTopic == "" {
+		return ErrMissingSourceTopic
+	}
+	if r.DestinationPubsubTopic == "" {
+		return ErrMissingDestinationTopic
+	}
+	for _, contentTopic := range r.ContentTopics {
+		if err := protocol.ValidateContentTopicPattern(contentTopic); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ParseRule parses sourcePubsubTopic:destinationPubsubTopic[:contentTopic,...]
+func ParseRule(value string) (Rule, error) {
+	parts := strings.SplitN(value, ":", 3)
+	if len(parts) < 2 {
+		return Rule{}, errors.New("expected source_pubsub_topic:destination_pubsub_topic[:content_topic,...]")
+	}
+
+	rule := Rule{
+		SourcePubsubTopic:      parts[0],
+		DestinationPubsubTopic: parts[1],
+	}
+	if len(parts) == 3 && parts[2] != "" {
+		for _, contentTopic := range strings.Split(parts[2], ",") {
+			rule.ContentTopics = append(rule.ContentTopics, strings.TrimSpace(contentTopic))
+		}
+	}
+
+	return rule, rule.Validate()
+}
+
+// seenCache contains the hashes of the messages published by the bridge. Expired hashes are
+// removed lazily
+type seenCache struct {
+	sync.Mutex
+	ttl       time.Duration
+	hashes    map[pb.MessageHash]time.Time
+	lastPrune time.Time
+}
+
+func (c *seenCache) add(hash pb.MessageHash, now time.Time) {
+	c.Lock()
+	defer c.Unlock()
+
+	c.hashes[hash] = now
+	if now.Sub(c.lastPrune) > c.ttl {
+		for h, t := range c.hashes {
+			if now.Sub(t) > c.ttl {
+				delete(c.hashes, h)
+			}
+		}
+		c.lastPrune = now
+	}
+}
+
+func (c *seenCache) has(hash pb.MessageHash, now time.Time) bool {
+	c.Lock()
+	defer c.Unlock()
+
+	t, ok := c.hashes[hash]
+	return ok && now.Sub(t) <= c.ttl
+}
+
+type bridgeRule struct {
+	Rule
+	src     Relay
+	dst     Relay
+	limiter *rate.Limiter
+}
+
+// Bridge forwards messages between pubsub topics, which can belong to different shards or clusters.
+// Messages published by the bridge are not forwarded again, so rules can be bidirectional
+type Bridge struct {
+	*service.CommonService
+	rules   []*bridgeRule
+	seen    *seenCache
+	metrics Metrics
+	log     *zap.Logger
+}
+
+// NewBridge creates a Bridge that forwards messages according to the rules. The pubsub topics of each
+// rule are subscribed and published on the relay set for their cluster with WithClusterRelay, or on
+// the local relay otherwise
+func NewBridge(local Relay, rules []Rule, reg prometheus.Registerer, log *zap.Logger, opts ...Option) (*Bridge, error) {
+	p := new(params)
+	for _, opt := range append(defaultOptions(), opts...) {
+		opt(p)
+	}
+
+	if len(rules) == 0 {
+		return nil, ErrNoRules
+	}
+
+	b := &Bridge{
+		CommonService: service.NewCommonService(),
+		seen: &seenCache{
+			ttl:    p.seenTTL,
+			hashes: make(map[pb.MessageHash]time.Time),
+		},
+		metrics: newMetrics(reg),
+		log:     log.Named("bridge"),
+	}
+
+	for _, rule := range rules {
+		if err := rule.Validate(); err != nil {
+			return nil, fmt.Errorf("invalid rule %s: %w", rule, err)
+		}
+		r := &bridgeRule{
+			Rule: rule,
+			src:  relayFor(local, p.clusterRelays, rule.SourcePubsubTopic),
+			dst:  relayFor(local, p.clusterRelays, rule.DestinationPubsubTopic),
+		}
+		if r.src == r.dst && rule.SourcePubsubTopic == rule.DestinationPubsubTopic {
+			return nil, fmt.Errorf("invalid rule %s: %w", rule, ErrSameTopic)
+		}
+
+		if p.rateLimit != rate.Inf {
+			r.limiter = rate.NewLimiter(p.rateLimit, p.rateBurst)
+		}
+		b.rules = append(b.rules, r)
+	}
+
+	return b, nil
+}
+
+// relayFor returns the relay of the cluster of a pubsub topic. Named topics, and the topics of the
+// clusters without relay, use the local relay
+func relayFor(local Relay, clusterRelays map[uint16]Relay, pubsubTopic string) Relay {
+	topic, err := protocol.ToWakuPubsubTopic(pubsubTopic)
+	if err != nil {
+		return local
+	}
+	shardTopic, err := protocol.ToShardPubsubTopic(topic)
+	if err != nil {
+		return local
+	}
+	if r, ok := clusterRelays[shardTopic.Cluster()]; ok {
+		return r
+	}
+	return local
+}
+
+// Start subscribes to the source and destination pubsub topics of the rules
+func (b *Bridge) Start(ctx context.Context) error {
+	return b.CommonService.Start(ctx, b.start)
+}
+
+func (b *Bridge) start() error {
+	for _, rule := range b.rules {
+		// Publishing requires the destination relay to be subscribed to the pubsub topic
+		_, err := rule.dst.Subscribe(b.Context(), protocol.NewContentFilter(rule.DestinationPubsubTopic), relay.WithoutConsumer())
+		if err != nil {
+			return fmt.Errorf("subscribing to destination of rule %s: %w", rule, err)
+		}
+
+		subs, err := rule.src.Subscribe(b.Context(), protocol.NewContentFilter(rule.SourcePubsubTopic, rule.ContentTopics...))
+		if err != nil {
+			return fmt.Errorf("subscribing to source of rule %s: %w", rule, err)
+		}
+
+		for _, sub := range subs {
+			b.WaitGroup().Add(1)
+			go b.forward(rule, sub)
+		}
+
+		b.log.Info("bridging", zap.String("source", rule.SourcePubsubTopic), zap.String("destination", rule.DestinationPubsubTopic), zap.Strings("contentTopics", rule.ContentTopics))
+	}
+
+	return nil
+}
+
+// Stop stops forwarding messages
+func (b *Bridge) Stop() {
+	b.CommonService.Stop(func() {})
+}
+
+func (b *Bridge) forward(rule *bridgeRule, sub *relay.Subscription) {
+	defer utils.LogOnPanic()
+	defer b.WaitGroup().Done()
+
+	for {
+		select {
+		case <-b.Context().Done():
+			return
+		case env, ok := <-sub.Ch:
+			if !ok {
+				return
+			}
+			b.handle(rule, env)
+		}
+	}
+}
+
+func (b *Bridge) handle(rule *bridgeRule, env *protocol.Envelope) {
+	now := time.Now()
+	logger := b.log.With(logging.Hash(env.Hash()), zap.String("source", rule.SourcePubsubTopic), zap.String("destination", rule.DestinationPubsubTopic))
+
+	// Messages published by the bridge itself
+	if b.seen.has(env.Hash(), now) {
+		b.metrics.RecordDropped(rule.Rule, loopDetected)
+		return
+	}
+
+	if rule.limiter != nil && !rule.limiter.Allow() {
+		logger.Debug("rate limit exceeded")
+		b.metrics.RecordDropped(rule.Rule, rateLimited)
+		return
+	}
+
+	msg := env.Message()
+	if len(rule.SigningKeys) != 0 {
+		msg = proto.Clone(msg).(*pb.WakuMessage)
+		msg.Meta = nil
+
+		var err error
+		if len(rule.SigningKeys) == 1 {
+			err = relay.SignMessage(rule.SigningKeys[0], msg, rule.DestinationPubsubTopic)
+		} else {
+			err = relay.SignMessageWithKeys(rule.SigningKeys, msg, rule.DestinationPubsubTopic)
+		}
+		if err != nil {
+			logger.Error("signing message", zap.Error(err))
+			b.metrics.RecordDropped(rule.Rule, signFailure)
+			return
+		}
+	}
+
+	// The same message can be received by several rules with the same destination
+	hash := msg.Hash(rule.DestinationPubsubTopic)
+	if b.seen.has(hash, now) {
+		b.metrics.RecordDropped(rule.Rule, loopDetected)
+		return
+	}
+	b.seen.add(hash, now)
+
+	_, err := rule.dst.Publish(b.Context(), msg, relay.WithPubSubTopic(rule.DestinationPubsubTopic))
+	if err != nil {
+		logger.Error("forwarding message", zap.Error(err))
+		b.metrics.RecordDropped(rule.Rule, publishFailure)
+		return
+	}
+
+	b.metrics.RecordForwarded(rule.Rule)
+}
@@ -0,0 +1,191 @@
+package bridge
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/rand"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/stretchr/testify/require"
+	"github.com/waku-org/go-waku/tests"
+	"github.com/waku-org/go-waku/waku/v2/protocol"
+	"github.com/waku-org/go-waku/waku/v2/protocol/pb"
+	"github.com/waku-org/go-waku/waku/v2/protocol/relay"
+	"github.com/waku-org/go-waku/waku/v2/timesource"
+	"github.com/waku-org/go-waku/waku/v2/utils"
+	"golang.org/x/time/rate"
+	"google.golang.org/protobuf/proto"
+)
+
+func makeRelay(t *testing.T) *relay.WakuRelay {
+	port, err := tests.FindFreePort(t, "", 5)
+	require.NoError(t, err)
+
+	host, err := tests.MakeHost(context.Background(), port, rand.Reader)
+	require.NoError(t, err)
+	bcaster := relay.NewBroadcaster(10)
+	r := relay.NewWakuRelay(bcaster, 0, timesource.NewDefaultClock(), prometheus.DefaultRegisterer, utils.Logger())
+	r.SetHost(host)
+	require.NoError(t, r.Start(context.Background()))
+	require.NoError(t, bcaster.Start(context.Background()))
+	t.Cleanup(r.Stop)
+	return r
+}
+
+// receive collects the messages received on a pubsub topic
+func receive(t *testing.T, r *relay.WakuRelay, pubsubTopic string) func() []*pb.WakuMessage {
+	subs, err := r.Subscribe(context.Background(), protocol.NewContentFilter(pubsubTopic))
+	require.NoError(t, err)
+
+	var mu sync.Mutex
+	var msgs []*pb.WakuMessage
+	go func() {
+		for env := range subs[0].Ch {
+			mu.Lock()
+			msgs = append(msgs, env.Message())
+			mu.Unlock()
+		}
+	}()
+
+	return func() []*pb.WakuMessage {
+		mu.Lock()
+		defer mu.Unlock()
+		return append([]*pb.WakuMessage(nil), msgs...)
+	}
+}
+
+func newTestMessage(payload string) *pb.WakuMessage {
+	return &pb.WakuMessage{
+		Payload:      []byte(payload),
+		ContentTopic: "/test/1/bridge/proto",
+		Timestamp:    utils.GetUnixEpoch(),
+	}
+}
+
+func TestBridgeLoopPrevention(t *testing.T) {
+	topicA := "/waku/2/rs/1/0"
+	topicB := "/waku/2/rs/2/0"
+
+	r := makeRelay(t)
+	receivedA := receive(t, r, topicA)
+	receivedB := receive(t, r, topicB)
+
+	b, err := NewBridge(r, []Rule{
+		{SourcePubsubTopic: topicA, DestinationPubsubTopic: topicB},
+		{SourcePubsubTopic: topicB, DestinationPubsubTopic: topicA},
+	}, prometheus.NewRegistry(), utils.Logger())
+	require.NoError(t, err)
+	require.NoError(t, b.Start(context.Background()))
+	defer b.Stop()
+
+	_, err = r.Publish(context.Background(), newTestMessage("hello"), relay.WithPubSubTopic(topicA))
+	require.NoError(t, err)
+
+	require.Eventually(t, func() bool {
+		return len(receivedB()) == 1
+	}, 2*time.Second, 10*time.Millisecond)
+
+	// The message forwarded to B is not sent back to A
+	time.Sleep(500 * time.Millisecond)
+	require.Len(t, receivedA(), 1)
+	require.Len(t, receivedB(), 1)
+}
+
+func TestBridgeRateLimitAndSigning(t *testing.T) {
+	topicA := "/waku/2/rs/1/0"
+	topicB := "/waku/2/rs/2/0"
+
+	privKey, err := crypto.GenerateKey()
+	require.NoError(t, err)
+
+	r := makeRelay(t)
+	receivedA := receive(t, r, topicA)
+	receivedB := receive(t, r, topicB)
+
+	b, err := NewBridge(r, []Rule{
+		{SourcePubsubTopic: topicA, DestinationPubsubTopic: topicB, SigningKeys: []*ecdsa.PrivateKey{privKey}},
+	}, prometheus.NewRegistry(), utils.Logger(), WithRateLimit(rate.Every(time.Hour), 2))
+	require.NoError(t, err)
+	require.NoError(t, b.Start(context.Background()))
+	defer b.Stop()
+
+	original := newTestMessage("hello")
+	for i := 0; i < 4; i++ {
+		msg := proto.Clone(original).(*pb.WakuMessage)
+		msg.Payload = append(msg.Payload, byte(i))
+		_, err = r.Publish(context.Background(), msg, relay.WithPubSubTopic(topicA))
+		require.NoError(t, err)
+	}
+
+	require.Eventually(t, func() bool {
+		return len(receivedB()) == 2
+	}, 2*time.Second, 10*time.Millisecond)
+	time.Sleep(500 * time.Millisecond)
+	require.Len(t, receivedB(), 2)
+
+	// Forwarded messages are signed for the destination topic
+	for _, msg := range receivedB() {
+		require.NotEmpty(t, msg.Meta)
+		expected := proto.Clone(msg).(*pb.WakuMessage)
+		expected.Meta = nil
+		require.NoError(t, relay.SignMessage(privKey, expected, topicB))
+		require.Equal(t, expected.Meta, msg.Meta)
+	}
+	require.Empty(t, receivedA()[0].Meta)
+}
+
+func TestBridgeClusterRelay(t *testing.T) {
+	topicA := "/waku/2/rs/1/0"
+	topicB := "/waku/2/rs/2/0"
+
+	local := makeRelay(t)
+	other := makeRelay(t)
+	receivedA := receive(t, local, topicA)
+	receivedB := receive(t, other, topicB)
+
+	b, err := NewBridge(local, []Rule{
+		{SourcePubsubTopic: topicA, DestinationPubsubTopic: topicB},
+		{SourcePubsubTopic: topicB, DestinationPubsubTopic: topicA},
+	}, prometheus.NewRegistry(), utils.Logger(), WithClusterRelay(2, other))
+	require.NoError(t, err)
+	require.NoError(t, b.Start(context.Background()))
+	defer b.Stop()
+
+	// The topics of cluster 2 are subscribed and published on the other relay
+	require.False(t, local.IsSubscribed(topicB))
+
+	_, err = local.Publish(context.Background(), newTestMessage("hello"), relay.WithPubSubTopic(topicA))
+	require.NoError(t, err)
+	require.Eventually(t, func() bool {
+		return len(receivedB()) == 1
+	}, 2*time.Second, 10*time.Millisecond)
+
+	_, err = other.Publish(context.Background(), newTestMessage("world"), relay.WithPubSubTopic(topicB))
+	require.NoError(t, err)
+	require.Eventually(t, func() bool {
+		return len(receivedA()) == 2
+	}, 2*time.Second, 10*time.Millisecond)
+}
+
+func TestParseRule(t *testing.T) {
+	rule, err := ParseRule("/waku/2/rs/1/0:/waku/2/rs/2/0")
+	require.NoError(t, err)
+	require.Equal(t, Rule{SourcePubsubTopic: "/waku/2/rs/1/0", DestinationPubsubTopic: "/waku/2/rs/2/0"}, rule)
+
+	rule, err = ParseRule("/waku/2/rs/1/0:/waku/2/rs/2/0:/app/1/chat/proto, /app/1/*")
+	require.NoError(t, err)
+	require.Equal(t, []string{"/app/1/chat/proto", "/app/1/*"}, rule.ContentTopics)
+
+	_, err = ParseRule("/waku/2/rs/1/0")
+	require.Error(t, err)
+
+	_, err = ParseRule(":/waku/2/rs/2/0")
+	require.ErrorIs(t, err, ErrMissingSourceTopic)
+
+	_, err = ParseRule("/waku/2/rs/1/0:/waku/2/rs/2/0:/app/1/[")
+	require.ErrorIs(t, err, protocol.ErrInvalidPattern)
+}
@@ -0,0 +1,63 @@
+package bridge
+
+import (
+	"github.com/libp2p/go-libp2p/p2p/metricshelper"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var forwardedMessages = prometheus.NewCounterVec(
+	prometheus.CounterOpts{
+		Name: "waku_bridge_forwarded_messages",
+		Help: "The number of messages forwarded by the bridge",
+	},
+	[]string{"source", "destination"},
+)
+
+var droppedMessages = prometheus.NewCounterVec(
+	prometheus.CounterOpts{
+		Name: "waku_bridge_dropped_messages",
+		Help: "The number of messages not forwarded by the bridge",
+	},
+	[]string{"source", "destination", "reason"},
+)
+
+var collectors = []prometheus.Collector{
+	forwardedMessages,
+	droppedMessages,
+}
+
+// Metrics exposes the functions required to update prometheus metrics for the bridge
+type Metrics interface {
+	RecordForwarded(rule Rule)
+	RecordDropped(rule Rule, reason dropReason)
+}
+
+type metricsImpl struct {
+	reg prometheus.Registerer
+}
+
+func newMetrics(reg prometheus.Registerer) Metrics {
+	metricshelper.RegisterCollectors(reg, collectors...)
+	return &metricsImpl{
+		reg: reg,
+	}
+}
+
+type dropReason string
+
+const (
+	loopDetected   dropReason = "loop"
+	rateLimited    dropReason = "rate_limit"
+	signFailure    dropReason = "sign_failure"
+	publishFailure dropReason = "publish_failure"
+)
+
+// RecordForwarded increases the counter of messages forwarded by a rule
+func (m *metricsImpl) RecordForwarded(rule Rule) {
+	forwardedMessages.WithLabelValues(rule.SourcePubsubTopic, rule.DestinationPubsubTopic).Inc()
+}
+
+// RecordDropped increases the counter of messages that a rule didn't forward
+func (m *metricsImpl) RecordDropped(rule Rule, reason dropReason) {
+	droppedMessages.WithLabelValues(rule.SourcePubsubTopic, rule.DestinationPubsubTopic, string(reason)).Inc()
+}
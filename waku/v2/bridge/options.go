@@ -0,0 +1,55 @@
+package bridge
+
+import (
+	"time"
+
+	"golang.org/x/time/rate"
+)
+
+// DefaultSeenTTL is the time during which the hashes of the forwarded messages are kept to prevent loops
+const DefaultSeenTTL = 10 * time.Minute
+
+type params struct {
+	rateLimit rate.Limit
+	rateBurst int
+	seenTTL   time.Duration
+
+	clusterRelays map[uint16]Relay
+}
+
+// Option is an optional setting of the Bridge
+type Option func(*params)
+
+// WithRateLimit limits the number of messages forwarded by each rule per second
+func WithRateLimit(limit rate.Limit, burst int) Option {
+	return func(p *params) {
+		p.rateLimit = limit
+		p.rateBurst = burst
+	}
+}
+
+// WithSeenTTL sets the time during which the hashes of the forwarded messages are kept. A message
+// that comes back to the bridge after this time is forwarded again
+func WithSeenTTL(ttl time.Duration) Option {
+	return func(p *params) {
+		p.seenTTL = ttl
+	}
+}
+
+// WithClusterRelay sets the relay used for the pubsub topics of a cluster, usually the relay of a
+// second node that belongs to that cluster
+func WithClusterRelay(cluster uint16, r Relay) Option {
+	return func(p *params) {
+		if p.clusterRelays == nil {
+			p.clusterRelays = make(map[uint16]Relay)
+		}
+		p.clusterRelays[cluster] = r
+	}
+}
+
+func defaultOptions() []Option {
+	return []Option{
+		WithRateLimit(rate.Inf, 0),
+		WithSeenTTL(DefaultSeenTTL),
+	}
+}
@@ -368,35 +368,9 @@ func (w *WakuNode) watchTopicShards(ctx context.Context) error {
 			case <-ctx.Done():
 				return
 			case <-evtRelayUnsubscribed.Out():
+				w.advertiseTopicShards()
 			case <-evtRelaySubscribed.Out():
-				topics := w.Relay().Topics()
-				rs, err := protocol.TopicsToRelayShards(topics...)
-				if err != nil {
-					w.log.Warn("could not set ENR shard info", zap.Error(err))
-					continue
-				}
-
-				if len(rs) > 0 {
-					if len(rs) > 1 {
-						w.log.Warn("could not set ENR shard info", zap.String("error", "multiple clusters found, use sharded topics within the same cluster"))
-						continue
-					}
-				}
-
-				if len(rs) == 1 {
-					w.log.Info("updating advertised relay shards in ENR", zap.Any("newShardInfo", rs[0]))
-					if len(rs[0].ShardIDs) != len(topics) {
-						w.log.Warn("A mix of named and static shards found. ENR shard will contain only the following shards", zap.Any("shards", rs[0]))
-					}
-
-					err = wenr.Update(w.log, w.localNode, wenr.WithWakuRelaySharding(rs[0]))
-					if err != nil {
-						w.log.Warn("could not set ENR shard info", zap.Error(err))
-						continue
-					}
-
-					w.enrChangeCh <- struct{}{}
-				}
+				w.advertiseTopicShards()
 			}
 		}
 	}()
@@ -404,6 +378,36 @@ func (w *WakuNode) watchTopicShards(ctx context.Context) error {
 	return nil
 }
 
+// advertiseTopicShards updates the shards of the ENR with the pubsub topics relay is subscribed to
+func (w *WakuNode) advertiseTopicShards() {
+	topics := w.Relay().Topics()
+	rs, err := protocol.TopicsToRelayShards(topics...)
+	if err != nil {
+		w.log.Warn("could not set ENR shard info", zap.Error(err))
+		return
+	}
+
+	if len(rs) > 1 {
+		w.log.Warn("could not set ENR shard info", zap.String("error", "multiple clusters found, use sharded topics within the same cluster"))
+		return
+	}
+
+	if len(rs) == 1 {
+		w.log.Info("updating advertised relay shards in ENR", zap.Any("newShardInfo", rs[0]))
+		if len(rs[0].ShardIDs) != len(topics) {
+			w.log.Warn("A mix of named and static shards found. ENR shard will contain only the following shards", zap.Any("shards", rs[0]))
+		}
+
+		err = w.SetRelayShards(rs[0])
+		if err != nil {
+			w.log.Warn("could not set ENR shard info", zap.Error(err))
+			return
+		}
+
+		w.enrChangeCh <- struct{}{}
+	}
+}
+
 func (w *WakuNode) registerAndMonitorReachability(ctx context.Context) {
 	var myEventSub event.Subscription
 	var err error
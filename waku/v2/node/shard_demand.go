@@ -0,0 +1,154 @@
+package node
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/waku-org/go-waku/waku/v2/protocol"
+	"github.com/waku-org/go-waku/waku/v2/protocol/filter"
+	"github.com/waku-org/go-waku/waku/v2/protocol/relay"
+	"github.com/waku-org/go-waku/waku/v2/utils"
+	"go.uber.org/zap"
+)
+
+var ErrShardLimitReached = errors.New("node has reached the maximum number of shards subscribed on demand")
+var ErrShardNotServed = errors.New("pubsub topic is not a shard of the cluster of the node")
+
+// ShardDemandConfig configures the subscription to the shards requested by filter and lightpush clients
+type ShardDemandConfig struct {
+	// MaxShards is the maximum number of shards subscribed on demand, in addition to the shards
+	// the node was started with
+	MaxShards int
+	// IdleTimeout is the time after which a shard subscribed on demand is unsubscribed if no
+	// client requested it and no filter subscriber is interested in it
+	IdleTimeout time.Duration
+}
+
+func (c ShardDemandConfig) Validate() error {
+	if c.MaxShards <= 0 {
+		return errors.New("max shards must be greater than 0")
+	}
+	if c.IdleTimeout <= 0 {
+		return errors.New("idle timeout must be greater than 0")
+	}
+	return nil
+}
+
+// shardDemand subscribes the relay of the node to the shards of its cluster requested by
+// clients. The shards advertised in the ENR, and therefore by the metadata protocol, follow
+// the relay subscriptions
+type shardDemand struct {
+	sync.Mutex
+	node *WakuNode
+	cfg  ShardDemandConfig
+	log  *zap.Logger
+	// lastRequested contains the pubsub topics subscribed on demand
+	lastRequested map[string]time.Time
+}
+
+func newShardDemand(node *WakuNode, cfg ShardDemandConfig) *shardDemand {
+	return &shardDemand{
+		node:          node,
+		cfg:           cfg,
+		log:           node.log.Named("shard-demand"),
+		lastRequested: make(map[string]time.Time),
+	}
+}
+
+func (d *shardDemand) Request(ctx context.Context, pubsubTopic string) error {
+	wakuRelay := d.node.Relay()
+	if wakuRelay == nil {
+		return ErrShardNotServed
+	}
+
+	d.Lock()
+	defer d.Unlock()
+
+	if _, ok := d.lastRequested[pubsubTopic]; ok {
+		d.lastRequested[pubsubTopic] = time.Now()
+		return nil
+	}
+
+	if wakuRelay.IsSubscribed(pubsubTopic) {
+		return nil
+	}
+
+	topic, err := protocol.ToWakuPubsubTopic(pubsubTopic)
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrShardNotServed, err)
+	}
+	shardTopic, err := protocol.ToShardPubsubTopic(topic)
+	if err != nil || shardTopic.Cluster() != d.node.ClusterID() {
+		return ErrShardNotServed
+	}
+
+	if len(d.lastRequested) >= d.cfg.MaxShards {
+		return ErrShardLimitReached
+	}
+
+	_, err = wakuRelay.Subscribe(ctx, protocol.NewContentFilter(pubsubTopic), relay.WithoutConsumer())
+	if err != nil {
+		return err
+	}
+
+	d.log.Info("subscribed to shard on demand", zap.String("pubsubTopic", pubsubTopic))
+	d.lastRequested[pubsubTopic] = time.Now()
+
+	return nil
+}
+
+func (d *shardDemand) inUse(pubsubTopic string) bool {
+	if filterFullNode, ok := d.node.filterFullNode.(*filter.WakuFilterFullNode); ok {
+		return filterFullNode.HasSubscribers(pubsubTopic)
+	}
+	return false
+}
+
+// unsubscribeIdle unsubscribes from the shards subscribed on demand that are no longer requested
+func (d *shardDemand) unsubscribeIdle(ctx context.Context) {
+	d.Lock()
+	defer d.Unlock()
+
+	now := time.Now()
+	for pubsubTopic, t := range d.lastRequested {
+		if d.inUse(pubsubTopic) {
+			d.lastRequested[pubsubTopic] = now
+			continue
+		}
+		if now.Sub(t) < d.cfg.IdleTimeout {
+			continue
+		}
+
+		err := d.node.Relay().Unsubscribe(ctx, protocol.NewContentFilter(pubsubTopic))
+		if err != nil {
+			d.log.Error("unsubscribing from idle shard", zap.String("pubsubTopic", pubsubTopic), zap.Error(err))
+			continue
+		}
+
+		d.log.Info("unsubscribed from idle shard", zap.String("pubsubTopic", pubsubTopic))
+		delete(d.lastRequested, pubsubTopic)
+	}
+}
+
+func (d *shardDemand) start(ctx context.Context, wg *sync.WaitGroup) {
+	wg.Add(1)
+	go func() {
+		defer utils.LogOnPanic()
+		defer wg.Done()
+
+		ticker := time.NewTicker(d.cfg.IdleTimeout / 2)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				d.unsubscribeIdle(ctx)
+			}
+		}
+	}()
+}
@@ -2,6 +2,7 @@ package node
 
 import (
 	"context"
+	"errors"
 	"math/rand"
 	"net"
 	"sync"
@@ -43,6 +44,7 @@ import (
 	"github.com/waku-org/go-waku/waku/v2/protocol/peer_exchange"
 	"github.com/waku-org/go-waku/waku/v2/protocol/relay"
 	"github.com/waku-org/go-waku/waku/v2/protocol/store"
+	"github.com/waku-org/go-waku/waku/v2/protocol/storesync"
 	"github.com/waku-org/go-waku/waku/v2/rendezvous"
 	"github.com/waku-org/go-waku/waku/v2/service"
 	"github.com/waku-org/go-waku/waku/v2/timesource"
@@ -100,9 +102,12 @@ type WakuNode struct {
 	rendezvous      Service
 	metadata        Service
 	filterFullNode  ReceptorService
+	shardDemand     *shardDemand
 	filterLightNode Service
 	legacyStore     ReceptorService
 	store           *store.WakuStore
+	storeServer     Service
+	storeSync       Service
 	rlnRelay        RLNRelay
 
 	wakuFlag          enr.WakuEnrBitfield
@@ -251,9 +256,32 @@ func New(opts ...WakuNodeOption) (*WakuNode, error) {
 	metadata := metadata.NewWakuMetadata(w.opts.clusterID, w.localNode, w.log)
 	w.metadata = metadata
 
-	relay := relay.NewWakuRelay(w.bcaster, w.opts.minRelayPeersToPublish, w.timesource, w.opts.prometheusReg, w.log,
+	relayOpts := []relay.RelayOption{
 		relay.WithPubSubOptions(w.opts.pubsubOpts),
-		relay.WithMaxMsgSize(w.opts.maxMsgSizeBytes))
+		relay.WithMaxMsgSize(w.opts.maxMsgSizeBytes),
+	}
+	if w.opts.relayRateLimit != nil {
+		relayOpts = append(relayOpts, relay.WithRateLimit(*w.opts.relayRateLimit))
+	}
+	if w.opts.gossipsubProfile != nil {
+		relayOpts = append(relayOpts, relay.WithGossipsubProfile(*w.opts.gossipsubProfile))
+	}
+	for pubsubTopic, scoreParams := range w.opts.topicScoreParams {
+		relayOpts = append(relayOpts, relay.WithTopicScoreParams(pubsubTopic, scoreParams))
+	}
+	if w.opts.middleware != nil {
+		relayOpts = append(relayOpts, relay.WithMiddleware(w.opts.middleware))
+		w.opts.filterOpts = append(w.opts.filterOpts, filter.WithMiddleware(w.opts.middleware))
+		w.opts.lightpushOpts = append(w.opts.lightpushOpts, lightpush.WithMiddleware(w.opts.middleware))
+	}
+
+	if w.opts.shardDemand != nil && w.opts.enableRelay {
+		w.shardDemand = newShardDemand(w, *w.opts.shardDemand)
+		w.opts.filterOpts = append(w.opts.filterOpts, filter.WithShardDemand(w.shardDemand))
+		w.opts.lightpushOpts = append(w.opts.lightpushOpts, lightpush.WithShardDemand(w.shardDemand))
+	}
+
+	relay := relay.NewWakuRelay(w.bcaster, w.opts.minRelayPeersToPublish, w.timesource, w.opts.prometheusReg, w.log, relayOpts...)
 
 	w.relay = relay
 
@@ -294,6 +322,17 @@ func New(opts ...WakuNodeOption) (*WakuNode, error) {
 
 	w.store = store.NewWakuStore(w.peermanager, w.timesource, w.log, w.opts.storeRateLimit)
 
+	storeMsgProvider, _ := w.opts.messageProvider.(store.MessageProvider)
+	w.storeServer = store.NewWakuStoreServer(storeMsgProvider, w.opts.prometheusReg, w.log)
+
+	if w.opts.enableStoreSync {
+		syncMsgProvider, ok := w.opts.messageProvider.(storesync.MessageProvider)
+		if !ok {
+			return nil, errors.New("store sync requires a message provider that exposes message hashes")
+		}
+		w.storeSync = storesync.NewWakuStoreSync(syncMsgProvider, w.peermanager, w.timesource, w.opts.prometheusReg, w.log, w.opts.storeSyncOpts...)
+	}
+
 	if params.storeFactory != nil {
 		w.storeFactory = params.storeFactory
 	} else {
@@ -418,6 +457,9 @@ func (w *WakuNode) Start(ctx context.Context) error {
 			return err
 		}
 		w.registerAndMonitorReachability(ctx)
+		if w.shardDemand != nil {
+			w.shardDemand.start(ctx, w.wg)
+		}
 	}
 	w.peermanager.Start(ctx)
 
@@ -517,9 +559,14 @@ func (w *WakuNode) Stop() {
 
 	w.relay.Stop()
 	w.lightPush.Stop()
-	w.legacyStore.Stop()
+	// The filter subscriptions are persisted in the DB closed when the store stops
 	w.filterFullNode.Stop()
 	w.filterLightNode.Stop()
+	w.legacyStore.Stop()
+	w.storeServer.Stop()
+	if w.storeSync != nil {
+		w.storeSync.Stop()
+	}
 
 	if w.opts.enableDiscV5 {
 		w.discoveryV5.Stop()
@@ -608,6 +655,11 @@ func (w *WakuNode) LegacyStore() legacy_store.Store {
 	return w.legacyStore.(legacy_store.Store)
 }
 
+// MessageProvider returns the MessageProvider used to store the messages, if any
+func (w *WakuNode) MessageProvider() legacy_store.MessageProvider {
+	return w.opts.messageProvider
+}
+
 // Store is used to access any operation related to Waku Store protocol
 func (w *WakuNode) Store() *store.WakuStore {
 	return w.store
@@ -698,6 +750,22 @@ func (w *WakuNode) startStore(ctx context.Context, sub *relay.Subscription) erro
 		return err
 	}
 
+	w.storeServer.SetHost(w.host)
+	err = w.storeServer.Start(ctx)
+	if err != nil {
+		w.log.Error("starting store v3", zap.Error(err))
+		return err
+	}
+
+	if w.storeSync != nil {
+		w.storeSync.SetHost(w.host)
+		err = w.storeSync.Start(ctx)
+		if err != nil {
+			w.log.Error("starting store sync", zap.Error(err))
+			return err
+		}
+	}
+
 	return nil
 }
 
@@ -600,3 +600,46 @@ func TestPeerExchangeRatelimit(t *testing.T) {
 	err = wakuNode1.PeerExchange().Request(ctx, 1)
 	require.NoError(t, err)
 }
+
+func TestShardDemand(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	testClusterID := uint16(22)
+
+	hostAddr, err := net.ResolveTCPAddr("tcp", "0.0.0.0:0")
+	require.NoError(t, err)
+	wakuNode, err := New(
+		WithHostAddress(hostAddr),
+		WithWakuRelay(),
+		WithWakuFilterFullNode(),
+		WithClusterID(testClusterID),
+		WithShardDemand(ShardDemandConfig{MaxShards: 1, IdleTimeout: 500 * time.Millisecond}),
+	)
+	require.NoError(t, err)
+	err = wakuNode.Start(ctx)
+	require.NoError(t, err)
+	defer wakuNode.Stop()
+
+	pubsubTopic1 := protocol.NewStaticShardingPubsubTopic(testClusterID, 1).String()
+	pubsubTopic2 := protocol.NewStaticShardingPubsubTopic(testClusterID, 2).String()
+	otherClusterTopic := protocol.NewStaticShardingPubsubTopic(testClusterID+1, 1).String()
+
+	require.NoError(t, wakuNode.shardDemand.Request(ctx, pubsubTopic1))
+	require.True(t, wakuNode.Relay().IsSubscribed(pubsubTopic1))
+	require.ErrorIs(t, wakuNode.shardDemand.Request(ctx, pubsubTopic2), ErrShardLimitReached)
+	require.ErrorIs(t, wakuNode.shardDemand.Request(ctx, otherClusterTopic), ErrShardNotServed)
+	require.ErrorIs(t, wakuNode.shardDemand.Request(ctx, "/waku/2/default-waku/proto"), ErrShardNotServed)
+
+	// The shard is advertised in the ENR
+	require.Eventually(t, func() bool {
+		rs, err := wenr.RelaySharding(wakuNode.ENR().Record())
+		return err == nil && rs != nil && rs.Contains(testClusterID, 1)
+	}, 2*time.Second, 50*time.Millisecond)
+
+	// The shard is unsubscribed once it is no longer requested
+	require.Eventually(t, func() bool {
+		return !wakuNode.Relay().IsSubscribed(pubsubTopic1)
+	}, 3*time.Second, 100*time.Millisecond)
+	require.NoError(t, wakuNode.shardDemand.Request(ctx, pubsubTopic2))
+}
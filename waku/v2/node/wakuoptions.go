@@ -33,6 +33,8 @@ import (
 	"github.com/waku-org/go-waku/waku/v2/protocol/lightpush"
 	"github.com/waku-org/go-waku/waku/v2/protocol/pb"
 	"github.com/waku-org/go-waku/waku/v2/protocol/peer_exchange"
+	"github.com/waku-org/go-waku/waku/v2/protocol/relay"
+	"github.com/waku-org/go-waku/waku/v2/protocol/storesync"
 	"github.com/waku-org/go-waku/waku/v2/rendezvous"
 	"github.com/waku-org/go-waku/waku/v2/timesource"
 	"github.com/waku-org/go-waku/waku/v2/utils"
@@ -91,9 +93,16 @@ type WakuNodeParameters struct {
 
 	minRelayPeersToPublish int
 	maxMsgSizeBytes        int
+	relayRateLimit         *relay.RateLimitConfig
+	middleware             *protocol.Middleware
+	gossipsubProfile       *relay.GossipsubProfile
+	topicScoreParams       map[string]pubsub.TopicScoreParams
+	shardDemand            *ShardDemandConfig
 
 	enableStore     bool
 	messageProvider legacy_store.MessageProvider
+	enableStoreSync bool
+	storeSyncOpts   []storesync.Option
 
 	storeRateLimit rate.Limit
 
@@ -427,6 +436,67 @@ func WithMaxMsgSize(maxMsgSizeBytes int) WakuNodeOption {
 	}
 }
 
+// WithRelayRateLimit is a WakuNodeOption that limits the rate of the messages accepted
+// via relay from each peer and for each content topic
+func WithRelayRateLimit(cfg relay.RateLimitConfig) WakuNodeOption {
+	return func(params *WakuNodeParameters) error {
+		if err := cfg.Validate(); err != nil {
+			return err
+		}
+		params.relayRateLimit = &cfg
+		return nil
+	}
+}
+
+// WithGossipsubProfile is a WakuNodeOption that sets the gossipsub parameters and the default
+// topic score parameters of relay from a named profile
+func WithGossipsubProfile(name string) WakuNodeOption {
+	return func(params *WakuNodeParameters) error {
+		profile, err := relay.GetGossipsubProfile(name)
+		if err != nil {
+			return err
+		}
+		params.gossipsubProfile = &profile
+		return nil
+	}
+}
+
+// WithTopicScoreParams is a WakuNodeOption that sets the peer score parameters of a pubsub topic
+func WithTopicScoreParams(pubsubTopic string, scoreParams pubsub.TopicScoreParams) WakuNodeOption {
+	return func(params *WakuNodeParameters) error {
+		if params.topicScoreParams == nil {
+			params.topicScoreParams = make(map[string]pubsub.TopicScoreParams)
+		}
+		params.topicScoreParams[pubsubTopic] = scoreParams
+		return nil
+	}
+}
+
+// WithShardDemand is a WakuNodeOption that subscribes relay, when enabled, to the shards of the cluster requested
+// by filter and lightpush clients, and unsubscribes from them once they're no longer requested
+func WithShardDemand(cfg ShardDemandConfig) WakuNodeOption {
+	return func(params *WakuNodeParameters) error {
+		if err := cfg.Validate(); err != nil {
+			return err
+		}
+		params.shardDemand = &cfg
+		return nil
+	}
+}
+
+// WithMessageHook is a WakuNodeOption that registers a hook executed on the messages published
+// with relay or lightpush, received with relay, or pushed to filter clients, depending on its stage.
+// Hooks of the same stage are executed in the order in which they're registered
+func WithMessageHook(stage protocol.HookStage, hook protocol.MessageHook) WakuNodeOption {
+	return func(params *WakuNodeParameters) error {
+		if params.middleware == nil {
+			params.middleware = protocol.NewMiddleware()
+		}
+		params.middleware.Register(stage, hook)
+		return nil
+	}
+}
+
 func WithMaxPeerConnections(maxPeers int) WakuNodeOption {
 	return func(params *WakuNodeParameters) error {
 		params.maxPeerConnections = maxPeers
@@ -498,6 +568,17 @@ func WithWakuStore() WakuNodeOption {
 	}
 }
 
+// WithWakuStoreSync enables the Waku V2 Store Sync protocol, used to periodically
+// retrieve from other store nodes the messages missing in the message provider.
+// Requires the store protocol to be enabled
+func WithWakuStoreSync(storeSyncOpts ...storesync.Option) WakuNodeOption {
+	return func(params *WakuNodeParameters) error {
+		params.enableStoreSync = true
+		params.storeSyncOpts = storeSyncOpts
+		return nil
+	}
+}
+
 // WithWakuStoreFactory is used to replace the default WakuStore with a custom
 // implementation that implements the store.Store interface
 func WithWakuStoreFactory(factory storeFactory) WakuNodeOption {
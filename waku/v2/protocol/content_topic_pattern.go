@@ -0,0 +1,120 @@
+package protocol
+
+import (
+	"errors"
+	"fmt"
+	"path"
+	"strconv"
+	"strings"
+)
+
+var ErrInvalidPattern = errors.New("invalid content topic pattern")
+var ErrPatternWithoutShard = errors.New("content topic pattern requires a literal application name and version to derive its shard")
+var ErrPatternNotSupported = errors.New("content topic patterns are only supported by relay subscriptions")
+
+// IsContentTopicPattern returns true if a content topic contains wildcards. Each segment of a pattern
+// is matched with the syntax of path.Match, so * matches any sequence of characters within a segment.
+// A pattern whose last segment is * also matches the content topics with more segments, so
+// /app/1/* matches every content topic of version 1 of app
+func IsContentTopicPattern(contentTopic string) bool {
+	return strings.ContainsAny(contentTopic, "*?[")
+}
+
+// ValidateContentTopicPattern returns an error if a content topic pattern is malformed
+func ValidateContentTopicPattern(pattern string) error {
+	for _, segment := range strings.Split(pattern, "/") {
+		if _, err := path.Match(segment, ""); err != nil {
+			return fmt.Errorf("%w: %s", ErrInvalidPattern, pattern)
+		}
+	}
+	return nil
+}
+
+// MatchContentTopic returns true if a content topic matches a pattern. Content topics without
+// wildcards only match themselves
+func MatchContentTopic(pattern string, contentTopic string) bool {
+	if !IsContentTopicPattern(pattern) {
+		return pattern == contentTopic
+	}
+
+	patternSegments := strings.Split(pattern, "/")
+	segments := strings.Split(contentTopic, "/")
+
+	last := len(patternSegments) - 1
+	if patternSegments[last] == "*" {
+		// Prefix pattern
+		if len(segments) < len(patternSegments) {
+			return false
+		}
+		patternSegments = patternSegments[:last]
+		segments = segments[:last]
+	} else if len(segments) != len(patternSegments) {
+		return false
+	}
+
+	for i, segment := range segments {
+		if ok, err := path.Match(patternSegments[i], segment); err != nil || !ok {
+			return false
+		}
+	}
+
+	return true
+}
+
+// Match returns true if a content topic is in the set or matches one of its patterns
+func (cf ContentTopicSet) Match(contentTopic string) bool {
+	if _, ok := cf[contentTopic]; ok {
+		return true
+	}
+
+	for pattern := range cf {
+		if IsContentTopicPattern(pattern) && MatchContentTopic(pattern, contentTopic) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// GetPubSubTopicFromContentTopicPattern derives the autosharding pubsub topic of a content topic
+// or a content topic pattern. Since the shard only depends on the application name and version,
+// all the content topics matched by a pattern belong to the same shard as long as these are not
+// wildcards. Only relay subscriptions, which match the patterns locally, accept patterns
+func GetPubSubTopicFromContentTopicPattern(pattern string) (string, error) {
+	if !IsContentTopicPattern(pattern) {
+		return GetPubSubTopicFromContentTopic(pattern)
+	}
+
+	if err := ValidateContentTopicPattern(pattern); err != nil {
+		return "", err
+	}
+
+	p := strings.Split(pattern, "/")
+
+	// Patterns starting with a generation: /0/app/version/...
+	if len(p) > 1 {
+		if generation, err := strconv.Atoi(p[1]); err == nil {
+			if generation > 0 {
+				return "", ErrInvalidGeneration
+			}
+			p = append(p[:1], p[2:]...)
+		}
+	}
+
+	if len(p) < 4 || p[0] != "" || len(p[1]) == 0 || len(p[2]) == 0 || IsContentTopicPattern(p[1]) || IsContentTopicPattern(p[2]) {
+		return "", fmt.Errorf("%w : %s", ErrPatternWithoutShard, pattern)
+	}
+
+	cTopic := ContentTopic{
+		ApplicationName:    p[1],
+		ApplicationVersion: p[2],
+	}
+
+	return GetShardFromContentTopic(cTopic, GenerationZeroShardsCount).String(), nil
+}
+
+// ContentFilterPatternsToPubSubTopicMap converts a content filter whose content topics can be
+// patterns into a map of pubsub topics and the corresponding content topics
+func ContentFilterPatternsToPubSubTopicMap(contentFilter ContentFilter) (map[PubsubTopicStr][]ContentTopicStr, error) {
+	return generatePubsubToContentTopicMap(contentFilter.PubsubTopic, contentFilter.ContentTopicsList(), GetPubSubTopicFromContentTopicPattern)
+}
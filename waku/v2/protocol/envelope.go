@@ -1,6 +1,8 @@
 package protocol
 
 import (
+	"sync"
+
 	"github.com/waku-org/go-waku/waku/v2/hash"
 	"github.com/waku-org/go-waku/waku/v2/protocol/legacy_store/pb"
 	wpb "github.com/waku-org/go-waku/waku/v2/protocol/pb"
@@ -13,6 +15,9 @@ type Envelope struct {
 	msg   *wpb.WakuMessage
 	hash  wpb.MessageHash
 	index *pb.Index
+
+	annotationsMu sync.RWMutex
+	annotations   map[string]string
 }
 
 // NewEnvelope creates a new Envelope that contains a WakuMessage
@@ -51,3 +56,22 @@ func (e *Envelope) Hash() wpb.MessageHash {
 func (e *Envelope) Index() *pb.Index {
 	return e.index
 }
+
+// Annotate attaches a value to the envelope. Annotations are local to the node and are not
+// sent with the message. They're usually set by a MessageHook
+func (e *Envelope) Annotate(key string, value string) {
+	e.annotationsMu.Lock()
+	defer e.annotationsMu.Unlock()
+	if e.annotations == nil {
+		e.annotations = make(map[string]string)
+	}
+	e.annotations[key] = value
+}
+
+// Annotation returns the value attached to the envelope with Annotate
+func (e *Envelope) Annotation(key string) (string, bool) {
+	e.annotationsMu.RLock()
+	defer e.annotationsMu.RUnlock()
+	value, ok := e.annotations[key]
+	return value, ok
+}
@@ -10,6 +10,7 @@ const MaxCriteriaPerSubscription = 1000
 const MaxContentTopicsPerRequest = 100
 const MessagePushTimeout = 20 * time.Second
 const DefaultIdleSubscriptionTimeout = 5 * time.Minute
+const DefaultPushQueueSize = 100
 
 type FilterError struct {
 	Code    int
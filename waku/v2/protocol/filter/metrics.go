@@ -1,10 +1,13 @@
 package filter
 
 import (
+	"sync"
 	"time"
 
+	"github.com/libp2p/go-libp2p/core/peer"
 	"github.com/libp2p/go-libp2p/p2p/metricshelper"
 	"github.com/prometheus/client_golang/prometheus"
+	"github.com/waku-org/go-waku/waku/v2/utils"
 )
 
 var filterMessages = prometheus.NewCounter(
@@ -49,6 +52,90 @@ var filterSubscriptions = prometheus.NewGauge(
 		Help: "The number of filter subscriptions",
 	})
 
+var filterPeerSubscriptions = prometheus.NewGaugeVec(
+	prometheus.GaugeOpts{
+		Name: "waku_filter_peer_subscriptions",
+		Help: "The number of filter criteria subscribed by each peer",
+	},
+	[]string{"peerID"},
+)
+
+var filterPeerPushes = prometheus.NewCounterVec(
+	prometheus.CounterOpts{
+		Name: "waku_filter_peer_pushes",
+		Help: "The number of messages pushed to each peer, by result",
+	},
+	[]string{"peerID", "result"},
+)
+
+// maxPeerLabels is the number of subscribers that get their own label in the per-peer metrics
+const maxPeerLabels = 100
+
+// peerLabels keeps the per-peer metrics bounded. Once maxPeerLabels subscribers are labelled, the
+// metrics of the others are aggregated under utils.OtherLabel until they unsubscribe
+type peerLabels struct {
+	sync.Mutex
+	labels        *utils.BoundedLabels
+	otherCriteria map[peer.ID]int
+	otherTotal    int
+}
+
+var subscriberLabels = &peerLabels{
+	labels:        utils.NewBoundedLabels(maxPeerLabels),
+	otherCriteria: make(map[peer.ID]int),
+}
+
+// label returns the label of a peer. A peer that was aggregated under utils.OtherLabel keeps
+// that label, so its subscriptions are only counted once
+func (p *peerLabels) label(peerID peer.ID) string {
+	if _, ok := p.otherCriteria[peerID]; ok {
+		return utils.OtherLabel
+	}
+
+	label := p.labels.Label(peerID.String())
+	if label == utils.OtherLabel {
+		p.otherCriteria[peerID] = 0
+	}
+	return label
+}
+
+func (p *peerLabels) recordSubscriptions(peerID peer.ID, criteria int) {
+	p.Lock()
+	defer p.Unlock()
+
+	label := p.label(peerID)
+	if label == utils.OtherLabel {
+		p.otherTotal += criteria - p.otherCriteria[peerID]
+		p.otherCriteria[peerID] = criteria
+		criteria = p.otherTotal
+	}
+
+	filterPeerSubscriptions.WithLabelValues(label).Set(float64(criteria))
+}
+
+// recordPush counts a push under the label given to the peer when its subscriptions were
+// recorded, so a push that completes after the peer unsubscribed does not take a label
+func (p *peerLabels) recordPush(peerID peer.ID, result pushResult) {
+	filterPeerPushes.WithLabelValues(p.labels.Get(peerID.String()), string(result)).Inc()
+}
+
+func (p *peerLabels) remove(peerID peer.ID) {
+	p.Lock()
+	defer p.Unlock()
+
+	if criteria, ok := p.otherCriteria[peerID]; ok {
+		delete(p.otherCriteria, peerID)
+		p.otherTotal -= criteria
+		filterPeerSubscriptions.WithLabelValues(utils.OtherLabel).Set(float64(p.otherTotal))
+		return
+	}
+
+	if p.labels.Release(peerID.String()) {
+		filterPeerSubscriptions.DeleteLabelValues(peerID.String())
+		filterPeerPushes.DeletePartialMatch(prometheus.Labels{"peerID": peerID.String()})
+	}
+}
+
 var collectors = []prometheus.Collector{
 	filterMessages,
 	filterErrors,
@@ -56,6 +143,8 @@ var collectors = []prometheus.Collector{
 	filterSubscriptions,
 	filterRequestDurationSeconds,
 	filterHandleMessageDurationSeconds,
+	filterPeerSubscriptions,
+	filterPeerPushes,
 }
 
 // Metrics exposes the functions required to update prometheus metrics for filter protocol
@@ -65,6 +154,9 @@ type Metrics interface {
 	RecordPushDuration(duration time.Duration)
 	RecordSubscriptions(num int)
 	RecordError(err metricsErrCategory)
+	RecordPeerSubscriptions(peerID peer.ID, criteria int)
+	RecordPeerPush(peerID peer.ID, result pushResult)
+	RemovePeer(peerID peer.ID)
 }
 
 type metricsImpl struct {
@@ -96,6 +188,17 @@ var (
 	peerNotFoundFailure        metricsErrCategory = "peer_not_found_failure"
 	writeResponseFailure       metricsErrCategory = "write_response_failure"
 	pushTimeoutFailure         metricsErrCategory = "push_timeout_failure"
+	maxPeersFailure            metricsErrCategory = "max_peers_failure"
+	maxCriteriaFailure         metricsErrCategory = "max_criteria_failure"
+)
+
+type pushResult string
+
+var (
+	pushSuccess     pushResult = "success"
+	pushFailure     pushResult = "failure"
+	pushRateLimited pushResult = "rate_limited"
+	pushQueueFull   pushResult = "queue_full"
 )
 
 // RecordError increases the counter for different error types
@@ -118,3 +221,18 @@ func (m *metricsImpl) RecordPushDuration(duration time.Duration) {
 func (m *metricsImpl) RecordSubscriptions(num int) {
 	filterSubscriptions.Set(float64(num))
 }
+
+// RecordPeerSubscriptions tracks the number of filter criteria subscribed by a peer
+func (m *metricsImpl) RecordPeerSubscriptions(peerID peer.ID, criteria int) {
+	subscriberLabels.recordSubscriptions(peerID, criteria)
+}
+
+// RecordPeerPush increases the counter of messages pushed to a peer with a given result
+func (m *metricsImpl) RecordPeerPush(peerID peer.ID, result pushResult) {
+	subscriberLabels.recordPush(peerID, result)
+}
+
+// RemovePeer removes the metrics of a peer that is no longer subscribed
+func (m *metricsImpl) RemovePeer(peerID peer.ID) {
+	subscriberLabels.remove(peerID)
+}
@@ -0,0 +1,45 @@
+package filter
+
+import (
+	"testing"
+
+	"github.com/libp2p/go-libp2p/core/peer"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/stretchr/testify/require"
+	"github.com/waku-org/go-waku/waku/v2/utils"
+)
+
+func TestPeerLabels(t *testing.T) {
+	labels := &peerLabels{
+		labels:        utils.NewBoundedLabels(1),
+		otherCriteria: make(map[peer.ID]int),
+	}
+
+	subscriptions := func(label string) int {
+		m := &dto.Metric{}
+		require.NoError(t, filterPeerSubscriptions.WithLabelValues(label).Write(m))
+		return int(m.GetGauge().GetValue())
+	}
+
+	peer1 := peer.ID("peer1")
+	peer2 := peer.ID("peer2")
+	peer3 := peer.ID("peer3")
+
+	// Once the labels are taken, the subscriptions of the other peers are added up
+	labels.recordSubscriptions(peer1, 1)
+	labels.recordSubscriptions(peer2, 2)
+	labels.recordSubscriptions(peer3, 3)
+	labels.recordSubscriptions(peer3, 4)
+	require.Equal(t, 1, subscriptions(peer1.String()))
+	require.Equal(t, 6, subscriptions(utils.OtherLabel))
+
+	// A peer aggregated with the others keeps its label when a label is released
+	labels.remove(peer1)
+	labels.recordSubscriptions(peer2, 3)
+	require.Equal(t, 7, subscriptions(utils.OtherLabel))
+
+	labels.remove(peer2)
+	labels.remove(peer3)
+	require.Equal(t, 0, subscriptions(utils.OtherLabel))
+	require.Empty(t, labels.otherCriteria)
+}
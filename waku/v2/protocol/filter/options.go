@@ -11,6 +11,7 @@ import (
 	"github.com/waku-org/go-waku/waku/v2/peermanager"
 	"github.com/waku-org/go-waku/waku/v2/protocol"
 	"go.uber.org/zap"
+	"golang.org/x/time/rate"
 )
 
 func (old *FilterSubscribeParameters) Copy() *FilterSubscribeParameters {
@@ -56,7 +57,14 @@ type (
 	FilterParameters struct {
 		Timeout        time.Duration
 		MaxSubscribers int
+		MaxCriteria    int
+		pushLimit      rate.Limit
+		pushBurst      int
+		pushQueueSize  int
 		pm             *peermanager.PeerManager
+		middleware     *protocol.Middleware
+		shardDemand    protocol.ShardDemand
+		store          SubscriptionStore
 	}
 
 	Option func(*FilterParameters)
@@ -196,15 +204,64 @@ func WithMaxSubscribers(maxSubscribers int) Option {
 	}
 }
 
+// WithMaxCriteria sets the maximum number of filter criteria, pairs of pubsub topic and
+// content topic, a peer can subscribe to
+func WithMaxCriteria(maxCriteria int) Option {
+	return func(params *FilterParameters) {
+		params.MaxCriteria = maxCriteria
+	}
+}
+
+// WithPushRateLimit limits the number of messages per second pushed to each subscriber. The
+// messages exceeding the limit are dropped. Use 0 to disable the limit
+func WithPushRateLimit(r rate.Limit, b int) Option {
+	return func(params *FilterParameters) {
+		params.pushLimit = r
+		params.pushBurst = b
+	}
+}
+
+// WithPushQueueSize sets the number of messages waiting to be pushed to each subscriber. The
+// messages are dropped when the queue of a subscriber is full
+func WithPushQueueSize(size int) Option {
+	return func(params *FilterParameters) {
+		params.pushQueueSize = size
+	}
+}
+
 func WithPeerManager(pm *peermanager.PeerManager) Option {
 	return func(params *FilterParameters) {
 		params.pm = pm
 	}
 }
 
+// WithMiddleware executes the PreFilterPush hooks of a middleware on the messages before they're
+// pushed to the subscribers
+func WithMiddleware(m *protocol.Middleware) Option {
+	return func(params *FilterParameters) {
+		params.middleware = m
+	}
+}
+
+// WithShardDemand subscribes the node to the pubsub topics requested by the subscribers
+func WithShardDemand(d protocol.ShardDemand) Option {
+	return func(params *FilterParameters) {
+		params.shardDemand = d
+	}
+}
+
+// WithSubscriptionStore persists the subscriptions, so they are restored when the node restarts
+func WithSubscriptionStore(store SubscriptionStore) Option {
+	return func(params *FilterParameters) {
+		params.store = store
+	}
+}
+
 func DefaultOptions() []Option {
 	return []Option{
 		WithTimeout(DefaultIdleSubscriptionTimeout),
 		WithMaxSubscribers(DefaultMaxSubscribers),
+		WithMaxCriteria(MaxCriteriaPerSubscription),
+		WithPushQueueSize(DefaultPushQueueSize),
 	}
 }
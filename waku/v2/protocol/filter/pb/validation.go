@@ -4,6 +4,7 @@ import (
 	"errors"
 	"fmt"
 
+	"github.com/waku-org/go-waku/waku/v2/protocol"
 	"golang.org/x/exp/slices"
 )
 
@@ -15,6 +16,7 @@ var (
 	errNoContentTopics    = errors.New("at least one contenttopic should be specified")
 	errMaxContentTopics   = fmt.Errorf("exceeds maximum content topics: %d", MaxContentTopicsPerRequest)
 	errEmptyContentTopics = errors.New("one or more content topics specified is empty")
+	errPatternNotAllowed  = errors.New("content topic patterns are not supported by filter")
 	errMissingMessage     = errors.New("missing WakuMessage field")
 )
 
@@ -39,6 +41,12 @@ func (x *FilterSubscribeRequest) Validate() error {
 		if len(x.ContentTopics) > MaxContentTopicsPerRequest {
 			return errMaxContentTopics
 		}
+
+		for _, contentTopic := range x.ContentTopics {
+			if protocol.IsContentTopicPattern(contentTopic) {
+				return errPatternNotAllowed
+			}
+		}
 	}
 
 	return nil
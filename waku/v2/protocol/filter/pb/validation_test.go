@@ -18,6 +18,8 @@ func TestValidateRequest(t *testing.T) {
 	require.ErrorIs(t, request.Validate(), errNoContentTopics)
 	request.ContentTopics = []string{""}
 	require.ErrorIs(t, request.Validate(), errEmptyContentTopics)
+	request.ContentTopics[0] = "/test/1/*"
+	require.ErrorIs(t, request.Validate(), errPatternNotAllowed)
 	request.ContentTopics[0] = "test"
 	require.NoError(t, request.Validate())
 }
@@ -0,0 +1,135 @@
+package filter
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/libp2p/go-libp2p/core/peer"
+	"github.com/waku-org/go-waku/waku/v2/protocol"
+	"github.com/waku-org/go-waku/waku/v2/utils"
+	"golang.org/x/time/rate"
+)
+
+var errPushRateLimited = errors.New("subscriber exceeded the push rate limit")
+var errPushQueueFull = errors.New("push queue of subscriber is full")
+
+// minPushWorkerIdleTime is the minimum duration the queue of a subscriber is kept after its last push
+const minPushWorkerIdleTime = time.Minute
+
+type pushQueue struct {
+	ch      chan *protocol.Envelope
+	limiter *rate.Limiter
+}
+
+// pushScheduler delivers the messages to each subscriber from its own queue, in order. A slow or
+// noisy subscriber only delays and drops its own messages, and cannot starve the others
+type pushScheduler struct {
+	sync.Mutex
+	queues    map[peer.ID]*pushQueue
+	queueSize int
+	limit     rate.Limit
+	burst     int
+	idleTime  time.Duration
+	push      func(ctx context.Context, peerID peer.ID, env *protocol.Envelope)
+	wg        *sync.WaitGroup
+}
+
+func newPushScheduler(queueSize int, limit rate.Limit, burst int, wg *sync.WaitGroup, push func(ctx context.Context, peerID peer.ID, env *protocol.Envelope)) *pushScheduler {
+	idleTime := minPushWorkerIdleTime
+	if limit > 0 {
+		// Queues are kept until the bucket of their limiter is full again, so removing them
+		// does not allow exceeding the limit
+		if t := time.Duration(float64(burst) / float64(limit) * float64(time.Second)); t > idleTime {
+			idleTime = t
+		}
+	}
+
+	return &pushScheduler{
+		queues:    make(map[peer.ID]*pushQueue),
+		queueSize: queueSize,
+		limit:     limit,
+		burst:     burst,
+		idleTime:  idleTime,
+		push:      push,
+		wg:        wg,
+	}
+}
+
+// enqueue schedules the push of a message to a subscriber. It returns an error if the message
+// is dropped because the subscriber exceeded the rate limit or its queue is full
+func (s *pushScheduler) enqueue(ctx context.Context, peerID peer.ID, env *protocol.Envelope) error {
+	s.Lock()
+	defer s.Unlock()
+
+	q, ok := s.queues[peerID]
+	if !ok {
+		q = &pushQueue{ch: make(chan *protocol.Envelope, s.queueSize)}
+		if s.limit > 0 {
+			q.limiter = rate.NewLimiter(s.limit, s.burst)
+		}
+		s.queues[peerID] = q
+
+		s.wg.Add(1)
+		go s.run(ctx, peerID, q)
+	}
+
+	// Messages are only enqueued while holding the lock, so the queue can't fill up after this
+	// check, and no token is spent on a message dropped because the queue is full
+	if len(q.ch) == cap(q.ch) {
+		return errPushQueueFull
+	}
+
+	if q.limiter != nil && !q.limiter.Allow() {
+		return errPushRateLimited
+	}
+
+	select {
+	case q.ch <- env:
+		return nil
+	default:
+		return errPushQueueFull
+	}
+}
+
+func (s *pushScheduler) run(ctx context.Context, peerID peer.ID, q *pushQueue) {
+	defer utils.LogOnPanic()
+	defer s.wg.Done()
+	defer func() {
+		s.Lock()
+		if s.queues[peerID] == q {
+			delete(s.queues, peerID)
+		}
+		s.Unlock()
+	}()
+
+	ticker := time.NewTicker(s.idleTime)
+	defer ticker.Stop()
+
+	lastPush := time.Now()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case env := <-q.ch:
+			s.push(ctx, peerID, env)
+			lastPush = time.Now()
+		case <-ticker.C:
+			if time.Since(lastPush) < s.idleTime {
+				continue
+			}
+
+			// Messages are only enqueued while holding the lock, so none is lost when the queue is removed
+			s.Lock()
+			idle := len(q.ch) == 0
+			if idle {
+				delete(s.queues, peerID)
+			}
+			s.Unlock()
+			if idle {
+				return
+			}
+		}
+	}
+}
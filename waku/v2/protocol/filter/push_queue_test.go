@@ -0,0 +1,80 @@
+package filter
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/libp2p/go-libp2p/core/peer"
+	"github.com/stretchr/testify/require"
+	"github.com/waku-org/go-waku/waku/v2/protocol"
+	"github.com/waku-org/go-waku/waku/v2/protocol/pb"
+	"github.com/waku-org/go-waku/waku/v2/utils"
+)
+
+func TestPushScheduler(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	slowPeer := createPeerID(t)
+	fastPeer := createPeerID(t)
+	limitedPeer := createPeerID(t)
+
+	started := make(chan struct{}, 10)
+	release := make(chan struct{})
+	pushed := make(chan int64, 10)
+	wg := &sync.WaitGroup{}
+	push := func(ctx context.Context, peerID peer.ID, env *protocol.Envelope) {
+		if peerID == slowPeer {
+			started <- struct{}{}
+			<-release
+			return
+		}
+		pushed <- env.Message().GetTimestamp()
+	}
+
+	s := newPushScheduler(2, 0, 0, wg, push)
+	limited := newPushScheduler(10, 1, 2, wg, push)
+
+	env := func(i int64) *protocol.Envelope {
+		return protocol.NewEnvelope(&pb.WakuMessage{ContentTopic: "topic1", Timestamp: &i}, *utils.GetUnixEpoch(), PUBSUB_TOPIC)
+	}
+
+	// The queue of a slow subscriber fills up without delaying the others
+	require.NoError(t, s.enqueue(ctx, slowPeer, env(1)))
+	<-started
+	require.NoError(t, s.enqueue(ctx, slowPeer, env(2)))
+	require.NoError(t, s.enqueue(ctx, slowPeer, env(3)))
+	require.ErrorIs(t, s.enqueue(ctx, slowPeer, env(4)), errPushQueueFull)
+
+	for i := int64(1); i <= 2; i++ {
+		require.NoError(t, s.enqueue(ctx, fastPeer, env(i)))
+		select {
+		case ts := <-pushed:
+			require.Equal(t, i, ts)
+		case <-time.After(time.Second):
+			require.Fail(t, "message not pushed")
+		}
+	}
+
+	// Messages dropped because the queue is full don't spend the tokens of the rate limit
+	limitedSlow := newPushScheduler(1, 0.001, 3, wg, push)
+	require.NoError(t, limitedSlow.enqueue(ctx, slowPeer, env(1)))
+	<-started
+	require.NoError(t, limitedSlow.enqueue(ctx, slowPeer, env(2)))
+	require.ErrorIs(t, limitedSlow.enqueue(ctx, slowPeer, env(3)), errPushQueueFull)
+	require.ErrorIs(t, limitedSlow.enqueue(ctx, slowPeer, env(4)), errPushQueueFull)
+	limitedSlow.Lock()
+	require.GreaterOrEqual(t, limitedSlow.queues[slowPeer].limiter.Tokens(), 1.0)
+	limitedSlow.Unlock()
+
+	// Pushes exceeding the rate limit are dropped
+	require.NoError(t, limited.enqueue(ctx, limitedPeer, env(1)))
+	require.NoError(t, limited.enqueue(ctx, limitedPeer, env(2)))
+	require.ErrorIs(t, limited.enqueue(ctx, limitedPeer, env(3)), errPushRateLimited)
+
+	close(release)
+	cancel()
+	wg.Wait()
+}
@@ -39,8 +39,12 @@ type (
 		*service.CommonService
 		subscriptions *SubscribersMap
 		pm            *peermanager.PeerManager
+		middleware    *protocol.Middleware
+		shardDemand   protocol.ShardDemand
 
 		maxSubscriptions int
+		maxCriteria      int
+		pushes           *pushScheduler
 	}
 )
 
@@ -60,6 +64,14 @@ func NewWakuFilterFullNode(timesource timesource.Timesource, reg prometheus.Regi
 	wf.metrics = newMetrics(reg)
 	wf.subscriptions = NewSubscribersMap(params.Timeout)
 	wf.maxSubscriptions = params.MaxSubscribers
+	wf.maxCriteria = params.MaxCriteria
+	wf.pushes = newPushScheduler(params.pushQueueSize, params.pushLimit, params.pushBurst, wf.WaitGroup(), wf.deliver)
+	wf.subscriptions.onRemove = wf.metrics.RemovePeer
+	wf.middleware = params.middleware
+	wf.shardDemand = params.shardDemand
+	if params.store != nil {
+		wf.subscriptions.setStore(params.store, wf.log)
+	}
 	if params.pm != nil {
 		params.pm.RegisterWakuProtocol(FilterSubscribeID_v20beta1, FilterSubscribeENRField)
 		wf.pm = params.pm
@@ -85,12 +97,46 @@ func (wf *WakuFilterFullNode) start(sub *relay.Subscription) error {
 	wf.WaitGroup().Add(1)
 	go wf.filterListener(wf.Context())
 
-	wf.subscriptions.Start(wf.Context())
+	wf.restoreSubscriptions()
+	wf.subscriptions.Start(wf.Context(), wf.WaitGroup())
 
 	wf.log.Info("filter-subscriber protocol started")
 	return nil
 }
 
+func (wf *WakuFilterFullNode) restoreSubscriptions() {
+	restored, err := wf.subscriptions.restore()
+	if err != nil {
+		wf.log.Error("restoring subscriptions", zap.Error(err))
+		return
+	}
+	if len(restored) == 0 {
+		return
+	}
+
+	if wf.shardDemand != nil {
+		for _, s := range restored {
+			if err := wf.shardDemand.Request(wf.Context(), s.PubsubTopic); err != nil {
+				wf.log.Warn("subscribing to pubsub topic of restored subscription", zap.String("pubsubTopic", s.PubsubTopic), zap.Error(err))
+			}
+		}
+	}
+
+	recorded := make(map[peer.ID]struct{})
+	for _, s := range restored {
+		if _, ok := recorded[s.PeerID]; ok {
+			continue
+		}
+		recorded[s.PeerID] = struct{}{}
+		if criteria, _ := wf.subscriptions.Criteria(s.PeerID, "", nil); criteria != 0 {
+			wf.metrics.RecordPeerSubscriptions(s.PeerID, criteria)
+		}
+	}
+
+	wf.metrics.RecordSubscriptions(wf.subscriptions.Count())
+	wf.log.Info("restored subscriptions", zap.Int("peers", wf.subscriptions.Count()))
+}
+
 func (wf *WakuFilterFullNode) onRequest(ctx context.Context) func(network.Stream) {
 	return func(stream network.Stream) {
 		logger := wf.log.With(logging.HostID("peer", stream.Conn().RemotePeer()))
@@ -172,21 +218,24 @@ func (wf *WakuFilterFullNode) ping(ctx context.Context, stream network.Stream, r
 }
 
 func (wf *WakuFilterFullNode) subscribe(ctx context.Context, stream network.Stream, request *pb.FilterSubscribeRequest) {
-	if wf.subscriptions.Count() >= wf.maxSubscriptions {
+	peerID := stream.Conn().RemotePeer()
+
+	if !wf.subscriptions.Has(peerID) && wf.subscriptions.Count() >= wf.maxSubscriptions {
+		wf.metrics.RecordError(maxPeersFailure)
 		wf.reply(ctx, stream, request, http.StatusServiceUnavailable, "node has reached maximum number of subscriptions")
 		return
 	}
 
-	peerID := stream.Conn().RemotePeer()
-
-	if totalSubs, exists := wf.subscriptions.Get(peerID); exists {
-		ctTotal := 0
-		for _, contentTopicSet := range totalSubs {
-			ctTotal += len(contentTopicSet)
-		}
+	_, criteria := wf.subscriptions.Criteria(peerID, *request.PubsubTopic, request.ContentTopics)
+	if criteria > wf.maxCriteria {
+		wf.metrics.RecordError(maxCriteriaFailure)
+		wf.reply(ctx, stream, request, http.StatusServiceUnavailable, "peer has reached maximum number of filter criteria")
+		return
+	}
 
-		if ctTotal+len(request.ContentTopics) > MaxCriteriaPerSubscription {
-			wf.reply(ctx, stream, request, http.StatusServiceUnavailable, "peer has reached maximum number of filter criteria")
+	if wf.shardDemand != nil {
+		if err := wf.shardDemand.Request(ctx, *request.PubsubTopic); err != nil {
+			wf.reply(ctx, stream, request, http.StatusServiceUnavailable, err.Error())
 			return
 		}
 	}
@@ -194,15 +243,25 @@ func (wf *WakuFilterFullNode) subscribe(ctx context.Context, stream network.Stre
 	wf.subscriptions.Set(peerID, *request.PubsubTopic, request.ContentTopics)
 
 	wf.metrics.RecordSubscriptions(wf.subscriptions.Count())
+	wf.metrics.RecordPeerSubscriptions(peerID, criteria)
 	wf.reply(ctx, stream, request, http.StatusOK)
 }
 
+// HasSubscribers returns true if a peer is subscribed to a pubsub topic
+func (wf *WakuFilterFullNode) HasSubscribers(pubsubTopic string) bool {
+	return wf.subscriptions.HasPubsubTopic(pubsubTopic)
+}
+
 func (wf *WakuFilterFullNode) unsubscribe(ctx context.Context, stream network.Stream, request *pb.FilterSubscribeRequest) {
-	err := wf.subscriptions.Delete(stream.Conn().RemotePeer(), *request.PubsubTopic, request.ContentTopics)
+	peerID := stream.Conn().RemotePeer()
+	err := wf.subscriptions.Delete(peerID, *request.PubsubTopic, request.ContentTopics)
 	if err != nil {
 		wf.reply(ctx, stream, request, http.StatusNotFound, peerHasNoSubscription)
 	} else {
 		wf.metrics.RecordSubscriptions(wf.subscriptions.Count())
+		if criteria, _ := wf.subscriptions.Criteria(peerID, "", nil); criteria != 0 {
+			wf.metrics.RecordPeerSubscriptions(peerID, criteria)
+		}
 		wf.reply(ctx, stream, request, http.StatusOK)
 	}
 }
@@ -224,6 +283,13 @@ func (wf *WakuFilterFullNode) filterListener(ctx context.Context) {
 	// This function is invoked for each message received
 	// on the full node in context of Waku2-Filter
 	handle := func(envelope *protocol.Envelope) error {
+		pushed, err := wf.middleware.Run(ctx, protocol.PreFilterPush, envelope)
+		if err != nil {
+			wf.log.Debug("message not pushed", logging.Hash(envelope.Hash()), zap.Error(err))
+			return nil
+		}
+		envelope = pushed
+
 		msg := envelope.Message()
 		pubsubTopic := envelope.PubsubTopic()
 		logger := utils.MessagesLogger("filter").With(logging.Hash(envelope.Hash()),
@@ -233,23 +299,19 @@ func (wf *WakuFilterFullNode) filterListener(ctx context.Context) {
 		logger.Debug("push message to filter subscribers")
 
 		// Each subscriber is a light node that earlier on invoked
-		// a FilterRequest on this node
+		// a FilterRequest on this node. Messages are pushed from a
+		// queue per subscriber, so a slow subscriber doesn't delay the others
 		for subscriber := range wf.subscriptions.Items(pubsubTopic, msg.ContentTopic) {
-			logger := logger.With(logging.HostID("peer", subscriber))
-			// Do a message push to light node
-			logger.Debug("pushing message to light node")
-			wf.WaitGroup().Add(1)
-			go func(subscriber peer.ID) {
-				defer utils.LogOnPanic()
-				defer wf.WaitGroup().Done()
-				start := time.Now()
-				err := wf.pushMessage(ctx, logger, subscriber, envelope)
-				if err != nil {
-					logger.Error("pushing message", zap.Error(err))
-					return
-				}
-				wf.metrics.RecordPushDuration(time.Since(start))
-			}(subscriber)
+			err := wf.pushes.enqueue(ctx, subscriber, envelope)
+			switch {
+			case errors.Is(err, errPushRateLimited):
+				wf.metrics.RecordPeerPush(subscriber, pushRateLimited)
+			case errors.Is(err, errPushQueueFull):
+				wf.metrics.RecordPeerPush(subscriber, pushQueueFull)
+			}
+			if err != nil {
+				logger.Debug("message not pushed to light node", logging.HostID("peer", subscriber), zap.Error(err))
+			}
 		}
 
 		return nil
@@ -262,6 +324,26 @@ func (wf *WakuFilterFullNode) filterListener(ctx context.Context) {
 	}
 }
 
+// deliver pushes a message to a subscriber. It's called from the queue of the subscriber
+func (wf *WakuFilterFullNode) deliver(ctx context.Context, peerID peer.ID, envelope *protocol.Envelope) {
+	logger := utils.MessagesLogger("filter").With(logging.Hash(envelope.Hash()),
+		zap.String("pubsubTopic", envelope.PubsubTopic()),
+		zap.String("contentTopic", envelope.Message().ContentTopic),
+		logging.HostID("peer", peerID),
+	)
+	logger.Debug("pushing message to light node")
+
+	start := time.Now()
+	err := wf.pushMessage(ctx, logger, peerID, envelope)
+	if err != nil {
+		wf.metrics.RecordPeerPush(peerID, pushFailure)
+		logger.Error("pushing message", zap.Error(err))
+		return
+	}
+	wf.metrics.RecordPushDuration(time.Since(start))
+	wf.metrics.RecordPeerPush(peerID, pushSuccess)
+}
+
 func (wf *WakuFilterFullNode) pushMessage(ctx context.Context, logger *zap.Logger, peerID peer.ID, env *protocol.Envelope) error {
 	pubSubTopic := env.PubsubTopic()
 	messagePush := &pb.MessagePush{
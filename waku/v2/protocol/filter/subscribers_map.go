@@ -11,6 +11,7 @@ import (
 	"github.com/libp2p/go-libp2p/core/peer"
 	"github.com/waku-org/go-waku/waku/v2/protocol"
 	"github.com/waku-org/go-waku/waku/v2/utils"
+	"go.uber.org/zap"
 )
 
 type PeerSet map[peer.ID]struct{}
@@ -28,6 +29,11 @@ type SubscribersMap struct {
 	interestMap map[string]PeerSet // key: sha256(pubsubTopic-contentTopic) => peers
 	timeout     time.Duration
 	lastSeen    map[peer.ID]time.Time
+
+	store *persistQueue
+
+	// onRemove is called when all the subscriptions of a peer are removed
+	onRemove func(peerID peer.ID)
 }
 
 func NewSubscribersMap(timeout time.Duration) *SubscribersMap {
@@ -39,8 +45,60 @@ func NewSubscribersMap(timeout time.Duration) *SubscribersMap {
 	}
 }
 
-func (sub *SubscribersMap) Start(ctx context.Context) {
+// setStore persists the changes of the subscriptions in a SubscriptionStore
+func (sub *SubscribersMap) setStore(store SubscriptionStore, log *zap.Logger) {
+	sub.store = newPersistQueue(store, log)
+}
+
+// restore loads the subscriptions of the peers seen within the subscription timeout from the
+// SubscriptionStore, and returns them
+func (sub *SubscribersMap) restore() ([]StoredSubscription, error) {
+	if sub.store == nil {
+		return nil, nil
+	}
+
+	stored, err := sub.store.store.Load(time.Now().Add(-sub.timeout))
+	if err != nil {
+		return nil, err
+	}
+
+	sub.Lock()
+	defer sub.Unlock()
+
+	for _, s := range stored {
+		pubsubTopicMap, ok := sub.items[s.PeerID]
+		if !ok {
+			pubsubTopicMap = make(PubsubTopics)
+			sub.items[s.PeerID] = pubsubTopicMap
+		}
+
+		contentTopicsMap, ok := pubsubTopicMap[s.PubsubTopic]
+		if !ok {
+			contentTopicsMap = make(protocol.ContentTopicSet)
+			pubsubTopicMap[s.PubsubTopic] = contentTopicsMap
+		}
+
+		for _, c := range s.ContentTopics {
+			contentTopicsMap[c] = struct{}{}
+			sub.addToInterestMap(s.PeerID, s.PubsubTopic, c)
+		}
+
+		if s.LastSeen.After(sub.lastSeen[s.PeerID]) {
+			sub.lastSeen[s.PeerID] = s.LastSeen
+		}
+	}
+
+	return stored, nil
+}
+
+// Start removes the expired subscriptions and persists the changes until the context is done.
+// The changes still pending then are persisted before wg is done
+func (sub *SubscribersMap) Start(ctx context.Context, wg *sync.WaitGroup) {
 	go sub.cleanUp(ctx, cleanupInterval)
+	if sub.store != nil {
+		wg.Add(1)
+		go sub.store.run(ctx, wg)
+	}
 }
 
 func (sub *SubscribersMap) Clear() {
@@ -56,7 +114,12 @@ func (sub *SubscribersMap) Set(peerID peer.ID, pubsubTopic string, contentTopics
 	sub.Lock()
 	defer sub.Unlock()
 
-	sub.lastSeen[peerID] = time.Now()
+	now := time.Now()
+	sub.lastSeen[peerID] = now
+
+	if sub.store != nil {
+		sub.store.put(peerID, pubsubTopic, contentTopics, now)
+	}
 
 	pubsubTopicMap, ok := sub.items[peerID]
 	if !ok {
@@ -116,7 +179,13 @@ func (sub *SubscribersMap) Delete(peerID peer.ID, pubsubTopic string, contentTop
 
 	// Updating first the lastSeen since this is a valid activity
 	// (it will still get deleted if all content topics are removed)
-	sub.lastSeen[peerID] = time.Now()
+	now := time.Now()
+	sub.lastSeen[peerID] = now
+
+	if sub.store != nil {
+		sub.store.delete(peerID, pubsubTopic, contentTopics)
+		sub.store.refresh(peerID, now)
+	}
 
 	// Removing content topics individually
 	for _, c := range contentTopics {
@@ -137,6 +206,9 @@ func (sub *SubscribersMap) Delete(peerID peer.ID, pubsubTopic string, contentTop
 	if len(sub.items[peerID]) == 0 {
 		delete(sub.items, peerID)
 		delete(sub.lastSeen, peerID)
+		if sub.onRemove != nil {
+			sub.onRemove(peerID)
+		}
 	}
 
 	return nil
@@ -158,6 +230,14 @@ func (sub *SubscribersMap) deleteAll(peerID peer.ID) error {
 	delete(sub.items, peerID)
 	delete(sub.lastSeen, peerID)
 
+	if sub.store != nil {
+		sub.store.deleteAll(peerID)
+	}
+
+	if sub.onRemove != nil {
+		sub.onRemove(peerID)
+	}
+
 	return nil
 }
 
@@ -183,6 +263,47 @@ func (sub *SubscribersMap) Count() int {
 	return len(sub.items)
 }
 
+// Criteria returns the number of filter criteria subscribed by a peer, and the number of
+// criteria it would have after subscribing to some content topics of a pubsub topic
+func (sub *SubscribersMap) Criteria(peerID peer.ID, pubsubTopic string, contentTopics []string) (int, int) {
+	sub.RLock()
+	defer sub.RUnlock()
+
+	current := 0
+	for _, contentTopicSet := range sub.items[peerID] {
+		current += len(contentTopicSet)
+	}
+
+	after := current
+	subscribed := sub.items[peerID][pubsubTopic]
+	added := make(map[string]struct{})
+	for _, c := range contentTopics {
+		if _, ok := subscribed[c]; ok {
+			continue
+		}
+		if _, ok := added[c]; ok {
+			continue
+		}
+		added[c] = struct{}{}
+		after++
+	}
+
+	return current, after
+}
+
+// HasPubsubTopic returns true if a peer is subscribed to a pubsub topic
+func (sub *SubscribersMap) HasPubsubTopic(pubsubTopic string) bool {
+	sub.RLock()
+	defer sub.RUnlock()
+
+	for _, pubsubTopics := range sub.items {
+		if _, ok := pubsubTopics[pubsubTopic]; ok {
+			return true
+		}
+	}
+	return false
+}
+
 func (sub *SubscribersMap) Items(pubsubTopic string, contentTopic string) <-chan peer.ID {
 	c := make(chan peer.ID)
 
@@ -234,7 +355,12 @@ func (sub *SubscribersMap) Refresh(peerID peer.ID) {
 	sub.Lock()
 	defer sub.Unlock()
 
-	sub.lastSeen[peerID] = time.Now()
+	now := time.Now()
+	sub.lastSeen[peerID] = now
+
+	if sub.store != nil {
+		sub.store.refresh(peerID, now)
+	}
 }
 
 func (sub *SubscribersMap) cleanUp(ctx context.Context, cleanupInterval time.Duration) {
@@ -250,7 +376,7 @@ func (sub *SubscribersMap) cleanUp(ctx context.Context, cleanupInterval time.Dur
 			sub.Lock()
 			for peerID, lastSeen := range sub.lastSeen {
 				elapsedTime := time.Since(lastSeen)
-				if elapsedTime < sub.timeout {
+				if elapsedTime > sub.timeout {
 					_ = sub.deleteAll(peerID)
 				}
 
@@ -9,6 +9,8 @@ import (
 	"github.com/libp2p/go-libp2p/core/test"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"github.com/waku-org/go-waku/waku/persistence/sqlite"
+	"github.com/waku-org/go-waku/waku/v2/utils"
 )
 
 const PUBSUB_TOPIC = "/test/topic"
@@ -107,6 +109,23 @@ func TestRemoveBogus(t *testing.T) {
 	require.Error(t, err)
 }
 
+func TestCriteria(t *testing.T) {
+	subs := NewSubscribersMap(5 * time.Second)
+	peerId := createPeerID(t)
+
+	current, after := subs.Criteria(peerId, PUBSUB_TOPIC, []string{"topic1", "topic1", "topic2"})
+	require.Equal(t, 0, current)
+	require.Equal(t, 2, after)
+
+	subs.Set(peerId, PUBSUB_TOPIC, []string{"topic1", "topic2"})
+	current, after = subs.Criteria(peerId, PUBSUB_TOPIC, []string{"topic2", "topic3"})
+	require.Equal(t, 2, current)
+	require.Equal(t, 3, after)
+
+	_, after = subs.Criteria(peerId, "/test/topic2", []string{"topic2"})
+	require.Equal(t, 3, after)
+}
+
 func TestCleanup(t *testing.T) {
 	subs := NewSubscribersMap(2 * time.Second)
 
@@ -125,6 +144,10 @@ func TestCleanup(t *testing.T) {
 	_, exists := subs.Get(peerId)
 	require.True(t, exists)
 
+	// Active subscriptions are kept
+	time.Sleep(time.Second)
+	require.True(t, subs.Has(peerId))
+
 	time.Sleep(2 * time.Second)
 
 	hasSubs = subs.Has(peerId)
@@ -133,3 +156,50 @@ func TestCleanup(t *testing.T) {
 	_, exists = subs.Get(peerId)
 	require.False(t, exists)
 }
+
+func TestPersistentSubscriptions(t *testing.T) {
+	db, err := sqlite.NewDB(":memory:", utils.Logger())
+	require.NoError(t, err)
+	err = sqlite.Migrations(db, utils.Logger())
+	require.NoError(t, err)
+	store := NewDBSubscriptionStore(db, utils.Logger())
+
+	subs := NewSubscribersMap(time.Minute)
+	subs.setStore(store, utils.Logger())
+
+	peerId1 := createPeerID(t)
+	peerId2 := createPeerID(t)
+	peerId3 := createPeerID(t)
+
+	subs.Set(peerId1, PUBSUB_TOPIC, []string{"topic1", "topic2"})
+	subs.Set(peerId1, "/test/topic2", []string{"topic3"})
+	subs.Set(peerId2, PUBSUB_TOPIC, []string{"topic1"})
+	subs.Set(peerId3, PUBSUB_TOPIC, []string{"topic1"})
+	err = subs.Delete(peerId1, PUBSUB_TOPIC, []string{"topic2"})
+	require.NoError(t, err)
+	err = subs.DeleteAll(peerId3)
+	require.NoError(t, err)
+	subs.store.flush()
+
+	// Subscriptions of peers not seen within the timeout are not restored
+	peerId4 := createPeerID(t)
+	err = store.Put(peerId4, PUBSUB_TOPIC, []string{"topic1"}, time.Now().Add(-2*time.Minute))
+	require.NoError(t, err)
+
+	restored := NewSubscribersMap(time.Minute)
+	restored.setStore(store, utils.Logger())
+	stored, err := restored.restore()
+	require.NoError(t, err)
+	require.Len(t, stored, 3)
+	require.Equal(t, 2, restored.Count())
+
+	pubsubTopics, ok := restored.Get(peerId1)
+	require.True(t, ok)
+	require.Len(t, pubsubTopics, 2)
+	require.Len(t, pubsubTopics[PUBSUB_TOPIC], 1)
+	require.Equal(t, peerId1, firstSubscriber(restored, "/test/topic2", "topic3"))
+	require.Empty(t, firstSubscriber(restored, PUBSUB_TOPIC, "topic2"))
+	require.True(t, restored.Has(peerId2))
+	require.False(t, restored.Has(peerId3))
+	require.False(t, restored.Has(peerId4))
+}
@@ -0,0 +1,260 @@
+package filter
+
+import (
+	"context"
+	"database/sql"
+	"sync"
+	"time"
+
+	"github.com/libp2p/go-libp2p/core/peer"
+	"github.com/waku-org/go-waku/waku/v2/utils"
+	"go.uber.org/zap"
+)
+
+// StoredSubscription is the subscription of a peer to a pubsub topic, as persisted by a SubscriptionStore
+type StoredSubscription struct {
+	PeerID        peer.ID
+	PubsubTopic   string
+	ContentTopics []string
+	LastSeen      time.Time
+}
+
+// SubscriptionStore persists the subscriptions of the filter full node, so they can be
+// restored after a restart
+type SubscriptionStore interface {
+	// Put adds content topics to the subscription of a peer to a pubsub topic
+	Put(peerID peer.ID, pubsubTopic string, contentTopics []string, lastSeen time.Time) error
+	// Delete removes content topics from the subscription of a peer to a pubsub topic
+	Delete(peerID peer.ID, pubsubTopic string, contentTopics []string) error
+	// DeleteAll removes all the subscriptions of a peer
+	DeleteAll(peerID peer.ID) error
+	// Refresh updates the last time a peer was seen
+	Refresh(peerID peer.ID, lastSeen time.Time) error
+	// Load removes the subscriptions of the peers not seen since a given time, and returns the rest
+	Load(seenSince time.Time) ([]StoredSubscription, error)
+}
+
+// DBSubscriptionStore is a SubscriptionStore backed by the SQL database used by the
+// message store. Its table is created by the database migrations
+type DBSubscriptionStore struct {
+	db  *sql.DB
+	log *zap.Logger
+}
+
+// NewDBSubscriptionStore creates a SubscriptionStore for a SQLite or PostgreSQL database
+func NewDBSubscriptionStore(db *sql.DB, log *zap.Logger) *DBSubscriptionStore {
+	return &DBSubscriptionStore{
+		db:  db,
+		log: log.Named("filter-subscriptions-db"),
+	}
+}
+
+func (s *DBSubscriptionStore) Put(peerID peer.ID, pubsubTopic string, contentTopics []string, lastSeen time.Time) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+
+	for _, contentTopic := range contentTopics {
+		_, err = tx.Exec("INSERT INTO filter_subscriptions (peerId, pubsubTopic, contentTopic, lastSeen) VALUES ($1, $2, $3, $4) ON CONFLICT (peerId, pubsubTopic, contentTopic) DO NOTHING",
+			peerID.String(), pubsubTopic, contentTopic, lastSeen.UnixNano())
+		if err != nil {
+			_ = tx.Rollback()
+			return err
+		}
+	}
+
+	_, err = tx.Exec("UPDATE filter_subscriptions SET lastSeen = $1 WHERE peerId = $2", lastSeen.UnixNano(), peerID.String())
+	if err != nil {
+		_ = tx.Rollback()
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func (s *DBSubscriptionStore) Delete(peerID peer.ID, pubsubTopic string, contentTopics []string) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+
+	for _, contentTopic := range contentTopics {
+		_, err = tx.Exec("DELETE FROM filter_subscriptions WHERE peerId = $1 AND pubsubTopic = $2 AND contentTopic = $3",
+			peerID.String(), pubsubTopic, contentTopic)
+		if err != nil {
+			_ = tx.Rollback()
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+func (s *DBSubscriptionStore) DeleteAll(peerID peer.ID) error {
+	_, err := s.db.Exec("DELETE FROM filter_subscriptions WHERE peerId = $1", peerID.String())
+	return err
+}
+
+func (s *DBSubscriptionStore) Refresh(peerID peer.ID, lastSeen time.Time) error {
+	_, err := s.db.Exec("UPDATE filter_subscriptions SET lastSeen = $1 WHERE peerId = $2", lastSeen.UnixNano(), peerID.String())
+	return err
+}
+
+func (s *DBSubscriptionStore) Load(seenSince time.Time) ([]StoredSubscription, error) {
+	_, err := s.db.Exec("DELETE FROM filter_subscriptions WHERE lastSeen < $1", seenSince.UnixNano())
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := s.db.Query("SELECT peerId, pubsubTopic, contentTopic, lastSeen FROM filter_subscriptions ORDER BY peerId, pubsubTopic")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var result []StoredSubscription
+	for rows.Next() {
+		var (
+			rawPeerID    string
+			pubsubTopic  string
+			contentTopic string
+			lastSeen     int64
+		)
+
+		err = rows.Scan(&rawPeerID, &pubsubTopic, &contentTopic, &lastSeen)
+		if err != nil {
+			return nil, err
+		}
+
+		peerID, err := peer.Decode(rawPeerID)
+		if err != nil {
+			s.log.Warn("decoding peer id", zap.String("peerID", rawPeerID), zap.Error(err))
+			continue
+		}
+
+		// Rows are sorted, so the content topics of a subscription are consecutive
+		if n := len(result); n != 0 && result[n-1].PeerID == peerID && result[n-1].PubsubTopic == pubsubTopic {
+			result[n-1].ContentTopics = append(result[n-1].ContentTopics, contentTopic)
+			continue
+		}
+
+		result = append(result, StoredSubscription{
+			PeerID:        peerID,
+			PubsubTopic:   pubsubTopic,
+			ContentTopics: []string{contentTopic},
+			LastSeen:      time.Unix(0, lastSeen),
+		})
+	}
+
+	return result, rows.Err()
+}
+
+// persistQueue applies the changes of the subscriptions to a SubscriptionStore in the background,
+// in the order they were made, so the database is not written while the subscriptions are locked.
+// Refreshes of the same peer are merged
+type persistQueue struct {
+	sync.Mutex
+	store     SubscriptionStore
+	ops       []func(SubscriptionStore) error
+	refreshes map[peer.ID]time.Time
+	wake      chan struct{}
+	flushMu   sync.Mutex
+	log       *zap.Logger
+}
+
+func newPersistQueue(store SubscriptionStore, log *zap.Logger) *persistQueue {
+	return &persistQueue{
+		store:     store,
+		refreshes: make(map[peer.ID]time.Time),
+		wake:      make(chan struct{}, 1),
+		log:       log,
+	}
+}
+
+func (q *persistQueue) put(peerID peer.ID, pubsubTopic string, contentTopics []string, lastSeen time.Time) {
+	// Put also refreshes the peer, so an older pending refresh must not be written after it
+	q.Lock()
+	if t, ok := q.refreshes[peerID]; ok && !t.After(lastSeen) {
+		delete(q.refreshes, peerID)
+	}
+	q.Unlock()
+
+	q.push(func(store SubscriptionStore) error {
+		return store.Put(peerID, pubsubTopic, contentTopics, lastSeen)
+	})
+}
+
+func (q *persistQueue) delete(peerID peer.ID, pubsubTopic string, contentTopics []string) {
+	q.push(func(store SubscriptionStore) error {
+		return store.Delete(peerID, pubsubTopic, contentTopics)
+	})
+}
+
+func (q *persistQueue) deleteAll(peerID peer.ID) {
+	q.push(func(store SubscriptionStore) error {
+		return store.DeleteAll(peerID)
+	})
+}
+
+func (q *persistQueue) refresh(peerID peer.ID, lastSeen time.Time) {
+	q.Lock()
+	q.refreshes[peerID] = lastSeen
+	q.Unlock()
+	q.notify()
+}
+
+func (q *persistQueue) push(op func(SubscriptionStore) error) {
+	q.Lock()
+	q.ops = append(q.ops, op)
+	q.Unlock()
+	q.notify()
+}
+
+func (q *persistQueue) notify() {
+	select {
+	case q.wake <- struct{}{}:
+	default:
+	}
+}
+
+// flush writes the pending changes to the store. Refreshes are written last, as refreshing
+// a peer whose subscriptions were deleted has no effect
+func (q *persistQueue) flush() {
+	q.flushMu.Lock()
+	defer q.flushMu.Unlock()
+
+	q.Lock()
+	ops, refreshes := q.ops, q.refreshes
+	q.ops = nil
+	q.refreshes = make(map[peer.ID]time.Time)
+	q.Unlock()
+
+	for _, op := range ops {
+		if err := op(q.store); err != nil {
+			q.log.Error("persisting filter subscriptions", zap.Error(err))
+		}
+	}
+
+	for peerID, lastSeen := range refreshes {
+		if err := q.store.Refresh(peerID, lastSeen); err != nil {
+			q.log.Error("persisting filter subscriptions", zap.Error(err))
+		}
+	}
+}
+
+// run writes the changes until the context is done. The changes pending by then are written before returning
+func (q *persistQueue) run(ctx context.Context, wg *sync.WaitGroup) {
+	defer utils.LogOnPanic()
+	defer wg.Done()
+
+	for {
+		select {
+		case <-ctx.Done():
+			q.flush()
+			return
+		case <-q.wake:
+			q.flush()
+		}
+	}
+}
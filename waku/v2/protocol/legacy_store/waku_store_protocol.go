@@ -150,6 +150,9 @@ func (store *WakuStore) storeMessage(env *protocol.Envelope) error {
 	}
 
 	err = store.msgProvider.Put(env)
+	if errors.Is(err, persistence.ErrDuplicatedMessage) {
+		return nil
+	}
 	if err != nil {
 		store.log.Error("storing message", zap.Error(err))
 		store.metrics.RecordError(storeFailure)
@@ -31,6 +31,10 @@ import (
 const LightPushID_v20beta1 = libp2pProtocol.ID("/vac/waku/lightpush/2.0.0-beta1")
 const LightPushENRField = uint8(1 << 3)
 
+// shardMeshTimeout is the maximum time waited for mesh peers before publishing in a pubsub topic
+// subscribed on demand
+const shardMeshTimeout = 5 * time.Second
+
 var (
 	ErrNoPeersAvailable = errors.New("no suitable remote peers")
 	ErrInvalidID        = errors.New("invalid request id")
@@ -38,12 +42,14 @@ var (
 
 // WakuLightPush is the implementation of the Waku LightPush protocol
 type WakuLightPush struct {
-	h       host.Host
-	relay   *relay.WakuRelay
-	limiter *rate.Limiter
-	cancel  context.CancelFunc
-	pm      *peermanager.PeerManager
-	metrics Metrics
+	h           host.Host
+	relay       *relay.WakuRelay
+	limiter     *rate.Limiter
+	middleware  *protocol.Middleware
+	shardDemand protocol.ShardDemand
+	cancel      context.CancelFunc
+	pm          *peermanager.PeerManager
+	metrics     Metrics
 
 	log *zap.Logger
 }
@@ -64,6 +70,8 @@ func NewWakuLightPush(relay *relay.WakuRelay, pm *peermanager.PeerManager, reg p
 	}
 
 	wakuLP.limiter = params.limiter
+	wakuLP.middleware = params.middleware
+	wakuLP.shardDemand = params.shardDemand
 
 	return wakuLP
 }
@@ -92,6 +100,36 @@ func (wakuLP *WakuLightPush) Start(ctx context.Context) error {
 	return nil
 }
 
+// waitForMesh waits for relay to have mesh peers in a pubsub topic, which was possibly just
+// subscribed on demand, so the message is not published before the mesh is formed. The message
+// is published anyway after shardMeshTimeout
+func (wakuLP *WakuLightPush) waitForMesh(ctx context.Context, pubsubTopic string, logger *zap.Logger) {
+	hasMesh := func() bool {
+		return len(wakuLP.relay.PubSub().MeshPeers(pubsubTopic)) != 0 && wakuLP.relay.EnoughPeersToPublishToTopic(pubsubTopic)
+	}
+	if hasMesh() {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, shardMeshTimeout)
+	defer cancel()
+
+	ticker := time.NewTicker(100 * time.Millisecond)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			logger.Debug("no mesh peers in pubsub topic, publishing anyway", zap.String("pubsubTopic", pubsubTopic))
+			return
+		case <-ticker.C:
+			if hasMesh() {
+				return
+			}
+		}
+	}
+}
+
 // relayIsNotAvailable determines if this node supports relaying messages for other lightpush clients
 func (wakuLP *WakuLightPush) relayIsNotAvailable() bool {
 	return wakuLP.relay == nil
@@ -148,7 +186,15 @@ func (wakuLP *WakuLightPush) onRequest(ctx context.Context) func(network.Stream)
 		// TODO: Assumes success, should probably be extended to check for network, peers, etc
 		// It might make sense to use WithReadiness option here?
 
-		_, err = wakuLP.relay.Publish(ctx, message, relay.WithPubSubTopic(pubSubTopic))
+		if wakuLP.shardDemand != nil {
+			err = wakuLP.shardDemand.Request(ctx, pubSubTopic)
+			if err == nil {
+				wakuLP.waitForMesh(ctx, pubSubTopic, logger)
+			}
+		}
+		if err == nil {
+			_, err = wakuLP.relay.Publish(ctx, message, relay.WithPubSubTopic(pubSubTopic))
+		}
 		if err != nil {
 			logger.Error("publishing message", zap.Error(err))
 			wakuLP.metrics.RecordError(messagePushFailure)
@@ -320,6 +366,18 @@ func (wakuLP *WakuLightPush) Publish(ctx context.Context, message *wpb.WakuMessa
 	if err != nil {
 		return wpb.MessageHash{}, err
 	}
+
+	if wakuLP.middleware != nil {
+		env, err := wakuLP.middleware.Run(ctx, protocol.PrePublish, protocol.NewEnvelope(message, time.Now().UnixNano(), params.pubsubTopic))
+		if err != nil {
+			return wpb.MessageHash{}, err
+		}
+		if env.PubsubTopic() != params.pubsubTopic {
+			return wpb.MessageHash{}, errors.New("hooks can't change the pubsub topic of a message")
+		}
+		message = env.Message()
+	}
+
 	req := new(pb.PushRequest)
 	req.Message = message
 	req.PubsubTopic = params.pubsubTopic
@@ -14,7 +14,9 @@ import (
 )
 
 type LightpushParameters struct {
-	limiter *rate.Limiter
+	limiter     *rate.Limiter
+	middleware  *protocol.Middleware
+	shardDemand protocol.ShardDemand
 }
 
 type Option func(*LightpushParameters)
@@ -26,6 +28,21 @@ func WithRateLimiter(r rate.Limit, b int) Option {
 	}
 }
 
+// WithMiddleware executes the PrePublish hooks of a middleware on the messages published with
+// lightpush. Messages received from lightpush clients go through the hooks of relay instead
+func WithMiddleware(m *protocol.Middleware) Option {
+	return func(params *LightpushParameters) {
+		params.middleware = m
+	}
+}
+
+// WithShardDemand subscribes the node to the pubsub topics of the messages pushed by clients
+func WithShardDemand(d protocol.ShardDemand) Option {
+	return func(params *LightpushParameters) {
+		params.shardDemand = d
+	}
+}
+
 type lightPushRequestParameters struct {
 	host              host.Host
 	peerAddr          multiaddr.Multiaddr
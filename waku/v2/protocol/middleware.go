@@ -0,0 +1,75 @@
+package protocol
+
+import (
+	"context"
+	"errors"
+	"sync"
+)
+
+// HookStage is the point of the flow of messages at which a MessageHook is executed
+type HookStage int
+
+const (
+	// PrePublish hooks are executed before a message is published with relay or lightpush
+	PrePublish HookStage = iota
+	// PostReceive hooks are executed before a message received with relay is delivered to the
+	// subscriptions of the node
+	PostReceive
+	// PreFilterPush hooks are executed before a filter full node pushes a message to its subscribers.
+	// Messages received with relay have already gone through the PostReceive hooks
+	PreFilterPush
+)
+
+// ErrMessageDropped is returned when a hook drops a message
+var ErrMessageDropped = errors.New("message dropped by hook")
+
+// MessageHook can modify, drop or annotate an envelope. It returns the envelope passed to the next
+// hook, which can be a new one created with NewEnvelope, or nil to drop the message. Returning an
+// error also drops the message
+type MessageHook func(ctx context.Context, env *Envelope) (*Envelope, error)
+
+// Middleware is a chain of hooks executed in the order in which they were registered. It can be
+// shared by several protocols, and hooks can be registered while the node is running
+type Middleware struct {
+	sync.RWMutex
+	hooks map[HookStage][]MessageHook
+}
+
+// NewMiddleware creates an empty chain of hooks
+func NewMiddleware() *Middleware {
+	return &Middleware{
+		hooks: make(map[HookStage][]MessageHook),
+	}
+}
+
+// Register adds a hook at the end of the chain of a stage
+func (m *Middleware) Register(stage HookStage, hook MessageHook) {
+	m.Lock()
+	defer m.Unlock()
+	m.hooks[stage] = append(m.hooks[stage], hook)
+}
+
+// Run executes the hooks of a stage. It returns ErrMessageDropped if a hook dropped the message.
+// A nil Middleware returns the envelope unchanged
+func (m *Middleware) Run(ctx context.Context, stage HookStage, env *Envelope) (*Envelope, error) {
+	if m == nil {
+		return env, nil
+	}
+
+	m.RLock()
+	hooks := m.hooks[stage]
+	m.RUnlock()
+
+	for _, hook := range hooks {
+		var err error
+		env, err = hook(ctx, env)
+		if err != nil {
+			return nil, errors.Join(ErrMessageDropped, err)
+		}
+		if env == nil {
+			return nil, ErrMessageDropped
+		}
+	}
+
+	return env, nil
+}
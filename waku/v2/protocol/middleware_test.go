@@ -0,0 +1,69 @@
+package protocol
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/waku-org/go-waku/waku/v2/protocol/pb"
+	"github.com/waku-org/go-waku/waku/v2/utils"
+)
+
+func TestMiddleware(t *testing.T) {
+	ctx := context.Background()
+	env := NewEnvelope(&pb.WakuMessage{Payload: []byte("hello"), ContentTopic: "/test/1/a/proto"}, *utils.GetUnixEpoch(), "test")
+
+	// A nil middleware doesn't change the envelope
+	var m *Middleware
+	result, err := m.Run(ctx, PrePublish, env)
+	require.NoError(t, err)
+	require.Equal(t, env, result)
+
+	m = NewMiddleware()
+	var order []string
+	m.Register(PrePublish, func(ctx context.Context, env *Envelope) (*Envelope, error) {
+		order = append(order, "enrich")
+		msg := &pb.WakuMessage{Payload: append(env.Message().Payload, []byte(" world")...), ContentTopic: env.Message().ContentTopic}
+		return NewEnvelope(msg, env.Index().ReceiverTime, env.PubsubTopic()), nil
+	})
+	m.Register(PrePublish, func(ctx context.Context, env *Envelope) (*Envelope, error) {
+		order = append(order, "audit")
+		env.Annotate("audited", "true")
+		return env, nil
+	})
+
+	result, err = m.Run(ctx, PrePublish, env)
+	require.NoError(t, err)
+	require.Equal(t, []string{"enrich", "audit"}, order)
+	require.Equal(t, []byte("hello world"), result.Message().Payload)
+	value, ok := result.Annotation("audited")
+	require.True(t, ok)
+	require.Equal(t, "true", value)
+
+	// Hooks of other stages are not executed
+	result, err = m.Run(ctx, PostReceive, env)
+	require.NoError(t, err)
+	require.Equal(t, env, result)
+	_, ok = env.Annotation("audited")
+	require.False(t, ok)
+
+	// Dropped messages don't reach the next hooks
+	m.Register(PostReceive, func(ctx context.Context, env *Envelope) (*Envelope, error) {
+		return nil, nil
+	})
+	m.Register(PostReceive, func(ctx context.Context, env *Envelope) (*Envelope, error) {
+		require.Fail(t, "hook executed after the message was dropped")
+		return env, nil
+	})
+	_, err = m.Run(ctx, PostReceive, env)
+	require.ErrorIs(t, err, ErrMessageDropped)
+
+	hookErr := errors.New("invalid message")
+	m.Register(PreFilterPush, func(ctx context.Context, env *Envelope) (*Envelope, error) {
+		return nil, hookErr
+	})
+	_, err = m.Run(ctx, PreFilterPush, env)
+	require.ErrorIs(t, err, ErrMessageDropped)
+	require.ErrorIs(t, err, hookErr)
+}
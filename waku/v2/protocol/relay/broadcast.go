@@ -3,6 +3,7 @@ package relay
 import (
 	"context"
 	"errors"
+	"os"
 	"sync"
 	"sync/atomic"
 
@@ -11,8 +12,12 @@ import (
 )
 
 type BroadcasterParameters struct {
-	dontConsume bool //Indicates whether to consume messages from subscription or drop
-	chLen       int
+	dontConsume  bool //Indicates whether to consume messages from subscription or drop
+	chLen        int
+	overflow     OverflowPolicy
+	onDrop       func(*protocol.Envelope)
+	spillDir     string
+	maxSpillSize int64
 }
 
 type BroadcasterOption func(*BroadcasterParameters)
@@ -38,10 +43,37 @@ func WithBufferSize(size int) BroadcasterOption {
 	}
 }
 
+// WithOverflowPolicy sets what happens to the messages of a subscription whose buffer is full.
+// By default the broadcaster waits for the subscriber to read a message
+func WithOverflowPolicy(policy OverflowPolicy) BroadcasterOption {
+	return func(params *BroadcasterParameters) {
+		params.overflow = policy
+	}
+}
+
+// WithDropHandler sets a function called with every message discarded by the overflow policy.
+// It's called from the broadcaster loop, so it must not block
+func WithDropHandler(fn func(*protocol.Envelope)) BroadcasterOption {
+	return func(params *BroadcasterParameters) {
+		params.onDrop = fn
+	}
+}
+
+// WithSpillDirectory sets the directory and the maximum size in bytes of the file used by the
+// OverflowSpillToDisk policy. The file is deleted when the subscription is closed
+func WithSpillDirectory(dir string, maxSize int64) BroadcasterOption {
+	return func(params *BroadcasterParameters) {
+		params.spillDir = dir
+		params.maxSpillSize = maxSize
+	}
+}
+
 // DefaultBroadcasterOptions specifies default options for broadcaster
 func DefaultBroadcasterOptions() []BroadcasterOption {
 	return []BroadcasterOption{
 		WithBufferSize(0),
+		WithOverflowPolicy(OverflowBlock),
+		WithSpillDirectory(os.TempDir(), DefaultMaxSpillSize),
 	}
 }
 
@@ -56,8 +88,8 @@ func newSubStore() Subscriptions {
 		topicsToSubs: make(map[string]map[int]*Subscription),
 	}
 }
-func (s *Subscriptions) createNewSubscription(contentFilter protocol.ContentFilter, dontConsume bool, chLen int) *Subscription {
-	ch := make(chan *protocol.Envelope, chLen)
+func (s *Subscriptions) createNewSubscription(contentFilter protocol.ContentFilter, params *BroadcasterParameters) *Subscription {
+	ch := make(chan *protocol.Envelope, params.chLen)
 	s.mu.Lock()
 	defer s.mu.Unlock()
 	s.id++
@@ -79,12 +111,21 @@ func (s *Subscriptions) createNewSubscription(contentFilter protocol.ContentFilt
 				return
 			}
 			if sub := s.topicsToSubs[pubsubTopic][id]; sub != nil {
-				close(sub.Ch)
+				sub.close()
 				delete(s.topicsToSubs[pubsubTopic], id)
 			}
 		},
 		contentFilter: contentFilter,
-		noConsume:     dontConsume,
+		noConsume:     params.dontConsume,
+		overflow:      params.overflow,
+		onDrop:        params.onDrop,
+	}
+	if params.overflow == OverflowSpillToDisk {
+		sub.spill = newSpillQueue(params.spillDir, params.maxSpillSize, func(lost int) {
+			sub.dropped.Add(uint64(lost))
+			subscriptionDroppedMessages.WithLabelValues(sub.metricLabels()...).Add(float64(lost))
+		})
+		sub.spill.start(ch)
 	}
 	s.topicsToSubs[pubsubTopic][id] = &sub
 	return &sub
@@ -123,7 +164,7 @@ func (s *Subscriptions) close() {
 	defer s.mu.Unlock()
 	for _, subs := range s.topicsToSubs {
 		for _, sub := range subs {
-			close(sub.Ch)
+			sub.close()
 		}
 	}
 	s.topicsToSubs = nil
@@ -202,7 +243,7 @@ func (b *broadcaster) Stop() {
 // Register returns a subscription for an specific pubsub topic and/or list of contentTopics
 func (b *broadcaster) Register(contentFilter protocol.ContentFilter, opts ...BroadcasterOption) *Subscription {
 	params := b.ProcessOpts(opts...)
-	return b.subscriptions.createNewSubscription(contentFilter, params.dontConsume, params.chLen)
+	return b.subscriptions.createNewSubscription(contentFilter, params)
 }
 
 func (b *broadcaster) ProcessOpts(opts ...BroadcasterOption) *BroadcasterParameters {
@@ -228,7 +269,7 @@ func (b *broadcaster) UnRegister(pubsubTopic string) {
 // RegisterForAll returns a subscription for all topics
 func (b *broadcaster) RegisterForAll(opts ...BroadcasterOption) *Subscription {
 	params := b.ProcessOpts(opts...)
-	return b.subscriptions.createNewSubscription(protocol.NewContentFilter(""), params.dontConsume, params.chLen)
+	return b.subscriptions.createNewSubscription(protocol.NewContentFilter(""), params)
 }
 
 // Submit is used to broadcast messages to subscribers. It only accepts value when running.
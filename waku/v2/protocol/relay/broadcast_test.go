@@ -2,6 +2,7 @@ package relay
 
 import (
 	"context"
+	"os"
 	"sync"
 	"testing"
 
@@ -101,3 +102,71 @@ func TestBroadcastNoOneListening(t *testing.T) {
 	b.Submit(env)
 	b.Stop()
 }
+
+func TestBroadcastOverflowPolicies(t *testing.T) {
+	b := NewBroadcaster(100)
+	require.NoError(t, b.Start(context.Background()))
+	defer b.Stop()
+
+	envelopes := make([]*protocol.Envelope, 5)
+	for i := range envelopes {
+		envelopes[i] = protocol.NewEnvelope(&pb.WakuMessage{Payload: []byte{byte(i)}, ContentTopic: "test"}, *utils.GetUnixEpoch(), "abc")
+	}
+
+	submitAll := func(sub *Subscription) {
+		for _, env := range envelopes {
+			sub.Submit(context.Background(), env)
+		}
+	}
+
+	readPayloads := func(sub *Subscription, n int) []byte {
+		var result []byte
+		for i := 0; i < n; i++ {
+			env := <-sub.Ch
+			result = append(result, env.Message().Payload...)
+		}
+		return result
+	}
+
+	var dropped []*protocol.Envelope
+	sub := b.RegisterForAll(WithBufferSize(2), WithOverflowPolicy(OverflowDropNewest), WithDropHandler(func(env *protocol.Envelope) {
+		dropped = append(dropped, env)
+	}))
+	submitAll(sub)
+	require.Equal(t, uint64(3), sub.Dropped())
+	require.Equal(t, envelopes[2:], dropped)
+	require.Equal(t, []byte{0, 1}, readPayloads(sub, 2))
+	sub.Unsubscribe()
+
+	sub = b.RegisterForAll(WithBufferSize(2), WithOverflowPolicy(OverflowDropOldest))
+	submitAll(sub)
+	require.Equal(t, uint64(3), sub.Dropped())
+	require.Equal(t, []byte{3, 4}, readPayloads(sub, 2))
+	sub.Unsubscribe()
+
+	// Without a buffer, messages are not dropped and the submission can be cancelled
+	sub = b.RegisterForAll(WithBufferSize(0), WithOverflowPolicy(OverflowDropOldest))
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	sub.Submit(ctx, envelopes[0])
+	require.Equal(t, uint64(0), sub.Dropped())
+	sub.Unsubscribe()
+
+	// Spilled messages are delivered in order once the subscriber catches up
+	dir := t.TempDir()
+	sub = b.RegisterForAll(WithBufferSize(2), WithOverflowPolicy(OverflowSpillToDisk), WithSpillDirectory(dir, 0))
+	submitAll(sub)
+	require.Equal(t, uint64(0), sub.Dropped())
+	require.Equal(t, 5, sub.Pending())
+	require.Equal(t, []byte{0, 1, 2, 3, 4}, readPayloads(sub, 5))
+
+	files, err := os.ReadDir(dir)
+	require.NoError(t, err)
+	require.Len(t, files, 1)
+	sub.Unsubscribe()
+
+	// The spill file is removed with the subscription
+	files, err = os.ReadDir(dir)
+	require.NoError(t, err)
+	require.Empty(t, files)
+}
@@ -8,7 +8,6 @@ import (
 	"github.com/libp2p/go-libp2p/core/peer"
 	"github.com/libp2p/go-libp2p/core/protocol"
 	"github.com/waku-org/go-waku/waku/v2/hash"
-	waku_proto "github.com/waku-org/go-waku/waku/v2/protocol"
 )
 
 var DefaultRelaySubscriptionBufferSize int = 1024
@@ -18,8 +17,9 @@ var DefaultRelaySubscriptionBufferSize int = 1024
 var DefaultPeerOutboundQSize int = 1024
 
 type RelaySubscribeParameters struct {
-	dontConsume bool
-	cacheSize   uint
+	dontConsume     bool
+	cacheSize       uint
+	broadcasterOpts []BroadcasterOption
 }
 
 type RelaySubscribeOption func(*RelaySubscribeParameters) error
@@ -40,6 +40,15 @@ func WithCacheSize(size uint) RelaySubscribeOption {
 	}
 }
 
+// WithBroadcasterOptions sets options of the subscriptions, such as the policy used when the
+// subscriber doesn't keep up with the messages received
+func WithBroadcasterOptions(opts ...BroadcasterOption) RelaySubscribeOption {
+	return func(params *RelaySubscribeParameters) error {
+		params.broadcasterOpts = append(params.broadcasterOpts, opts...)
+		return nil
+	}
+}
+
 func msgIDFn(pmsg *pubsub_pb.Message) string {
 	return string(hash.SHA256(pmsg.Data))
 }
@@ -73,24 +82,12 @@ func (w *WakuRelay) setDefaultPeerScoreParams() {
 }
 
 func (w *WakuRelay) defaultPubsubOptions() []pubsub.Option {
-
-	cfg := pubsub.DefaultGossipSubParams()
-	cfg.PruneBackoff = time.Minute
-	cfg.UnsubscribeBackoff = 5 * time.Second
-	cfg.GossipFactor = 0.25
-	cfg.D = waku_proto.GossipSubDMin
-	cfg.Dlo = 4
-	cfg.Dhi = 8
-	cfg.Dout = 3
-	cfg.Dlazy = waku_proto.GossipSubDMin
-	cfg.HeartbeatInterval = time.Second
-	cfg.HistoryLength = 6
-	cfg.HistoryGossip = 3
-	cfg.FanoutTTL = time.Minute
+	w.params = w.relayParams.profile.Params
+	topicParams := w.relayParams.profile.TopicScoreParams
+	w.topicParams = &topicParams
 
 	w.setDefaultPeerScoreParams()
 
-	w.setDefaultTopicParams()
 	return []pubsub.Option{
 		pubsub.WithMessageSignaturePolicy(pubsub.StrictNoSign),
 		pubsub.WithNoAuthor(),
@@ -108,7 +105,7 @@ func (w *WakuRelay) defaultPubsubOptions() []pubsub.Option {
 				}
 			},
 		),
-		pubsub.WithGossipSubParams(cfg),
+		pubsub.WithGossipSubParams(w.params),
 		pubsub.WithFloodPublish(true),
 		pubsub.WithSeenMessagesTTL(2 * time.Minute),
 		pubsub.WithPeerScore(w.peerScoreParams, w.peerScoreThresholds),
@@ -116,30 +113,3 @@ func (w *WakuRelay) defaultPubsubOptions() []pubsub.Option {
 		pubsub.WithPeerOutboundQueueSize(DefaultPeerOutboundQSize),
 	}
 }
-
-func (w *WakuRelay) setDefaultTopicParams() {
-	w.topicParams = &pubsub.TopicScoreParams{
-		TopicWeight: 1,
-		// p1: favours peers already in the mesh
-		TimeInMeshWeight:  0.01,
-		TimeInMeshQuantum: time.Second,
-		TimeInMeshCap:     10.0,
-		// p2: rewards fast peers
-		FirstMessageDeliveriesWeight: 1.0,
-		FirstMessageDeliveriesDecay:  0.5,
-		FirstMessageDeliveriesCap:    10.0,
-		// p3: penalizes lazy peers. safe low value
-		MeshMessageDeliveriesWeight:     0,
-		MeshMessageDeliveriesDecay:      0,
-		MeshMessageDeliveriesCap:        0,
-		MeshMessageDeliveriesThreshold:  0,
-		MeshMessageDeliveriesWindow:     0,
-		MeshMessageDeliveriesActivation: 0,
-		// p3b: tracks history of prunes
-		MeshFailurePenaltyWeight: 0,
-		MeshFailurePenaltyDecay:  0,
-		// p4: penalizes invalid messages. highly penalize peers sending wrong messages
-		InvalidMessageDeliveriesWeight: -100.0,
-		InvalidMessageDeliveriesDecay:  0.5,
-	}
-}
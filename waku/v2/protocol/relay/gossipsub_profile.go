@@ -0,0 +1,164 @@
+package relay
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	pubsub "github.com/libp2p/go-libp2p-pubsub"
+	waku_proto "github.com/waku-org/go-waku/waku/v2/protocol"
+)
+
+const (
+	// ProfileDefault balances latency and bandwidth
+	ProfileDefault = "default"
+	// ProfileLowLatency uses a larger mesh and more frequent heartbeats so messages reach every
+	// peer in fewer hops, at the cost of more duplicates
+	ProfileLowLatency = "low-latency"
+	// ProfileBandwidthSaving uses a smaller mesh and sends less gossip
+	ProfileBandwidthSaving = "bandwidth-saving"
+	// ProfileMobile minimizes the number of connections kept in the mesh and the frequency of
+	// heartbeats, for devices with limited bandwidth and battery
+	ProfileMobile = "mobile"
+)
+
+// GossipsubProfile is a named set of gossipsub parameters. Params apply to every pubsub topic,
+// while TopicScoreParams are used for the topics without their own score parameters
+type GossipsubProfile struct {
+	Name             string
+	Params           pubsub.GossipSubParams
+	TopicScoreParams pubsub.TopicScoreParams
+}
+
+func defaultGossipSubParams() pubsub.GossipSubParams {
+	cfg := pubsub.DefaultGossipSubParams()
+	cfg.PruneBackoff = time.Minute
+	cfg.UnsubscribeBackoff = 5 * time.Second
+	cfg.GossipFactor = 0.25
+	cfg.D = waku_proto.GossipSubDMin
+	cfg.Dlo = 4
+	cfg.Dhi = 8
+	cfg.Dout = 3
+	cfg.Dlazy = waku_proto.GossipSubDMin
+	cfg.HeartbeatInterval = time.Second
+	cfg.HistoryLength = 6
+	cfg.HistoryGossip = 3
+	cfg.FanoutTTL = time.Minute
+	return cfg
+}
+
+func defaultTopicScoreParams() pubsub.TopicScoreParams {
+	return pubsub.TopicScoreParams{
+		TopicWeight: 1,
+		// p1: favours peers already in the mesh
+		TimeInMeshWeight:  0.01,
+		TimeInMeshQuantum: time.Second,
+		TimeInMeshCap:     10.0,
+		// p2: rewards fast peers
+		FirstMessageDeliveriesWeight: 1.0,
+		FirstMessageDeliveriesDecay:  0.5,
+		FirstMessageDeliveriesCap:    10.0,
+		// p3: penalizes lazy peers. safe low value
+		MeshMessageDeliveriesWeight:     0,
+		MeshMessageDeliveriesDecay:      0,
+		MeshMessageDeliveriesCap:        0,
+		MeshMessageDeliveriesThreshold:  0,
+		MeshMessageDeliveriesWindow:     0,
+		MeshMessageDeliveriesActivation: 0,
+		// p3b: tracks history of prunes
+		MeshFailurePenaltyWeight: 0,
+		MeshFailurePenaltyDecay:  0,
+		// p4: penalizes invalid messages. highly penalize peers sending wrong messages
+		InvalidMessageDeliveriesWeight: -100.0,
+		InvalidMessageDeliveriesDecay:  0.5,
+	}
+}
+
+var gossipsubProfiles = map[string]func() GossipsubProfile{
+	ProfileDefault: func() GossipsubProfile {
+		return GossipsubProfile{
+			Name:             ProfileDefault,
+			Params:           defaultGossipSubParams(),
+			TopicScoreParams: defaultTopicScoreParams(),
+		}
+	},
+	ProfileLowLatency: func() GossipsubProfile {
+		cfg := defaultGossipSubParams()
+		cfg.D = 8
+		cfg.Dlo = 6
+		cfg.Dhi = 12
+		cfg.Dlazy = 8
+		cfg.GossipFactor = 0.5
+		cfg.HeartbeatInterval = 700 * time.Millisecond
+
+		topicParams := defaultTopicScoreParams()
+		// Favour the peers that deliver messages first
+		topicParams.FirstMessageDeliveriesWeight = 2.0
+		topicParams.FirstMessageDeliveriesCap = 20.0
+
+		return GossipsubProfile{
+			Name:             ProfileLowLatency,
+			Params:           cfg,
+			TopicScoreParams: topicParams,
+		}
+	},
+	ProfileBandwidthSaving: func() GossipsubProfile {
+		cfg := defaultGossipSubParams()
+		cfg.D = 4
+		cfg.Dlo = 3
+		cfg.Dhi = 6
+		cfg.Dout = 2
+		cfg.Dscore = 3
+		cfg.Dlazy = 3
+		cfg.GossipFactor = 0.15
+		cfg.HistoryGossip = 2
+
+		return GossipsubProfile{
+			Name:             ProfileBandwidthSaving,
+			Params:           cfg,
+			TopicScoreParams: defaultTopicScoreParams(),
+		}
+	},
+	ProfileMobile: func() GossipsubProfile {
+		cfg := defaultGossipSubParams()
+		cfg.D = 3
+		cfg.Dlo = 2
+		cfg.Dhi = 4
+		cfg.Dout = 1
+		cfg.Dscore = 2
+		cfg.Dlazy = 2
+		cfg.GossipFactor = 0.1
+		cfg.HeartbeatInterval = 2 * time.Second
+		cfg.HistoryLength = 5
+		cfg.HistoryGossip = 2
+
+		topicParams := defaultTopicScoreParams()
+		// Mobile peers reconnect often, so time in mesh is less meaningful
+		topicParams.TimeInMeshCap = 5.0
+
+		return GossipsubProfile{
+			Name:             ProfileMobile,
+			Params:           cfg,
+			TopicScoreParams: topicParams,
+		}
+	},
+}
+
+// GossipsubProfileNames returns the names of the available profiles
+func GossipsubProfileNames() []string {
+	var names []string
+	for name := range gossipsubProfiles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// GetGossipsubProfile returns the parameters of a profile
+func GetGossipsubProfile(name string) (GossipsubProfile, error) {
+	profile, ok := gossipsubProfiles[name]
+	if !ok {
+		return GossipsubProfile{}, fmt.Errorf("unknown gossipsub profile %s, expected one of %v", name, GossipsubProfileNames())
+	}
+	return profile(), nil
+}
@@ -29,16 +29,63 @@ var pubsubTopics = prometheus.NewGauge(
 		Help: "Number of PubSub Topics node is subscribed to",
 	})
 
+var throttledPeerMessages = prometheus.NewCounterVec(
+	prometheus.CounterOpts{
+		Name: "waku_relay_throttled_peer_messages",
+		Help: "The number of messages dropped because the peer that forwarded them exceeded the rate limit",
+	},
+	[]string{"pubsubTopic", "peerID"},
+)
+
+var throttledContentTopicMessages = prometheus.NewCounterVec(
+	prometheus.CounterOpts{
+		Name: "waku_relay_throttled_content_topic_messages",
+		Help: "The number of messages dropped because their content topic exceeded the rate limit",
+	},
+	[]string{"pubsubTopic", "contentTopic"},
+)
+
+// maxThrottledLabels is the number of peers, and of content topics, that get their own label in
+// the throttling metrics. The others are counted together under utils.OtherLabel
+const maxThrottledLabels = 50
+
+var throttledPeerLabels = utils.NewBoundedLabels(maxThrottledLabels)
+var throttledContentTopicLabels = utils.NewBoundedLabels(maxThrottledLabels)
+
+var subscriptionDroppedMessages = prometheus.NewCounterVec(
+	prometheus.CounterOpts{
+		Name: "waku_relay_subscription_dropped_messages",
+		Help: "The number of messages dropped because the buffer of a subscription was full",
+	},
+	[]string{"subscription", "pubsubTopic", "policy"},
+)
+
+var subscriptionSpilledMessages = prometheus.NewCounterVec(
+	prometheus.CounterOpts{
+		Name: "waku_relay_subscription_spilled_messages",
+		Help: "The number of messages written to disk because the buffer of a subscription was full",
+	},
+	[]string{"subscription", "pubsubTopic"},
+)
+
 var collectors = []prometheus.Collector{
 	messages,
 	messageSize,
 	pubsubTopics,
+	throttledPeerMessages,
+	throttledContentTopicMessages,
+	subscriptionDroppedMessages,
+	subscriptionSpilledMessages,
 }
 
 // Metrics exposes the functions required to update prometheus metrics for relay protocol
 type Metrics interface {
 	RecordMessage(envelope *waku_proto.Envelope)
 	SetPubSubTopics(int)
+	RecordThrottledPeer(pubsubTopic string, peerID string)
+	RecordThrottledContentTopic(pubsubTopic string, contentTopic string)
+	RemoveThrottledPeer(peerID string)
+	RemoveThrottledContentTopic(contentTopic string)
 }
 
 type metricsImpl struct {
@@ -70,3 +117,27 @@ func (m *metricsImpl) RecordMessage(envelope *waku_proto.Envelope) {
 func (m *metricsImpl) SetPubSubTopics(size int) {
 	pubsubTopics.Set(float64(size))
 }
+
+// RecordThrottledPeer increases the counter of messages dropped due to the rate limit of a peer
+func (m *metricsImpl) RecordThrottledPeer(pubsubTopic string, peerID string) {
+	throttledPeerMessages.WithLabelValues(pubsubTopic, throttledPeerLabels.Label(peerID)).Inc()
+}
+
+// RecordThrottledContentTopic increases the counter of messages dropped due to the rate limit of a content topic
+func (m *metricsImpl) RecordThrottledContentTopic(pubsubTopic string, contentTopic string) {
+	throttledContentTopicMessages.WithLabelValues(pubsubTopic, throttledContentTopicLabels.Label(contentTopic)).Inc()
+}
+
+// RemoveThrottledPeer removes the throttling metrics of a peer that is no longer rate limited
+func (m *metricsImpl) RemoveThrottledPeer(peerID string) {
+	if throttledPeerLabels.Release(peerID) {
+		throttledPeerMessages.DeletePartialMatch(prometheus.Labels{"peerID": peerID})
+	}
+}
+
+// RemoveThrottledContentTopic removes the throttling metrics of a content topic that is no longer rate limited
+func (m *metricsImpl) RemoveThrottledContentTopic(contentTopic string) {
+	if throttledContentTopicLabels.Release(contentTopic) {
+		throttledContentTopicMessages.DeletePartialMatch(prometheus.Labels{"contentTopic": contentTopic})
+	}
+}
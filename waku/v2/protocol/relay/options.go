@@ -1,6 +1,9 @@
 package relay
 
-import pubsub "github.com/libp2p/go-libp2p-pubsub"
+import (
+	pubsub "github.com/libp2p/go-libp2p-pubsub"
+	waku_proto "github.com/waku-org/go-waku/waku/v2/protocol"
+)
 
 type publishParameters struct {
 	pubsubTopic string
@@ -26,6 +29,10 @@ func WithDefaultPubsubTopic() PublishOption {
 type relayParameters struct {
 	pubsubOpts      []pubsub.Option
 	maxMsgSizeBytes int
+	rateLimit       *RateLimitConfig
+	middleware      *waku_proto.Middleware
+	profile         GossipsubProfile
+	topicScores     map[string]pubsub.TopicScoreParams
 }
 
 type RelayOption func(*relayParameters)
@@ -45,8 +52,44 @@ func WithMaxMsgSize(maxMsgSizeBytes int) RelayOption {
 	}
 }
 
+// WithRateLimit enforces token bucket limits on the messages forwarded by each peer
+// and on the messages received for each content topic
+func WithRateLimit(cfg RateLimitConfig) RelayOption {
+	return func(params *relayParameters) {
+		params.rateLimit = &cfg
+	}
+}
+
+// WithMiddleware executes the PrePublish hooks of a middleware on the messages published, and
+// its PostReceive hooks on the messages received before they're delivered to the subscriptions
+func WithMiddleware(m *waku_proto.Middleware) RelayOption {
+	return func(params *relayParameters) {
+		params.middleware = m
+	}
+}
+
+// WithGossipsubProfile sets the gossipsub parameters of the relay, and the score parameters of the
+// pubsub topics that don't have their own. Options passed with WithPubSubOptions take precedence
+func WithGossipsubProfile(profile GossipsubProfile) RelayOption {
+	return func(params *relayParameters) {
+		params.profile = profile
+	}
+}
+
+// WithTopicScoreParams sets the score parameters of a pubsub topic
+func WithTopicScoreParams(pubsubTopic string, scoreParams pubsub.TopicScoreParams) RelayOption {
+	return func(params *relayParameters) {
+		if params.topicScores == nil {
+			params.topicScores = make(map[string]pubsub.TopicScoreParams)
+		}
+		params.topicScores[pubsubTopic] = scoreParams
+	}
+}
+
 func defaultOptions() []RelayOption {
+	defaultProfile, _ := GetGossipsubProfile(ProfileDefault)
 	return []RelayOption{
 		WithMaxMsgSize(defaultMaxMsgSizeBytes),
+		WithGossipsubProfile(defaultProfile),
 	}
 }
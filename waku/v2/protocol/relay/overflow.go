@@ -0,0 +1,258 @@
+package relay
+
+import (
+	"encoding/binary"
+	"errors"
+	"io"
+	"os"
+	"sync"
+
+	"github.com/waku-org/go-waku/waku/v2/protocol"
+	"github.com/waku-org/go-waku/waku/v2/protocol/pb"
+	"github.com/waku-org/go-waku/waku/v2/utils"
+	"google.golang.org/protobuf/proto"
+)
+
+// OverflowPolicy determines what happens to the messages of a subscription whose buffer is full
+type OverflowPolicy int
+
+const (
+	// OverflowBlock waits until the subscriber reads a message. A slow subscriber delays the
+	// delivery of messages to every other subscriber
+	OverflowBlock OverflowPolicy = iota
+	// OverflowDropOldest discards the oldest message in the buffer to make room for the new one.
+	// Subscriptions without a buffer behave like OverflowBlock
+	OverflowDropOldest
+	// OverflowDropNewest discards the new message
+	OverflowDropNewest
+	// OverflowSpillToDisk writes the messages that don't fit in the buffer to a file, from which
+	// they are delivered in order once the subscriber catches up. Messages are dropped once the
+	// file reaches its maximum size
+	OverflowSpillToDisk
+)
+
+func (p OverflowPolicy) String() string {
+	switch p {
+	case OverflowBlock:
+		return "block"
+	case OverflowDropOldest:
+		return "drop-oldest"
+	case OverflowDropNewest:
+		return "drop-newest"
+	case OverflowSpillToDisk:
+		return "spill-to-disk"
+	default:
+		return "unknown"
+	}
+}
+
+// DefaultMaxSpillSize is the maximum size in bytes of the file used by OverflowSpillToDisk
+const DefaultMaxSpillSize = 100 * 1024 * 1024
+
+var errSpillFull = errors.New("spill file is full")
+
+// spillQueue is a FIFO of envelopes stored in a temporary file. The file is created when the
+// first envelope is spilled and truncated every time the subscriber catches up
+type spillQueue struct {
+	sync.Mutex
+	dir     string
+	maxSize int64
+	file    *os.File
+	// readOffset is the position of the oldest envelope, which is only discarded once it's
+	// delivered so new envelopes can't overtake it
+	readOffset  int64
+	writeOffset int64
+	count       int
+
+	notify chan struct{}
+	quit   chan struct{}
+	wg     sync.WaitGroup
+	// onError is called with the number of envelopes lost when the file can't be read
+	onError func(lost int)
+}
+
+func newSpillQueue(dir string, maxSize int64, onError func(lost int)) *spillQueue {
+	return &spillQueue{
+		dir:     dir,
+		maxSize: maxSize,
+		notify:  make(chan struct{}, 1),
+		quit:    make(chan struct{}),
+		onError: onError,
+	}
+}
+
+func encodeEnvelope(env *protocol.Envelope) ([]byte, error) {
+	msg, err := proto.Marshal(env.Message())
+	if err != nil {
+		return nil, err
+	}
+
+	// length | pubsub topic length | pubsub topic | receiver time | message
+	data := make([]byte, 4, 4+binary.MaxVarintLen64*2+len(env.PubsubTopic())+len(msg))
+	data = binary.AppendUvarint(data, uint64(len(env.PubsubTopic())))
+	data = append(data, env.PubsubTopic()...)
+	data = binary.AppendVarint(data, env.Index().ReceiverTime)
+	data = append(data, msg...)
+	binary.BigEndian.PutUint32(data, uint32(len(data)-4))
+	return data, nil
+}
+
+func decodeEnvelope(data []byte) (*protocol.Envelope, error) {
+	topicLen, n := binary.Uvarint(data)
+	if n <= 0 || uint64(len(data)-n) < topicLen {
+		return nil, io.ErrUnexpectedEOF
+	}
+	data = data[n:]
+	pubsubTopic := string(data[:topicLen])
+	data = data[topicLen:]
+
+	receiverTime, n := binary.Varint(data)
+	if n <= 0 {
+		return nil, io.ErrUnexpectedEOF
+	}
+
+	msg := &pb.WakuMessage{}
+	if err := proto.Unmarshal(data[n:], msg); err != nil {
+		return nil, err
+	}
+
+	return protocol.NewEnvelope(msg, receiverTime, pubsubTopic), nil
+}
+
+func (q *spillQueue) len() int {
+	q.Lock()
+	defer q.Unlock()
+	return q.count
+}
+
+func (q *spillQueue) push(env *protocol.Envelope) error {
+	data, err := encodeEnvelope(env)
+	if err != nil {
+		return err
+	}
+
+	q.Lock()
+	defer q.Unlock()
+
+	if q.maxSize > 0 && q.writeOffset-q.readOffset+int64(len(data)) > q.maxSize {
+		return errSpillFull
+	}
+
+	if q.file == nil {
+		q.file, err = os.CreateTemp(q.dir, "relay-subscription-*.spill")
+		if err != nil {
+			return err
+		}
+	}
+
+	_, err = q.file.WriteAt(data, q.writeOffset)
+	if err != nil {
+		return err
+	}
+	q.writeOffset += int64(len(data))
+	q.count++
+
+	select {
+	case q.notify <- struct{}{}:
+	default:
+	}
+
+	return nil
+}
+
+// peek returns the oldest envelope and the offset of the next one
+func (q *spillQueue) peek() (*protocol.Envelope, int64, error) {
+	q.Lock()
+	defer q.Unlock()
+
+	if q.count == 0 {
+		return nil, 0, nil
+	}
+
+	header := make([]byte, 4)
+	if _, err := q.file.ReadAt(header, q.readOffset); err != nil {
+		return nil, 0, err
+	}
+
+	data := make([]byte, binary.BigEndian.Uint32(header))
+	if _, err := q.file.ReadAt(data, q.readOffset+4); err != nil {
+		return nil, 0, err
+	}
+
+	env, err := decodeEnvelope(data)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return env, q.readOffset + 4 + int64(len(data)), nil
+}
+
+func (q *spillQueue) advance(next int64) {
+	q.Lock()
+	defer q.Unlock()
+
+	q.readOffset = next
+	q.count--
+	if q.count == 0 {
+		q.reset()
+	}
+}
+
+func (q *spillQueue) reset() {
+	q.readOffset = 0
+	q.writeOffset = 0
+	q.count = 0
+	_ = q.file.Truncate(0)
+}
+
+// run delivers the spilled envelopes to the subscription channel
+func (q *spillQueue) run(ch chan<- *protocol.Envelope) {
+	defer utils.LogOnPanic()
+	defer q.wg.Done()
+
+	for {
+		env, next, err := q.peek()
+		if err != nil {
+			q.Lock()
+			lost := q.count
+			q.reset()
+			q.Unlock()
+			q.onError(lost)
+			continue
+		}
+
+		if env == nil {
+			select {
+			case <-q.notify:
+				continue
+			case <-q.quit:
+				return
+			}
+		}
+
+		select {
+		case ch <- env:
+			q.advance(next)
+		case <-q.quit:
+			return
+		}
+	}
+}
+
+func (q *spillQueue) start(ch chan<- *protocol.Envelope) {
+	q.wg.Add(1)
+	go q.run(ch)
+}
+
+// stop must be called before closing the subscription channel
+func (q *spillQueue) stop() {
+	close(q.quit)
+	q.wg.Wait()
+
+	q.Lock()
+	defer q.Unlock()
+	if q.file != nil {
+		_ = q.file.Close()
+		_ = os.Remove(q.file.Name())
+	}
+}
@@ -0,0 +1,3 @@
+package pb
+
+//go:generate protoc -I. --go_opt=paths=source_relative --go_opt=Mprotected_topic.proto=github.com/waku-org/go-waku/waku/v2/protocol/relay/pb --go_out=. ./protected_topic.proto
@@ -0,0 +1,328 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.34.2
+// 	protoc        v3.21.12
+// source: protected_topic.proto
+
+package pb
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	reflect "reflect"
+	sync "sync"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+// Payload of the messages of protected topics that require the signatures of more
+// than one key. Each signature covers the message with the inner payload
+type SignedPayload struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Payload    []byte   `protobuf:"bytes,1,opt,name=payload,proto3" json:"payload,omitempty"`
+	Signatures [][]byte `protobuf:"bytes,2,rep,name=signatures,proto3" json:"signatures,omitempty"`
+}
+
+func (x *SignedPayload) Reset() {
+	*x = SignedPayload{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_protected_topic_proto_msgTypes[0]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *SignedPayload) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SignedPayload) ProtoMessage() {}
+
+func (x *SignedPayload) ProtoReflect() protoreflect.Message {
+	mi := &file_protected_topic_proto_msgTypes[0]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SignedPayload.ProtoReflect.Descriptor instead.
+func (*SignedPayload) Descriptor() ([]byte, []int) {
+	return file_protected_topic_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *SignedPayload) GetPayload() []byte {
+	if x != nil {
+		return x.Payload
+	}
+	return nil
+}
+
+func (x *SignedPayload) GetSignatures() [][]byte {
+	if x != nil {
+		return x.Signatures
+	}
+	return nil
+}
+
+type TopicKey struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	// secp256k1 public key, compressed or uncompressed
+	PublicKey []byte `protobuf:"bytes,1,opt,name=public_key,json=publicKey,proto3" json:"public_key,omitempty"`
+	// Unix time in nanoseconds from which the key is valid. 0 means no lower bound
+	NotBefore int64 `protobuf:"zigzag64,2,opt,name=not_before,json=notBefore,proto3" json:"not_before,omitempty"`
+	// Unix time in nanoseconds until which the key is valid. 0 means no upper bound
+	NotAfter int64 `protobuf:"zigzag64,3,opt,name=not_after,json=notAfter,proto3" json:"not_after,omitempty"`
+}
+
+func (x *TopicKey) Reset() {
+	*x = TopicKey{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_protected_topic_proto_msgTypes[1]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *TopicKey) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*TopicKey) ProtoMessage() {}
+
+func (x *TopicKey) ProtoReflect() protoreflect.Message {
+	mi := &file_protected_topic_proto_msgTypes[1]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use TopicKey.ProtoReflect.Descriptor instead.
+func (*TopicKey) Descriptor() ([]byte, []int) {
+	return file_protected_topic_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *TopicKey) GetPublicKey() []byte {
+	if x != nil {
+		return x.PublicKey
+	}
+	return nil
+}
+
+func (x *TopicKey) GetNotBefore() int64 {
+	if x != nil {
+		return x.NotBefore
+	}
+	return 0
+}
+
+func (x *TopicKey) GetNotAfter() int64 {
+	if x != nil {
+		return x.NotAfter
+	}
+	return 0
+}
+
+// Change of the keys of a protected topic, published in the topic itself
+type KeyUpdate struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	// Must be greater than the sequence of the last update applied
+	Sequence uint64      `protobuf:"varint,1,opt,name=sequence,proto3" json:"sequence,omitempty"`
+	Add      []*TopicKey `protobuf:"bytes,2,rep,name=add,proto3" json:"add,omitempty"`
+	// Public keys that are no longer allowed to sign messages
+	Revoke [][]byte `protobuf:"bytes,3,rep,name=revoke,proto3" json:"revoke,omitempty"`
+}
+
+func (x *KeyUpdate) Reset() {
+	*x = KeyUpdate{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_protected_topic_proto_msgTypes[2]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *KeyUpdate) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*KeyUpdate) ProtoMessage() {}
+
+func (x *KeyUpdate) ProtoReflect() protoreflect.Message {
+	mi := &file_protected_topic_proto_msgTypes[2]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use KeyUpdate.ProtoReflect.Descriptor instead.
+func (*KeyUpdate) Descriptor() ([]byte, []int) {
+	return file_protected_topic_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *KeyUpdate) GetSequence() uint64 {
+	if x != nil {
+		return x.Sequence
+	}
+	return 0
+}
+
+func (x *KeyUpdate) GetAdd() []*TopicKey {
+	if x != nil {
+		return x.Add
+	}
+	return nil
+}
+
+func (x *KeyUpdate) GetRevoke() [][]byte {
+	if x != nil {
+		return x.Revoke
+	}
+	return nil
+}
+
+var File_protected_topic_proto protoreflect.FileDescriptor
+
+var file_protected_topic_proto_rawDesc = []byte{
+	0x0a, 0x15, 0x70, 0x72, 0x6f, 0x74, 0x65, 0x63, 0x74, 0x65, 0x64, 0x5f, 0x74, 0x6f, 0x70, 0x69,
+	0x63, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x12, 0x1d, 0x77, 0x61, 0x6b, 0x75, 0x2e, 0x72, 0x65,
+	0x6c, 0x61, 0x79, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x65, 0x63, 0x74, 0x65, 0x64, 0x5f, 0x74, 0x6f,
+	0x70, 0x69, 0x63, 0x2e, 0x76, 0x31, 0x22, 0x49, 0x0a, 0x0d, 0x53, 0x69, 0x67, 0x6e, 0x65, 0x64,
+	0x50, 0x61, 0x79, 0x6c, 0x6f, 0x61, 0x64, 0x12, 0x18, 0x0a, 0x07, 0x70, 0x61, 0x79, 0x6c, 0x6f,
+	0x61, 0x64, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x07, 0x70, 0x61, 0x79, 0x6c, 0x6f, 0x61,
+	0x64, 0x12, 0x1e, 0x0a, 0x0a, 0x73, 0x69, 0x67, 0x6e, 0x61, 0x74, 0x75, 0x72, 0x65, 0x73, 0x18,
+	0x02, 0x20, 0x03, 0x28, 0x0c, 0x52, 0x0a, 0x73, 0x69, 0x67, 0x6e, 0x61, 0x74, 0x75, 0x72, 0x65,
+	0x73, 0x22, 0x65, 0x0a, 0x08, 0x54, 0x6f, 0x70, 0x69, 0x63, 0x4b, 0x65, 0x79, 0x12, 0x1d, 0x0a,
+	0x0a, 0x70, 0x75, 0x62, 0x6c, 0x69, 0x63, 0x5f, 0x6b, 0x65, 0x79, 0x18, 0x01, 0x20, 0x01, 0x28,
+	0x0c, 0x52, 0x09, 0x70, 0x75, 0x62, 0x6c, 0x69, 0x63, 0x4b, 0x65, 0x79, 0x12, 0x1d, 0x0a, 0x0a,
+	0x6e, 0x6f, 0x74, 0x5f, 0x62, 0x65, 0x66, 0x6f, 0x72, 0x65, 0x18, 0x02, 0x20, 0x01, 0x28, 0x12,
+	0x52, 0x09, 0x6e, 0x6f, 0x74, 0x42, 0x65, 0x66, 0x6f, 0x72, 0x65, 0x12, 0x1b, 0x0a, 0x09, 0x6e,
+	0x6f, 0x74, 0x5f, 0x61, 0x66, 0x74, 0x65, 0x72, 0x18, 0x03, 0x20, 0x01, 0x28, 0x12, 0x52, 0x08,
+	0x6e, 0x6f, 0x74, 0x41, 0x66, 0x74, 0x65, 0x72, 0x22, 0x7a, 0x0a, 0x09, 0x4b, 0x65, 0x79, 0x55,
+	0x70, 0x64, 0x61, 0x74, 0x65, 0x12, 0x1a, 0x0a, 0x08, 0x73, 0x65, 0x71, 0x75, 0x65, 0x6e, 0x63,
+	0x65, 0x18, 0x01, 0x20, 0x01, 0x28, 0x04, 0x52, 0x08, 0x73, 0x65, 0x71, 0x75, 0x65, 0x6e, 0x63,
+	0x65, 0x12, 0x39, 0x0a, 0x03, 0x61, 0x64, 0x64, 0x18, 0x02, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x27,
+	0x2e, 0x77, 0x61, 0x6b, 0x75, 0x2e, 0x72, 0x65, 0x6c, 0x61, 0x79, 0x2e, 0x70, 0x72, 0x6f, 0x74,
+	0x65, 0x63, 0x74, 0x65, 0x64, 0x5f, 0x74, 0x6f, 0x70, 0x69, 0x63, 0x2e, 0x76, 0x31, 0x2e, 0x54,
+	0x6f, 0x70, 0x69, 0x63, 0x4b, 0x65, 0x79, 0x52, 0x03, 0x61, 0x64, 0x64, 0x12, 0x16, 0x0a, 0x06,
+	0x72, 0x65, 0x76, 0x6f, 0x6b, 0x65, 0x18, 0x03, 0x20, 0x03, 0x28, 0x0c, 0x52, 0x06, 0x72, 0x65,
+	0x76, 0x6f, 0x6b, 0x65, 0x62, 0x06, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x33,
+}
+
+var (
+	file_protected_topic_proto_rawDescOnce sync.Once
+	file_protected_topic_proto_rawDescData = file_protected_topic_proto_rawDesc
+)
+
+func file_protected_topic_proto_rawDescGZIP() []byte {
+	file_protected_topic_proto_rawDescOnce.Do(func() {
+		file_protected_topic_proto_rawDescData = protoimpl.X.CompressGZIP(file_protected_topic_proto_rawDescData)
+	})
+	return file_protected_topic_proto_rawDescData
+}
+
+var file_protected_topic_proto_msgTypes = make([]protoimpl.MessageInfo, 3)
+var file_protected_topic_proto_goTypes = []any{
+	(*SignedPayload)(nil), // 0: waku.relay.protected_topic.v1.SignedPayload
+	(*TopicKey)(nil),      // 1: waku.relay.protected_topic.v1.TopicKey
+	(*KeyUpdate)(nil),     // 2: waku.relay.protected_topic.v1.KeyUpdate
+}
+var file_protected_topic_proto_depIdxs = []int32{
+	1, // 0: waku.relay.protected_topic.v1.KeyUpdate.add:type_name -> waku.relay.protected_topic.v1.TopicKey
+	1, // [1:1] is the sub-list for method output_type
+	1, // [1:1] is the sub-list for method input_type
+	1, // [1:1] is the sub-list for extension type_name
+	1, // [1:1] is the sub-list for extension extendee
+	0, // [0:1] is the sub-list for field type_name
+}
+
+func init() { file_protected_topic_proto_init() }
+func file_protected_topic_proto_init() {
+	if File_protected_topic_proto != nil {
+		return
+	}
+	if !protoimpl.UnsafeEnabled {
+		file_protected_topic_proto_msgTypes[0].Exporter = func(v any, i int) any {
+			switch v := v.(*SignedPayload); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_protected_topic_proto_msgTypes[1].Exporter = func(v any, i int) any {
+			switch v := v.(*TopicKey); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_protected_topic_proto_msgTypes[2].Exporter = func(v any, i int) any {
+			switch v := v.(*KeyUpdate); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: file_protected_topic_proto_rawDesc,
+			NumEnums:      0,
+			NumMessages:   3,
+			NumExtensions: 0,
+			NumServices:   0,
+		},
+		GoTypes:           file_protected_topic_proto_goTypes,
+		DependencyIndexes: file_protected_topic_proto_depIdxs,
+		MessageInfos:      file_protected_topic_proto_msgTypes,
+	}.Build()
+	File_protected_topic_proto = out.File
+	file_protected_topic_proto_rawDesc = nil
+	file_protected_topic_proto_goTypes = nil
+	file_protected_topic_proto_depIdxs = nil
+}
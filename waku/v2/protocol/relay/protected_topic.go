@@ -0,0 +1,465 @@
+package relay
+
+import (
+	"bufio"
+	"context"
+	"crypto/ecdsa"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/crypto/secp256k1"
+	"github.com/waku-org/go-waku/waku/v2/protocol/pb"
+	relaypb "github.com/waku-org/go-waku/waku/v2/protocol/relay/pb"
+	"github.com/waku-org/go-waku/waku/v2/timesource"
+	"github.com/waku-org/go-waku/waku/v2/utils"
+	"go.uber.org/zap"
+	"google.golang.org/protobuf/encoding/protodelim"
+	"google.golang.org/protobuf/proto"
+)
+
+// KeyUpdateContentTopic is the content topic of the messages that change the keys of a
+// protected topic. Their payload is a KeyUpdate, signed like any other message of the topic
+const KeyUpdateContentTopic = "/waku/1/protected-topic-key-update/proto"
+
+var (
+	ErrNoTopicKeys         = errors.New("protected topic requires at least one key")
+	ErrInvalidThreshold    = errors.New("threshold must be between 1 and the number of keys")
+	ErrMissingPublicKey    = errors.New("missing public key")
+	ErrInvalidKeyValidity  = errors.New("key expires before becoming valid")
+	ErrNotEnoughSignatures = errors.New("not enough private keys to reach the threshold")
+)
+
+var (
+	errStaleKeyUpdate          = errors.New("key update sequence is not greater than the last one applied")
+	errKeysChanged             = errors.New("keys changed after the key update was verified")
+	errKeyUpdateBelowThreshold = errors.New("key update leaves fewer keys than the threshold")
+)
+
+// ProtectedTopicKey is a key allowed to sign the messages of a protected topic. A zero
+// NotBefore or NotAfter means that the validity of the key is not limited in that direction
+type ProtectedTopicKey struct {
+	PublicKey *ecdsa.PublicKey
+	NotBefore time.Time
+	NotAfter  time.Time
+}
+
+func (k ProtectedTopicKey) validAt(t time.Time) bool {
+	if !k.NotBefore.IsZero() && t.Before(k.NotBefore) {
+		return false
+	}
+	return k.NotAfter.IsZero() || !t.After(k.NotAfter)
+}
+
+// ProtectedTopicConfig contains the keys allowed to sign the messages of a protected topic.
+// Messages must be signed by Threshold different keys that are valid when the message is
+// validated. A Threshold of 0 is treated as 1
+type ProtectedTopicConfig struct {
+	Keys      []ProtectedTopicKey
+	Threshold int
+	// StatePath is the file where the key updates applied are saved. They are applied again on
+	// top of Keys when the topic is added, so rotated and revoked keys are not accepted after a
+	// restart. If it's empty, key updates are only kept in memory
+	StatePath string
+}
+
+func (c ProtectedTopicConfig) threshold() int {
+	if c.Threshold == 0 {
+		return 1
+	}
+	return c.Threshold
+}
+
+func (c ProtectedTopicConfig) Validate() error {
+	if len(c.Keys) == 0 {
+		return ErrNoTopicKeys
+	}
+
+	if c.threshold() < 1 || c.threshold() > len(c.Keys) {
+		return ErrInvalidThreshold
+	}
+
+	for _, k := range c.Keys {
+		if k.PublicKey == nil {
+			return ErrMissingPublicKey
+		}
+		if !k.NotBefore.IsZero() && !k.NotAfter.IsZero() && k.NotAfter.Before(k.NotBefore) {
+			return ErrInvalidKeyValidity
+		}
+	}
+
+	return nil
+}
+
+// protectedTopic validates the messages of a protected topic and applies the key updates
+// published in it. The key set is never modified in place so it can be read while an
+// update is applied
+type protectedTopic struct {
+	sync.RWMutex
+	timesource timesource.Timesource
+	threshold  int
+	// keys are indexed by their compressed public key
+	keys      map[string]ProtectedTopicKey
+	sequence  uint64
+	statePath string
+	log       *zap.Logger
+}
+
+func newProtectedTopic(cfg ProtectedTopicConfig, t timesource.Timesource, log *zap.Logger) (*protectedTopic, error) {
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+
+	keys := make(map[string]ProtectedTopicKey)
+	for _, k := range cfg.Keys {
+		keys[string(crypto.CompressPubkey(k.PublicKey))] = k
+	}
+
+	p := &protectedTopic{
+		timesource: t,
+		threshold:  cfg.threshold(),
+		keys:       keys,
+		statePath:  cfg.StatePath,
+		log:        log,
+	}
+
+	if p.statePath != "" {
+		if err := p.loadKeyUpdates(); err != nil {
+			return nil, err
+		}
+	}
+
+	return p, nil
+}
+
+// verify checks that a message is signed by enough of the keys that are currently valid. It
+// returns the payload that was signed, and the sequence of the key set used to verify it
+func (p *protectedTopic) verify(msg *pb.WakuMessage, topic string) ([]byte, uint64, bool) {
+	if !withinTimeWindow(p.timesource, msg) {
+		return nil, 0, false
+	}
+
+	payload := msg.Payload
+	signatures := [][]byte{msg.Meta}
+	if p.threshold > 1 {
+		envelope := &relaypb.SignedPayload{}
+		if err := proto.Unmarshal(msg.Payload, envelope); err != nil {
+			return nil, 0, false
+		}
+		payload = envelope.Payload
+		signatures = envelope.Signatures
+	}
+
+	p.RLock()
+	keys := p.keys
+	sequence := p.sequence
+	p.RUnlock()
+
+	now := p.timesource.Now()
+	var validKeys [][]byte
+	for id, k := range keys {
+		if k.validAt(now) {
+			validKeys = append(validKeys, []byte(id))
+		}
+	}
+
+	if len(signatures) > len(validKeys) {
+		return nil, 0, false
+	}
+
+	// Each key counts once towards the threshold, no matter how many times it signed the message
+	hash := hashWithPayload(topic, msg, payload)
+	used := make([]bool, len(validKeys))
+	signed := 0
+	for _, signature := range signatures {
+		for i, key := range validKeys {
+			if !used[i] && secp256k1.VerifySignature(key, hash, signature) {
+				used[i] = true
+				signed++
+				break
+			}
+		}
+	}
+
+	if signed < p.threshold {
+		return nil, 0, false
+	}
+
+	return payload, sequence, true
+}
+
+// validate accepts the messages signed by enough keys. Key updates are also checked against the
+// current keys, but they're only applied by onDelivery once all the validators accept them
+func (p *protectedTopic) validate(ctx context.Context, msg *pb.WakuMessage, topic string) bool {
+	payload, sequence, ok := p.verify(msg, topic)
+	if !ok {
+		return false
+	}
+
+	if msg.ContentTopic == KeyUpdateContentTopic {
+		update := &relaypb.KeyUpdate{}
+		if err := proto.Unmarshal(payload, update); err != nil {
+			return false
+		}
+
+		p.RLock()
+		_, err := p.updatedKeys(update, sequence)
+		p.RUnlock()
+		if err != nil {
+			p.log.Debug("rejecting key update", zap.Uint64("sequence", update.Sequence), zap.Error(err))
+			return false
+		}
+	}
+
+	return true
+}
+
+// onDelivery applies the key updates delivered by relay, which were accepted by all the validators
+func (p *protectedTopic) onDelivery(msg *pb.WakuMessage, topic string) {
+	if msg.ContentTopic != KeyUpdateContentTopic {
+		return
+	}
+
+	// The signatures are verified again, since another update could have revoked the keys
+	// that signed this one after it was validated
+	payload, sequence, ok := p.verify(msg, topic)
+	if !ok {
+		p.log.Warn("ignoring key update not signed by the current keys")
+		return
+	}
+
+	update := &relaypb.KeyUpdate{}
+	if err := proto.Unmarshal(payload, update); err != nil {
+		return
+	}
+
+	if err := p.applyKeyUpdate(update, sequence); err != nil {
+		p.log.Warn("ignoring key update", zap.Uint64("sequence", update.Sequence), zap.Error(err))
+	}
+}
+
+// updatedKeys returns the keys resulting from applying an update to the current keys. The
+// update is rejected if another one was applied after the signatures were verified, since
+// the keys that signed it may have been revoked. Must be called with the lock held
+func (p *protectedTopic) updatedKeys(update *relaypb.KeyUpdate, verifiedSequence uint64) (map[string]ProtectedTopicKey, error) {
+	if p.sequence != verifiedSequence {
+		return nil, errKeysChanged
+	}
+
+	if update.Sequence <= p.sequence {
+		return nil, errStaleKeyUpdate
+	}
+
+	keys := maps.Clone(p.keys)
+	for _, revoked := range update.Revoke {
+		publicKey, err := parsePublicKey(revoked)
+		if err != nil {
+			return nil, err
+		}
+		delete(keys, string(crypto.CompressPubkey(publicKey)))
+	}
+
+	for _, added := range update.Add {
+		key, err := topicKeyFromProto(added)
+		if err != nil {
+			return nil, err
+		}
+		keys[string(crypto.CompressPubkey(key.PublicKey))] = key
+	}
+
+	// Updates that would prevent any further message from being accepted are rejected
+	if len(keys) < p.threshold {
+		return nil, errKeyUpdateBelowThreshold
+	}
+
+	return keys, nil
+}
+
+// applyKeyUpdate changes the keys of the topic, and saves the update if a state path is configured
+func (p *protectedTopic) applyKeyUpdate(update *relaypb.KeyUpdate, verifiedSequence uint64) error {
+	p.Lock()
+	defer p.Unlock()
+
+	keys, err := p.updatedKeys(update, verifiedSequence)
+	if err != nil {
+		return err
+	}
+
+	if p.statePath != "" {
+		if err := p.saveKeyUpdate(update); err != nil {
+			// The update is applied anyway, like the rest of the nodes of the topic will do
+			p.log.Error("saving key update", zap.String("path", p.statePath), zap.Uint64("sequence", update.Sequence), zap.Error(err))
+		}
+	}
+
+	p.keys = keys
+	p.sequence = update.Sequence
+
+	p.log.Info("applied key update", zap.Uint64("sequence", update.Sequence), zap.Int("added", len(update.Add)), zap.Int("revoked", len(update.Revoke)), zap.Int("keys", len(keys)))
+
+	return nil
+}
+
+// saveKeyUpdate appends a key update to the state file
+func (p *protectedTopic) saveKeyUpdate(update *relaypb.KeyUpdate) error {
+	f, err := os.OpenFile(p.statePath, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	if _, err := protodelim.MarshalTo(f, update); err != nil {
+		return err
+	}
+
+	return f.Sync()
+}
+
+// loadKeyUpdates applies the key updates saved in the state file, in the order they were applied
+func (p *protectedTopic) loadKeyUpdates() error {
+	f, err := os.Open(p.statePath)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil
+		}
+		return err
+	}
+	defer f.Close()
+
+	reader := bufio.NewReader(f)
+	for {
+		update := &relaypb.KeyUpdate{}
+		err := protodelim.UnmarshalFrom(reader, update)
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return fmt.Errorf("reading key updates from %s: %w", p.statePath, err)
+		}
+
+		keys, err := p.updatedKeys(update, p.sequence)
+		if err != nil {
+			return fmt.Errorf("applying saved key update %d: %w", update.Sequence, err)
+		}
+
+		p.keys = keys
+		p.sequence = update.Sequence
+	}
+
+	if p.sequence != 0 {
+		p.log.Info("applied saved key updates", zap.Uint64("sequence", p.sequence), zap.Int("keys", len(p.keys)))
+	}
+
+	return nil
+}
+
+func parsePublicKey(b []byte) (*ecdsa.PublicKey, error) {
+	if len(b) == 33 {
+		return crypto.DecompressPubkey(b)
+	}
+	return crypto.UnmarshalPubkey(b)
+}
+
+func topicKeyFromProto(k *relaypb.TopicKey) (ProtectedTopicKey, error) {
+	publicKey, err := parsePublicKey(k.PublicKey)
+	if err != nil {
+		return ProtectedTopicKey{}, err
+	}
+
+	result := ProtectedTopicKey{PublicKey: publicKey}
+	if k.NotBefore != 0 {
+		result.NotBefore = time.Unix(0, k.NotBefore)
+	}
+	if k.NotAfter != 0 {
+		result.NotAfter = time.Unix(0, k.NotAfter)
+	}
+	if !result.NotBefore.IsZero() && !result.NotAfter.IsZero() && result.NotAfter.Before(result.NotBefore) {
+		return ProtectedTopicKey{}, ErrInvalidKeyValidity
+	}
+
+	return result, nil
+}
+
+// AddProtectedTopic registers a gossipsub validator for a topic which will check that messages are
+// signed by a threshold of the keys of the topic. With a threshold of 1 the signature is contained
+// in the Meta field, as with SignMessage. Otherwise messages must be signed with SignMessageWithKeys.
+// Keys can be added and revoked by publishing a KeyUpdate in the topic, signed like any other message.
+// Updates are applied once relay delivers them, so the node must be subscribed to the topic, and nodes
+// joining later must be configured with the current keys. Applied updates are saved in cfg.StatePath
+func (w *WakuRelay) AddProtectedTopic(topic string, cfg ProtectedTopicConfig) error {
+	p, err := newProtectedTopic(cfg, w.timesource, w.log.Named("protected-topic").With(zap.String("topic", topic)))
+	if err != nil {
+		return err
+	}
+
+	var publicKeys []string
+	for _, k := range cfg.Keys {
+		publicKeys = append(publicKeys, hex.EncodeToString(crypto.CompressPubkey(k.PublicKey)))
+	}
+	w.log.Info("adding validator to protected topic", zap.String("topic", topic), zap.Strings("publicKeys", publicKeys), zap.Int("threshold", p.threshold))
+
+	w.RegisterTopicValidator(topic, p.validate)
+	w.registerDeliveryHandler(topic, p.onDelivery)
+
+	if !w.IsSubscribed(topic) {
+		w.log.Warn("relay is not subscribed to protected topic", zap.String("topic", topic))
+	}
+
+	return nil
+}
+
+// SignMessageWithKeys signs a message for a protected topic with a threshold greater than 1. The
+// payload of the message is replaced by a SignedPayload containing the original payload and the
+// signatures, which can be read back with OpenSignedPayload
+func SignMessageWithKeys(privKeys []*ecdsa.PrivateKey, msg *pb.WakuMessage, pubsubTopic string) error {
+	if len(privKeys) == 0 {
+		return ErrNotEnoughSignatures
+	}
+
+	hash := msgHash(pubsubTopic, msg)
+	envelope := &relaypb.SignedPayload{Payload: msg.Payload}
+	for _, privKey := range privKeys {
+		sign, err := secp256k1.Sign(hash, crypto.FromECDSA(privKey))
+		if err != nil {
+			return err
+		}
+		envelope.Signatures = append(envelope.Signatures, sign[0:64]) // Remove V
+	}
+
+	payload, err := proto.Marshal(envelope)
+	if err != nil {
+		return err
+	}
+
+	msg.Payload = payload
+	return nil
+}
+
+// OpenSignedPayload returns the original payload of a message signed with SignMessageWithKeys
+func OpenSignedPayload(msg *pb.WakuMessage) ([]byte, error) {
+	envelope := &relaypb.SignedPayload{}
+	if err := proto.Unmarshal(msg.Payload, envelope); err != nil {
+		return nil, err
+	}
+	return envelope.Payload, nil
+}
+
+// NewKeyUpdateMessage creates an unsigned message that changes the keys of a protected topic
+func NewKeyUpdateMessage(update *relaypb.KeyUpdate) (*pb.WakuMessage, error) {
+	payload, err := proto.Marshal(update)
+	if err != nil {
+		return nil, err
+	}
+
+	return &pb.WakuMessage{
+		Payload:      payload,
+		ContentTopic: KeyUpdateContentTopic,
+		Timestamp:    utils.GetUnixEpoch(),
+	}, nil
+}
@@ -0,0 +1,144 @@
+package relay
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/stretchr/testify/require"
+	"github.com/waku-org/go-waku/waku/v2/protocol/pb"
+	relaypb "github.com/waku-org/go-waku/waku/v2/protocol/relay/pb"
+	"github.com/waku-org/go-waku/waku/v2/utils"
+	"google.golang.org/protobuf/proto"
+)
+
+func TestProtectedTopic(t *testing.T) {
+	pubsubTopic := "/waku/2/rs/99/1"
+	now := time.Now()
+	timesource := NewFakeTimesource(now)
+
+	var privKeys []*ecdsa.PrivateKey
+	for i := 0; i < 4; i++ {
+		privKey, err := crypto.GenerateKey()
+		require.NoError(t, err)
+		privKeys = append(privKeys, privKey)
+	}
+
+	newMessage := func(payload string, signers ...*ecdsa.PrivateKey) *pb.WakuMessage {
+		msg := &pb.WakuMessage{
+			Payload:      []byte(payload),
+			ContentTopic: "/test/1/protected/proto",
+			Timestamp:    proto.Int64(now.UnixNano()),
+		}
+		require.NoError(t, SignMessageWithKeys(signers, msg, pubsubTopic))
+		return msg
+	}
+
+	newKeyUpdate := func(update *relaypb.KeyUpdate, signers ...*ecdsa.PrivateKey) *pb.WakuMessage {
+		msg, err := NewKeyUpdateMessage(update)
+		require.NoError(t, err)
+		msg.Timestamp = proto.Int64(now.UnixNano())
+		require.NoError(t, SignMessageWithKeys(signers, msg, pubsubTopic))
+		return msg
+	}
+
+	// Key 2 is not valid yet
+	cfg := ProtectedTopicConfig{
+		Keys: []ProtectedTopicKey{
+			{PublicKey: &privKeys[0].PublicKey},
+			{PublicKey: &privKeys[1].PublicKey, NotAfter: now.Add(time.Hour)},
+			{PublicKey: &privKeys[2].PublicKey, NotBefore: now.Add(time.Hour)},
+		},
+		Threshold: 2,
+		StatePath: filepath.Join(t.TempDir(), "keys"),
+	}
+	p, err := newProtectedTopic(cfg, timesource, utils.Logger())
+	require.NoError(t, err)
+
+	validate := func(msg *pb.WakuMessage) bool {
+		return p.validate(context.Background(), msg, pubsubTopic)
+	}
+
+	msg := newMessage("test", privKeys[0], privKeys[1])
+	require.True(t, validate(msg))
+	payload, err := OpenSignedPayload(msg)
+	require.NoError(t, err)
+	require.Equal(t, []byte("test"), payload)
+
+	// Below the threshold, repeated or not yet valid keys
+	require.False(t, validate(newMessage("test", privKeys[0])))
+	require.False(t, validate(newMessage("test", privKeys[0], privKeys[0])))
+	require.False(t, validate(newMessage("test", privKeys[0], privKeys[2])))
+	require.False(t, validate(newMessage("test", privKeys[0], privKeys[3])))
+
+	// Tampered payload
+	msg = newMessage("test", privKeys[0], privKeys[1])
+	envelope := &relaypb.SignedPayload{}
+	require.NoError(t, proto.Unmarshal(msg.Payload, envelope))
+	envelope.Payload = []byte("tampered")
+	msg.Payload, err = proto.Marshal(envelope)
+	require.NoError(t, err)
+	require.False(t, validate(msg))
+
+	// Rotation: key 1 is revoked and replaced by key 3
+	update := &relaypb.KeyUpdate{
+		Sequence: 1,
+		Add:      []*relaypb.TopicKey{{PublicKey: crypto.CompressPubkey(&privKeys[3].PublicKey)}},
+		Revoke:   [][]byte{crypto.FromECDSAPub(&privKeys[1].PublicKey)},
+	}
+	require.False(t, validate(newKeyUpdate(update, privKeys[0])))
+	msg = newKeyUpdate(update, privKeys[0], privKeys[1])
+	require.True(t, validate(msg))
+
+	// Updates are only applied once relay delivers them
+	require.True(t, validate(newMessage("test", privKeys[0], privKeys[1])))
+	p.onDelivery(msg, pubsubTopic)
+
+	require.False(t, validate(newMessage("test", privKeys[0], privKeys[1])))
+	require.True(t, validate(newMessage("test", privKeys[0], privKeys[3])))
+
+	// Applied updates are restored from the state file
+	restored, err := newProtectedTopic(cfg, timesource, utils.Logger())
+	require.NoError(t, err)
+	require.Equal(t, uint64(1), restored.sequence)
+	require.False(t, restored.validate(context.Background(), newMessage("test", privKeys[0], privKeys[1]), pubsubTopic))
+	require.True(t, restored.validate(context.Background(), newMessage("test", privKeys[0], privKeys[3]), pubsubTopic))
+
+	// Updates can't be replayed, and must leave enough keys to reach the threshold
+	require.False(t, validate(newKeyUpdate(update, privKeys[0], privKeys[3])))
+	update = &relaypb.KeyUpdate{
+		Sequence: 2,
+		Revoke:   [][]byte{crypto.FromECDSAPub(&privKeys[0].PublicKey), crypto.FromECDSAPub(&privKeys[3].PublicKey)},
+	}
+	require.False(t, validate(newKeyUpdate(update, privKeys[0], privKeys[3])))
+
+	// Key 2 becomes valid, and key 1 expires
+	later := now.Add(2 * time.Hour)
+	p.timesource = NewFakeTimesource(later)
+	msg = &pb.WakuMessage{
+		Payload:      []byte("test"),
+		ContentTopic: "/test/1/protected/proto",
+		Timestamp:    proto.Int64(later.UnixNano()),
+	}
+	require.NoError(t, SignMessageWithKeys([]*ecdsa.PrivateKey{privKeys[2], privKeys[3]}, msg, pubsubTopic))
+	require.True(t, validate(msg))
+}
+
+func TestProtectedTopicConfig(t *testing.T) {
+	privKey, err := crypto.GenerateKey()
+	require.NoError(t, err)
+	key := ProtectedTopicKey{PublicKey: &privKey.PublicKey}
+
+	require.ErrorIs(t, ProtectedTopicConfig{}.Validate(), ErrNoTopicKeys)
+	require.ErrorIs(t, ProtectedTopicConfig{Keys: []ProtectedTopicKey{key}, Threshold: 2}.Validate(), ErrInvalidThreshold)
+	require.ErrorIs(t, ProtectedTopicConfig{Keys: []ProtectedTopicKey{{}}}.Validate(), ErrMissingPublicKey)
+
+	now := time.Now()
+	expired := ProtectedTopicKey{PublicKey: &privKey.PublicKey, NotBefore: now, NotAfter: now.Add(-time.Second)}
+	require.ErrorIs(t, ProtectedTopicConfig{Keys: []ProtectedTopicKey{expired}}.Validate(), ErrInvalidKeyValidity)
+
+	require.NoError(t, ProtectedTopicConfig{Keys: []ProtectedTopicKey{key}}.Validate())
+}
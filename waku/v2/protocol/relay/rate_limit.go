@@ -0,0 +1,190 @@
+package relay
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	pubsub "github.com/libp2p/go-libp2p-pubsub"
+	"github.com/libp2p/go-libp2p/core/peer"
+	"github.com/waku-org/go-waku/logging"
+	"github.com/waku-org/go-waku/waku/v2/protocol/pb"
+	"go.uber.org/zap"
+	"golang.org/x/time/rate"
+)
+
+// RateLimitConfig contains the token bucket limits applied to the messages received via relay
+type RateLimitConfig struct {
+	// PeerLimit is the number of messages per second accepted from each peer that forwards
+	// messages to this node. Set it to 0 to disable the limit
+	PeerLimit rate.Limit
+	// PeerBurst is the number of messages a peer can forward at once
+	PeerBurst int
+	// ContentTopicLimit is the number of messages per second accepted for each content topic.
+	// Set it to 0 to disable the limit
+	ContentTopicLimit rate.Limit
+	// ContentTopicBurst is the number of messages that can be received at once for a content topic
+	ContentTopicBurst int
+	// Reject indicates that the messages exceeding a limit are rejected, which penalizes the
+	// score of the peer that forwarded them. Otherwise, the messages are ignored
+	Reject bool
+}
+
+// Validate checks that the limits and bursts are consistent
+func (c RateLimitConfig) Validate() error {
+	if c.PeerLimit < 0 || c.ContentTopicLimit < 0 {
+		return errors.New("rate limits cannot be negative")
+	}
+	if c.PeerLimit > 0 && c.PeerBurst < 1 {
+		return errors.New("peer burst must be greater than 0")
+	}
+	if c.ContentTopicLimit > 0 && c.ContentTopicBurst < 1 {
+		return errors.New("content topic burst must be greater than 0")
+	}
+	return nil
+}
+
+// rateLimiterCleanupInterval is the frequency at which the limiters of inactive peers and
+// content topics are removed
+const rateLimiterCleanupInterval = time.Minute
+
+// minLimiterIdleTime is the minimum duration a limiter is kept after its last use
+const minLimiterIdleTime = 5 * time.Minute
+
+type limiterEntry struct {
+	limiter  *rate.Limiter
+	lastSeen time.Time
+}
+
+// limiterSet keeps a token bucket per key. A limiter is removed once it's idle for long enough
+// for its bucket to be full again, so removing it does not allow exceeding the limit
+type limiterSet struct {
+	limit    rate.Limit
+	burst    int
+	idleTime time.Duration
+	limiters map[string]*limiterEntry
+}
+
+func newLimiterSet(limit rate.Limit, burst int) *limiterSet {
+	idleTime := time.Duration(float64(burst) / float64(limit) * float64(time.Second))
+	if idleTime < minLimiterIdleTime {
+		idleTime = minLimiterIdleTime
+	}
+
+	return &limiterSet{
+		limit:    limit,
+		burst:    burst,
+		idleTime: idleTime,
+		limiters: make(map[string]*limiterEntry),
+	}
+}
+
+func (s *limiterSet) reserve(key string, now time.Time) *rate.Reservation {
+	entry, ok := s.limiters[key]
+	if !ok {
+		entry = &limiterEntry{limiter: rate.NewLimiter(s.limit, s.burst)}
+		s.limiters[key] = entry
+	}
+	entry.lastSeen = now
+	return entry.limiter.ReserveN(now, 1)
+}
+
+// prune removes the idle limiters, calling onRemove with their key
+func (s *limiterSet) prune(now time.Time, onRemove func(key string)) {
+	for key, entry := range s.limiters {
+		if now.Sub(entry.lastSeen) > s.idleTime {
+			delete(s.limiters, key)
+			onRemove(key)
+		}
+	}
+}
+
+// rateLimiter is a peer validator that enforces a token bucket limit on the messages forwarded by
+// each peer, and on the messages received for each content topic
+type rateLimiter struct {
+	sync.Mutex
+
+	peers         *limiterSet
+	contentTopics *limiterSet
+	penalty       pubsub.ValidationResult
+	lastCleanup   time.Time
+	now           func() time.Time
+
+	metrics Metrics
+	log     *zap.Logger
+}
+
+func newRateLimiter(cfg RateLimitConfig, metrics Metrics, log *zap.Logger) *rateLimiter {
+	r := &rateLimiter{
+		penalty: pubsub.ValidationIgnore,
+		now:     time.Now,
+		metrics: metrics,
+		log:     log.Named("rate-limit"),
+	}
+
+	if cfg.Reject {
+		r.penalty = pubsub.ValidationReject
+	}
+
+	if cfg.PeerLimit > 0 {
+		r.peers = newLimiterSet(cfg.PeerLimit, cfg.PeerBurst)
+	}
+
+	if cfg.ContentTopicLimit > 0 {
+		r.contentTopics = newLimiterSet(cfg.ContentTopicLimit, cfg.ContentTopicBurst)
+	}
+
+	return r
+}
+
+// reserve consumes a token for the message, returning false if it exceeds the limit
+func reserve(set *limiterSet, key string, now time.Time) (*rate.Reservation, bool) {
+	if set == nil {
+		return nil, true
+	}
+
+	reservation := set.reserve(key, now)
+	if reservation.DelayFrom(now) > 0 {
+		reservation.CancelAt(now)
+		return nil, false
+	}
+
+	return reservation, true
+}
+
+func (r *rateLimiter) Validate(ctx context.Context, from peer.ID, msg *pb.WakuMessage, topic string) pubsub.ValidationResult {
+	r.Lock()
+	defer r.Unlock()
+
+	now := r.now()
+	if now.Sub(r.lastCleanup) >= rateLimiterCleanupInterval {
+		if r.peers != nil {
+			r.peers.prune(now, r.metrics.RemoveThrottledPeer)
+		}
+		if r.contentTopics != nil {
+			r.contentTopics.prune(now, r.metrics.RemoveThrottledContentTopic)
+		}
+		r.lastCleanup = now
+	}
+
+	peerReservation, ok := reserve(r.peers, from.String(), now)
+	if !ok {
+		r.metrics.RecordThrottledPeer(topic, from.String())
+		r.log.Debug("peer exceeded the rate limit", logging.HostID("peer", from), zap.String("pubsubTopic", topic))
+		return r.penalty
+	}
+
+	_, ok = reserve(r.contentTopics, msg.ContentTopic, now)
+	if !ok {
+		// The message does not count towards the peer limit
+		if peerReservation != nil {
+			peerReservation.CancelAt(now)
+		}
+		r.metrics.RecordThrottledContentTopic(topic, msg.ContentTopic)
+		r.log.Debug("content topic exceeded the rate limit", logging.HostID("peer", from), zap.String("pubsubTopic", topic), zap.String("contentTopic", msg.ContentTopic))
+		return r.penalty
+	}
+
+	return pubsub.ValidationAccept
+}
@@ -0,0 +1,88 @@
+package relay
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	pubsub "github.com/libp2p/go-libp2p-pubsub"
+	"github.com/libp2p/go-libp2p/core/peer"
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/stretchr/testify/require"
+	"github.com/waku-org/go-waku/tests"
+	"github.com/waku-org/go-waku/waku/v2/protocol/pb"
+	"github.com/waku-org/go-waku/waku/v2/utils"
+	"google.golang.org/protobuf/proto"
+)
+
+func TestRateLimitValidator(t *testing.T) {
+	cfg := RateLimitConfig{
+		PeerLimit:         1,
+		PeerBurst:         2,
+		ContentTopicLimit: 1,
+		ContentTopicBurst: 3,
+		Reject:            true,
+	}
+	require.NoError(t, cfg.Validate())
+
+	now := time.Now()
+	limiter := newRateLimiter(cfg, newMetrics(prometheus.NewRegistry(), utils.Logger()), utils.Logger())
+	limiter.now = func() time.Time { return now }
+
+	peer1 := peer.ID("peer1")
+	peer2 := peer.ID("peer2")
+	msgA := tests.CreateWakuMessage("a", proto.Int64(now.UnixNano()))
+	msgB := tests.CreateWakuMessage("b", proto.Int64(now.UnixNano()))
+
+	validate := func(from peer.ID, msg *pb.WakuMessage) pubsub.ValidationResult {
+		return limiter.Validate(context.Background(), from, msg, "test")
+	}
+
+	// The peer limit is exceeded
+	require.Equal(t, pubsub.ValidationAccept, validate(peer1, msgA))
+	require.Equal(t, pubsub.ValidationAccept, validate(peer1, msgA))
+	require.Equal(t, pubsub.ValidationReject, validate(peer1, msgA))
+	throttled := &dto.Metric{}
+	require.NoError(t, throttledPeerMessages.WithLabelValues("test", peer1.String()).Write(throttled))
+	require.Equal(t, 1, int(throttled.GetCounter().GetValue()))
+
+	// The content topic limit is exceeded. The throttled message
+	// does not count towards the limit of the peer
+	require.Equal(t, pubsub.ValidationAccept, validate(peer2, msgA))
+	require.Equal(t, pubsub.ValidationReject, validate(peer2, msgA))
+	require.Equal(t, pubsub.ValidationAccept, validate(peer2, msgB))
+	require.Equal(t, pubsub.ValidationReject, validate(peer2, msgB))
+
+	// Tokens are refilled over time
+	now = now.Add(time.Second)
+	require.Equal(t, pubsub.ValidationAccept, validate(peer1, msgB))
+	require.Equal(t, pubsub.ValidationReject, validate(peer1, msgB))
+
+	// Limiters of inactive peers and content topics are removed
+	require.Len(t, limiter.peers.limiters, 2)
+	require.Len(t, limiter.contentTopics.limiters, 2)
+	now = now.Add(minLimiterIdleTime + time.Second)
+	require.Equal(t, pubsub.ValidationAccept, validate(peer1, msgA))
+	require.Len(t, limiter.peers.limiters, 1)
+	require.Len(t, limiter.contentTopics.limiters, 1)
+	// and so are the metrics of the throttled ones
+	series := make(chan prometheus.Metric, 10)
+	throttledPeerMessages.Collect(series)
+	require.Empty(t, series)
+
+	// Messages exceeding the limits can be ignored instead of rejected
+	cfg.Reject = false
+	cfg.ContentTopicLimit = 0
+	require.NoError(t, cfg.Validate())
+	limiter = newRateLimiter(cfg, newMetrics(prometheus.NewRegistry(), utils.Logger()), utils.Logger())
+	limiter.now = func() time.Time { return now }
+	require.Nil(t, limiter.contentTopics)
+	require.Equal(t, pubsub.ValidationAccept, validate(peer1, msgA))
+	require.Equal(t, pubsub.ValidationAccept, validate(peer1, msgA))
+	require.Equal(t, pubsub.ValidationIgnore, validate(peer1, msgA))
+
+	// Invalid configurations
+	require.Error(t, RateLimitConfig{PeerLimit: 1}.Validate())
+	require.Error(t, RateLimitConfig{ContentTopicLimit: -1}.Validate())
+}
@@ -2,9 +2,10 @@ package relay
 
 import (
 	"context"
+	"strconv"
+	"sync/atomic"
 
 	"github.com/waku-org/go-waku/waku/v2/protocol"
-	"golang.org/x/exp/slices"
 )
 
 // Subscription handles the details of a particular Topic subscription. There may be many subscriptions for a given topic.
@@ -15,6 +16,11 @@ type Subscription struct {
 	contentFilter protocol.ContentFilter
 	subType       SubscriptionType
 	noConsume     bool
+
+	overflow OverflowPolicy
+	onDrop   func(*protocol.Envelope)
+	spill    *spillQueue
+	dropped  atomic.Uint64
 }
 
 type SubscriptionType int
@@ -28,17 +34,94 @@ const (
 func (s *Subscription) Submit(ctx context.Context, msg *protocol.Envelope) {
 	//Filter and notify
 	// - if contentFilter doesn't have a contentTopic
-	// - if contentFilter has contentTopics and it matches with message
-	if !s.noConsume && (len(s.contentFilter.ContentTopicsList()) == 0 ||
-		(len(s.contentFilter.ContentTopicsList()) > 0 && slices.Contains(s.contentFilter.ContentTopicsList(), msg.Message().ContentTopic))) {
-		select {
-		case <-ctx.Done():
-			return
-		case s.Ch <- msg:
+	// - if contentFilter has contentTopics or patterns and the message matches one of them
+	if !s.noConsume && (len(s.contentFilter.ContentTopics) == 0 || s.contentFilter.ContentTopics.Match(msg.Message().ContentTopic)) {
+		overflow := s.overflow
+		if overflow == OverflowDropOldest && cap(s.Ch) == 0 {
+			// There is no buffer to drop messages from
+			overflow = OverflowBlock
+		}
+
+		switch overflow {
+		case OverflowDropNewest:
+			select {
+			case s.Ch <- msg:
+			default:
+				s.drop(msg)
+			}
+		case OverflowDropOldest:
+			for {
+				select {
+				case s.Ch <- msg:
+					return
+				default:
+				}
+				select {
+				case oldest := <-s.Ch:
+					s.drop(oldest)
+				default:
+				}
+			}
+		case OverflowSpillToDisk:
+			// Messages are only sent directly while nothing is spilled, to preserve their order
+			if s.spill.len() == 0 {
+				select {
+				case s.Ch <- msg:
+					return
+				default:
+				}
+			}
+			if err := s.spill.push(msg); err != nil {
+				s.drop(msg)
+				return
+			}
+			subscriptionSpilledMessages.WithLabelValues(strconv.Itoa(s.ID), s.contentFilter.PubsubTopic).Inc()
+		default:
+			select {
+			case <-ctx.Done():
+				return
+			case s.Ch <- msg:
+			}
 		}
 	}
 }
 
+func (s *Subscription) metricLabels() []string {
+	return []string{strconv.Itoa(s.ID), s.contentFilter.PubsubTopic, s.overflow.String()}
+}
+
+func (s *Subscription) drop(msg *protocol.Envelope) {
+	s.dropped.Add(1)
+	subscriptionDroppedMessages.WithLabelValues(s.metricLabels()...).Inc()
+	if s.onDrop != nil {
+		s.onDrop(msg)
+	}
+}
+
+// Dropped returns the number of messages discarded by the overflow policy of the subscription
+func (s *Subscription) Dropped() uint64 {
+	return s.dropped.Load()
+}
+
+// Pending returns the number of messages waiting to be read, including those spilled to disk
+func (s *Subscription) Pending() int {
+	pending := len(s.Ch)
+	if s.spill != nil {
+		pending += s.spill.len()
+	}
+	return pending
+}
+
+func (s *Subscription) close() {
+	if s.spill != nil {
+		s.spill.stop()
+	}
+	close(s.Ch)
+
+	subscriptionDroppedMessages.DeleteLabelValues(s.metricLabels()...)
+	subscriptionSpilledMessages.DeleteLabelValues(strconv.Itoa(s.ID), s.contentFilter.PubsubTopic)
+}
+
 // NewSubscription creates a subscription that will only receive messages based on the contentFilter
 func NewSubscription(contentFilter protocol.ContentFilter) *Subscription {
 	ch := make(chan *protocol.Envelope)
@@ -0,0 +1,119 @@
+package tracer
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/libp2p/go-msgio/pbio"
+	"github.com/waku-org/go-waku/waku/v2/protocol/relay/tracer/pb"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/encoding/protowire"
+	"google.golang.org/protobuf/proto"
+)
+
+const (
+	// FormatProtobuf stores each event as a length-delimited protobuf
+	FormatProtobuf = "protobuf"
+	// FormatJSONL stores each event as a JSON object in its own line
+	FormatJSONL = "jsonl"
+)
+
+// maxEventSize is the maximum size accepted for a single event of a trace file
+const maxEventSize = 1024 * 1024
+
+type encodeFn func(evt *pb.TraceEvent) ([]byte, error)
+
+func encoder(format string) (encodeFn, error) {
+	switch format {
+	case FormatProtobuf:
+		return encodeProtobuf, nil
+	case FormatJSONL:
+		return encodeJSONL, nil
+	default:
+		return nil, fmt.Errorf("unsupported trace format: %s", format)
+	}
+}
+
+// encodeProtobuf encodes an event with its length as prefix, so that each event
+// is written at once and trace files are always rotated between events
+func encodeProtobuf(evt *pb.TraceEvent) ([]byte, error) {
+	data, err := proto.Marshal(evt)
+	if err != nil {
+		return nil, err
+	}
+	result := protowire.AppendVarint(nil, uint64(len(data)))
+	return append(result, data...), nil
+}
+
+func encodeJSONL(evt *pb.TraceEvent) ([]byte, error) {
+	line, err := protojson.Marshal(evt)
+	if err != nil {
+		return nil, err
+	}
+	return append(line, '\n'), nil
+}
+
+// Reader reads the events of a trace file. It returns io.EOF once
+// there are no more events to read
+type Reader interface {
+	Read() (*pb.TraceEvent, error)
+}
+
+// NewReader creates a Reader for a trace file written in a specific format
+func NewReader(format string, r io.Reader) (Reader, error) {
+	switch format {
+	case FormatProtobuf:
+		return &protobufReader{reader: pbio.NewDelimitedReader(r, maxEventSize)}, nil
+	case FormatJSONL:
+		return &jsonlReader{reader: bufio.NewReader(r)}, nil
+	default:
+		return nil, fmt.Errorf("unsupported trace format: %s", format)
+	}
+}
+
+type protobufReader struct {
+	reader pbio.ReadCloser
+}
+
+func (p *protobufReader) Read() (*pb.TraceEvent, error) {
+	evt := new(pb.TraceEvent)
+	err := p.reader.ReadMsg(evt)
+	if err != nil {
+		return nil, err
+	}
+	return evt, nil
+}
+
+type jsonlReader struct {
+	reader *bufio.Reader
+}
+
+func (j *jsonlReader) Read() (*pb.TraceEvent, error) {
+	for {
+		line, err := j.reader.ReadBytes('\n')
+		if err != nil && (!errors.Is(err, io.EOF) || len(line) == 0) {
+			return nil, err
+		}
+
+		line = bytes.TrimSpace(line)
+		if len(line) == 0 {
+			// Skip empty lines
+			continue
+		}
+
+		if len(line) > maxEventSize {
+			return nil, errors.New("event exceeds the maximum size")
+		}
+
+		evt := new(pb.TraceEvent)
+		err = protojson.Unmarshal(line, evt)
+		if err != nil {
+			return nil, err
+		}
+
+		return evt, nil
+	}
+}
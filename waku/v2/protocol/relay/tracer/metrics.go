@@ -0,0 +1,53 @@
+package tracer
+
+import (
+	"github.com/libp2p/go-libp2p/p2p/metricshelper"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/waku-org/go-waku/waku/v2/protocol/relay/tracer/pb"
+)
+
+var traceEvents = prometheus.NewCounterVec(
+	prometheus.CounterOpts{
+		Name: "waku_relay_trace_events",
+		Help: "The number of relay events written to the trace file",
+	},
+	[]string{"type"},
+)
+
+var traceDroppedEvents = prometheus.NewCounter(
+	prometheus.CounterOpts{
+		Name: "waku_relay_trace_dropped_events",
+		Help: "The number of relay events that could not be written to the trace file",
+	})
+
+var collectors = []prometheus.Collector{
+	traceEvents,
+	traceDroppedEvents,
+}
+
+// Metrics exposes the functions required to update prometheus metrics for the relay tracer
+type Metrics interface {
+	RecordEvent(eventType pb.TraceEvent_Type)
+	RecordDroppedEvent()
+}
+
+type metricsImpl struct {
+	reg prometheus.Registerer
+}
+
+func newMetrics(reg prometheus.Registerer) Metrics {
+	metricshelper.RegisterCollectors(reg, collectors...)
+	return &metricsImpl{
+		reg: reg,
+	}
+}
+
+// RecordEvent increases the counter of events written to the trace file
+func (m *metricsImpl) RecordEvent(eventType pb.TraceEvent_Type) {
+	traceEvents.WithLabelValues(eventType.String()).Inc()
+}
+
+// RecordDroppedEvent increases the counter of events that were dropped
+func (m *metricsImpl) RecordDroppedEvent() {
+	traceDroppedEvents.Inc()
+}
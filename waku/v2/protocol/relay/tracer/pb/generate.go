@@ -0,0 +1,3 @@
+package pb
+
+//go:generate protoc -I. --go_opt=paths=source_relative --go_opt=Mtrace.proto=github.com/waku-org/go-waku/waku/v2/protocol/relay/tracer/pb --go_out=. ./trace.proto
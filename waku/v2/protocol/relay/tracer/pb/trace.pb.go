@@ -0,0 +1,287 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.34.2
+// 	protoc        v3.21.12
+// source: trace.proto
+
+package pb
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	reflect "reflect"
+	sync "sync"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+type TraceEvent_Type int32
+
+const (
+	TraceEvent_PUBLISH   TraceEvent_Type = 0
+	TraceEvent_DELIVER   TraceEvent_Type = 1
+	TraceEvent_DUPLICATE TraceEvent_Type = 2
+	TraceEvent_REJECT    TraceEvent_Type = 3
+	TraceEvent_GRAFT     TraceEvent_Type = 4
+	TraceEvent_PRUNE     TraceEvent_Type = 5
+)
+
+// Enum value maps for TraceEvent_Type.
+var (
+	TraceEvent_Type_name = map[int32]string{
+		0: "PUBLISH",
+		1: "DELIVER",
+		2: "DUPLICATE",
+		3: "REJECT",
+		4: "GRAFT",
+		5: "PRUNE",
+	}
+	TraceEvent_Type_value = map[string]int32{
+		"PUBLISH":   0,
+		"DELIVER":   1,
+		"DUPLICATE": 2,
+		"REJECT":    3,
+		"GRAFT":     4,
+		"PRUNE":     5,
+	}
+)
+
+func (x TraceEvent_Type) Enum() *TraceEvent_Type {
+	p := new(TraceEvent_Type)
+	*p = x
+	return p
+}
+
+func (x TraceEvent_Type) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (TraceEvent_Type) Descriptor() protoreflect.EnumDescriptor {
+	return file_trace_proto_enumTypes[0].Descriptor()
+}
+
+func (TraceEvent_Type) Type() protoreflect.EnumType {
+	return &file_trace_proto_enumTypes[0]
+}
+
+func (x TraceEvent_Type) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use TraceEvent_Type.Descriptor instead.
+func (TraceEvent_Type) EnumDescriptor() ([]byte, []int) {
+	return file_trace_proto_rawDescGZIP(), []int{0, 0}
+}
+
+// Event recorded by the relay tracer of a node
+type TraceEvent struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Type TraceEvent_Type `protobuf:"varint,1,opt,name=type,proto3,enum=waku.relay.trace.v1.TraceEvent_Type" json:"type,omitempty"`
+	// Unix time in nanoseconds at which the event was recorded
+	Timestamp int64 `protobuf:"zigzag64,2,opt,name=timestamp,proto3" json:"timestamp,omitempty"`
+	// Peer ID of the node that recorded the event
+	NodeId      string `protobuf:"bytes,3,opt,name=node_id,json=nodeId,proto3" json:"node_id,omitempty"`
+	PubsubTopic string `protobuf:"bytes,4,opt,name=pubsub_topic,json=pubsubTopic,proto3" json:"pubsub_topic,omitempty"`
+	// Used with PUBLISH, DELIVER, DUPLICATE and REJECT events
+	MessageHash []byte `protobuf:"bytes,10,opt,name=message_hash,json=messageHash,proto3" json:"message_hash,omitempty"`
+	// Peer the message was received from
+	ReceivedFrom string `protobuf:"bytes,11,opt,name=received_from,json=receivedFrom,proto3" json:"received_from,omitempty"`
+	// Used with REJECT events
+	Reason string `protobuf:"bytes,12,opt,name=reason,proto3" json:"reason,omitempty"`
+	// Used with GRAFT and PRUNE events
+	PeerId string `protobuf:"bytes,20,opt,name=peer_id,json=peerId,proto3" json:"peer_id,omitempty"`
+}
+
+func (x *TraceEvent) Reset() {
+	*x = TraceEvent{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_trace_proto_msgTypes[0]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *TraceEvent) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*TraceEvent) ProtoMessage() {}
+
+func (x *TraceEvent) ProtoReflect() protoreflect.Message {
+	mi := &file_trace_proto_msgTypes[0]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use TraceEvent.ProtoReflect.Descriptor instead.
+func (*TraceEvent) Descriptor() ([]byte, []int) {
+	return file_trace_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *TraceEvent) GetType() TraceEvent_Type {
+	if x != nil {
+		return x.Type
+	}
+	return TraceEvent_PUBLISH
+}
+
+func (x *TraceEvent) GetTimestamp() int64 {
+	if x != nil {
+		return x.Timestamp
+	}
+	return 0
+}
+
+func (x *TraceEvent) GetNodeId() string {
+	if x != nil {
+		return x.NodeId
+	}
+	return ""
+}
+
+func (x *TraceEvent) GetPubsubTopic() string {
+	if x != nil {
+		return x.PubsubTopic
+	}
+	return ""
+}
+
+func (x *TraceEvent) GetMessageHash() []byte {
+	if x != nil {
+		return x.MessageHash
+	}
+	return nil
+}
+
+func (x *TraceEvent) GetReceivedFrom() string {
+	if x != nil {
+		return x.ReceivedFrom
+	}
+	return ""
+}
+
+func (x *TraceEvent) GetReason() string {
+	if x != nil {
+		return x.Reason
+	}
+	return ""
+}
+
+func (x *TraceEvent) GetPeerId() string {
+	if x != nil {
+		return x.PeerId
+	}
+	return ""
+}
+
+var File_trace_proto protoreflect.FileDescriptor
+
+var file_trace_proto_rawDesc = []byte{
+	0x0a, 0x0b, 0x74, 0x72, 0x61, 0x63, 0x65, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x12, 0x13, 0x77,
+	0x61, 0x6b, 0x75, 0x2e, 0x72, 0x65, 0x6c, 0x61, 0x79, 0x2e, 0x74, 0x72, 0x61, 0x63, 0x65, 0x2e,
+	0x76, 0x31, 0x22, 0xec, 0x02, 0x0a, 0x0a, 0x54, 0x72, 0x61, 0x63, 0x65, 0x45, 0x76, 0x65, 0x6e,
+	0x74, 0x12, 0x38, 0x0a, 0x04, 0x74, 0x79, 0x70, 0x65, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0e, 0x32,
+	0x24, 0x2e, 0x77, 0x61, 0x6b, 0x75, 0x2e, 0x72, 0x65, 0x6c, 0x61, 0x79, 0x2e, 0x74, 0x72, 0x61,
+	0x63, 0x65, 0x2e, 0x76, 0x31, 0x2e, 0x54, 0x72, 0x61, 0x63, 0x65, 0x45, 0x76, 0x65, 0x6e, 0x74,
+	0x2e, 0x54, 0x79, 0x70, 0x65, 0x52, 0x04, 0x74, 0x79, 0x70, 0x65, 0x12, 0x1c, 0x0a, 0x09, 0x74,
+	0x69, 0x6d, 0x65, 0x73, 0x74, 0x61, 0x6d, 0x70, 0x18, 0x02, 0x20, 0x01, 0x28, 0x12, 0x52, 0x09,
+	0x74, 0x69, 0x6d, 0x65, 0x73, 0x74, 0x61, 0x6d, 0x70, 0x12, 0x17, 0x0a, 0x07, 0x6e, 0x6f, 0x64,
+	0x65, 0x5f, 0x69, 0x64, 0x18, 0x03, 0x20, 0x01, 0x28, 0x09, 0x52, 0x06, 0x6e, 0x6f, 0x64, 0x65,
+	0x49, 0x64, 0x12, 0x21, 0x0a, 0x0c, 0x70, 0x75, 0x62, 0x73, 0x75, 0x62, 0x5f, 0x74, 0x6f, 0x70,
+	0x69, 0x63, 0x18, 0x04, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0b, 0x70, 0x75, 0x62, 0x73, 0x75, 0x62,
+	0x54, 0x6f, 0x70, 0x69, 0x63, 0x12, 0x21, 0x0a, 0x0c, 0x6d, 0x65, 0x73, 0x73, 0x61, 0x67, 0x65,
+	0x5f, 0x68, 0x61, 0x73, 0x68, 0x18, 0x0a, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x0b, 0x6d, 0x65, 0x73,
+	0x73, 0x61, 0x67, 0x65, 0x48, 0x61, 0x73, 0x68, 0x12, 0x23, 0x0a, 0x0d, 0x72, 0x65, 0x63, 0x65,
+	0x69, 0x76, 0x65, 0x64, 0x5f, 0x66, 0x72, 0x6f, 0x6d, 0x18, 0x0b, 0x20, 0x01, 0x28, 0x09, 0x52,
+	0x0c, 0x72, 0x65, 0x63, 0x65, 0x69, 0x76, 0x65, 0x64, 0x46, 0x72, 0x6f, 0x6d, 0x12, 0x16, 0x0a,
+	0x06, 0x72, 0x65, 0x61, 0x73, 0x6f, 0x6e, 0x18, 0x0c, 0x20, 0x01, 0x28, 0x09, 0x52, 0x06, 0x72,
+	0x65, 0x61, 0x73, 0x6f, 0x6e, 0x12, 0x17, 0x0a, 0x07, 0x70, 0x65, 0x65, 0x72, 0x5f, 0x69, 0x64,
+	0x18, 0x14, 0x20, 0x01, 0x28, 0x09, 0x52, 0x06, 0x70, 0x65, 0x65, 0x72, 0x49, 0x64, 0x22, 0x51,
+	0x0a, 0x04, 0x54, 0x79, 0x70, 0x65, 0x12, 0x0b, 0x0a, 0x07, 0x50, 0x55, 0x42, 0x4c, 0x49, 0x53,
+	0x48, 0x10, 0x00, 0x12, 0x0b, 0x0a, 0x07, 0x44, 0x45, 0x4c, 0x49, 0x56, 0x45, 0x52, 0x10, 0x01,
+	0x12, 0x0d, 0x0a, 0x09, 0x44, 0x55, 0x50, 0x4c, 0x49, 0x43, 0x41, 0x54, 0x45, 0x10, 0x02, 0x12,
+	0x0a, 0x0a, 0x06, 0x52, 0x45, 0x4a, 0x45, 0x43, 0x54, 0x10, 0x03, 0x12, 0x09, 0x0a, 0x05, 0x47,
+	0x52, 0x41, 0x46, 0x54, 0x10, 0x04, 0x12, 0x09, 0x0a, 0x05, 0x50, 0x52, 0x55, 0x4e, 0x45, 0x10,
+	0x05, 0x62, 0x06, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x33,
+}
+
+var (
+	file_trace_proto_rawDescOnce sync.Once
+	file_trace_proto_rawDescData = file_trace_proto_rawDesc
+)
+
+func file_trace_proto_rawDescGZIP() []byte {
+	file_trace_proto_rawDescOnce.Do(func() {
+		file_trace_proto_rawDescData = protoimpl.X.CompressGZIP(file_trace_proto_rawDescData)
+	})
+	return file_trace_proto_rawDescData
+}
+
+var file_trace_proto_enumTypes = make([]protoimpl.EnumInfo, 1)
+var file_trace_proto_msgTypes = make([]protoimpl.MessageInfo, 1)
+var file_trace_proto_goTypes = []any{
+	(TraceEvent_Type)(0), // 0: waku.relay.trace.v1.TraceEvent.Type
+	(*TraceEvent)(nil),   // 1: waku.relay.trace.v1.TraceEvent
+}
+var file_trace_proto_depIdxs = []int32{
+	0, // 0: waku.relay.trace.v1.TraceEvent.type:type_name -> waku.relay.trace.v1.TraceEvent.Type
+	1, // [1:1] is the sub-list for method output_type
+	1, // [1:1] is the sub-list for method input_type
+	1, // [1:1] is the sub-list for extension type_name
+	1, // [1:1] is the sub-list for extension extendee
+	0, // [0:1] is the sub-list for field type_name
+}
+
+func init() { file_trace_proto_init() }
+func file_trace_proto_init() {
+	if File_trace_proto != nil {
+		return
+	}
+	if !protoimpl.UnsafeEnabled {
+		file_trace_proto_msgTypes[0].Exporter = func(v any, i int) any {
+			switch v := v.(*TraceEvent); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: file_trace_proto_rawDesc,
+			NumEnums:      1,
+			NumMessages:   1,
+			NumExtensions: 0,
+			NumServices:   0,
+		},
+		GoTypes:           file_trace_proto_goTypes,
+		DependencyIndexes: file_trace_proto_depIdxs,
+		EnumInfos:         file_trace_proto_enumTypes,
+		MessageInfos:      file_trace_proto_msgTypes,
+	}.Build()
+	File_trace_proto = out.File
+	file_trace_proto_rawDesc = nil
+	file_trace_proto_goTypes = nil
+	file_trace_proto_depIdxs = nil
+}
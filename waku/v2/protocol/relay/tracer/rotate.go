@@ -0,0 +1,102 @@
+package tracer
+
+import (
+	"errors"
+	"fmt"
+	"os"
+)
+
+// rotatingFile is a file that is renamed once it reaches a maximum size, keeping
+// a limited number of previous files named path.1 (the most recent) to path.N
+type rotatingFile struct {
+	path     string
+	maxSize  int64
+	maxFiles int
+
+	file *os.File
+	size int64
+}
+
+func openRotatingFile(path string, maxSize int64, maxFiles int) (*rotatingFile, error) {
+	r := &rotatingFile{
+		path:     path,
+		maxSize:  maxSize,
+		maxFiles: maxFiles,
+	}
+
+	err := r.open()
+	if err != nil {
+		return nil, err
+	}
+
+	return r, nil
+}
+
+func (r *rotatingFile) open() error {
+	f, err := os.OpenFile(r.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0600)
+	if err != nil {
+		return err
+	}
+
+	info, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return err
+	}
+
+	r.file = f
+	r.size = info.Size()
+	return nil
+}
+
+// Write writes the data to the current file, rotating it first if the data does not fit
+func (r *rotatingFile) Write(data []byte) (int, error) {
+	if r.maxSize > 0 && r.size > 0 && r.size+int64(len(data)) > r.maxSize {
+		err := r.rotate()
+		if err != nil {
+			return 0, err
+		}
+	}
+
+	n, err := r.file.Write(data)
+	r.size += int64(n)
+	return n, err
+}
+
+func (r *rotatingFile) rotate() error {
+	err := r.file.Close()
+	if err != nil {
+		return err
+	}
+
+	if r.maxFiles > 0 {
+		err = os.Remove(fmt.Sprintf("%s.%d", r.path, r.maxFiles))
+		if err != nil && !errors.Is(err, os.ErrNotExist) {
+			return err
+		}
+
+		for i := r.maxFiles - 1; i > 0; i-- {
+			err = os.Rename(fmt.Sprintf("%s.%d", r.path, i), fmt.Sprintf("%s.%d", r.path, i+1))
+			if err != nil && !errors.Is(err, os.ErrNotExist) {
+				return err
+			}
+		}
+
+		err = os.Rename(r.path, r.path+".1")
+	} else {
+		err = os.Remove(r.path)
+	}
+	if err != nil {
+		return err
+	}
+
+	return r.open()
+}
+
+func (r *rotatingFile) Sync() error {
+	return r.file.Sync()
+}
+
+func (r *rotatingFile) Close() error {
+	return r.file.Close()
+}
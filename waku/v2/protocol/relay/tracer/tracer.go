@@ -0,0 +1,252 @@
+package tracer
+
+import (
+	"sync"
+	"time"
+
+	pubsub "github.com/libp2p/go-libp2p-pubsub"
+	"github.com/libp2p/go-libp2p/core/peer"
+	"github.com/libp2p/go-libp2p/core/protocol"
+	"github.com/prometheus/client_golang/prometheus"
+	wpb "github.com/waku-org/go-waku/waku/v2/protocol/pb"
+	"github.com/waku-org/go-waku/waku/v2/protocol/relay/tracer/pb"
+	"github.com/waku-org/go-waku/waku/v2/utils"
+	"go.uber.org/zap"
+)
+
+const (
+	DefaultMaxFileSize = 100 * 1024 * 1024
+	DefaultMaxFiles    = 5
+	DefaultBufferSize  = 10000
+)
+
+type params struct {
+	format      string
+	maxFileSize int64
+	maxFiles    int
+	bufferSize  int
+}
+
+// Option is an optional setting of the Tracer
+type Option func(*params)
+
+// WithFormat sets the format of the trace files. Use FormatProtobuf (default) or FormatJSONL
+func WithFormat(format string) Option {
+	return func(p *params) {
+		p.format = format
+	}
+}
+
+// WithRotation sets the size in bytes at which a trace file is rotated, and the number of
+// rotated files that are kept. A maxFileSize of 0 disables the rotation
+func WithRotation(maxFileSize int64, maxFiles int) Option {
+	return func(p *params) {
+		p.maxFileSize = maxFileSize
+		p.maxFiles = maxFiles
+	}
+}
+
+// WithBufferSize sets the number of events that can be waiting to be written. Once the
+// buffer is full, new events are dropped so the tracer never slows down the relay
+func WithBufferSize(size int) Option {
+	return func(p *params) {
+		p.bufferSize = size
+	}
+}
+
+func defaultOptions() []Option {
+	return []Option{
+		WithFormat(FormatProtobuf),
+		WithRotation(DefaultMaxFileSize, DefaultMaxFiles),
+		WithBufferSize(DefaultBufferSize),
+	}
+}
+
+// event contains the information of a pubsub event required to build a trace event. Messages
+// are decoded and hashed when the event is written to avoid blocking the pubsub event loop
+type event struct {
+	eventType    pb.TraceEvent_Type
+	timestamp    int64
+	pubsubTopic  string
+	data         []byte
+	receivedFrom peer.ID
+	reason       string
+	peerID       peer.ID
+}
+
+// Tracer is a gossipsub RawTracer that writes the publish, deliver, duplicate, reject, graft
+// and prune events of every pubsub topic to a rotating file. It's attached to relay with
+// pubsub.WithRawTracer
+type Tracer struct {
+	nodeID  peer.ID
+	file    *rotatingFile
+	encode  encodeFn
+	events  chan event
+	quit    chan struct{}
+	stop    sync.Once
+	wg      sync.WaitGroup
+	metrics Metrics
+	log     *zap.Logger
+}
+
+var _ pubsub.RawTracer = (*Tracer)(nil)
+
+// NewTracer creates a Tracer that writes the events recorded by the node with nodeID to a file
+func NewTracer(path string, nodeID peer.ID, reg prometheus.Registerer, log *zap.Logger, opts ...Option) (*Tracer, error) {
+	p := new(params)
+	for _, opt := range append(defaultOptions(), opts...) {
+		opt(p)
+	}
+
+	encode, err := encoder(p.format)
+	if err != nil {
+		return nil, err
+	}
+
+	file, err := openRotatingFile(path, p.maxFileSize, p.maxFiles)
+	if err != nil {
+		return nil, err
+	}
+
+	t := &Tracer{
+		nodeID:  nodeID,
+		file:    file,
+		encode:  encode,
+		events:  make(chan event, p.bufferSize),
+		quit:    make(chan struct{}),
+		metrics: newMetrics(reg),
+		log:     log.Named("relay-tracer"),
+	}
+
+	t.wg.Add(1)
+	go t.writeEvents()
+
+	t.log.Info("tracing relay events", zap.String("file", path), zap.String("format", p.format))
+
+	return t, nil
+}
+
+// Stop writes the pending events and closes the trace file
+func (t *Tracer) Stop() {
+	t.stop.Do(func() {
+		close(t.quit)
+		t.wg.Wait()
+		if err := t.file.Close(); err != nil {
+			t.log.Error("closing trace file", zap.Error(err))
+		}
+	})
+}
+
+func (t *Tracer) writeEvents() {
+	defer utils.LogOnPanic()
+	defer t.wg.Done()
+
+	for {
+		select {
+		case evt := <-t.events:
+			t.write(evt)
+		case <-t.quit:
+			for {
+				select {
+				case evt := <-t.events:
+					t.write(evt)
+				default:
+					return
+				}
+			}
+		}
+	}
+}
+
+func (t *Tracer) write(evt event) {
+	traceEvent := &pb.TraceEvent{
+		Type:        evt.eventType,
+		Timestamp:   evt.timestamp,
+		NodeId:      t.nodeID.String(),
+		PubsubTopic: evt.pubsubTopic,
+		Reason:      evt.reason,
+	}
+
+	if evt.receivedFrom != "" {
+		traceEvent.ReceivedFrom = evt.receivedFrom.String()
+	}
+
+	if evt.peerID != "" {
+		traceEvent.PeerId = evt.peerID.String()
+	}
+
+	if evt.data != nil {
+		// Messages that can't be decoded are traced without hash
+		if msg, err := wpb.Unmarshal(evt.data); err == nil {
+			hash := msg.Hash(evt.pubsubTopic)
+			traceEvent.MessageHash = hash[:]
+		}
+	}
+
+	data, err := t.encode(traceEvent)
+	if err == nil {
+		_, err = t.file.Write(data)
+	}
+	if err != nil {
+		t.metrics.RecordDroppedEvent()
+		t.log.Error("writing trace event", zap.Error(err))
+		return
+	}
+
+	t.metrics.RecordEvent(evt.eventType)
+}
+
+func (t *Tracer) enqueue(evt event) {
+	evt.timestamp = time.Now().UnixNano()
+	select {
+	case t.events <- evt:
+	default:
+		t.metrics.RecordDroppedEvent()
+	}
+}
+
+func (t *Tracer) messageEvent(eventType pb.TraceEvent_Type, msg *pubsub.Message, reason string) {
+	t.enqueue(event{
+		eventType:    eventType,
+		pubsubTopic:  msg.GetTopic(),
+		data:         msg.Data,
+		receivedFrom: msg.ReceivedFrom,
+		reason:       reason,
+	})
+}
+
+func (t *Tracer) DeliverMessage(msg *pubsub.Message) {
+	// Messages published by this node are delivered locally too
+	if msg.ReceivedFrom == t.nodeID {
+		t.messageEvent(pb.TraceEvent_PUBLISH, msg, "")
+		return
+	}
+	t.messageEvent(pb.TraceEvent_DELIVER, msg, "")
+}
+
+func (t *Tracer) DuplicateMessage(msg *pubsub.Message) {
+	t.messageEvent(pb.TraceEvent_DUPLICATE, msg, "")
+}
+
+func (t *Tracer) RejectMessage(msg *pubsub.Message, reason string) {
+	t.messageEvent(pb.TraceEvent_REJECT, msg, reason)
+}
+
+func (t *Tracer) Graft(p peer.ID, topic string) {
+	t.enqueue(event{eventType: pb.TraceEvent_GRAFT, pubsubTopic: topic, peerID: p})
+}
+
+func (t *Tracer) Prune(p peer.ID, topic string) {
+	t.enqueue(event{eventType: pb.TraceEvent_PRUNE, pubsubTopic: topic, peerID: p})
+}
+
+func (t *Tracer) AddPeer(p peer.ID, proto protocol.ID)     {}
+func (t *Tracer) RemovePeer(p peer.ID)                     {}
+func (t *Tracer) Join(topic string)                        {}
+func (t *Tracer) Leave(topic string)                       {}
+func (t *Tracer) ValidateMessage(msg *pubsub.Message)      {}
+func (t *Tracer) ThrottlePeer(p peer.ID)                   {}
+func (t *Tracer) RecvRPC(rpc *pubsub.RPC)                  {}
+func (t *Tracer) SendRPC(rpc *pubsub.RPC, p peer.ID)       {}
+func (t *Tracer) DropRPC(rpc *pubsub.RPC, p peer.ID)       {}
+func (t *Tracer) UndeliverableMessage(msg *pubsub.Message) {}
@@ -0,0 +1,115 @@
+package tracer
+
+import (
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	pubsub "github.com/libp2p/go-libp2p-pubsub"
+	pubsub_pb "github.com/libp2p/go-libp2p-pubsub/pb"
+	"github.com/libp2p/go-libp2p/core/peer"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/stretchr/testify/require"
+	"github.com/waku-org/go-waku/tests"
+	"github.com/waku-org/go-waku/waku/v2/protocol/relay/tracer/pb"
+	"github.com/waku-org/go-waku/waku/v2/utils"
+	"google.golang.org/protobuf/proto"
+)
+
+func readTraceFile(t *testing.T, format string, path string) []*pb.TraceEvent {
+	f, err := os.Open(path)
+	require.NoError(t, err)
+	defer f.Close()
+
+	reader, err := NewReader(format, f)
+	require.NoError(t, err)
+
+	var result []*pb.TraceEvent
+	for {
+		evt, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			return result
+		}
+		require.NoError(t, err)
+		result = append(result, evt)
+	}
+}
+
+func TestTracer(t *testing.T) {
+	pubsubTopic := "/waku/2/rs/99/1"
+	nodeID := peer.ID("node")
+	remoteID := peer.ID("remote")
+
+	msg := tests.CreateWakuMessage("test", utils.GetUnixEpoch())
+	data, err := proto.Marshal(msg)
+	require.NoError(t, err)
+	hash := msg.Hash(pubsubTopic)
+
+	pubsubMsg := func(from peer.ID) *pubsub.Message {
+		return &pubsub.Message{
+			Message:      &pubsub_pb.Message{Data: data, Topic: proto.String(pubsubTopic)},
+			ReceivedFrom: from,
+		}
+	}
+
+	for _, format := range []string{FormatProtobuf, FormatJSONL} {
+		t.Run(format, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "trace")
+			tracer, err := NewTracer(path, nodeID, prometheus.NewRegistry(), utils.Logger(), WithFormat(format))
+			require.NoError(t, err)
+
+			tracer.DeliverMessage(pubsubMsg(nodeID))
+			tracer.DeliverMessage(pubsubMsg(remoteID))
+			tracer.DuplicateMessage(pubsubMsg(remoteID))
+			tracer.RejectMessage(pubsubMsg(remoteID), pubsub.RejectValidationFailed)
+			tracer.Graft(remoteID, pubsubTopic)
+			tracer.Prune(remoteID, pubsubTopic)
+			tracer.Stop()
+
+			events := readTraceFile(t, format, path)
+			require.Len(t, events, 6)
+
+			expectedTypes := []pb.TraceEvent_Type{pb.TraceEvent_PUBLISH, pb.TraceEvent_DELIVER, pb.TraceEvent_DUPLICATE, pb.TraceEvent_REJECT, pb.TraceEvent_GRAFT, pb.TraceEvent_PRUNE}
+			for i, evt := range events {
+				require.Equal(t, expectedTypes[i], evt.Type)
+				require.Equal(t, nodeID.String(), evt.NodeId)
+				require.Equal(t, pubsubTopic, evt.PubsubTopic)
+				require.NotZero(t, evt.Timestamp)
+				if i < 4 {
+					require.Equal(t, hash[:], evt.MessageHash)
+				} else {
+					require.Equal(t, remoteID.String(), evt.PeerId)
+				}
+			}
+			require.Equal(t, remoteID.String(), events[1].ReceivedFrom)
+			require.Equal(t, pubsub.RejectValidationFailed, events[3].Reason)
+		})
+	}
+}
+
+func TestTracerRotation(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "trace")
+	tracer, err := NewTracer(path, peer.ID("node"), prometheus.NewRegistry(), utils.Logger(), WithRotation(100, 2))
+	require.NoError(t, err)
+
+	for i := 0; i < 50; i++ {
+		tracer.Graft(peer.ID("remote"), "/waku/2/rs/99/1")
+	}
+	tracer.Stop()
+
+	// Only the most recent rotated files are kept
+	_, err = os.Stat(path + ".3")
+	require.ErrorIs(t, err, os.ErrNotExist)
+
+	for _, file := range []string{path, path + ".1", path + ".2"} {
+		info, err := os.Stat(file)
+		require.NoError(t, err)
+		require.LessOrEqual(t, info.Size(), int64(100))
+
+		// Files are rotated between events
+		events := readTraceFile(t, FormatProtobuf, file)
+		require.NotEmpty(t, events)
+	}
+}
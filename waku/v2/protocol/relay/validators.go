@@ -4,7 +4,6 @@ import (
 	"context"
 	"crypto/ecdsa"
 	"encoding/binary"
-	"encoding/hex"
 	"time"
 
 	"github.com/ethereum/go-ethereum/crypto"
@@ -15,10 +14,15 @@ import (
 	"github.com/waku-org/go-waku/waku/v2/hash"
 	"github.com/waku-org/go-waku/waku/v2/protocol/pb"
 	"github.com/waku-org/go-waku/waku/v2/timesource"
-	"go.uber.org/zap"
+	"github.com/waku-org/go-waku/waku/v2/utils"
 )
 
 func msgHash(pubSubTopic string, msg *pb.WakuMessage) []byte {
+	return hashWithPayload(pubSubTopic, msg, msg.Payload)
+}
+
+// hashWithPayload hashes a message as if it contained the given payload
+func hashWithPayload(pubSubTopic string, msg *pb.WakuMessage, payload []byte) []byte {
 	timestampBytes := make([]byte, 8)
 	binary.LittleEndian.PutUint64(timestampBytes, uint64(msg.GetTimestamp()))
 
@@ -29,7 +33,7 @@ func msgHash(pubSubTopic string, msg *pb.WakuMessage) []byte {
 
 	return hash.SHA256(
 		[]byte(pubSubTopic),
-		msg.Payload,
+		payload,
 		[]byte(msg.ContentTopic),
 		timestampBytes,
 		[]byte{ephemeralByte},
@@ -51,51 +55,79 @@ func (w *WakuRelay) RegisterTopicValidator(topic string, fn validatorFn) {
 	w.topicValidators[topic] = append(w.topicValidators[topic], fn)
 }
 
+// PeerValidatorFn validates a message forwarded by a peer. Unlike the validators registered with
+// RegisterDefaultValidator and RegisterTopicValidator, it can ignore a message instead of rejecting
+// it, in which case the peer is not penalized by gossipsub
+type PeerValidatorFn = func(ctx context.Context, from peer.ID, msg *pb.WakuMessage, topic string) pubsub.ValidationResult
+
+// RegisterDefaultPeerValidator registers a validator that is applied to the messages received
+// in all the pubsub topics. Messages published by this node are not checked by peer validators
+func (w *WakuRelay) RegisterDefaultPeerValidator(fn PeerValidatorFn) {
+	w.topicValidatorMutex.Lock()
+	defer w.topicValidatorMutex.Unlock()
+	w.defaultPeerValidators = append(w.defaultPeerValidators, fn)
+}
+
 func (w *WakuRelay) RemoveTopicValidator(topic string) {
 	w.topicValidatorMutex.Lock()
 	defer w.topicValidatorMutex.Unlock()
 
 	delete(w.topicValidators, topic)
+	delete(w.deliveryHandlers, topic)
 }
 
-func (w *WakuRelay) topicValidator(topic string) func(ctx context.Context, peerID peer.ID, message *pubsub.Message) bool {
-	return func(ctx context.Context, peerID peer.ID, message *pubsub.Message) bool {
+// deliveryHandlerFn is called with the messages of a topic that were accepted by all the validators
+type deliveryHandlerFn = func(msg *pb.WakuMessage, topic string)
+
+func (w *WakuRelay) registerDeliveryHandler(topic string, fn deliveryHandlerFn) {
+	w.topicValidatorMutex.Lock()
+	defer w.topicValidatorMutex.Unlock()
+
+	w.deliveryHandlers[topic] = append(w.deliveryHandlers[topic], fn)
+}
+
+func (w *WakuRelay) topicValidator(topic string) func(ctx context.Context, peerID peer.ID, message *pubsub.Message) pubsub.ValidationResult {
+	return func(ctx context.Context, peerID peer.ID, message *pubsub.Message) pubsub.ValidationResult {
 		msg, err := pb.Unmarshal(message.Data)
 		if err != nil {
-			return false
+			return pubsub.ValidationReject
 		}
 
 		w.topicValidatorMutex.RLock()
 		validators := w.topicValidators[topic]
 		validators = append(validators, w.defaultTopicValidators...)
+		peerValidators := w.defaultPeerValidators
 		w.topicValidatorMutex.RUnlock()
 		exists := len(validators) > 0
 
 		if exists {
 			for _, v := range validators {
 				if !v(ctx, msg, topic) {
-					return false
+					return pubsub.ValidationReject
 				}
 			}
 		}
 
-		return true
+		if w.host != nil && peerID == w.host.ID() {
+			return pubsub.ValidationAccept
+		}
+
+		for _, v := range peerValidators {
+			if result := v(ctx, peerID, msg, topic); result != pubsub.ValidationAccept {
+				return result
+			}
+		}
+
+		return pubsub.ValidationAccept
 	}
 }
 
 // AddSignedTopicValidator registers a gossipsub validator for a topic which will check that messages Meta field contains a valid ECDSA signature for the specified pubsub topic. This is used as a DoS prevention mechanism
 func (w *WakuRelay) AddSignedTopicValidator(topic string, publicKey *ecdsa.PublicKey) error {
-	w.log.Info("adding validator to signed topic", zap.String("topic", topic), zap.String("publicKey", hex.EncodeToString(secp256k1.S256().Marshal(publicKey.X, publicKey.Y))))
-
-	fn := signedTopicBuilder(w.timesource, publicKey)
-
-	w.RegisterTopicValidator(topic, fn)
-
-	if !w.IsSubscribed(topic) {
-		w.log.Warn("relay is not subscribed to signed topic", zap.String("topic", topic))
-	}
-
-	return nil
+	return w.AddProtectedTopic(topic, ProtectedTopicConfig{
+		Keys:      []ProtectedTopicKey{{PublicKey: publicKey}},
+		Threshold: 1,
+	})
 }
 
 const messageWindowDuration = time.Minute * 5
@@ -112,17 +144,13 @@ func withinTimeWindow(t timesource.Timesource, msg *pb.WakuMessage) bool {
 }
 
 func signedTopicBuilder(t timesource.Timesource, publicKey *ecdsa.PublicKey) validatorFn {
-	publicKeyBytes := crypto.FromECDSAPub(publicKey)
-	return func(ctx context.Context, msg *pb.WakuMessage, topic string) bool {
-		if !withinTimeWindow(t, msg) {
+	p, err := newProtectedTopic(ProtectedTopicConfig{Keys: []ProtectedTopicKey{{PublicKey: publicKey}}}, t, utils.Logger())
+	if err != nil {
+		return func(ctx context.Context, msg *pb.WakuMessage, topic string) bool {
 			return false
 		}
-
-		msgHash := msgHash(topic, msg)
-		signature := msg.Meta
-
-		return secp256k1.VerifySignature(publicKeyBytes, msgHash, signature)
 	}
+	return p.validate
 }
 
 // SignMessage adds an ECDSA signature to a WakuMessage as an opt-in mechanism for DoS prevention
@@ -54,6 +54,8 @@ type WakuRelay struct {
 	topicValidatorMutex    sync.RWMutex
 	topicValidators        map[string][]validatorFn
 	defaultTopicValidators []validatorFn
+	defaultPeerValidators  []PeerValidatorFn
+	deliveryHandlers       map[string][]deliveryHandlerFn
 
 	topicsMutex sync.RWMutex
 	topics      map[string]*pubsubTopicSubscriptionDetails
@@ -82,6 +84,7 @@ func NewWakuRelay(bcaster Broadcaster, minPeersToPublish int, timesource timesou
 	w.timesource = timesource
 	w.topics = make(map[string]*pubsubTopicSubscriptionDetails)
 	w.topicValidators = make(map[string][]validatorFn)
+	w.deliveryHandlers = make(map[string][]deliveryHandlerFn)
 	w.bcaster = bcaster
 	w.minPeersToPublish = minPeersToPublish
 	w.CommonService = service.NewCommonService()
@@ -90,15 +93,23 @@ func NewWakuRelay(bcaster Broadcaster, minPeersToPublish int, timesource timesou
 	w.events = eventbus.NewBus()
 	w.metrics = newMetrics(reg, w.logMessages)
 	w.relayParams = new(relayParameters)
-	w.relayParams.pubsubOpts = w.defaultPubsubOptions()
 
 	options := defaultOptions()
 	options = append(options, opts...)
 	for _, opt := range options {
 		opt(w.relayParams)
 	}
+	w.relayParams.pubsubOpts = append(w.defaultPubsubOptions(), w.relayParams.pubsubOpts...)
 	w.log.Info("relay config", zap.Int("max-msg-size-bytes", w.relayParams.maxMsgSizeBytes),
-		zap.Int("min-peers-to-publish", w.minPeersToPublish))
+		zap.Int("min-peers-to-publish", w.minPeersToPublish), zap.String("gossipsub-profile", w.relayParams.profile.Name))
+
+	if cfg := w.relayParams.rateLimit; cfg != nil {
+		w.log.Info("relay rate limit",
+			zap.Float64("peer-limit", float64(cfg.PeerLimit)), zap.Int("peer-burst", cfg.PeerBurst),
+			zap.Float64("content-topic-limit", float64(cfg.ContentTopicLimit)), zap.Int("content-topic-burst", cfg.ContentTopicBurst),
+			zap.Bool("reject", cfg.Reject))
+		w.RegisterDefaultPeerValidator(newRateLimiter(*cfg, w.metrics, w.log).Validate)
+	}
 	return w
 }
 
@@ -193,7 +204,7 @@ func (w *WakuRelay) upsertTopic(topic string) (*pubsub.Topic, error) {
 			return nil, err
 		}
 
-		err = newTopic.SetScoreParams(w.topicParams)
+		err = newTopic.SetScoreParams(w.TopicScoreParams(topic))
 		if err != nil {
 			w.log.Error("failed to set score params", zap.String("pubsubTopic", topic), zap.Error(err))
 			return nil, err
@@ -286,6 +297,20 @@ func (w *WakuRelay) Publish(ctx context.Context, message *pb.WakuMessage, opts .
 		}
 	}
 
+	if w.relayParams.middleware != nil {
+		env, err := w.relayParams.middleware.Run(ctx, waku_proto.PrePublish, waku_proto.NewEnvelope(message, w.timesource.Now().UnixNano(), params.pubsubTopic))
+		if err != nil {
+			return pb.MessageHash{}, err
+		}
+		if env.PubsubTopic() != params.pubsubTopic {
+			return pb.MessageHash{}, errors.New("hooks can't change the pubsub topic of a message")
+		}
+		message = env.Message()
+		if err := message.Validate(); err != nil {
+			return pb.MessageHash{}, err
+		}
+	}
+
 	if !w.EnoughPeersToPublishToTopic(params.pubsubTopic) {
 		return pb.MessageHash{}, errors.New("not enough peers to publish")
 	}
@@ -358,7 +383,7 @@ func (w *WakuRelay) GetSubscriptionWithPubsubTopic(pubsubTopic string, contentTo
 
 // GetSubscription fetches subscription matching a contentTopic(via autosharding)
 func (w *WakuRelay) GetSubscription(contentTopic string) (*Subscription, error) {
-	pubsubTopic, err := waku_proto.GetPubSubTopicFromContentTopic(contentTopic)
+	pubsubTopic, err := waku_proto.GetPubSubTopicFromContentTopicPattern(contentTopic)
 	if err != nil {
 		w.log.Error("failed to derive pubsubTopic", zap.Error(err), zap.String("contentTopic", contentTopic))
 		return nil, err
@@ -391,7 +416,13 @@ func (w *WakuRelay) EnoughPeersToPublishToTopic(topic string) bool {
 func (w *WakuRelay) subscribe(ctx context.Context, contentFilter waku_proto.ContentFilter, opts ...RelaySubscribeOption) ([]*Subscription, error) {
 
 	var subscriptions []*Subscription
-	pubSubTopicMap, err := waku_proto.ContentFilterToPubSubTopicMap(contentFilter)
+	for contentTopic := range contentFilter.ContentTopics {
+		if err := waku_proto.ValidateContentTopicPattern(contentTopic); err != nil {
+			return nil, err
+		}
+	}
+
+	pubSubTopicMap, err := waku_proto.ContentFilterPatternsToPubSubTopicMap(contentFilter)
 	if err != nil {
 		return nil, err
 	}
@@ -426,8 +457,8 @@ func (w *WakuRelay) subscribe(ctx context.Context, contentFilter waku_proto.Cont
 			}
 		}
 
-		subscription := w.bcaster.Register(cFilter, WithBufferSize(int(params.cacheSize)),
-			WithConsumerOption(params.dontConsume))
+		bcasterOpts := []BroadcasterOption{WithBufferSize(int(params.cacheSize)), WithConsumerOption(params.dontConsume)}
+		subscription := w.bcaster.Register(cFilter, append(bcasterOpts, params.broadcasterOpts...)...)
 
 		// Create Content subscription
 		w.topicsMutex.Lock()
@@ -450,6 +481,8 @@ func (w *WakuRelay) subscribe(ctx context.Context, contentFilter waku_proto.Cont
 
 // Subscribe returns a Subscription to receive messages as per contentFilter
 // contentFilter can contain pubSubTopic and contentTopics or only contentTopics(in case of autosharding)
+// contentTopics can be patterns such as /app/1/room-*/proto or /app/1/* (see waku_proto.IsContentTopicPattern).
+// With autosharding, the application name and version of a pattern can't contain wildcards
 func (w *WakuRelay) Subscribe(ctx context.Context, contentFilter waku_proto.ContentFilter, opts ...RelaySubscribeOption) ([]*Subscription, error) {
 	return w.subscribe(ctx, contentFilter, opts...)
 }
@@ -457,7 +490,7 @@ func (w *WakuRelay) Subscribe(ctx context.Context, contentFilter waku_proto.Cont
 // Unsubscribe closes a subscription to a pubsub topic
 func (w *WakuRelay) Unsubscribe(ctx context.Context, contentFilter waku_proto.ContentFilter) error {
 
-	pubSubTopicMap, err := waku_proto.ContentFilterToPubSubTopicMap(contentFilter)
+	pubSubTopicMap, err := waku_proto.ContentFilterPatternsToPubSubTopicMap(contentFilter)
 	if err != nil {
 		w.log.Error("failed to derive pubsubTopic from contentFilter", zap.String("pubsubTopic", contentFilter.PubsubTopic),
 			zap.Strings("contentTopics", contentFilter.ContentTopicsList()))
@@ -553,9 +586,22 @@ func (w *WakuRelay) pubsubTopicMsgHandler(sub *pubsub.Subscription) {
 			return
 		}
 
+		w.topicValidatorMutex.RLock()
+		deliveryHandlers := w.deliveryHandlers[sub.Topic()]
+		w.topicValidatorMutex.RUnlock()
+		for _, h := range deliveryHandlers {
+			h(wakuMessage, sub.Topic())
+		}
+
 		envelope := waku_proto.NewEnvelope(wakuMessage, w.timesource.Now().UnixNano(), sub.Topic())
 		w.metrics.RecordMessage(envelope)
 
+		envelope, err = w.relayParams.middleware.Run(w.Context(), waku_proto.PostReceive, envelope)
+		if err != nil {
+			w.log.Debug("message not delivered", zap.String("pubsubTopic", sub.Topic()), zap.Error(err))
+			continue
+		}
+
 		w.bcaster.Submit(envelope)
 	}
 
@@ -565,3 +611,16 @@ func (w *WakuRelay) pubsubTopicMsgHandler(sub *pubsub.Subscription) {
 func (w *WakuRelay) Params() pubsub.GossipSubParams {
 	return w.params
 }
+
+// Profile returns the name of the gossipsub profile used by WakuRelay
+func (w *WakuRelay) Profile() string {
+	return w.relayParams.profile.Name
+}
+
+// TopicScoreParams returns the peer score parameters of a pubsub topic
+func (w *WakuRelay) TopicScoreParams(pubsubTopic string) *pubsub.TopicScoreParams {
+	if scoreParams, ok := w.relayParams.topicScores[pubsubTopic]; ok {
+		return &scoreParams
+	}
+	return w.topicParams
+}
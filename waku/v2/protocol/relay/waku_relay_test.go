@@ -483,3 +483,23 @@ func TestWakuRelayStaticSharding(t *testing.T) {
 	tests.WaitForMsg(t, 2*time.Second, &wg, subs1[0].Ch)
 
 }
+
+func TestWakuRelayGossipsubProfile(t *testing.T) {
+	_, err := GetGossipsubProfile("unknown")
+	require.Error(t, err)
+
+	profile, err := GetGossipsubProfile(ProfileLowLatency)
+	require.NoError(t, err)
+
+	anotherTopic := "/waku/2/go/relay/another-topic"
+	scoreParams := profile.TopicScoreParams
+	scoreParams.TopicWeight = 0.5
+
+	relay := NewWakuRelay(NewBroadcaster(10), 0, timesource.NewDefaultClock(), prometheus.DefaultRegisterer, utils.Logger(),
+		WithGossipsubProfile(profile), WithTopicScoreParams(anotherTopic, scoreParams))
+
+	require.Equal(t, ProfileLowLatency, relay.Profile())
+	require.Equal(t, profile.Params, relay.Params())
+	require.Equal(t, profile.TopicScoreParams, *relay.TopicScoreParams(defaultTestPubSubTopic))
+	require.Equal(t, 0.5, relay.TopicScoreParams(anotherTopic).TopicWeight)
+}
@@ -232,7 +232,13 @@ func GetShardFromContentTopic(topic ContentTopic, shardCount int) StaticSharding
 	return NewStaticShardingPubsubTopic(ClusterIndex, uint16(shard))
 }
 
+// GetPubSubTopicFromContentTopic derives the autosharding pubsub topic of a content topic.
+// Content topic patterns are rejected, see GetPubSubTopicFromContentTopicPattern
 func GetPubSubTopicFromContentTopic(cTopicString string) (string, error) {
+	if IsContentTopicPattern(cTopicString) {
+		return "", fmt.Errorf("%w : %s", ErrPatternNotSupported, cTopicString)
+	}
+
 	cTopic, err := StringToContentTopic(cTopicString)
 	if err != nil {
 		return "", fmt.Errorf("%s : %s", err.Error(), cTopicString)
@@ -243,13 +249,17 @@ func GetPubSubTopicFromContentTopic(cTopicString string) (string, error) {
 }
 
 func GeneratePubsubToContentTopicMap(pubsubTopic string, contentTopics []string) (map[string][]string, error) {
+	return generatePubsubToContentTopicMap(pubsubTopic, contentTopics, GetPubSubTopicFromContentTopic)
+}
+
+func generatePubsubToContentTopicMap(pubsubTopic string, contentTopics []string, getPubSubTopic func(string) (string, error)) (map[string][]string, error) {
 
 	pubSubTopicMap := make(map[string][]string, 0)
 
 	if pubsubTopic == "" {
 		//Should we derive pubsub topic from contentTopic so that peer selection and discovery can be done accordingly?
 		for _, cTopic := range contentTopics {
-			pTopic, err := GetPubSubTopicFromContentTopic(cTopic)
+			pTopic, err := getPubSubTopic(cTopic)
 			if err != nil {
 				return nil, err
 			}
@@ -0,0 +1,11 @@
+package protocol
+
+import "context"
+
+// ShardDemand is notified by the filter and lightpush service protocols of the pubsub topics
+// requested by their clients, so the node can serve pubsub topics it wasn't configured with
+type ShardDemand interface {
+	// Request subscribes the node to a pubsub topic if it's not subscribed yet. It returns an
+	// error if the node can't serve the pubsub topic
+	Request(ctx context.Context, pubsubTopic string) error
+}
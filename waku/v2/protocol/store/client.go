@@ -6,6 +6,7 @@ import (
 	"errors"
 	"fmt"
 	"math"
+	"sync"
 
 	"github.com/libp2p/go-libp2p/core/host"
 	"github.com/libp2p/go-libp2p/core/peer"
@@ -72,6 +73,7 @@ type WakuStore struct {
 	pm         *peermanager.PeerManager
 
 	defaultRatelimit rate.Limit
+	rateLimitersMu   sync.Mutex
 	rateLimiters     map[peer.ID]*rate.Limiter
 }
 
@@ -118,6 +120,8 @@ func (s *WakuStore) Request(ctx context.Context, criteria Criteria, opts ...Requ
 		pubsubTopics = append(pubsubTopics, filterCriteria.PubsubTopic)
 	}
 
+	federated := params.maxPeers > 0 && params.selectedPeer == "" && params.peerAddr == nil
+
 	//Add Peer to peerstore.
 	if s.pm != nil && params.peerAddr != nil {
 		pData, err := s.pm.AddPeer(params.peerAddr, peerstore.Static, pubsubTopics, StoreQueryID_v300)
@@ -128,7 +132,7 @@ func (s *WakuStore) Request(ctx context.Context, criteria Criteria, opts ...Requ
 		params.selectedPeer = pData.AddrInfo.ID
 	}
 
-	if s.pm != nil && params.selectedPeer == "" {
+	if s.pm != nil && params.selectedPeer == "" && !federated {
 		if isFilterCriteria {
 			selectedPeers, err := s.pm.SelectPeers(
 				peermanager.PeerSelectionCriteria{
@@ -148,7 +152,7 @@ func (s *WakuStore) Request(ctx context.Context, criteria Criteria, opts ...Requ
 		}
 	}
 
-	if params.selectedPeer == "" {
+	if params.selectedPeer == "" && !federated {
 		return nil, ErrNoPeersAvailable
 	}
 
@@ -177,6 +181,10 @@ func (s *WakuStore) Request(ctx context.Context, criteria Criteria, opts ...Requ
 		return nil, err
 	}
 
+	if federated {
+		return s.federatedRequest(ctx, criteria, storeRequest, params)
+	}
+
 	response, err := s.queryFrom(ctx, storeRequest, params)
 	if err != nil {
 		return nil, err
@@ -189,6 +197,7 @@ func (s *WakuStore) Request(ctx context.Context, criteria Criteria, opts ...Requ
 		storeResponse: response,
 		peerID:        params.selectedPeer,
 		cursor:        response.PaginationCursor,
+		received:      len(response.Messages),
 	}
 
 	return result, nil
@@ -218,6 +227,7 @@ func (s *WakuStore) RequestRaw(ctx context.Context, peerID peer.ID, storeRequest
 		storeResponse: response,
 		peerID:        params.selectedPeer,
 		cursor:        response.PaginationCursor,
+		received:      len(response.Messages),
 	}
 
 	return result, nil
@@ -297,11 +307,13 @@ func (s *WakuStore) queryFrom(ctx context.Context, storeRequest *pb.StoreQueryRe
 	logger.Debug("sending store request")
 
 	if !params.skipRatelimit {
+		s.rateLimitersMu.Lock()
 		rateLimiter, ok := s.rateLimiters[params.selectedPeer]
 		if !ok {
 			rateLimiter = rate.NewLimiter(s.defaultRatelimit, 1)
 			s.rateLimiters[params.selectedPeer] = rateLimiter
 		}
+		s.rateLimitersMu.Unlock()
 		err := rateLimiter.Wait(ctx)
 		if err != nil {
 			return nil, err
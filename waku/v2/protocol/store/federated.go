@@ -0,0 +1,321 @@
+package store
+
+import (
+	"bytes"
+	"context"
+	"encoding/hex"
+	"errors"
+	"sync"
+
+	"github.com/libp2p/go-libp2p/core/peer"
+	"github.com/waku-org/go-waku/logging"
+	"github.com/waku-org/go-waku/waku/v2/peermanager"
+	"github.com/waku-org/go-waku/waku/v2/protocol"
+	wpb "github.com/waku-org/go-waku/waku/v2/protocol/pb"
+	"github.com/waku-org/go-waku/waku/v2/protocol/store/pb"
+	"go.uber.org/zap"
+	"google.golang.org/protobuf/proto"
+)
+
+// PeerCoverage reports the messages retrieved from a store node during a query
+type PeerCoverage struct {
+	PeerID peer.ID
+	// Messages is the number of messages returned by the store node
+	Messages int
+	// Unique is the number of messages included in the result that were not
+	// previously returned by another store node
+	Unique int
+	// Complete indicates that all the pages of the store node were retrieved
+	Complete bool
+	// Error is the error that made the query to the store node fail, if any
+	Error error
+}
+
+// federatedPeer is the state of the pagination of a store node within a federated query
+type federatedPeer struct {
+	coverage PeerCoverage
+	started  bool
+	cursor   []byte
+	buffer   []*pb.WakuMessageKeyValue
+}
+
+func (p *federatedPeer) pending() bool {
+	return p.coverage.Error == nil && (!p.started || p.cursor != nil)
+}
+
+// federatedResult is the Result of a query sent to multiple store nodes. Each page is built by
+// merging the messages of every store node ordered by (timestamp, message hash), and the
+// duplicated messages are removed. Store nodes that fail are ignored as long as at least one
+// of them answers the first page
+type federatedResult struct {
+	store         *WakuStore
+	storeRequest  *pb.StoreQueryRequest
+	pageLimit     int
+	forward       bool
+	skipRatelimit bool
+
+	peers []*federatedPeer
+	seen  map[wpb.MessageHash]struct{}
+
+	done          bool
+	messages      []*pb.WakuMessageKeyValue
+	cursor        []byte
+	storeResponse *pb.StoreQueryResponse
+}
+
+func (s *WakuStore) selectFederatedPeers(ctx context.Context, criteria Criteria, params *Parameters) (peer.IDSlice, error) {
+	if s.pm == nil {
+		if len(params.preferredPeers) == 0 {
+			return nil, ErrNoPeersAvailable
+		}
+
+		peers := params.preferredPeers
+		if len(peers) > params.maxPeers {
+			peers = peers[:params.maxPeers]
+		}
+		return peers, nil
+	}
+
+	var pubsubTopics []string
+	if filterCriteria, ok := criteria.(FilterCriteria); ok {
+		pubsubTopics = []string{filterCriteria.PubsubTopic}
+	}
+
+	return s.pm.SelectPeers(
+		peermanager.PeerSelectionCriteria{
+			SelectionType: params.peerSelectionType,
+			Proto:         StoreQueryID_v300,
+			PubsubTopics:  pubsubTopics,
+			SpecificPeers: params.preferredPeers,
+			MaxPeers:      params.maxPeers,
+			Ctx:           ctx,
+		},
+	)
+}
+
+func (s *WakuStore) federatedRequest(ctx context.Context, criteria Criteria, storeRequest *pb.StoreQueryRequest, params *Parameters) (Result, error) {
+	selectedPeers, err := s.selectFederatedPeers(ctx, criteria, params)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(selectedPeers) == 0 {
+		return nil, ErrNoPeersAvailable
+	}
+
+	result := &federatedResult{
+		store:         s,
+		storeRequest:  storeRequest,
+		pageLimit:     int(storeRequest.GetPaginationLimit()),
+		forward:       storeRequest.PaginationForward,
+		skipRatelimit: params.skipRatelimit,
+		seen:          make(map[wpb.MessageHash]struct{}),
+	}
+
+	for _, p := range selectedPeers {
+		result.peers = append(result.peers, &federatedPeer{
+			coverage: PeerCoverage{PeerID: p},
+			cursor:   storeRequest.PaginationCursor,
+		})
+	}
+
+	err = result.nextPage(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var errs []error
+	for _, p := range result.peers {
+		if p.coverage.Error == nil {
+			return result, nil
+		}
+		errs = append(errs, p.coverage.Error)
+	}
+
+	// All the store nodes failed
+	return nil, errors.Join(errs...)
+}
+
+// fill retrieves the next page of the store nodes whose buffered messages were consumed
+func (r *federatedResult) fill(ctx context.Context) {
+	var wg sync.WaitGroup
+	for _, p := range r.peers {
+		if len(p.buffer) != 0 || !p.pending() {
+			continue
+		}
+
+		wg.Add(1)
+		go func(p *federatedPeer) {
+			defer wg.Done()
+			r.fetch(ctx, p)
+		}(p)
+	}
+	wg.Wait()
+}
+
+func (r *federatedResult) fetch(ctx context.Context, p *federatedPeer) {
+	storeRequest := proto.Clone(r.storeRequest).(*pb.StoreQueryRequest)
+	storeRequest.RequestId = hex.EncodeToString(protocol.GenerateRequestID())
+	storeRequest.PaginationCursor = p.cursor
+
+	params := &Parameters{
+		selectedPeer:  p.coverage.PeerID,
+		skipRatelimit: r.skipRatelimit,
+	}
+
+	response, err := r.store.queryFrom(ctx, storeRequest, params)
+	if err != nil {
+		r.store.log.Warn("federated store query failed", logging.HostID("peer", p.coverage.PeerID), zap.Error(err))
+		p.coverage.Error = err
+		return
+	}
+
+	messages := response.Messages
+	if !r.forward {
+		// Pages are in chronological order, but the newest messages are consumed first
+		messages = make([]*pb.WakuMessageKeyValue, len(response.Messages))
+		for i, m := range response.Messages {
+			messages[len(messages)-1-i] = m
+		}
+	}
+
+	p.started = true
+	p.buffer = messages
+	p.cursor = response.PaginationCursor
+	p.coverage.Messages += len(messages)
+	p.coverage.Complete = p.cursor == nil
+}
+
+// before determines whether a message must be included before another one in the merged result.
+// Messages are ordered by (timestamp, message hash), which is the (storedAt, messageHash) order
+// used by the store nodes for the messages that have a timestamp, so messages sharing a timestamp
+// are ordered the same way regardless of the store node they come from. Without the message
+// content (i.e. IncludeData(false)) the timestamp is unknown and the messages of each page are
+// merged by hash only
+func (r *federatedResult) before(a *pb.WakuMessageKeyValue, b *pb.WakuMessageKeyValue) bool {
+	tsA := a.GetMessage().GetTimestamp()
+	tsB := b.GetMessage().GetTimestamp()
+
+	cmp := bytes.Compare(a.MessageHash, b.MessageHash)
+	if tsA < tsB {
+		cmp = -1
+	} else if tsA > tsB {
+		cmp = 1
+	}
+
+	if r.forward {
+		return cmp < 0
+	}
+	return cmp > 0
+}
+
+func (r *federatedResult) nextPage(ctx context.Context) error {
+	var page []*pb.WakuMessageKeyValue
+	var last *pb.WakuMessageKeyValue
+	for len(page) < r.pageLimit {
+		r.fill(ctx)
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		var next *federatedPeer
+		for _, p := range r.peers {
+			if len(p.buffer) == 0 {
+				continue
+			}
+			if next == nil || r.before(p.buffer[0], next.buffer[0]) {
+				next = p
+			}
+		}
+
+		if next == nil {
+			// All the store nodes were exhausted
+			break
+		}
+
+		msg := next.buffer[0]
+		next.buffer = next.buffer[1:]
+
+		hash := wpb.ToMessageHash(msg.MessageHash)
+		if _, ok := r.seen[hash]; ok {
+			continue
+		}
+		r.seen[hash] = struct{}{}
+		next.coverage.Unique++
+
+		page = append(page, msg)
+		last = msg
+	}
+
+	r.cursor = nil
+	for _, p := range r.peers {
+		if last != nil && (len(p.buffer) != 0 || p.pending()) {
+			// The hash of the last message can be used as cursor with any store node
+			r.cursor = last.MessageHash
+			break
+		}
+	}
+
+	// The messages are always returned in chronological order
+	if !r.forward {
+		for i, j := 0, len(page)-1; i < j; i, j = i+1, j-1 {
+			page[i], page[j] = page[j], page[i]
+		}
+	}
+
+	r.messages = page
+	r.storeResponse = &pb.StoreQueryResponse{
+		RequestId:        r.storeRequest.RequestId,
+		StatusCode:       proto.Uint32(ok),
+		StatusDesc:       proto.String("OK"),
+		Messages:         page,
+		PaginationCursor: r.cursor,
+	}
+
+	return nil
+}
+
+func (r *federatedResult) Cursor() []byte {
+	return r.cursor
+}
+
+func (r *federatedResult) IsComplete() bool {
+	return r.done
+}
+
+// PeerID returns the first store node the query was sent to. Use Coverage to
+// obtain the list of store nodes
+func (r *federatedResult) PeerID() peer.ID {
+	return r.peers[0].coverage.PeerID
+}
+
+func (r *federatedResult) Query() *pb.StoreQueryRequest {
+	return r.storeRequest
+}
+
+// Response returns a response containing the merged page of messages
+func (r *federatedResult) Response() *pb.StoreQueryResponse {
+	return r.storeResponse
+}
+
+func (r *federatedResult) Next(ctx context.Context, opts ...RequestOption) error {
+	if r.cursor == nil {
+		r.done = true
+		r.messages = nil
+		return nil
+	}
+
+	return r.nextPage(ctx)
+}
+
+func (r *federatedResult) Messages() []*pb.WakuMessageKeyValue {
+	return r.messages
+}
+
+func (r *federatedResult) Coverage() []PeerCoverage {
+	result := make([]PeerCoverage, len(r.peers))
+	for i, p := range r.peers {
+		result[i] = p.coverage
+	}
+	return result
+}
@@ -0,0 +1,129 @@
+package store
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/libp2p/go-libp2p"
+	"github.com/libp2p/go-libp2p/core/host"
+	"github.com/libp2p/go-libp2p/core/peerstore"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/stretchr/testify/require"
+	"github.com/waku-org/go-waku/tests"
+	"github.com/waku-org/go-waku/waku/persistence"
+	"github.com/waku-org/go-waku/waku/persistence/sqlite"
+	"github.com/waku-org/go-waku/waku/v2/protocol"
+	"github.com/waku-org/go-waku/waku/v2/protocol/pb"
+	"github.com/waku-org/go-waku/waku/v2/timesource"
+	"github.com/waku-org/go-waku/waku/v2/utils"
+	"golang.org/x/time/rate"
+	"google.golang.org/protobuf/proto"
+)
+
+func startStoreNode(t *testing.T, ctx context.Context, pubsubTopic string, messages []*pb.WakuMessage) (host.Host, func()) {
+	db, err := sqlite.NewDB(":memory:", utils.Logger())
+	require.NoError(t, err)
+	dbStore, err := persistence.NewDBStore(prometheus.DefaultRegisterer, utils.Logger(), persistence.WithDB(db), persistence.WithMigrations(sqlite.Migrations))
+	require.NoError(t, err)
+
+	for _, msg := range messages {
+		require.NoError(t, dbStore.Put(protocol.NewEnvelope(msg, msg.GetTimestamp(), pubsubTopic)))
+	}
+
+	h, err := libp2p.New(libp2p.DefaultTransports, libp2p.ListenAddrStrings("/ip4/0.0.0.0/tcp/0"))
+	require.NoError(t, err)
+
+	server := NewWakuStoreServer(dbStore, prometheus.DefaultRegisterer, utils.Logger())
+	server.SetHost(h)
+	require.NoError(t, server.Start(ctx))
+
+	return h, func() {
+		server.Stop()
+		h.Close()
+		db.Close()
+	}
+}
+
+func TestFederatedQuery(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	pubsubTopic := "/waku/2/rs/99/1"
+	now := time.Now()
+	var messages []*pb.WakuMessage
+	for i := 0; i < 6; i++ {
+		messages = append(messages, tests.CreateWakuMessage("test", proto.Int64(now.Add(time.Duration(i)*time.Second).UnixNano())))
+	}
+
+	// Each store node has gaps in its history, that are covered by the other one
+	host1, stop1 := startStoreNode(t, ctx, pubsubTopic, []*pb.WakuMessage{messages[0], messages[1], messages[2], messages[4]})
+	defer stop1()
+	host2, stop2 := startStoreNode(t, ctx, pubsubTopic, []*pb.WakuMessage{messages[1], messages[3], messages[4], messages[5]})
+	defer stop2()
+
+	// A store node that is not reachable
+	host3, stop3 := startStoreNode(t, ctx, pubsubTopic, nil)
+	host3Addr := tests.GetHostAddress(host3)
+	stop3()
+
+	client, err := libp2p.New(libp2p.DefaultTransports, libp2p.ListenAddrStrings("/ip4/0.0.0.0/tcp/0"))
+	require.NoError(t, err)
+	defer client.Close()
+	for _, h := range []host.Host{host1, host2} {
+		client.Peerstore().AddAddr(h.ID(), tests.GetHostAddress(h), peerstore.PermanentAddrTTL)
+	}
+	// A closed host has no listen addresses left, so the one it used is added instead
+	client.Peerstore().AddAddr(host3.ID(), host3Addr, peerstore.PermanentAddrTTL)
+
+	s := NewWakuStore(nil, timesource.NewDefaultClock(), utils.Logger(), rate.Inf)
+	s.SetHost(client)
+
+	criteria := FilterCriteria{ContentFilter: protocol.NewContentFilter(pubsubTopic, "test")}
+	peers := WithAutomaticPeerSelection(host1.ID(), host2.ID(), host3.ID())
+
+	// Forward pagination
+	result, err := s.Query(ctx, criteria, peers, WithFederation(3), WithPaging(true, 2))
+	require.NoError(t, err)
+
+	var hashes []pb.MessageHash
+	for !result.IsComplete() {
+		for _, m := range result.Messages() {
+			hashes = append(hashes, m.WakuMessageHash())
+		}
+		require.NoError(t, result.Next(ctx))
+	}
+
+	require.Len(t, hashes, len(messages))
+	for i := range messages {
+		require.Equal(t, messages[i].Hash(pubsubTopic), hashes[i])
+	}
+
+	coverage := result.(CoverageResult).Coverage()
+	require.Len(t, coverage, 3)
+	require.Equal(t, 4, coverage[0].Messages)
+	require.True(t, coverage[0].Complete)
+	require.Equal(t, 4, coverage[1].Messages)
+	require.True(t, coverage[1].Complete)
+	require.Equal(t, len(messages), coverage[0].Unique+coverage[1].Unique)
+	require.Error(t, coverage[2].Error)
+
+	// Backward pagination returns the most recent messages in chronological order
+	result, err = s.Query(ctx, criteria, peers, WithFederation(3), WithPaging(false, 4))
+	require.NoError(t, err)
+	require.Len(t, result.Messages(), 4)
+	for i, m := range result.Messages() {
+		require.Equal(t, messages[i+2].Hash(pubsubTopic), m.WakuMessageHash())
+	}
+	require.NotNil(t, result.Cursor())
+
+	require.NoError(t, result.Next(ctx))
+	require.Len(t, result.Messages(), 2)
+	require.Equal(t, messages[0].Hash(pubsubTopic), result.Messages()[0].WakuMessageHash())
+	require.Equal(t, messages[1].Hash(pubsubTopic), result.Messages()[1].WakuMessageHash())
+	require.Nil(t, result.Cursor())
+
+	// The query fails if none of the store nodes is available
+	_, err = s.Query(ctx, criteria, WithAutomaticPeerSelection(host3.ID()), WithFederation(1))
+	require.Error(t, err)
+}
@@ -0,0 +1,71 @@
+package store
+
+import (
+	"time"
+
+	"github.com/libp2p/go-libp2p/p2p/metricshelper"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var storeQueries = prometheus.NewCounter(
+	prometheus.CounterOpts{
+		Name: "waku_store_v3_queries",
+		Help: "The number of the store v3 queries received",
+	})
+
+var storeErrors = prometheus.NewCounterVec(
+	prometheus.CounterOpts{
+		Name: "waku_store_v3_errors",
+		Help: "The distribution of the store v3 protocol errors",
+	},
+	[]string{"error_type"},
+)
+
+var storeQueryDurationSeconds = prometheus.NewHistogram(
+	prometheus.HistogramOpts{
+		Name: "waku_store_v3_query_duration_seconds",
+		Help: "Duration of store v3 queries",
+	})
+
+var collectors = []prometheus.Collector{
+	storeQueries,
+	storeErrors,
+	storeQueryDurationSeconds,
+}
+
+// Metrics exposes the functions required to update prometheus metrics for store protocol
+type Metrics interface {
+	RecordQuery(duration time.Duration)
+	RecordError(err metricsErrCategory)
+}
+
+type metricsImpl struct {
+	reg prometheus.Registerer
+}
+
+func newMetrics(reg prometheus.Registerer) Metrics {
+	metricshelper.RegisterCollectors(reg, collectors...)
+	return &metricsImpl{
+		reg: reg,
+	}
+}
+
+// RecordQuery increases the counter of store queries received and tracks their duration
+func (m *metricsImpl) RecordQuery(duration time.Duration) {
+	storeQueries.Inc()
+	storeQueryDurationSeconds.Observe(duration.Seconds())
+}
+
+type metricsErrCategory string
+
+var (
+	decodeRPCFailure     metricsErrCategory = "decode_rpc_failure"
+	writeResponseFailure metricsErrCategory = "write_response_failure"
+	invalidRequest       metricsErrCategory = "invalid_request"
+	queryFailure         metricsErrCategory = "query_failure"
+)
+
+// RecordError increases the counter for different error types
+func (m *metricsImpl) RecordError(err metricsErrCategory) {
+	storeErrors.WithLabelValues(string(err)).Inc()
+}
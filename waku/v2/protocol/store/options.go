@@ -14,6 +14,7 @@ type Parameters struct {
 	peerAddr          multiaddr.Multiaddr
 	peerSelectionType peermanager.PeerSelection
 	preferredPeers    peer.IDSlice
+	maxPeers          int
 	requestID         []byte
 	cursor            []byte
 	pageLimit         uint64
@@ -78,6 +79,23 @@ func WithFastestPeerSelection(fromThesePeers ...peer.ID) RequestOption {
 	}
 }
 
+// WithFederation is an option used to send the query to up to maxPeers store nodes, chosen
+// with the peer selection options, instead of a single one. The pages returned by each store
+// node are merged by timestamp and message hash and the duplicated messages are removed, so the
+// result is complete as long as any of the store nodes has each message. Store nodes that fail
+// to answer are skipped, and the messages retrieved from each of them are reported by
+// CoverageResult.Coverage
+// Note: This option is not compatible with WithPeer and WithPeerAddr
+func WithFederation(maxPeers int) RequestOption {
+	return func(params *Parameters) error {
+		if maxPeers < 1 {
+			return errors.New("federated queries require at least one peer")
+		}
+		params.maxPeers = maxPeers
+		return nil
+	}
+}
+
 // WithRequestID is an option to set a specific request ID to be used when
 // creating a store request
 func WithRequestID(requestID []byte) RequestOption {
@@ -18,6 +18,13 @@ type Result interface {
 	Messages() []*pb.WakuMessageKeyValue
 }
 
+// CoverageResult is implemented by the results that report which store nodes answered
+// a query, like the results of the queries sent with WithFederation
+type CoverageResult interface {
+	Result
+	Coverage() []PeerCoverage
+}
+
 type resultImpl struct {
 	done bool
 
@@ -27,6 +34,7 @@ type resultImpl struct {
 	storeResponse *pb.StoreQueryResponse
 	cursor        []byte
 	peerID        peer.ID
+	received      int
 }
 
 func (r *resultImpl) Cursor() []byte {
@@ -63,6 +71,7 @@ func (r *resultImpl) Next(ctx context.Context, opts ...RequestOption) error {
 
 	r.cursor = newResult.cursor
 	r.messages = newResult.messages
+	r.received += len(newResult.messages)
 
 	return nil
 }
@@ -70,3 +79,12 @@ func (r *resultImpl) Next(ctx context.Context, opts ...RequestOption) error {
 func (r *resultImpl) Messages() []*pb.WakuMessageKeyValue {
 	return r.messages
 }
+
+func (r *resultImpl) Coverage() []PeerCoverage {
+	return []PeerCoverage{{
+		PeerID:   r.peerID,
+		Messages: r.received,
+		Unique:   r.received,
+		Complete: r.cursor == nil,
+	}}
+}
@@ -0,0 +1,175 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"math"
+	"net/http"
+	"time"
+
+	"github.com/libp2p/go-libp2p/core/host"
+	"github.com/libp2p/go-libp2p/core/network"
+	"github.com/libp2p/go-msgio/pbio"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/waku-org/go-waku/logging"
+	"github.com/waku-org/go-waku/waku/persistence"
+	"github.com/waku-org/go-waku/waku/v2/protocol"
+	"github.com/waku-org/go-waku/waku/v2/protocol/store/pb"
+	"github.com/waku-org/go-waku/waku/v2/service"
+	"go.uber.org/zap"
+	"google.golang.org/protobuf/proto"
+)
+
+// MessageProvider is the interface used by a store node to retrieve the messages
+// that answer a store query
+type MessageProvider interface {
+	StoreQuery(query *pb.StoreQueryRequest) ([]byte, []persistence.StoredMessage, error)
+}
+
+// WakuStoreServer mounts the store v3 protocol and answers the queries received
+// using the messages available in a MessageProvider
+type WakuStoreServer struct {
+	*service.CommonService
+
+	h           host.Host
+	msgProvider MessageProvider
+	metrics     Metrics
+	log         *zap.Logger
+}
+
+// NewWakuStoreServer is used to instantiate a StoreV3 server. Notice that the
+// server does not manage the lifecycle of the MessageProvider
+func NewWakuStoreServer(msgProvider MessageProvider, reg prometheus.Registerer, log *zap.Logger) *WakuStoreServer {
+	s := new(WakuStoreServer)
+	s.CommonService = service.NewCommonService()
+	s.msgProvider = msgProvider
+	s.metrics = newMetrics(reg)
+	s.log = log.Named("store-server")
+	return s
+}
+
+// Sets the host to be able to mount or consume a protocol
+func (s *WakuStoreServer) SetHost(h host.Host) {
+	s.h = h
+}
+
+// Start mounts the store v3 protocol
+func (s *WakuStoreServer) Start(ctx context.Context) error {
+	return s.CommonService.Start(ctx, s.start)
+}
+
+func (s *WakuStoreServer) start() error {
+	if s.msgProvider == nil {
+		s.log.Info("store v3 protocol not mounted (no message provider)")
+		return nil
+	}
+
+	s.h.SetStreamHandlerMatch(StoreQueryID_v300, protocol.PrefixTextMatch(string(StoreQueryID_v300)), s.onRequest)
+
+	s.log.Info("store v3 protocol started")
+
+	return nil
+}
+
+// Stop unmounts the store v3 protocol
+func (s *WakuStoreServer) Stop() {
+	s.CommonService.Stop(func() {
+		s.h.RemoveStreamHandler(StoreQueryID_v300)
+	})
+}
+
+func (s *WakuStoreServer) onRequest(stream network.Stream) {
+	logger := s.log.With(logging.HostID("peer", stream.Conn().RemotePeer()))
+
+	writer := pbio.NewDelimitedWriter(stream)
+	reader := pbio.NewDelimitedReader(stream, math.MaxInt32)
+
+	storeRequest := &pb.StoreQueryRequest{}
+	err := reader.ReadMsg(storeRequest)
+	if err != nil {
+		logger.Error("reading request", zap.Error(err))
+		s.metrics.RecordError(decodeRPCFailure)
+		if err := stream.Reset(); err != nil {
+			s.log.Error("resetting connection", zap.Error(err))
+		}
+		return
+	}
+
+	logger = logger.With(zap.String("requestId", storeRequest.RequestId))
+	logger.Info("received store query")
+
+	start := time.Now()
+	storeResponse := s.handleRequest(logger, storeRequest)
+	s.metrics.RecordQuery(time.Since(start))
+
+	logger = logger.With(zap.Uint32("statusCode", storeResponse.GetStatusCode()), zap.Int("messages", len(storeResponse.Messages)))
+
+	err = writer.WriteMsg(storeResponse)
+	if err != nil {
+		logger.Error("writing response", zap.Error(err))
+		s.metrics.RecordError(writeResponseFailure)
+		if err := stream.Reset(); err != nil {
+			s.log.Error("resetting connection", zap.Error(err))
+		}
+		return
+	}
+
+	logger.Info("response sent")
+	stream.Close()
+}
+
+func (s *WakuStoreServer) handleRequest(logger *zap.Logger, storeRequest *pb.StoreQueryRequest) *pb.StoreQueryResponse {
+	requestID := storeRequest.RequestId
+	if requestID == "" {
+		requestID = "N/A"
+	}
+
+	if err := storeRequest.Validate(); err != nil {
+		logger.Debug("invalid request received", zap.Error(err))
+		s.metrics.RecordError(invalidRequest)
+		return newStoreResponse(requestID, http.StatusBadRequest, err.Error())
+	}
+
+	pageLimit := storeRequest.GetPaginationLimit()
+	if pageLimit == 0 {
+		pageLimit = DefaultPageSize
+	} else if pageLimit > uint64(MaxPageSize) {
+		pageLimit = MaxPageSize
+	}
+	storeRequest.PaginationLimit = proto.Uint64(pageLimit)
+
+	cursor, storedMessages, err := s.msgProvider.StoreQuery(storeRequest)
+	if err != nil {
+		if errors.Is(err, persistence.ErrInvalidCursor) {
+			return newStoreResponse(requestID, http.StatusBadRequest, err.Error())
+		}
+
+		logger.Error("obtaining messages from db", zap.Error(err))
+		s.metrics.RecordError(queryFailure)
+		return newStoreResponse(requestID, http.StatusInternalServerError, http.StatusText(http.StatusInternalServerError))
+	}
+
+	storeResponse := newStoreResponse(requestID, http.StatusOK, http.StatusText(http.StatusOK))
+	storeResponse.PaginationCursor = cursor
+	storeResponse.Messages = make([]*pb.WakuMessageKeyValue, len(storedMessages))
+	for i, storedMessage := range storedMessages {
+		kv := &pb.WakuMessageKeyValue{
+			MessageHash: storedMessage.MessageHash,
+		}
+		if storeRequest.IncludeData {
+			kv.Message = storedMessage.Message
+			kv.PubsubTopic = proto.String(storedMessage.PubsubTopic)
+		}
+		storeResponse.Messages[i] = kv
+	}
+
+	return storeResponse
+}
+
+func newStoreResponse(requestID string, statusCode int, statusDesc string) *pb.StoreQueryResponse {
+	return &pb.StoreQueryResponse{
+		RequestId:  requestID,
+		StatusCode: proto.Uint32(uint32(statusCode)),
+		StatusDesc: proto.String(statusDesc),
+	}
+}
@@ -0,0 +1,131 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/libp2p/go-libp2p"
+	"github.com/libp2p/go-libp2p/core/peerstore"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/stretchr/testify/require"
+	"github.com/waku-org/go-waku/tests"
+	"github.com/waku-org/go-waku/waku/persistence"
+	"github.com/waku-org/go-waku/waku/persistence/sqlite"
+	"github.com/waku-org/go-waku/waku/v2/protocol"
+	"github.com/waku-org/go-waku/waku/v2/protocol/pb"
+	"github.com/waku-org/go-waku/waku/v2/timesource"
+	"github.com/waku-org/go-waku/waku/v2/utils"
+	"golang.org/x/time/rate"
+	"google.golang.org/protobuf/proto"
+)
+
+func TestStoreServer(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	db, err := sqlite.NewDB(":memory:", utils.Logger())
+	require.NoError(t, err)
+	dbStore, err := persistence.NewDBStore(prometheus.DefaultRegisterer, utils.Logger(), persistence.WithDB(db), persistence.WithMigrations(sqlite.Migrations))
+	require.NoError(t, err)
+
+	pubsubTopic := "/waku/2/rs/99/1"
+	now := time.Now()
+	var messages []*pb.WakuMessage
+	for i := 0; i < 5; i++ {
+		msg := tests.CreateWakuMessage("test", proto.Int64(now.Add(time.Duration(i)*time.Second).UnixNano()), fmt.Sprintf("payload %d", i))
+		require.NoError(t, dbStore.Put(protocol.NewEnvelope(msg, msg.GetTimestamp(), pubsubTopic)))
+		messages = append(messages, msg)
+	}
+	otherMsg := tests.CreateWakuMessage("other", proto.Int64(now.UnixNano()))
+	require.NoError(t, dbStore.Put(protocol.NewEnvelope(otherMsg, otherMsg.GetTimestamp(), pubsubTopic)))
+
+	host1, err := libp2p.New(libp2p.DefaultTransports, libp2p.ListenAddrStrings("/ip4/0.0.0.0/tcp/0"))
+	require.NoError(t, err)
+
+	server := NewWakuStoreServer(dbStore, prometheus.DefaultRegisterer, utils.Logger())
+	server.SetHost(host1)
+	require.NoError(t, server.Start(ctx))
+	defer server.Stop()
+
+	host2, err := libp2p.New(libp2p.DefaultTransports, libp2p.ListenAddrStrings("/ip4/0.0.0.0/tcp/0"))
+	require.NoError(t, err)
+	host2.Peerstore().AddAddr(host1.ID(), tests.GetHostAddress(host1), peerstore.PermanentAddrTTL)
+
+	client := NewWakuStore(nil, timesource.NewDefaultClock(), utils.Logger(), rate.Inf)
+	client.SetHost(host2)
+
+	criteria := FilterCriteria{ContentFilter: protocol.NewContentFilter(pubsubTopic, "test")}
+
+	// Check for message existence
+	exists, err := client.Exists(ctx, messages[0].Hash(pubsubTopic), WithPeer(host1.ID()))
+	require.NoError(t, err)
+	require.True(t, exists)
+
+	exists, err = client.Exists(ctx, pb.MessageHash{1, 2, 3}, WithPeer(host1.ID()))
+	require.NoError(t, err)
+	require.False(t, exists)
+
+	// Query messages with forward pagination
+	response, err := client.Query(ctx, criteria, WithPeer(host1.ID()), WithPaging(true, 2))
+	require.NoError(t, err)
+	require.Len(t, response.Messages(), 2)
+	require.Equal(t, messages[0].Hash(pubsubTopic), response.Messages()[0].WakuMessageHash())
+	require.Equal(t, messages[1].Hash(pubsubTopic), response.Messages()[1].WakuMessageHash())
+	require.Equal(t, pubsubTopic, response.Messages()[0].GetPubsubTopic())
+	require.Equal(t, messages[0].Payload, response.Messages()[0].Message.Payload)
+
+	require.NoError(t, response.Next(ctx))
+	require.Len(t, response.Messages(), 2)
+	require.Equal(t, messages[2].Hash(pubsubTopic), response.Messages()[0].WakuMessageHash())
+	require.Equal(t, messages[3].Hash(pubsubTopic), response.Messages()[1].WakuMessageHash())
+
+	require.NoError(t, response.Next(ctx))
+	require.Len(t, response.Messages(), 1)
+	require.Equal(t, messages[4].Hash(pubsubTopic), response.Messages()[0].WakuMessageHash())
+	require.Empty(t, response.Cursor())
+
+	require.NoError(t, response.Next(ctx))
+	require.True(t, response.IsComplete())
+
+	// Query messages with backward pagination
+	response, err = client.Query(ctx, criteria, WithPeer(host1.ID()), WithPaging(false, 2))
+	require.NoError(t, err)
+	require.Len(t, response.Messages(), 2)
+	require.Equal(t, messages[3].Hash(pubsubTopic), response.Messages()[0].WakuMessageHash())
+	require.Equal(t, messages[4].Hash(pubsubTopic), response.Messages()[1].WakuMessageHash())
+
+	require.NoError(t, response.Next(ctx))
+	require.Len(t, response.Messages(), 2)
+	require.Equal(t, messages[1].Hash(pubsubTopic), response.Messages()[0].WakuMessageHash())
+	require.Equal(t, messages[2].Hash(pubsubTopic), response.Messages()[1].WakuMessageHash())
+
+	// Query messages within a time range
+	response, err = client.Query(ctx, FilterCriteria{
+		ContentFilter: protocol.NewContentFilter(pubsubTopic, "test"),
+		TimeStart:     messages[1].Timestamp,
+		TimeEnd:       messages[2].Timestamp,
+	}, WithPeer(host1.ID()))
+	require.NoError(t, err)
+	require.Len(t, response.Messages(), 2)
+	require.Empty(t, response.Cursor())
+
+	// Query by message hash without returning the message content
+	response, err = client.QueryByHash(ctx, []pb.MessageHash{messages[2].Hash(pubsubTopic), otherMsg.Hash(pubsubTopic)}, WithPeer(host1.ID()), IncludeData(false))
+	require.NoError(t, err)
+	require.Len(t, response.Messages(), 2)
+	for _, m := range response.Messages() {
+		require.Nil(t, m.Message)
+		require.Empty(t, m.GetPubsubTopic())
+	}
+
+	// Inexistent cursors should fail
+	_, err = client.Query(ctx, criteria, WithPeer(host1.ID()), WithCursor(make([]byte, 32)))
+	require.Error(t, err)
+	var storeErr *StoreError
+	require.True(t, errors.As(err, &storeErr))
+	require.Equal(t, http.StatusBadRequest, storeErr.Code)
+}
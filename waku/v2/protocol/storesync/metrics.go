@@ -0,0 +1,110 @@
+package storesync
+
+import (
+	"time"
+
+	"github.com/libp2p/go-libp2p/p2p/metricshelper"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var syncMessagesReconciled = prometheus.NewCounter(
+	prometheus.CounterOpts{
+		Name: "waku_store_sync_messages_reconciled",
+		Help: "The number of missing messages retrieved from other store nodes and inserted in the message store",
+	})
+
+var syncMissingHashes = prometheus.NewCounter(
+	prometheus.CounterOpts{
+		Name: "waku_store_sync_missing_hashes",
+		Help: "The number of message hashes found to be missing in the message store",
+	})
+
+var syncSessions = prometheus.NewCounterVec(
+	prometheus.CounterOpts{
+		Name: "waku_store_sync_sessions",
+		Help: "The number of store sync sessions",
+	},
+	[]string{"role"},
+)
+
+var syncSessionDurationSeconds = prometheus.NewHistogram(
+	prometheus.HistogramOpts{
+		Name: "waku_store_sync_session_duration_seconds",
+		Help: "Duration of store sync sessions",
+	})
+
+var syncErrors = prometheus.NewCounterVec(
+	prometheus.CounterOpts{
+		Name: "waku_store_sync_errors",
+		Help: "The distribution of the store sync protocol errors",
+	},
+	[]string{"error_type"},
+)
+
+var collectors = []prometheus.Collector{
+	syncMessagesReconciled,
+	syncMissingHashes,
+	syncSessions,
+	syncSessionDurationSeconds,
+	syncErrors,
+}
+
+// Metrics exposes the functions required to update prometheus metrics for store sync protocol
+type Metrics interface {
+	RecordSession(role sessionRole, duration time.Duration)
+	RecordMissingHashes(num int)
+	RecordMessagesReconciled(num int)
+	RecordError(err metricsErrCategory)
+}
+
+type metricsImpl struct {
+	reg prometheus.Registerer
+}
+
+func newMetrics(reg prometheus.Registerer) Metrics {
+	metricshelper.RegisterCollectors(reg, collectors...)
+	return &metricsImpl{
+		reg: reg,
+	}
+}
+
+type sessionRole string
+
+var (
+	initiatorRole sessionRole = "initiator"
+	responderRole sessionRole = "responder"
+)
+
+// RecordSession increases the counter of sync sessions and tracks their duration
+func (m *metricsImpl) RecordSession(role sessionRole, duration time.Duration) {
+	syncSessions.WithLabelValues(string(role)).Inc()
+	syncSessionDurationSeconds.Observe(duration.Seconds())
+}
+
+// RecordMissingHashes increases the counter of message hashes missing in the message store
+func (m *metricsImpl) RecordMissingHashes(num int) {
+	syncMissingHashes.Add(float64(num))
+}
+
+// RecordMessagesReconciled increases the counter of messages retrieved and inserted in the message store
+func (m *metricsImpl) RecordMessagesReconciled(num int) {
+	syncMessagesReconciled.Add(float64(num))
+}
+
+type metricsErrCategory string
+
+var (
+	dialFailure          metricsErrCategory = "dial_failure"
+	decodeRPCFailure     metricsErrCategory = "decode_rpc_failure"
+	writeRequestFailure  metricsErrCategory = "write_request_failure"
+	writeResponseFailure metricsErrCategory = "write_response_failure"
+	invalidMessage       metricsErrCategory = "invalid_message"
+	dbFailure            metricsErrCategory = "db_failure"
+	transferFailure      metricsErrCategory = "transfer_failure"
+	hashMismatch         metricsErrCategory = "hash_mismatch"
+)
+
+// RecordError increases the counter for different error types
+func (m *metricsImpl) RecordError(err metricsErrCategory) {
+	syncErrors.WithLabelValues(string(err)).Inc()
+}
@@ -0,0 +1,98 @@
+package storesync
+
+import (
+	"time"
+
+	"github.com/libp2p/go-libp2p/core/peer"
+)
+
+// StoreSyncParameters are the settings used to configure the store sync protocol
+type StoreSyncParameters struct {
+	interval         time.Duration
+	syncRange        time.Duration
+	relayJitter      time.Duration
+	maxPeers         int
+	peers            peer.IDSlice
+	pubsubTopics     []string
+	itemSetThreshold int
+	branchingFactor  int
+}
+
+type Option func(*StoreSyncParameters)
+
+// WithInterval is an option used to specify how often a node synchronizes its
+// message store with other store nodes
+func WithInterval(interval time.Duration) Option {
+	return func(params *StoreSyncParameters) {
+		params.interval = interval
+	}
+}
+
+// WithSyncRange is an option used to specify how far in the past the messages
+// are compared in each synchronization
+func WithSyncRange(syncRange time.Duration) Option {
+	return func(params *StoreSyncParameters) {
+		params.syncRange = syncRange
+	}
+}
+
+// WithRelayJitter is an option used to exclude from the synchronization the most
+// recent messages, which might still be propagating in the relay network
+func WithRelayJitter(jitter time.Duration) Option {
+	return func(params *StoreSyncParameters) {
+		params.relayJitter = jitter
+	}
+}
+
+// WithPeers is an option used to specify the store nodes to synchronize with.
+// If no peers are specified, peers that support the store sync protocol will be
+// selected from the peerstore
+func WithPeers(peers ...peer.ID) Option {
+	return func(params *StoreSyncParameters) {
+		params.peers = peers
+	}
+}
+
+// WithMaxPeers is an option used to specify the maximum number of peers selected
+// from the peerstore in each synchronization
+func WithMaxPeers(maxPeers int) Option {
+	return func(params *StoreSyncParameters) {
+		params.maxPeers = maxPeers
+	}
+}
+
+// WithPubsubTopics is an option used to restrict the synchronization to a list of
+// pubsub topics. By default messages from all pubsub topics are synchronized
+func WithPubsubTopics(pubsubTopics ...string) Option {
+	return func(params *StoreSyncParameters) {
+		params.pubsubTopics = pubsubTopics
+	}
+}
+
+// WithItemSetThreshold is an option used to specify the number of message hashes
+// below which a range is exchanged as a list of hashes instead of being split
+func WithItemSetThreshold(threshold int) Option {
+	return func(params *StoreSyncParameters) {
+		params.itemSetThreshold = threshold
+	}
+}
+
+// WithBranchingFactor is an option used to specify the number of subranges in
+// which a range is split when its fingerprint does not match
+func WithBranchingFactor(branchingFactor int) Option {
+	return func(params *StoreSyncParameters) {
+		params.branchingFactor = branchingFactor
+	}
+}
+
+// DefaultOptions returns the default options used by the store sync protocol
+func DefaultOptions() []Option {
+	return []Option{
+		WithInterval(5 * time.Minute),
+		WithSyncRange(time.Hour),
+		WithRelayJitter(20 * time.Second),
+		WithMaxPeers(1),
+		WithItemSetThreshold(64),
+		WithBranchingFactor(8),
+	}
+}
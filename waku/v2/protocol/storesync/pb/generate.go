@@ -0,0 +1,3 @@
+package pb
+
+//go:generate protoc -I. --go_opt=paths=source_relative --go_opt=Mstoresync.proto=github.com/waku-org/go-waku/waku/v2/protocol/storesync/pb --go_out=. ./storesync.proto
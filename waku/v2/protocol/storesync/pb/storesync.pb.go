@@ -0,0 +1,431 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.34.2
+// 	protoc        v3.21.12
+// source: storesync.proto
+
+// Protocol identifier: /vac/waku/store-sync/1.0.0
+
+package pb
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	reflect "reflect"
+	sync "sync"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+type Range_Mode int32
+
+const (
+	Range_SKIP        Range_Mode = 0
+	Range_FINGERPRINT Range_Mode = 1
+	Range_ITEM_SET    Range_Mode = 2
+)
+
+// Enum value maps for Range_Mode.
+var (
+	Range_Mode_name = map[int32]string{
+		0: "SKIP",
+		1: "FINGERPRINT",
+		2: "ITEM_SET",
+	}
+	Range_Mode_value = map[string]int32{
+		"SKIP":        0,
+		"FINGERPRINT": 1,
+		"ITEM_SET":    2,
+	}
+)
+
+func (x Range_Mode) Enum() *Range_Mode {
+	p := new(Range_Mode)
+	*p = x
+	return p
+}
+
+func (x Range_Mode) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (Range_Mode) Descriptor() protoreflect.EnumDescriptor {
+	return file_storesync_proto_enumTypes[0].Descriptor()
+}
+
+func (Range_Mode) Type() protoreflect.EnumType {
+	return &file_storesync_proto_enumTypes[0]
+}
+
+func (x Range_Mode) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use Range_Mode.Descriptor instead.
+func (Range_Mode) EnumDescriptor() ([]byte, []int) {
+	return file_storesync_proto_rawDescGZIP(), []int{1, 0}
+}
+
+// Items of the set being reconciled are ordered by timestamp and message hash.
+// A bound is exclusive when used as upper bound of a range, and inclusive otherwise.
+type Bound struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Timestamp   int64  `protobuf:"zigzag64,1,opt,name=timestamp,proto3" json:"timestamp,omitempty"`
+	MessageHash []byte `protobuf:"bytes,2,opt,name=message_hash,json=messageHash,proto3" json:"message_hash,omitempty"`
+}
+
+func (x *Bound) Reset() {
+	*x = Bound{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_storesync_proto_msgTypes[0]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *Bound) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Bound) ProtoMessage() {}
+
+func (x *Bound) ProtoReflect() protoreflect.Message {
+	mi := &file_storesync_proto_msgTypes[0]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Bound.ProtoReflect.Descriptor instead.
+func (*Bound) Descriptor() ([]byte, []int) {
+	return file_storesync_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *Bound) GetTimestamp() int64 {
+	if x != nil {
+		return x.Timestamp
+	}
+	return 0
+}
+
+func (x *Bound) GetMessageHash() []byte {
+	if x != nil {
+		return x.MessageHash
+	}
+	return nil
+}
+
+type Range struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Lower *Bound     `protobuf:"bytes,1,opt,name=lower,proto3" json:"lower,omitempty"`
+	Upper *Bound     `protobuf:"bytes,2,opt,name=upper,proto3" json:"upper,omitempty"`
+	Mode  Range_Mode `protobuf:"varint,3,opt,name=mode,proto3,enum=waku.store_sync.v1.Range_Mode" json:"mode,omitempty"`
+	// Used with FINGERPRINT mode
+	Fingerprint []byte `protobuf:"bytes,10,opt,name=fingerprint,proto3" json:"fingerprint,omitempty"`
+	Count       uint64 `protobuf:"varint,11,opt,name=count,proto3" json:"count,omitempty"`
+	// Used with ITEM_SET mode
+	MessageHashes [][]byte `protobuf:"bytes,20,rep,name=message_hashes,json=messageHashes,proto3" json:"message_hashes,omitempty"`
+}
+
+func (x *Range) Reset() {
+	*x = Range{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_storesync_proto_msgTypes[1]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *Range) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Range) ProtoMessage() {}
+
+func (x *Range) ProtoReflect() protoreflect.Message {
+	mi := &file_storesync_proto_msgTypes[1]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Range.ProtoReflect.Descriptor instead.
+func (*Range) Descriptor() ([]byte, []int) {
+	return file_storesync_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *Range) GetLower() *Bound {
+	if x != nil {
+		return x.Lower
+	}
+	return nil
+}
+
+func (x *Range) GetUpper() *Bound {
+	if x != nil {
+		return x.Upper
+	}
+	return nil
+}
+
+func (x *Range) GetMode() Range_Mode {
+	if x != nil {
+		return x.Mode
+	}
+	return Range_SKIP
+}
+
+func (x *Range) GetFingerprint() []byte {
+	if x != nil {
+		return x.Fingerprint
+	}
+	return nil
+}
+
+func (x *Range) GetCount() uint64 {
+	if x != nil {
+		return x.Count
+	}
+	return 0
+}
+
+func (x *Range) GetMessageHashes() [][]byte {
+	if x != nil {
+		return x.MessageHashes
+	}
+	return nil
+}
+
+type StoreSyncMessage struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	RequestId   string   `protobuf:"bytes,1,opt,name=request_id,json=requestId,proto3" json:"request_id,omitempty"`
+	PubsubTopic *string  `protobuf:"bytes,2,opt,name=pubsub_topic,json=pubsubTopic,proto3,oneof" json:"pubsub_topic,omitempty"`
+	Ranges      []*Range `protobuf:"bytes,10,rep,name=ranges,proto3" json:"ranges,omitempty"`
+	// Message hashes the sender stores and that the receiver does not have
+	MissingHashes [][]byte `protobuf:"bytes,20,rep,name=missing_hashes,json=missingHashes,proto3" json:"missing_hashes,omitempty"`
+}
+
+func (x *StoreSyncMessage) Reset() {
+	*x = StoreSyncMessage{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_storesync_proto_msgTypes[2]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *StoreSyncMessage) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*StoreSyncMessage) ProtoMessage() {}
+
+func (x *StoreSyncMessage) ProtoReflect() protoreflect.Message {
+	mi := &file_storesync_proto_msgTypes[2]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use StoreSyncMessage.ProtoReflect.Descriptor instead.
+func (*StoreSyncMessage) Descriptor() ([]byte, []int) {
+	return file_storesync_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *StoreSyncMessage) GetRequestId() string {
+	if x != nil {
+		return x.RequestId
+	}
+	return ""
+}
+
+func (x *StoreSyncMessage) GetPubsubTopic() string {
+	if x != nil && x.PubsubTopic != nil {
+		return *x.PubsubTopic
+	}
+	return ""
+}
+
+func (x *StoreSyncMessage) GetRanges() []*Range {
+	if x != nil {
+		return x.Ranges
+	}
+	return nil
+}
+
+func (x *StoreSyncMessage) GetMissingHashes() [][]byte {
+	if x != nil {
+		return x.MissingHashes
+	}
+	return nil
+}
+
+var File_storesync_proto protoreflect.FileDescriptor
+
+var file_storesync_proto_rawDesc = []byte{
+	0x0a, 0x0f, 0x73, 0x74, 0x6f, 0x72, 0x65, 0x73, 0x79, 0x6e, 0x63, 0x2e, 0x70, 0x72, 0x6f, 0x74,
+	0x6f, 0x12, 0x12, 0x77, 0x61, 0x6b, 0x75, 0x2e, 0x73, 0x74, 0x6f, 0x72, 0x65, 0x5f, 0x73, 0x79,
+	0x6e, 0x63, 0x2e, 0x76, 0x31, 0x22, 0x48, 0x0a, 0x05, 0x42, 0x6f, 0x75, 0x6e, 0x64, 0x12, 0x1c,
+	0x0a, 0x09, 0x74, 0x69, 0x6d, 0x65, 0x73, 0x74, 0x61, 0x6d, 0x70, 0x18, 0x01, 0x20, 0x01, 0x28,
+	0x12, 0x52, 0x09, 0x74, 0x69, 0x6d, 0x65, 0x73, 0x74, 0x61, 0x6d, 0x70, 0x12, 0x21, 0x0a, 0x0c,
+	0x6d, 0x65, 0x73, 0x73, 0x61, 0x67, 0x65, 0x5f, 0x68, 0x61, 0x73, 0x68, 0x18, 0x02, 0x20, 0x01,
+	0x28, 0x0c, 0x52, 0x0b, 0x6d, 0x65, 0x73, 0x73, 0x61, 0x67, 0x65, 0x48, 0x61, 0x73, 0x68, 0x22,
+	0xad, 0x02, 0x0a, 0x05, 0x52, 0x61, 0x6e, 0x67, 0x65, 0x12, 0x2f, 0x0a, 0x05, 0x6c, 0x6f, 0x77,
+	0x65, 0x72, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x19, 0x2e, 0x77, 0x61, 0x6b, 0x75, 0x2e,
+	0x73, 0x74, 0x6f, 0x72, 0x65, 0x5f, 0x73, 0x79, 0x6e, 0x63, 0x2e, 0x76, 0x31, 0x2e, 0x42, 0x6f,
+	0x75, 0x6e, 0x64, 0x52, 0x05, 0x6c, 0x6f, 0x77, 0x65, 0x72, 0x12, 0x2f, 0x0a, 0x05, 0x75, 0x70,
+	0x70, 0x65, 0x72, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x19, 0x2e, 0x77, 0x61, 0x6b, 0x75,
+	0x2e, 0x73, 0x74, 0x6f, 0x72, 0x65, 0x5f, 0x73, 0x79, 0x6e, 0x63, 0x2e, 0x76, 0x31, 0x2e, 0x42,
+	0x6f, 0x75, 0x6e, 0x64, 0x52, 0x05, 0x75, 0x70, 0x70, 0x65, 0x72, 0x12, 0x32, 0x0a, 0x04, 0x6d,
+	0x6f, 0x64, 0x65, 0x18, 0x03, 0x20, 0x01, 0x28, 0x0e, 0x32, 0x1e, 0x2e, 0x77, 0x61, 0x6b, 0x75,
+	0x2e, 0x73, 0x74, 0x6f, 0x72, 0x65, 0x5f, 0x73, 0x79, 0x6e, 0x63, 0x2e, 0x76, 0x31, 0x2e, 0x52,
+	0x61, 0x6e, 0x67, 0x65, 0x2e, 0x4d, 0x6f, 0x64, 0x65, 0x52, 0x04, 0x6d, 0x6f, 0x64, 0x65, 0x12,
+	0x20, 0x0a, 0x0b, 0x66, 0x69, 0x6e, 0x67, 0x65, 0x72, 0x70, 0x72, 0x69, 0x6e, 0x74, 0x18, 0x0a,
+	0x20, 0x01, 0x28, 0x0c, 0x52, 0x0b, 0x66, 0x69, 0x6e, 0x67, 0x65, 0x72, 0x70, 0x72, 0x69, 0x6e,
+	0x74, 0x12, 0x14, 0x0a, 0x05, 0x63, 0x6f, 0x75, 0x6e, 0x74, 0x18, 0x0b, 0x20, 0x01, 0x28, 0x04,
+	0x52, 0x05, 0x63, 0x6f, 0x75, 0x6e, 0x74, 0x12, 0x25, 0x0a, 0x0e, 0x6d, 0x65, 0x73, 0x73, 0x61,
+	0x67, 0x65, 0x5f, 0x68, 0x61, 0x73, 0x68, 0x65, 0x73, 0x18, 0x14, 0x20, 0x03, 0x28, 0x0c, 0x52,
+	0x0d, 0x6d, 0x65, 0x73, 0x73, 0x61, 0x67, 0x65, 0x48, 0x61, 0x73, 0x68, 0x65, 0x73, 0x22, 0x2f,
+	0x0a, 0x04, 0x4d, 0x6f, 0x64, 0x65, 0x12, 0x08, 0x0a, 0x04, 0x53, 0x4b, 0x49, 0x50, 0x10, 0x00,
+	0x12, 0x0f, 0x0a, 0x0b, 0x46, 0x49, 0x4e, 0x47, 0x45, 0x52, 0x50, 0x52, 0x49, 0x4e, 0x54, 0x10,
+	0x01, 0x12, 0x0c, 0x0a, 0x08, 0x49, 0x54, 0x45, 0x4d, 0x5f, 0x53, 0x45, 0x54, 0x10, 0x02, 0x22,
+	0xc4, 0x01, 0x0a, 0x10, 0x53, 0x74, 0x6f, 0x72, 0x65, 0x53, 0x79, 0x6e, 0x63, 0x4d, 0x65, 0x73,
+	0x73, 0x61, 0x67, 0x65, 0x12, 0x1d, 0x0a, 0x0a, 0x72, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x5f,
+	0x69, 0x64, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x09, 0x72, 0x65, 0x71, 0x75, 0x65, 0x73,
+	0x74, 0x49, 0x64, 0x12, 0x26, 0x0a, 0x0c, 0x70, 0x75, 0x62, 0x73, 0x75, 0x62, 0x5f, 0x74, 0x6f,
+	0x70, 0x69, 0x63, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x48, 0x00, 0x52, 0x0b, 0x70, 0x75, 0x62,
+	0x73, 0x75, 0x62, 0x54, 0x6f, 0x70, 0x69, 0x63, 0x88, 0x01, 0x01, 0x12, 0x31, 0x0a, 0x06, 0x72,
+	0x61, 0x6e, 0x67, 0x65, 0x73, 0x18, 0x0a, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x19, 0x2e, 0x77, 0x61,
+	0x6b, 0x75, 0x2e, 0x73, 0x74, 0x6f, 0x72, 0x65, 0x5f, 0x73, 0x79, 0x6e, 0x63, 0x2e, 0x76, 0x31,
+	0x2e, 0x52, 0x61, 0x6e, 0x67, 0x65, 0x52, 0x06, 0x72, 0x61, 0x6e, 0x67, 0x65, 0x73, 0x12, 0x25,
+	0x0a, 0x0e, 0x6d, 0x69, 0x73, 0x73, 0x69, 0x6e, 0x67, 0x5f, 0x68, 0x61, 0x73, 0x68, 0x65, 0x73,
+	0x18, 0x14, 0x20, 0x03, 0x28, 0x0c, 0x52, 0x0d, 0x6d, 0x69, 0x73, 0x73, 0x69, 0x6e, 0x67, 0x48,
+	0x61, 0x73, 0x68, 0x65, 0x73, 0x42, 0x0f, 0x0a, 0x0d, 0x5f, 0x70, 0x75, 0x62, 0x73, 0x75, 0x62,
+	0x5f, 0x74, 0x6f, 0x70, 0x69, 0x63, 0x62, 0x06, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x33,
+}
+
+var (
+	file_storesync_proto_rawDescOnce sync.Once
+	file_storesync_proto_rawDescData = file_storesync_proto_rawDesc
+)
+
+func file_storesync_proto_rawDescGZIP() []byte {
+	file_storesync_proto_rawDescOnce.Do(func() {
+		file_storesync_proto_rawDescData = protoimpl.X.CompressGZIP(file_storesync_proto_rawDescData)
+	})
+	return file_storesync_proto_rawDescData
+}
+
+var file_storesync_proto_enumTypes = make([]protoimpl.EnumInfo, 1)
+var file_storesync_proto_msgTypes = make([]protoimpl.MessageInfo, 3)
+var file_storesync_proto_goTypes = []any{
+	(Range_Mode)(0),          // 0: waku.store_sync.v1.Range.Mode
+	(*Bound)(nil),            // 1: waku.store_sync.v1.Bound
+	(*Range)(nil),            // 2: waku.store_sync.v1.Range
+	(*StoreSyncMessage)(nil), // 3: waku.store_sync.v1.StoreSyncMessage
+}
+var file_storesync_proto_depIdxs = []int32{
+	1, // 0: waku.store_sync.v1.Range.lower:type_name -> waku.store_sync.v1.Bound
+	1, // 1: waku.store_sync.v1.Range.upper:type_name -> waku.store_sync.v1.Bound
+	0, // 2: waku.store_sync.v1.Range.mode:type_name -> waku.store_sync.v1.Range.Mode
+	2, // 3: waku.store_sync.v1.StoreSyncMessage.ranges:type_name -> waku.store_sync.v1.Range
+	4, // [4:4] is the sub-list for method output_type
+	4, // [4:4] is the sub-list for method input_type
+	4, // [4:4] is the sub-list for extension type_name
+	4, // [4:4] is the sub-list for extension extendee
+	0, // [0:4] is the sub-list for field type_name
+}
+
+func init() { file_storesync_proto_init() }
+func file_storesync_proto_init() {
+	if File_storesync_proto != nil {
+		return
+	}
+	if !protoimpl.UnsafeEnabled {
+		file_storesync_proto_msgTypes[0].Exporter = func(v any, i int) any {
+			switch v := v.(*Bound); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_storesync_proto_msgTypes[1].Exporter = func(v any, i int) any {
+			switch v := v.(*Range); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_storesync_proto_msgTypes[2].Exporter = func(v any, i int) any {
+			switch v := v.(*StoreSyncMessage); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+	}
+	file_storesync_proto_msgTypes[2].OneofWrappers = []any{}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: file_storesync_proto_rawDesc,
+			NumEnums:      1,
+			NumMessages:   3,
+			NumExtensions: 0,
+			NumServices:   0,
+		},
+		GoTypes:           file_storesync_proto_goTypes,
+		DependencyIndexes: file_storesync_proto_depIdxs,
+		EnumInfos:         file_storesync_proto_enumTypes,
+		MessageInfos:      file_storesync_proto_msgTypes,
+	}.Build()
+	File_storesync_proto = out.File
+	file_storesync_proto_rawDesc = nil
+	file_storesync_proto_goTypes = nil
+	file_storesync_proto_depIdxs = nil
+}
@@ -0,0 +1,66 @@
+package pb
+
+import (
+	"errors"
+)
+
+// MaxRanges is the maximum number of ranges allowed in a single store sync message
+const MaxRanges = 256
+
+var (
+	errMissingRequestID   = errors.New("missing RequestId field")
+	errMaxRanges          = errors.New("exceeds the maximum number of ranges allowed")
+	errMissingBound       = errors.New("missing range bound")
+	errInvalidRange       = errors.New("invalid range")
+	errInvalidFingerprint = errors.New("invalid fingerprint")
+	errInvalidMessageHash = errors.New("invalid message hash")
+)
+
+func (x *StoreSyncMessage) Validate() error {
+	if x.RequestId == "" {
+		return errMissingRequestID
+	}
+
+	if len(x.Ranges) > MaxRanges {
+		return errMaxRanges
+	}
+
+	for _, r := range x.Ranges {
+		if err := r.Validate(); err != nil {
+			return err
+		}
+	}
+
+	for _, h := range x.MissingHashes {
+		if len(h) != 32 {
+			return errInvalidMessageHash
+		}
+	}
+
+	return nil
+}
+
+func (x *Range) Validate() error {
+	if x.Lower == nil || x.Upper == nil {
+		return errMissingBound
+	}
+
+	if x.Lower.Timestamp > x.Upper.Timestamp {
+		return errInvalidRange
+	}
+
+	switch x.Mode {
+	case Range_FINGERPRINT:
+		if len(x.Fingerprint) != 32 {
+			return errInvalidFingerprint
+		}
+	case Range_ITEM_SET:
+		for _, h := range x.MessageHashes {
+			if len(h) != 32 {
+				return errInvalidMessageHash
+			}
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,187 @@
+package storesync
+
+import (
+	"bytes"
+	"sort"
+
+	"github.com/waku-org/go-waku/waku/persistence"
+	wpb "github.com/waku-org/go-waku/waku/v2/protocol/pb"
+	"github.com/waku-org/go-waku/waku/v2/protocol/storesync/pb"
+)
+
+// itemSet is the list of message hashes known by a node within a time range,
+// ordered by timestamp and then by message hash
+type itemSet []persistence.StoredMessageHash
+
+func compareToBound(item persistence.StoredMessageHash, b *pb.Bound) int {
+	if item.StoredAt < b.Timestamp {
+		return -1
+	}
+	if item.StoredAt > b.Timestamp {
+		return 1
+	}
+	return bytes.Compare(item.Hash[:], b.MessageHash)
+}
+
+func compareBounds(a *pb.Bound, b *pb.Bound) int {
+	if a.Timestamp < b.Timestamp {
+		return -1
+	}
+	if a.Timestamp > b.Timestamp {
+		return 1
+	}
+	return bytes.Compare(a.MessageHash, b.MessageHash)
+}
+
+// subset returns the items within [lower, upper)
+func (s itemSet) subset(lower *pb.Bound, upper *pb.Bound) itemSet {
+	start := sort.Search(len(s), func(i int) bool {
+		return compareToBound(s[i], lower) >= 0
+	})
+	end := sort.Search(len(s), func(i int) bool {
+		return compareToBound(s[i], upper) >= 0
+	})
+	if end < start {
+		return nil
+	}
+	return s[start:end]
+}
+
+// fingerprint is the XOR of all the message hashes of the set
+func (s itemSet) fingerprint() []byte {
+	var result wpb.MessageHash
+	for _, item := range s {
+		for i := range result {
+			result[i] ^= item.Hash[i]
+		}
+	}
+	return result[:]
+}
+
+func (s itemSet) hashes() [][]byte {
+	result := make([][]byte, len(s))
+	for i := range s {
+		result[i] = s[i].Hash.Bytes()
+	}
+	return result
+}
+
+func itemBound(item persistence.StoredMessageHash) *pb.Bound {
+	return &pb.Bound{
+		Timestamp:   item.StoredAt,
+		MessageHash: item.Hash.Bytes(),
+	}
+}
+
+// reconciler determines the differences between the local set of items and the set of a
+// remote peer by exchanging ranges. Ranges whose fingerprint differs are split into smaller
+// ranges until they are small enough to be exchanged as a list of message hashes
+type reconciler struct {
+	items            itemSet
+	itemSetThreshold int
+	branchingFactor  int
+}
+
+func newReconciler(items itemSet, itemSetThreshold int, branchingFactor int) *reconciler {
+	return &reconciler{
+		items:            items,
+		itemSetThreshold: itemSetThreshold,
+		branchingFactor:  branchingFactor,
+	}
+}
+
+// initialRange returns the range used to start a reconciliation between two bounds
+func (r *reconciler) initialRange(lower *pb.Bound, upper *pb.Bound) *pb.Range {
+	return r.fingerprintRange(lower, upper, r.items.subset(lower, upper))
+}
+
+func (r *reconciler) fingerprintRange(lower *pb.Bound, upper *pb.Bound, items itemSet) *pb.Range {
+	return &pb.Range{
+		Lower:       lower,
+		Upper:       upper,
+		Mode:        pb.Range_FINGERPRINT,
+		Fingerprint: items.fingerprint(),
+		Count:       uint64(len(items)),
+	}
+}
+
+// process handles the ranges received from a remote peer. It returns the ranges that
+// need to be sent back, the hashes the remote peer is missing, and the hashes that are
+// missing locally
+func (r *reconciler) process(ranges []*pb.Range) ([]*pb.Range, [][]byte, []wpb.MessageHash) {
+	var reply []*pb.Range
+	var remoteMissing [][]byte
+	var localMissing []wpb.MessageHash
+
+	for _, rng := range ranges {
+		localItems := r.items.subset(rng.Lower, rng.Upper)
+
+		switch rng.Mode {
+		case pb.Range_FINGERPRINT:
+			if rng.Count == uint64(len(localItems)) && bytes.Equal(rng.Fingerprint, localItems.fingerprint()) {
+				continue
+			}
+
+			if len(localItems) <= r.itemSetThreshold {
+				reply = append(reply, &pb.Range{
+					Lower:         rng.Lower,
+					Upper:         rng.Upper,
+					Mode:          pb.Range_ITEM_SET,
+					MessageHashes: localItems.hashes(),
+				})
+				continue
+			}
+
+			reply = append(reply, r.split(rng.Lower, rng.Upper, localItems)...)
+
+		case pb.Range_ITEM_SET:
+			remoteHashes := make(map[wpb.MessageHash]struct{}, len(rng.MessageHashes))
+			for _, h := range rng.MessageHashes {
+				remoteHashes[wpb.ToMessageHash(h)] = struct{}{}
+			}
+
+			localHashes := make(map[wpb.MessageHash]struct{}, len(localItems))
+			for _, item := range localItems {
+				localHashes[item.Hash] = struct{}{}
+				if _, ok := remoteHashes[item.Hash]; !ok {
+					remoteMissing = append(remoteMissing, item.Hash.Bytes())
+				}
+			}
+
+			for h := range remoteHashes {
+				if _, ok := localHashes[h]; !ok {
+					localMissing = append(localMissing, h)
+				}
+			}
+		}
+	}
+
+	return reply, remoteMissing, localMissing
+}
+
+// split divides a range in subranges containing approximately the same number of local items
+func (r *reconciler) split(lower *pb.Bound, upper *pb.Bound, items itemSet) []*pb.Range {
+	branches := r.branchingFactor
+	if branches > len(items) {
+		branches = len(items)
+	}
+
+	var result []*pb.Range
+	currLower := lower
+	start := 0
+	for i := 1; i <= branches; i++ {
+		end := i * len(items) / branches
+
+		currUpper := upper
+		if i != branches {
+			currUpper = itemBound(items[end])
+		}
+
+		result = append(result, r.fingerprintRange(currLower, currUpper, items[start:end]))
+
+		currLower = currUpper
+		start = end
+	}
+
+	return result
+}
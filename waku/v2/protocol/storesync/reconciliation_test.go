@@ -0,0 +1,104 @@
+package storesync
+
+import (
+	"crypto/rand"
+	"sort"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/waku-org/go-waku/waku/persistence"
+	wpb "github.com/waku-org/go-waku/waku/v2/protocol/pb"
+	"github.com/waku-org/go-waku/waku/v2/protocol/storesync/pb"
+)
+
+func randomItem(t *testing.T, storedAt int64) persistence.StoredMessageHash {
+	var hash wpb.MessageHash
+	_, err := rand.Read(hash[:])
+	require.NoError(t, err)
+	return persistence.StoredMessageHash{StoredAt: storedAt, Hash: hash}
+}
+
+func sortItems(items itemSet) itemSet {
+	sort.Slice(items, func(i, j int) bool {
+		return compareToBound(items[i], itemBound(items[j])) < 0
+	})
+	return items
+}
+
+// reconcile simulates a sync session between two reconcilers and returns the hashes
+// missing on each side
+func reconcile(t *testing.T, local *reconciler, remote *reconciler, lower *pb.Bound, upper *pb.Bound) ([]wpb.MessageHash, []wpb.MessageHash) {
+	var localMissing, remoteMissing []wpb.MessageHash
+
+	ranges := []*pb.Range{local.initialRange(lower, upper)}
+	sides := []*reconciler{remote, local}
+	missing := []*[]wpb.MessageHash{&remoteMissing, &localMissing}
+	for i := 0; len(ranges) != 0; i++ {
+		require.Less(t, i, MaxRounds)
+
+		reply, otherMissing, selfMissing := sides[i%2].process(ranges)
+		*missing[i%2] = append(*missing[i%2], selfMissing...)
+		for _, h := range otherMissing {
+			*missing[(i+1)%2] = append(*missing[(i+1)%2], wpb.ToMessageHash(h))
+		}
+		ranges = reply
+	}
+
+	return localMissing, remoteMissing
+}
+
+func TestReconciliation(t *testing.T) {
+	var common itemSet
+	for i := 0; i < 1000; i++ {
+		common = append(common, randomItem(t, int64(i)))
+	}
+
+	onlyLocal := []persistence.StoredMessageHash{randomItem(t, 10), randomItem(t, 500)}
+	onlyRemote := []persistence.StoredMessageHash{randomItem(t, 20), randomItem(t, 750), randomItem(t, 999)}
+
+	localItems := sortItems(append(append(itemSet{}, common...), onlyLocal...))
+	remoteItems := sortItems(append(append(itemSet{}, common...), onlyRemote...))
+
+	local := newReconciler(localItems, 16, 4)
+	remote := newReconciler(remoteItems, 16, 4)
+
+	lower := &pb.Bound{Timestamp: 0}
+	upper := &pb.Bound{Timestamp: 1000}
+
+	localMissing, remoteMissing := reconcile(t, local, remote, lower, upper)
+
+	require.ElementsMatch(t, []wpb.MessageHash{onlyRemote[0].Hash, onlyRemote[1].Hash, onlyRemote[2].Hash}, localMissing)
+	require.ElementsMatch(t, []wpb.MessageHash{onlyLocal[0].Hash, onlyLocal[1].Hash}, remoteMissing)
+}
+
+func TestReconciliationInSync(t *testing.T) {
+	var items itemSet
+	for i := 0; i < 100; i++ {
+		items = append(items, randomItem(t, int64(i)))
+	}
+	items = sortItems(items)
+
+	local := newReconciler(items, 16, 4)
+	remote := newReconciler(items, 16, 4)
+
+	lower := &pb.Bound{Timestamp: 0}
+	upper := &pb.Bound{Timestamp: 100}
+
+	reply, remoteMissing, localMissing := remote.process([]*pb.Range{local.initialRange(lower, upper)})
+	require.Empty(t, reply)
+	require.Empty(t, remoteMissing)
+	require.Empty(t, localMissing)
+}
+
+func TestItemSetSubset(t *testing.T) {
+	var items itemSet
+	for i := 0; i < 10; i++ {
+		items = append(items, randomItem(t, int64(i)))
+	}
+	items = sortItems(items)
+
+	require.Len(t, items.subset(&pb.Bound{Timestamp: 2}, &pb.Bound{Timestamp: 5}), 3)
+	require.Len(t, items.subset(itemBound(items[2]), itemBound(items[5])), 3)
+	require.Empty(t, items.subset(&pb.Bound{Timestamp: 5}, &pb.Bound{Timestamp: 2}))
+	require.Len(t, items.subset(&pb.Bound{Timestamp: 0}, &pb.Bound{Timestamp: 100}), 10)
+}
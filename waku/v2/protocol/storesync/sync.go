@@ -0,0 +1,481 @@
+package storesync
+
+import (
+	"context"
+	"encoding/hex"
+	"errors"
+	"math"
+	"time"
+
+	"github.com/libp2p/go-libp2p/core/host"
+	"github.com/libp2p/go-libp2p/core/network"
+	"github.com/libp2p/go-libp2p/core/peer"
+	libp2pProtocol "github.com/libp2p/go-libp2p/core/protocol"
+	"github.com/libp2p/go-msgio/pbio"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/waku-org/go-waku/logging"
+	"github.com/waku-org/go-waku/waku/persistence"
+	"github.com/waku-org/go-waku/waku/v2/peermanager"
+	"github.com/waku-org/go-waku/waku/v2/protocol"
+	wpb "github.com/waku-org/go-waku/waku/v2/protocol/pb"
+	"github.com/waku-org/go-waku/waku/v2/protocol/store"
+	"github.com/waku-org/go-waku/waku/v2/protocol/storesync/pb"
+	"github.com/waku-org/go-waku/waku/v2/service"
+	"github.com/waku-org/go-waku/waku/v2/timesource"
+	"go.uber.org/zap"
+	"golang.org/x/time/rate"
+	"google.golang.org/protobuf/proto"
+)
+
+// StoreSyncID_v100 is the current Waku Store Sync protocol identifier
+const StoreSyncID_v100 = libp2pProtocol.ID("/vac/waku/store-sync/1.0.0")
+
+// MaxRounds is the maximum number of messages exchanged by each side in a sync session
+const MaxRounds = 64
+
+// transferBatchSize is the number of message hashes requested in a single store query
+// when retrieving missing messages
+const transferBatchSize = 100
+
+var (
+	ErrNoPeersAvailable = errors.New("no suitable remote peers")
+	ErrInvalidRequestID = errors.New("unexpected request id")
+	ErrTooManyRounds    = errors.New("sync session exceeded the maximum number of rounds")
+	ErrTooManyRanges    = errors.New("too many ranges to reconcile")
+)
+
+// MessageProvider is the interface used by the store sync protocol to obtain the
+// message hashes known by the node and to insert the messages retrieved from other
+// store nodes
+type MessageProvider interface {
+	MessageHashes(pubsubTopic string, start int64, end int64) ([]persistence.StoredMessageHash, error)
+	Put(env *protocol.Envelope) error
+}
+
+// WakuStoreSync periodically reconciles the messages of the local message store with
+// the ones stored by other store nodes. Differences are found by exchanging the
+// fingerprints of time ranges, and the missing messages are retrieved using the
+// store protocol
+type WakuStoreSync struct {
+	*service.CommonService
+
+	h           host.Host
+	pm          *peermanager.PeerManager
+	msgProvider MessageProvider
+	storeClient *store.WakuStore
+	timesource  timesource.Timesource
+	params      *StoreSyncParameters
+	metrics     Metrics
+	log         *zap.Logger
+}
+
+// NewWakuStoreSync is used to instantiate the store sync protocol. The peermanager
+// is optional and is only used to select peers when none are configured with WithPeers
+func NewWakuStoreSync(msgProvider MessageProvider, pm *peermanager.PeerManager, timesource timesource.Timesource, reg prometheus.Registerer, log *zap.Logger, opts ...Option) *WakuStoreSync {
+	s := new(WakuStoreSync)
+	s.CommonService = service.NewCommonService()
+	s.msgProvider = msgProvider
+	s.pm = pm
+	s.timesource = timesource
+	s.metrics = newMetrics(reg)
+	s.log = log.Named("store-sync")
+	s.storeClient = store.NewWakuStore(pm, timesource, log, rate.Inf)
+
+	params := new(StoreSyncParameters)
+	optList := DefaultOptions()
+	optList = append(optList, opts...)
+	for _, opt := range optList {
+		opt(params)
+	}
+	s.params = params
+
+	if pm != nil {
+		pm.RegisterWakuProtocol(StoreSyncID_v100, store.StoreENRField)
+	}
+
+	return s
+}
+
+// Sets the host to be able to mount or consume a protocol
+func (s *WakuStoreSync) SetHost(h host.Host) {
+	s.h = h
+	s.storeClient.SetHost(h)
+}
+
+// Start mounts the store sync protocol and starts the periodic synchronization
+func (s *WakuStoreSync) Start(ctx context.Context) error {
+	return s.CommonService.Start(ctx, s.start)
+}
+
+func (s *WakuStoreSync) start() error {
+	if s.msgProvider == nil {
+		return errors.New("a message provider is required for store sync")
+	}
+
+	s.h.SetStreamHandlerMatch(StoreSyncID_v100, protocol.PrefixTextMatch(string(StoreSyncID_v100)), s.onRequest)
+
+	s.WaitGroup().Add(1)
+	go s.syncLoop()
+
+	s.log.Info("store sync protocol started", zap.Duration("interval", s.params.interval), zap.Duration("range", s.params.syncRange))
+
+	return nil
+}
+
+// Stop unmounts the store sync protocol and stops the periodic synchronization
+func (s *WakuStoreSync) Stop() {
+	s.CommonService.Stop(func() {
+		s.h.RemoveStreamHandler(StoreSyncID_v100)
+	})
+}
+
+func (s *WakuStoreSync) syncLoop() {
+	defer s.WaitGroup().Done()
+
+	ticker := time.NewTicker(s.params.interval)
+	defer ticker.Stop()
+
+	s.syncWithPeers()
+
+	for {
+		select {
+		case <-s.Context().Done():
+			return
+		case <-ticker.C:
+			s.syncWithPeers()
+		}
+	}
+}
+
+func (s *WakuStoreSync) selectPeers() (peer.IDSlice, error) {
+	if len(s.params.peers) != 0 {
+		return s.params.peers, nil
+	}
+
+	if s.pm == nil {
+		return nil, ErrNoPeersAvailable
+	}
+
+	peers, err := s.pm.SelectPeers(peermanager.PeerSelectionCriteria{
+		SelectionType: peermanager.Automatic,
+		Proto:         StoreSyncID_v100,
+		PubsubTopics:  s.params.pubsubTopics,
+		MaxPeers:      s.params.maxPeers,
+		Ctx:           s.Context(),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if len(peers) == 0 {
+		return nil, ErrNoPeersAvailable
+	}
+
+	return peers, nil
+}
+
+func (s *WakuStoreSync) syncWithPeers() {
+	peers, err := s.selectPeers()
+	if err != nil {
+		s.log.Debug("could not select peers for store sync", zap.Error(err))
+		return
+	}
+
+	pubsubTopics := s.params.pubsubTopics
+	if len(pubsubTopics) == 0 {
+		// An empty pubsub topic synchronizes the messages from all topics
+		pubsubTopics = []string{""}
+	}
+
+	for _, p := range peers {
+		for _, pubsubTopic := range pubsubTopics {
+			if s.Context().Err() != nil {
+				return
+			}
+
+			err := s.Sync(s.Context(), p, pubsubTopic)
+			if err != nil {
+				s.log.Error("synchronizing messages", logging.HostID("peer", p), zap.String("pubsubTopic", pubsubTopic), zap.Error(err))
+			}
+		}
+	}
+}
+
+// Sync reconciles the messages stored for a pubsub topic during the configured sync range
+// with the messages stored by a peer, and retrieves from the peer the messages that are
+// missing locally. An empty pubsub topic synchronizes the messages of all pubsub topics
+func (s *WakuStoreSync) Sync(ctx context.Context, peerID peer.ID, pubsubTopic string) error {
+	logger := s.log.With(logging.HostID("peer", peerID), zap.String("pubsubTopic", pubsubTopic))
+
+	now := s.timesource.Now()
+	lower := &pb.Bound{Timestamp: now.Add(-s.params.syncRange).UnixNano()}
+	upper := &pb.Bound{Timestamp: now.Add(-s.params.relayJitter).UnixNano()}
+
+	items, err := s.loadItems(pubsubTopic, lower, upper)
+	if err != nil {
+		s.metrics.RecordError(dbFailure)
+		return err
+	}
+
+	stream, err := s.h.NewStream(ctx, peerID, StoreSyncID_v100)
+	if err != nil {
+		s.metrics.RecordError(dialFailure)
+		return err
+	}
+
+	r := newReconciler(items, s.params.itemSetThreshold, s.params.branchingFactor)
+
+	initialMsg := &pb.StoreSyncMessage{
+		RequestId: hex.EncodeToString(protocol.GenerateRequestID()),
+		Ranges:    []*pb.Range{r.initialRange(lower, upper)},
+	}
+	if pubsubTopic != "" {
+		initialMsg.PubsubTopic = proto.String(pubsubTopic)
+	}
+
+	logger = logger.With(zap.String("requestId", initialMsg.RequestId))
+	logger.Debug("starting sync session", zap.Int("items", len(items)))
+
+	start := time.Now()
+
+	writer := pbio.NewDelimitedWriter(stream)
+	err = writer.WriteMsg(initialMsg)
+	if err != nil {
+		s.metrics.RecordError(writeRequestFailure)
+		if err := stream.Reset(); err != nil {
+			logger.Error("resetting connection", zap.Error(err))
+		}
+		return err
+	}
+
+	missing, err := s.exchange(stream, initialMsg.RequestId, r)
+	if err != nil {
+		if err := stream.Reset(); err != nil {
+			logger.Error("resetting connection", zap.Error(err))
+		}
+		return err
+	}
+	stream.Close()
+
+	s.metrics.RecordSession(initiatorRole, time.Since(start))
+
+	return s.transferMissing(ctx, logger, peerID, missing)
+}
+
+func (s *WakuStoreSync) onRequest(stream network.Stream) {
+	peerID := stream.Conn().RemotePeer()
+	logger := s.log.With(logging.HostID("peer", peerID))
+
+	reader := pbio.NewDelimitedReader(stream, math.MaxInt32)
+
+	initialMsg := &pb.StoreSyncMessage{}
+	err := reader.ReadMsg(initialMsg)
+	if err != nil {
+		logger.Error("reading request", zap.Error(err))
+		s.metrics.RecordError(decodeRPCFailure)
+		if err := stream.Reset(); err != nil {
+			logger.Error("resetting connection", zap.Error(err))
+		}
+		return
+	}
+
+	logger = logger.With(zap.String("requestId", initialMsg.RequestId), zap.String("pubsubTopic", initialMsg.GetPubsubTopic()))
+
+	if err := initialMsg.Validate(); err != nil || len(initialMsg.Ranges) == 0 {
+		logger.Debug("invalid sync request received", zap.Error(err))
+		s.metrics.RecordError(invalidMessage)
+		if err := stream.Reset(); err != nil {
+			logger.Error("resetting connection", zap.Error(err))
+		}
+		return
+	}
+
+	lower, upper := initialMsg.Ranges[0].Lower, initialMsg.Ranges[0].Upper
+	for _, rng := range initialMsg.Ranges[1:] {
+		if compareBounds(rng.Lower, lower) < 0 {
+			lower = rng.Lower
+		}
+		if compareBounds(rng.Upper, upper) > 0 {
+			upper = rng.Upper
+		}
+	}
+
+	items, err := s.loadItems(initialMsg.GetPubsubTopic(), lower, upper)
+	if err != nil {
+		logger.Error("loading message hashes", zap.Error(err))
+		s.metrics.RecordError(dbFailure)
+		if err := stream.Reset(); err != nil {
+			logger.Error("resetting connection", zap.Error(err))
+		}
+		return
+	}
+
+	start := time.Now()
+
+	r := newReconciler(items, s.params.itemSetThreshold, s.params.branchingFactor)
+	missing, done, err := s.handleMessage(stream, r, initialMsg)
+	if err == nil && !done {
+		var moreMissing []wpb.MessageHash
+		moreMissing, err = s.exchange(stream, initialMsg.RequestId, r)
+		missing = append(missing, moreMissing...)
+	}
+	if err != nil {
+		logger.Error("sync session failed", zap.Error(err))
+		if err := stream.Reset(); err != nil {
+			logger.Error("resetting connection", zap.Error(err))
+		}
+		return
+	}
+	stream.Close()
+
+	s.metrics.RecordSession(responderRole, time.Since(start))
+
+	err = s.transferMissing(s.Context(), logger, peerID, missing)
+	if err != nil {
+		logger.Error("retrieving missing messages", zap.Error(err))
+	}
+}
+
+// exchange reads and answers the messages sent by the remote peer until one of the
+// sides has no ranges left to reconcile. It returns the message hashes missing locally
+func (s *WakuStoreSync) exchange(stream network.Stream, requestID string, r *reconciler) ([]wpb.MessageHash, error) {
+	reader := pbio.NewDelimitedReader(stream, math.MaxInt32)
+
+	var missing []wpb.MessageHash
+	for i := 0; i < MaxRounds; i++ {
+		msg := &pb.StoreSyncMessage{}
+		err := reader.ReadMsg(msg)
+		if err != nil {
+			s.metrics.RecordError(decodeRPCFailure)
+			return nil, err
+		}
+
+		if msg.RequestId != requestID {
+			s.metrics.RecordError(invalidMessage)
+			return nil, ErrInvalidRequestID
+		}
+
+		if err := msg.Validate(); err != nil {
+			s.metrics.RecordError(invalidMessage)
+			return nil, err
+		}
+
+		localMissing, done, err := s.handleMessage(stream, r, msg)
+		if err != nil {
+			return nil, err
+		}
+
+		missing = append(missing, localMissing...)
+
+		if done {
+			return missing, nil
+		}
+	}
+
+	return nil, ErrTooManyRounds
+}
+
+// handleMessage processes the ranges received in a message and sends back the reply. It
+// returns the message hashes missing locally, and whether the session is over
+func (s *WakuStoreSync) handleMessage(stream network.Stream, r *reconciler, msg *pb.StoreSyncMessage) ([]wpb.MessageHash, bool, error) {
+	var missing []wpb.MessageHash
+	for _, h := range msg.MissingHashes {
+		missing = append(missing, wpb.ToMessageHash(h))
+	}
+
+	if len(msg.Ranges) == 0 {
+		// The remote peer has nothing left to reconcile
+		return missing, true, nil
+	}
+
+	reply, remoteMissing, localMissing := r.process(msg.Ranges)
+	missing = append(missing, localMissing...)
+
+	if len(reply) > pb.MaxRanges {
+		s.metrics.RecordError(invalidMessage)
+		return nil, false, ErrTooManyRanges
+	}
+
+	writer := pbio.NewDelimitedWriter(stream)
+	err := writer.WriteMsg(&pb.StoreSyncMessage{
+		RequestId:     msg.RequestId,
+		Ranges:        reply,
+		MissingHashes: remoteMissing,
+	})
+	if err != nil {
+		s.metrics.RecordError(writeResponseFailure)
+		return nil, false, err
+	}
+
+	return missing, len(reply) == 0, nil
+}
+
+func (s *WakuStoreSync) loadItems(pubsubTopic string, lower *pb.Bound, upper *pb.Bound) (itemSet, error) {
+	items, err := s.msgProvider.MessageHashes(pubsubTopic, lower.Timestamp, upper.Timestamp)
+	if err != nil {
+		return nil, err
+	}
+	return itemSet(items), nil
+}
+
+// transferMissing retrieves from a peer the messages whose hashes are missing locally, and
+// inserts them in the message store
+func (s *WakuStoreSync) transferMissing(ctx context.Context, logger *zap.Logger, peerID peer.ID, missing []wpb.MessageHash) error {
+	if len(missing) == 0 {
+		logger.Debug("no missing messages")
+		return nil
+	}
+
+	s.metrics.RecordMissingHashes(len(missing))
+
+	logger.Info("retrieving missing messages", zap.Int("count", len(missing)))
+
+	inserted := 0
+	for i := 0; i < len(missing); i += transferBatchSize {
+		end := i + transferBatchSize
+		if end > len(missing) {
+			end = len(missing)
+		}
+
+		result, err := s.storeClient.QueryByHash(ctx, missing[i:end], store.WithPeer(peerID), store.IncludeData(true), store.WithPaging(true, transferBatchSize))
+		if err != nil {
+			s.metrics.RecordError(transferFailure)
+			return err
+		}
+
+		for !result.IsComplete() {
+			for _, kv := range result.Messages() {
+				if kv.Message == nil {
+					continue
+				}
+
+				env := protocol.NewEnvelope(kv.Message, s.timesource.Now().UnixNano(), kv.GetPubsubTopic())
+				if env.Hash() != kv.WakuMessageHash() {
+					logger.Warn("received message does not match its hash", logging.HexBytes("hash", kv.MessageHash))
+					s.metrics.RecordError(hashMismatch)
+					continue
+				}
+
+				err := s.msgProvider.Put(env)
+				if err != nil {
+					// The message could have been received via relay in the meantime
+					logger.Debug("inserting message", logging.HexBytes("hash", kv.MessageHash), zap.Error(err))
+					continue
+				}
+				inserted++
+			}
+
+			err = result.Next(ctx)
+			if err != nil {
+				s.metrics.RecordError(transferFailure)
+				return err
+			}
+		}
+	}
+
+	s.metrics.RecordMessagesReconciled(inserted)
+
+	logger.Info("missing messages retrieved", zap.Int("inserted", inserted))
+
+	return nil
+}
@@ -164,3 +164,45 @@ func TestShardPubsubTopic(t *testing.T) {
 	}
 
 }
+
+func TestContentTopicPattern(t *testing.T) {
+	require.False(t, IsContentTopicPattern("/toychat/2/huilong/proto"))
+	require.True(t, IsContentTopicPattern("/toychat/2/*"))
+
+	require.True(t, MatchContentTopic("/toychat/2/huilong/proto", "/toychat/2/huilong/proto"))
+	require.True(t, MatchContentTopic("/toychat/2/room-*/proto", "/toychat/2/room-1/proto"))
+	require.False(t, MatchContentTopic("/toychat/2/room-*/proto", "/toychat/2/lobby/proto"))
+	require.False(t, MatchContentTopic("/toychat/2/room-*/proto", "/toychat/2/room-1/rfc26"))
+	require.False(t, MatchContentTopic("/toychat/2/room-*", "/toychat/2/room-1/proto"))
+
+	// Prefix patterns
+	require.True(t, MatchContentTopic("/toychat/2/*", "/toychat/2/huilong/proto"))
+	require.True(t, MatchContentTopic("/toychat/*/*", "/toychat/3/huilong/proto"))
+	require.False(t, MatchContentTopic("/toychat/2/*", "/toychat/3/huilong/proto"))
+	require.False(t, MatchContentTopic("/toychat/2/*", "/toychat/2"))
+
+	set := NewContentTopicSet("/toychat/2/huilong/proto", "/other/1/*")
+	require.True(t, set.Match("/toychat/2/huilong/proto"))
+	require.True(t, set.Match("/other/1/a/proto"))
+	require.False(t, set.Match("/toychat/2/a/proto"))
+
+	require.ErrorIs(t, ValidateContentTopicPattern("/toychat/2/[a/proto"), ErrInvalidPattern)
+
+	// Patterns belong to the shard of their application name and version
+	expected, err := GetPubSubTopicFromContentTopic("/toychat/2/huilong/proto")
+	require.NoError(t, err)
+	for _, pattern := range []string{"/toychat/2/*", "/toychat/2/room-*/proto", "/0/toychat/2/*"} {
+		pubsubTopic, err := GetPubSubTopicFromContentTopicPattern(pattern)
+		require.NoError(t, err)
+		require.Equal(t, expected, pubsubTopic)
+
+		// Patterns are only accepted by relay subscriptions
+		_, err = GetPubSubTopicFromContentTopic(pattern)
+		require.ErrorIs(t, err, ErrPatternNotSupported)
+	}
+
+	_, err = GetPubSubTopicFromContentTopicPattern("/toychat/*/huilong/proto")
+	require.ErrorIs(t, err, ErrPatternWithoutShard)
+	_, err = GetPubSubTopicFromContentTopicPattern("/*")
+	require.ErrorIs(t, err, ErrPatternWithoutShard)
+}
@@ -0,0 +1,66 @@
+package utils
+
+import "sync"
+
+// OtherLabel is the label value shared by the values that don't fit in a BoundedLabels
+const OtherLabel = "other"
+
+// BoundedLabels assigns metric label values to an unbounded set of values, like peer IDs or
+// content topics, so the number of series of a metric stays bounded. Up to max values get
+// their own label, and the others share OtherLabel until a label is released
+type BoundedLabels struct {
+	sync.Mutex
+	max    int
+	values map[string]struct{}
+}
+
+// NewBoundedLabels creates a BoundedLabels that gives their own label to up to max values
+func NewBoundedLabels(max int) *BoundedLabels {
+	return &BoundedLabels{
+		max:    max,
+		values: make(map[string]struct{}),
+	}
+}
+
+// Label returns the label of a value, which is the value itself if it already has its own
+// label or there is room for it, and OtherLabel otherwise
+func (b *BoundedLabels) Label(value string) string {
+	b.Lock()
+	defer b.Unlock()
+
+	if _, ok := b.values[value]; ok {
+		return value
+	}
+
+	if len(b.values) >= b.max {
+		return OtherLabel
+	}
+
+	b.values[value] = struct{}{}
+	return value
+}
+
+// Get returns the label of a value without giving it its own label
+func (b *BoundedLabels) Get(value string) string {
+	b.Lock()
+	defer b.Unlock()
+
+	if _, ok := b.values[value]; ok {
+		return value
+	}
+	return OtherLabel
+}
+
+// Release frees the label of a value, so it can be given to another value. It returns
+// whether the value had its own label, in which case its series should be deleted
+func (b *BoundedLabels) Release(value string) bool {
+	b.Lock()
+	defer b.Unlock()
+
+	if _, ok := b.values[value]; !ok {
+		return false
+	}
+
+	delete(b.values, value)
+	return true
+}
@@ -0,0 +1,24 @@
+package utils
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestBoundedLabels(t *testing.T) {
+	labels := NewBoundedLabels(2)
+
+	require.Equal(t, "a", labels.Label("a"))
+	require.Equal(t, "b", labels.Label("b"))
+	require.Equal(t, OtherLabel, labels.Label("c"))
+	require.Equal(t, "a", labels.Label("a"))
+	require.Equal(t, "b", labels.Get("b"))
+	require.Equal(t, OtherLabel, labels.Get("d"))
+
+	// Released labels can be given to other values
+	require.True(t, labels.Release("a"))
+	require.False(t, labels.Release("c"))
+	require.Equal(t, "c", labels.Label("c"))
+	require.Equal(t, OtherLabel, labels.Label("a"))
+}